@@ -40,7 +40,7 @@ var (
 		Use:     "aws",
 		Short:   "Prepare an OpenShift AWS cloud",
 		Long:    "This command prepares an OpenShift installer-provisioned infrastructure (IPI) on AWS cloud for Submariner installation.",
-		PreRunE: checkAWSFlags,
+		PreRunE: checkAWSPrepareFlags,
 		Run: func(_ *cobra.Command, _ []string) {
 			exit.OnError(cloudRestConfigProducer.RunOnSelectedContext(
 				func(clusterInfo *cluster.Info, _ string, status reporter.Interface) error {
@@ -88,6 +88,7 @@ func init() {
 	awsPrepareCmd.Flags().StringVar(&awsConfig.GWInstanceType, "gateway-instance", "c5d.large", "Type of gateways instance machine")
 	awsPrepareCmd.Flags().IntVar(&awsConfig.Gateways, "gateways", defaultNumGateways,
 		"Number of dedicated gateways to deploy (Set to `0` when using --load-balancer mode)")
+	addDedicatedGatewayFlag(awsPrepareCmd, &awsConfig.DedicatedGateway)
 
 	cloudPrepareCmd.AddCommand(awsPrepareCmd)
 
@@ -103,3 +104,11 @@ func checkAWSFlags(_ *cobra.Command, _ []string) error {
 
 	return nil
 }
+
+func checkAWSPrepareFlags(cmd *cobra.Command, args []string) error {
+	if err := checkAWSFlags(cmd, args); err != nil {
+		return err
+	}
+
+	return checkDedicatedGatewayFlags(awsConfig.DedicatedGateway, awsConfig.Gateways)
+}
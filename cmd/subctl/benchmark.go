@@ -19,6 +19,9 @@ limitations under the License.
 package subctl
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/onsi/ginkgo/v2"
 	"github.com/spf13/cobra"
 	"github.com/submariner-io/admiral/pkg/reporter"
@@ -33,8 +36,11 @@ import (
 )
 
 var (
-	intraCluster bool
-	verbose      bool
+	intraCluster       bool
+	verbose            bool
+	throughputProtocol string
+	throughputDuration time.Duration
+	throughputWarmup   time.Duration
 
 	benchmarkRestConfigProducer = restconfig.NewProducer().WithPrefixedContext("to")
 
@@ -47,8 +53,8 @@ var (
 		Use:   "throughput --context <kubeContext1> [--tocontext <kubeContext2>]",
 		Short: "Benchmark throughput",
 		Long:  "This command runs throughput tests within a cluster or between two clusters",
-		Args:  checkBenchmarkArguments,
-		Run:   buildBenchmarkRunner(benchmark.StartThroughputTests),
+		Args:  checkBenchmarkThroughputArguments,
+		Run:   buildBenchmarkRunner(throughputTests),
 	}
 	benchmarkLatencyCmd = &cobra.Command{
 		Use:   "latency --context <kubeContext1> [--tocontext <kubeContext2>]",
@@ -62,6 +68,13 @@ var (
 func init() {
 	addBenchmarkFlags(benchmarkCmd)
 
+	benchmarkThroughputCmd.Flags().StringVar(&throughputProtocol, "protocol", benchmark.TCPProtocol,
+		fmt.Sprintf("protocol to use for the throughput tests (%s or %s)", benchmark.TCPProtocol, benchmark.UDPProtocol))
+	benchmarkThroughputCmd.Flags().DurationVar(&throughputDuration, "duration", benchmark.DefaultThroughputDuration,
+		"duration of each measured throughput test, in whole seconds")
+	benchmarkThroughputCmd.Flags().DurationVar(&throughputWarmup, "warmup", benchmark.DefaultThroughputWarmup,
+		"duration of the discarded warmup run preceding each measured throughput test, in whole seconds; 0 to disable it")
+
 	benchmarkCmd.AddCommand(benchmarkThroughputCmd)
 	benchmarkCmd.AddCommand(benchmarkLatencyCmd)
 	rootCmd.AddCommand(benchmarkCmd)
@@ -85,22 +98,37 @@ func checkBenchmarkArguments(cmd *cobra.Command, args []string) error {
 	return checkNoArguments(cmd, args)
 }
 
+func checkBenchmarkThroughputArguments(cmd *cobra.Command, args []string) error {
+	if throughputProtocol != benchmark.TCPProtocol && throughputProtocol != benchmark.UDPProtocol {
+		return fmt.Errorf("unsupported protocol %q, the supported protocols are %s and %s", throughputProtocol,
+			benchmark.TCPProtocol, benchmark.UDPProtocol)
+	}
+
+	if throughputDuration < time.Second || throughputDuration%time.Second != 0 {
+		return fmt.Errorf("invalid --duration %s, it must be a whole number of seconds, at least 1s", throughputDuration)
+	}
+
+	if throughputWarmup < 0 || throughputWarmup%time.Second != 0 {
+		return fmt.Errorf("invalid --warmup %s, it must be a whole number of seconds", throughputWarmup)
+	}
+
+	return checkBenchmarkArguments(cmd, args)
+}
+
+func throughputTests(intraCluster, verbose bool) error {
+	return benchmark.StartThroughputTests(intraCluster, verbose, &benchmark.ThroughputOptions{
+		Protocol: throughputProtocol,
+		Duration: throughputDuration,
+		Warmup:   throughputWarmup,
+	})
+}
+
 func buildBenchmarkRunner(run func(intraCluster, verbose bool) error) func(command *cobra.Command, args []string) {
 	return func(_ *cobra.Command, _ []string) {
-		exit.OnError(benchmarkRestConfigProducer.RunOnSelectedContext(
-			func(fromClusterInfo *cluster.Info, _ string, status reporter.Interface) error {
-				// Try to run using the "to" context
-				toContextPresent, err := benchmarkRestConfigProducer.RunOnSelectedPrefixedContext(
-					"to",
-					func(toClusterInfo *cluster.Info, _ string, _ reporter.Interface) error {
-						return runBenchmark(run, fromClusterInfo, toClusterInfo, verbose)
-					}, status)
-
-				if toContextPresent {
-					return err //nolint:wrapcheck // No need to wrap errors here.
-				}
-
-				return runBenchmark(run, fromClusterInfo, nil, verbose)
+		exit.OnError(benchmarkRestConfigProducer.RunOnSelectedContextWithPrefixes([]string{"to"},
+			func(fromClusterInfo *cluster.Info, _ string, prefixedClusterInfos []*cluster.Info, _ reporter.Interface) error {
+				// Without a "to" context, the benchmark runs within the "from" cluster
+				return runBenchmark(run, fromClusterInfo, prefixedClusterInfos[0], verbose)
 			}, cli.NewReporter()))
 	}
 }
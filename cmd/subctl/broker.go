@@ -20,16 +20,120 @@ package subctl
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/submariner-io/admiral/pkg/reporter"
 	"github.com/submariner-io/admiral/pkg/resource"
+	"github.com/submariner-io/subctl/internal/cli"
+	"github.com/submariner-io/subctl/internal/exit"
+	"github.com/submariner-io/subctl/internal/restconfig"
+	"github.com/submariner-io/subctl/pkg/broker"
 	"github.com/submariner-io/subctl/pkg/brokercr"
 	"github.com/submariner-io/subctl/pkg/client"
+	"github.com/submariner-io/subctl/pkg/cluster"
 	"github.com/submariner-io/submariner-operator/api/v1alpha1"
 	"k8s.io/client-go/rest"
+	"k8s.io/utils/set"
 	controllerClient "sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+var (
+	brokerRestConfigProducer = restconfig.NewProducer().WithContextsFlag()
+
+	brokerCmd = &cobra.Command{
+		Use:   "broker",
+		Short: "Manage the Submariner Broker",
+		Long:  "This command manages aspects of the Submariner Broker shared by the joined clusters.",
+	}
+	brokerRotatePSKCmd = &cobra.Command{
+		Use:   "rotate-psk [broker-info.subm]",
+		Short: "Rotate the IPsec PSK used by the joined clusters",
+		Long: `This command generates a new IPsec PSK, stores it in the broker information file (defaulting to
+broker-info.subm), and updates it in the clusters in the selected contexts. All the clusters joined to the Broker
+must be updated in a single run, since each run generates a new PSK; the clusters registered with the Broker which
+weren't updated are reported.
+
+The gateways keep using the previous PSK until they're restarted, for example by deleting the gateway pods; until
+then, connections between clusters whose gateways use different PSKs fail.`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(_ *cobra.Command, args []string) {
+			brokerInfoFile := broker.InfoFileName
+			if len(args) > 0 {
+				brokerInfoFile = args[0]
+			}
+
+			exit.OnError(rotateIPSecPSK(brokerInfoFile, cli.NewReporter()))
+		},
+	}
+)
+
+func init() {
+	brokerRestConfigProducer.SetupFlags(brokerCmd.PersistentFlags())
+	addFleetFlag(brokerCmd, brokerRestConfigProducer)
+	brokerCmd.AddCommand(brokerRotatePSKCmd)
+	rootCmd.AddCommand(brokerCmd)
+}
+
+func rotateIPSecPSK(brokerInfoFile string, status reporter.Interface) error {
+	psk, err := broker.RotateIPSecPSK(brokerInfoFile, status)
+	if err != nil {
+		return err //nolint:wrapcheck // No need to wrap errors here.
+	}
+
+	updated := set.New[string]()
+
+	err = brokerRestConfigProducer.RunOnAllContexts(restconfig.IfConnectivityInstalled(
+		func(clusterInfo *cluster.Info, _ string, status reporter.Interface) error {
+			if err := broker.UpdateIPSecPSK(context.TODO(), clusterInfo, psk, status); err != nil {
+				return err //nolint:wrapcheck // No need to wrap errors here.
+			}
+
+			updated.Insert(clusterInfo.Submariner.Spec.ClusterID)
+
+			return nil
+		}), status)
+	if err != nil {
+		return err //nolint:wrapcheck // No need to wrap errors here.
+	}
+
+	return checkAllClustersUpdated(brokerInfoFile, updated, status)
+}
+
+// checkAllClustersUpdated reports the clusters registered with the broker whose PSK wasn't updated.
+func checkAllClustersUpdated(brokerInfoFile string, updated set.Set[string], status reporter.Interface) error {
+	status.Start("Checking that all the clusters registered with the Broker were updated")
+	defer status.End()
+
+	brokerInfo, err := broker.ReadInfoFromFile(brokerInfoFile)
+	if err != nil {
+		return status.Error(err, "")
+	}
+
+	clusters, err := brokerInfo.ListClusters(context.TODO())
+	if err != nil {
+		return status.Error(err, "")
+	}
+
+	missing := []string{}
+
+	for i := range clusters {
+		if !updated.Has(clusters[i].Spec.ClusterID) {
+			missing = append(missing, clusters[i].Spec.ClusterID)
+		}
+	}
+
+	if len(missing) > 0 {
+		return status.Error(fmt.Errorf("the IPsec PSK wasn't updated in the registered clusters %q; run this command again"+
+			" including all the joined clusters' contexts", missing), "")
+	}
+
+	status.Success("All the clusters registered with the Broker were updated")
+
+	return nil
+}
+
 func getBroker(ctx context.Context, config *rest.Config, namespace string) (*v1alpha1.Broker, bool, error) {
 	brokerClientProducer, err := client.NewProducerFromRestConfig(config)
 	if err != nil {
@@ -21,6 +21,8 @@ limitations under the License.
 package subctl
 
 import (
+	"errors"
+
 	"github.com/spf13/cobra"
 	"github.com/submariner-io/subctl/internal/restconfig"
 	"github.com/submariner-io/subctl/pkg/cloud"
@@ -72,8 +74,24 @@ func init() {
 		port.NATTDiscovery, "NAT discovery port")
 	cloudPrepareCmd.PersistentFlags().Uint16Var(&cloudOptions.ports.Vxlan, "vxlan-port", port.IntraClusterVxLAN, "Internal VXLAN port")
 
-	addLoadBalancerFlag(cloudPrepareCmd, &cloudOptions.useLoadBalancer)
+	// The flag is persistent so that it's available on the cloud-specific subcommands
+	cloudPrepareCmd.PersistentFlags().BoolVar(&cloudOptions.useLoadBalancer, "load-balancer", false,
+		"enable automatic LoadBalancer in front of the gateways")
 	cloudCmd.AddCommand(cloudPrepareCmd)
 
 	cloudCmd.AddCommand(cloudCleanupCmd)
 }
+
+func addDedicatedGatewayFlag(cmd *cobra.Command, p *bool) {
+	cmd.Flags().BoolVar(p, "dedicated-gateway", true,
+		"Deploy dedicated gateway instances; if false, the gateway ports are opened on the existing worker nodes instead,"+
+			" which avoids additional instances but exposes the workers and shares their resources with the gateways")
+}
+
+func checkDedicatedGatewayFlags(dedicatedGateway bool, gateways int) error {
+	if cloudOptions.useLoadBalancer && !dedicatedGateway && gateways != 0 {
+		return errors.New("--gateways 0 must be specified when using --load-balancer with --dedicated-gateway=false")
+	}
+
+	return nil
+}
@@ -21,29 +21,39 @@ limitations under the License.
 package subctl
 
 import (
+	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/submariner-io/admiral/pkg/reporter"
+	"github.com/submariner-io/admiral/pkg/resource"
 	"github.com/submariner-io/subctl/internal/cli"
 	"github.com/submariner-io/subctl/internal/component"
 	"github.com/submariner-io/subctl/internal/constants"
 	"github.com/submariner-io/subctl/internal/exit"
 	"github.com/submariner-io/subctl/internal/restconfig"
 	"github.com/submariner-io/subctl/pkg/broker"
+	"github.com/submariner-io/subctl/pkg/brokercr"
 	"github.com/submariner-io/subctl/pkg/cluster"
 	"github.com/submariner-io/subctl/pkg/deploy"
+	"github.com/submariner-io/subctl/pkg/serviceaccount"
+	"github.com/submariner-io/submariner-operator/api/v1alpha1"
 	"github.com/submariner-io/submariner-operator/pkg/discovery/clustersetip"
 	"github.com/submariner-io/submariner-operator/pkg/discovery/globalnet"
 	"k8s.io/utils/set"
+	controllerClient "sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 var (
 	deployflags       deploy.BrokerOptions
 	ipsecSubmFile     string
+	brokerCAFile      string
+	writeInfoOnly     bool
+	waitForBrokerURL  time.Duration
 	defaultComponents = []string{component.ServiceDiscovery, component.Connectivity}
 )
 
@@ -54,6 +64,11 @@ var deployRestConfigProducer = restconfig.NewProducer().
 var deployBroker = &cobra.Command{
 	Use:   "deploy-broker",
 	Short: "Deploys the broker",
+	Long: `Deploys the broker and saves the information needed to join clusters to it in broker-info.subm.
+
+Deploying is idempotent: running the command again on a cluster where the broker is already deployed updates the
+existing broker to match the given options, and preserves the IPsec PSK stored in broker-info.subm if the file
+describes the same broker.`,
 	Run: func(_ *cobra.Command, _ []string) {
 		exit.OnError(deployRestConfigProducer.RunOnSelectedContext(deployBrokerInContext, cli.NewReporter()))
 	},
@@ -90,6 +105,15 @@ func addDeployBrokerFlags(flags *pflag.FlagSet) {
 
 	flags.StringVar(&deployflags.BrokerURL, "broker-url", "",
 		"broker API endpoint URL (stored in the broker information file, defaults to the context URL)")
+	flags.StringVar(&brokerCAFile, "broker-ca-file", "",
+		"PEM-encoded CA bundle to store in the broker information file instead of the cluster CA, for broker URLs served"+
+			" with a certificate from another CA, e.g. by a re-encrypting load balancer")
+	flags.DurationVar(&waitForBrokerURL, "wait-for-broker-url", 0,
+		"maximum time to wait for the broker URL to be served, e.g. by a load balancer which is still being provisioned")
+	flags.DurationVar(&deployflags.TokenWaitTimeout, "token-wait-timeout", serviceaccount.DefaultTokenWaitTimeout,
+		"maximum time to wait for the token controller to generate the broker administrator service account token")
+	flags.BoolVar(&writeInfoOnly, "write-info-only", false,
+		"only write the broker information file for the broker already deployed, without deploying anything")
 	flags.BoolVar(&deployflags.BrokerSpec.ClustersetIPEnabled, "enable-clusterset-ip", false,
 		"set default support for use of clusterset IP for exported services in connecting clusters (default disabled)")
 	flags.StringVar(&deployflags.BrokerSpec.ClustersetIPCIDRRange, "clusterset-ip-cidr-range",
@@ -100,30 +124,144 @@ func deployBrokerInContext(clusterInfo *cluster.Info, namespace string, status r
 	deployflags.BrokerNamespace = namespace
 	deployflags.HTTPProxyConfig = httpProxyConfig
 
-	if err := deploy.Broker(&deployflags, clusterInfo.ClientProducer, status); err != nil {
-		return err //nolint:wrapcheck // No need to wrap errors here.
-	}
+	var brokerCA []byte
 
-	ipsecPSK := []byte{}
-	var err error
+	if brokerCAFile != "" {
+		var err error
 
-	if ipsecSubmFile != "" {
-		ipsecData, err := broker.ReadInfoFromFile(ipsecSubmFile)
+		brokerCA, err = broker.ReadCABundle(brokerCAFile)
 		if err != nil {
-			return errors.Wrapf(err, "error importing IPsec PSK from file %q", ipsecSubmFile)
+			return status.Error(err, "error reading the broker CA bundle")
+		}
+	}
+
+	// Check the URL before changing anything, so that giving up leaves the cluster as it was
+	if deployflags.BrokerURL != "" {
+		if err := broker.CheckURL(clusterInfo.RestConfig, deployflags.BrokerURL, waitForBrokerURL, status); err != nil {
+			return err //nolint:wrapcheck // No need to wrap errors here.
 		}
+	}
 
-		ipsecPSK = ipsecData.IPSecPSK.Data["psk"]
+	existingBroker, err := getExistingBroker(clusterInfo, namespace)
+	if err != nil {
+		return status.Error(err, "error retrieving the Broker resource")
 	}
 
-	if len(ipsecPSK) == 0 {
-		ipsecPSK, err = broker.GenerateRandomPSK()
-		if err != nil {
+	if writeInfoOnly {
+		if existingBroker == nil {
+			return status.Error(fmt.Errorf("no broker is deployed in namespace %q", namespace),
+				"unable to write the broker information")
+		}
+
+		if !set.New(existingBroker.Spec.Components...).Equal(set.New(deployflags.BrokerSpec.Components...)) {
+			status.Warning("The deployed broker has components %s, but the broker information will list %s",
+				strings.Join(existingBroker.Spec.Components, ","), strings.Join(deployflags.BrokerSpec.Components, ","))
+		}
+	} else {
+		reportExistingMemberInstallation(clusterInfo, namespace, status)
+
+		if existingBroker != nil {
+			status.Success("A broker is already deployed in namespace %q, it will be updated", namespace)
+		}
+
+		if err := deploy.Broker(&deployflags, clusterInfo.ClientProducer, status); err != nil {
 			return err //nolint:wrapcheck // No need to wrap errors here.
 		}
 	}
 
+	ipsecPSK, err := determineIPsecPSK(clusterInfo, namespace, status)
+	if err != nil {
+		return err
+	}
+
+	if deployflags.BrokerURL != "" || brokerCA != nil {
+		if err := checkBrokerTLS(clusterInfo, namespace, brokerCA, status); err != nil {
+			return err
+		}
+	}
+
 	return broker.WriteInfoToFile( //nolint:wrapcheck // No need to wrap errors here.
-		clusterInfo.RestConfig, namespace, deployflags.BrokerURL, ipsecPSK,
+		clusterInfo.RestConfig, namespace, deployflags.BrokerURL, brokerCA, ipsecPSK,
 		set.New(deployflags.BrokerSpec.Components...), deployflags.BrokerSpec.DefaultCustomDomains, status)
 }
+
+// checkBrokerTLS verifies that the broker URL serves a certificate the member clusters will trust: one signed by the system
+// CAs, or by the CA they fall back to, i.e. the given CA bundle if any, the cluster CA from the broker client token otherwise.
+func checkBrokerTLS(clusterInfo *cluster.Info, namespace string, brokerCA []byte, status reporter.Interface) error {
+	if brokerCA == nil {
+		clientToken, err := serviceaccount.GetTokenSecretFor(context.TODO(), clusterInfo.ClientProducer.ForKubernetes(), namespace,
+			constants.SubmarinerBrokerAdminSA)
+		if err != nil {
+			return status.Error(err, "error retrieving the broker client token")
+		}
+
+		brokerCA = clientToken.Data["ca.crt"]
+	}
+
+	//nolint:wrapcheck // No need to wrap errors here.
+	return broker.CheckTLS(broker.InfoURL(clusterInfo.RestConfig, deployflags.BrokerURL), brokerCA, status)
+}
+
+func getExistingBroker(clusterInfo *cluster.Info, namespace string) (*v1alpha1.Broker, error) {
+	existing := &v1alpha1.Broker{}
+
+	err := clusterInfo.ClientProducer.ForGeneral().Get(context.TODO(), controllerClient.ObjectKey{
+		Namespace: namespace,
+		Name:      brokercr.Name,
+	}, existing)
+	if resource.IsNotFoundErr(err) {
+		return nil, nil
+	}
+
+	return existing, err //nolint:wrapcheck // The caller wraps the error
+}
+
+// determineIPsecPSK returns the PSK imported with --ipsec-psk-from if any, then the PSK from an existing broker
+// information file for the same broker, and finally a new random PSK.
+func determineIPsecPSK(clusterInfo *cluster.Info, namespace string, status reporter.Interface) ([]byte, error) {
+	if ipsecSubmFile != "" {
+		ipsecData, err := broker.ReadInfoFromFile(ipsecSubmFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error importing IPsec PSK from file %q", ipsecSubmFile)
+		}
+
+		if psk := ipsecData.IPSecPSK.Data["psk"]; len(psk) > 0 {
+			return psk, nil
+		}
+	}
+
+	psk := broker.ExistingIPsecPSK(broker.InfoFileName, namespace, broker.InfoURL(clusterInfo.RestConfig, deployflags.BrokerURL))
+	if len(psk) > 0 {
+		status.Success("Reusing the IPsec PSK from the existing file %q", broker.InfoFileName)
+		return psk, nil
+	}
+
+	return broker.GenerateRandomPSK() //nolint:wrapcheck // No need to wrap errors here.
+}
+
+// reportExistingMemberInstallation explains how deploying the broker interacts with member components already joined
+// on the same cluster.
+func reportExistingMemberInstallation(clusterInfo *cluster.Info, brokerNamespace string, status reporter.Interface) {
+	var clusterID, memberBrokerNamespace string
+
+	switch {
+	case clusterInfo.Submariner != nil:
+		clusterID = clusterInfo.Submariner.Spec.ClusterID
+		memberBrokerNamespace = clusterInfo.Submariner.Spec.BrokerK8sRemoteNamespace
+	case clusterInfo.ServiceDiscovery != nil:
+		clusterID = clusterInfo.ServiceDiscovery.Spec.ClusterID
+		memberBrokerNamespace = clusterInfo.ServiceDiscovery.Spec.BrokerK8sRemoteNamespace
+	default:
+		return
+	}
+
+	status.Warning("This cluster is already joined as member %q; the broker will share the operator in namespace %q with it",
+		clusterID, constants.OperatorNamespace)
+
+	if memberBrokerNamespace != brokerNamespace {
+		status.Warning("The member is joined to a broker using namespace %q, not %q: it will stay connected to its current broker"+
+			" and must be re-joined with the new broker-info.subm to use this one", memberBrokerNamespace, brokerNamespace)
+	}
+
+	status.Warning("Uninstalling Submariner from this cluster will keep the broker until no other cluster is registered with it")
+}
@@ -20,6 +20,7 @@ package subctl
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
@@ -27,14 +28,22 @@ import (
 	"github.com/submariner-io/subctl/internal/cli"
 	"github.com/submariner-io/subctl/internal/constants"
 	"github.com/submariner-io/subctl/internal/exit"
+	"github.com/submariner-io/subctl/internal/pods"
 	"github.com/submariner-io/subctl/internal/restconfig"
 	"github.com/submariner-io/subctl/pkg/cluster"
 	"github.com/submariner-io/subctl/pkg/diagnose"
+	"github.com/submariner-io/submariner/pkg/cni"
 	k8serrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/utils/set"
 )
 
 var (
-	diagnoseFirewallOptions diagnose.FirewallOptions
+	diagnoseFirewallOptions   diagnose.FirewallOptions
+	diagnoseCNIOptions        diagnose.CNIOptions
+	diagnoseDeploymentOptions diagnose.DeploymentOptions
+	diagnoseImagesOptions     diagnose.ImagesOptions
+	diagnoseFailFast          bool
+	serviceDiscoveryVerbose   bool
 
 	diagnoseRestConfigProducer = restconfig.NewProducer().WithDefaultNamespace(constants.OperatorNamespace).WithInClusterFlag()
 
@@ -42,6 +51,14 @@ var (
 							WithDefaultNamespace(constants.OperatorNamespace).WithPrefixedContext("remote")
 	diagnoseFirewallNatDiscoveryRestConfigProducer = restconfig.NewProducer().
 							WithDefaultNamespace(constants.OperatorNamespace).WithPrefixedContext("remote")
+	diagnoseFirewallMSSRestConfigProducer = restconfig.NewProducer().
+						WithDefaultNamespace(constants.OperatorNamespace).WithPrefixedContext("remote")
+	diagnoseGlobalnetSourceRestConfigProducer = restconfig.NewProducer().
+							WithDefaultNamespace(constants.OperatorNamespace).WithPrefixedContext("remote")
+	diagnoseGlobalnetDatapathRestConfigProducer = restconfig.NewProducer().
+							WithDefaultNamespace(constants.OperatorNamespace).WithPrefixedContext("remote")
+	diagnoseServiceDiscoveryCrossClusterRestConfigProducer = restconfig.NewProducer().
+								WithDefaultNamespace(constants.OperatorNamespace).WithPrefixedContext("remote")
 
 	diagnoseCmd = &cobra.Command{
 		Use:   "diagnose",
@@ -52,10 +69,44 @@ var (
 	diagnoseCNICmd = &cobra.Command{
 		Use:   "cni",
 		Short: "Check the CNI network plugin",
-		Long:  "This command checks if the detected CNI network plugin is supported by Submariner.",
+		Long: "This command checks if the detected CNI network plugin is supported by Submariner. With Calico, it also checks" +
+			" the IPPools, and --fix creates or patches the IPPools required for the remote clusters' CIDRs.",
+		Run: func(_ *cobra.Command, _ []string) {
+			exit.OnError(
+				diagnoseRestConfigProducer.RunOnAllContexts(restconfig.IfConnectivityInstalled(cniConfig), cli.NewReporter()))
+		},
+	}
+
+	diagnoseCalicoIPPoolCmd = &cobra.Command{
+		Use:   "ippool",
+		Short: "Check the Calico IPPool configuration",
+		Long: "This command checks that the Calico IPPools for the remote cluster CIDRs are configured as Submariner requires," +
+			" that the local IPPools use VXLAN encapsulation, and that no IPPool overlaps with the service CIDR.",
+		Run: func(_ *cobra.Command, _ []string) {
+			exit.OnError(
+				diagnoseRestConfigProducer.RunOnAllContexts(restconfig.IfConnectivityInstalled(diagnose.CalicoIPPools), cli.NewReporter()))
+		},
+	}
+
+	diagnoseOVNCmd = &cobra.Command{
+		Use:   "ovn",
+		Short: "Check the OVN-Kubernetes configuration",
+		Long: "This command checks that the OVN cluster router has static routes or reroute policies for the remote cluster" +
+			" CIDRs, and that the OVN network plugin syncer is running.",
 		Run: func(_ *cobra.Command, _ []string) {
 			exit.OnError(
-				diagnoseRestConfigProducer.RunOnAllContexts(restconfig.IfConnectivityInstalled(diagnose.CNIConfig), cli.NewReporter()))
+				diagnoseRestConfigProducer.RunOnAllContexts(restconfig.IfConnectivityInstalled(diagnose.OVNConfig), cli.NewReporter()))
+		},
+	}
+
+	diagnoseGatewayNodesCmd = &cobra.Command{
+		Use:   "gateway-nodes",
+		Short: "Check the gateway node labels and resource pressure",
+		Long: "This command checks that the nodes labeled as gateways match the Gateway resources, that the gateway nodes" +
+			" aren't under memory, disk or PID pressure, and that their CPU usage isn't too high.",
+		Run: func(_ *cobra.Command, _ []string) {
+			exit.OnError(diagnoseRestConfigProducer.RunOnAllContexts(
+				restconfig.IfConnectivityInstalled(diagnose.GatewayNodeLabels, diagnose.GatewayNodePressure), cli.NewReporter()))
 		},
 	}
 
@@ -72,8 +123,10 @@ var (
 	diagnoseDeploymentCmd = &cobra.Command{
 		Use:   "deployment",
 		Short: "Check the Submariner deployment",
-		Long:  "This command checks that the Submariner components are properly deployed and running with no overlapping CIDRs.",
-		Args:  checkImageOverrides,
+		Long: "This command checks that the Submariner components are properly deployed and running with no overlapping CIDRs.\n" +
+			"It also checks that the operator watches the namespaces containing the Submariner resources.\n" +
+			"On OpenShift, it also checks that the Submariner service accounts can use the SCCs their pods require.",
+		Args: checkImageOverrides,
 		Run: func(_ *cobra.Command, _ []string) {
 			exit.OnError(
 				diagnoseRestConfigProducer.RunOnAllContexts(func(clusterInfo *cluster.Info, ns string, status reporter.Interface) error {
@@ -88,6 +141,18 @@ var (
 		},
 	}
 
+	diagnoseImagesCmd = &cobra.Command{
+		Use:   "images",
+		Short: "Check that the Submariner images can be pulled",
+		Long: "This command checks that the images of the deployed Submariner components can be pulled in the cluster, by running" +
+			" a short-lived pod for each image; if Submariner isn't deployed, the images of all the components are checked.\n" +
+			"This is useful to check that all the images are mirrored in disconnected environments.",
+		Args: checkImageOverrides,
+		Run: func(_ *cobra.Command, _ []string) {
+			exit.OnError(diagnoseRestConfigProducer.RunOnAllContexts(images, cli.NewReporter()))
+		},
+	}
+
 	diagnoseVersionCmd = &cobra.Command{
 		Use:   "k8s-version",
 		Short: "Check the Kubernetes version",
@@ -97,14 +162,71 @@ var (
 		},
 	}
 
+	diagnoseBrokerComponentsCmd = &cobra.Command{
+		Use:   "broker-components",
+		Short: "Check the components enabled at the Broker",
+		Long: `This command checks that the components enabled in the cluster, such as service discovery or Globalnet,
+were also enabled at the Broker the cluster joined.`,
+		Run: func(_ *cobra.Command, _ []string) {
+			exit.OnError(diagnoseRestConfigProducer.RunOnAllContexts(diagnose.BrokerComponents, cli.NewReporter()))
+		},
+	}
+
+	diagnosePodSecurityCmd = &cobra.Command{
+		Use:   "pod-security",
+		Short: "Check the Pod Security admission labels on the operator namespace",
+		Long: "This command checks that the Pod Security admission labels on the operator namespace allow the privileged" +
+			" Submariner pods.",
+		Run: func(_ *cobra.Command, _ []string) {
+			exit.OnError(diagnoseRestConfigProducer.RunOnAllContexts(restconfig.IfConnectivityInstalled(diagnose.PodSecurityAdmission),
+				cli.NewReporter()))
+		},
+	}
+
 	diagnoseKubeProxyModeCmd = &cobra.Command{
 		Use:   "kube-proxy-mode",
 		Short: "Check the kube-proxy mode",
 		Long:  "This command checks if the kube-proxy mode is supported by Submariner.",
 		Args:  checkImageOverrides,
 		Run: func(_ *cobra.Command, _ []string) {
-			exit.OnError(
-				diagnoseRestConfigProducer.RunOnAllContexts(restconfig.IfConnectivityInstalled(kubeProxyMode), cli.NewReporter()))
+			exit.OnError(diagnoseRestConfigProducer.RunOnAllContexts(
+				restconfig.IfConnectivityInstalled(outOfCluster(kubeProxyMode)), cli.NewReporter()))
+		},
+	}
+
+	diagnoseKubeProxyConflictsCmd = &cobra.Command{
+		Use:   "kube-proxy-conflicts",
+		Short: "Check for kube-proxy masquerading rules conflicting with Submariner",
+		Long: "This command checks that no masquerading rules set up by kube-proxy or the network plugin on the active" +
+			" Gateway node match the CIDRs of the remote clusters.",
+		Args: checkImageOverrides,
+		Run: func(_ *cobra.Command, _ []string) {
+			exit.OnError(diagnoseRestConfigProducer.RunOnAllContexts(
+				restconfig.IfConnectivityInstalled(outOfCluster(kubeProxyConflicts)), cli.NewReporter()))
+		},
+	}
+
+	diagnoseNATTraversalCmd = &cobra.Command{
+		Use:   "nat-traversal",
+		Short: "Check NAT traversal on the Gateway nodes",
+		Long: "This command checks that the nat-discovery port is bound on the active Gateway node and that the gateway" +
+			" connections use the remote public IPs when NAT is enabled.",
+		Args: checkImageOverrides,
+		Run: func(_ *cobra.Command, _ []string) {
+			exit.OnError(diagnoseRestConfigProducer.RunOnAllContexts(
+				restconfig.IfConnectivityInstalled(outOfCluster(natTraversal)), cli.NewReporter()))
+		},
+	}
+
+	diagnoseNATConfigCmd = &cobra.Command{
+		Use:   "nat-config",
+		Short: "Check the NAT configuration advertised by the local Endpoint",
+		Long: "This command checks that the public IP advertised by the local Endpoint matches the one resolved from the active" +
+			" Gateway node and, when NAT is disabled, that its private IP is assigned to the Gateway node.",
+		Args: checkImageOverrides,
+		Run: func(_ *cobra.Command, _ []string) {
+			exit.OnError(diagnoseRestConfigProducer.RunOnAllContexts(
+				restconfig.IfConnectivityInstalled(outOfCluster(natConfig)), cli.NewReporter()))
 		},
 	}
 
@@ -117,11 +239,25 @@ var (
 	diagnoseFirewallVxLANCmd = &cobra.Command{
 		Use:   "intra-cluster",
 		Short: "Check firewall access for intra-cluster Submariner VxLAN traffic",
-		Long:  "This command checks if the firewall configuration allows traffic over vx-submariner interface.",
-		Args:  checkFirewallArguments,
+		Long: `This command checks if the firewall configuration allows traffic over vx-submariner interface.
+With OVN-Kubernetes, it checks that traffic from non-gateway nodes reaches the gateway over the OVN overlay instead,
+and that the OVN cluster router has routes or reroute policies for all the remote cluster CIDRs.`,
+		Args: checkFirewallArguments,
 		Run: func(_ *cobra.Command, _ []string) {
-			exit.OnError(
-				diagnoseRestConfigProducer.RunOnAllContexts(restconfig.IfConnectivityInstalled(firewallIntraVxLANConfig), cli.NewReporter()))
+			exit.OnError(diagnoseRestConfigProducer.RunOnAllContexts(
+				restconfig.IfConnectivityInstalled(outOfCluster(firewallIntraVxLANConfig)), cli.NewReporter()))
+		},
+	}
+
+	diagnoseFirewallWireGuardCmd = &cobra.Command{
+		Use:   "wireguard",
+		Short: "Check WireGuard support on the Gateway nodes",
+		Long: "This command checks that the kernel of each Gateway node supports WireGuard and that IPv4 forwarding is enabled," +
+			" when WireGuard is the cable driver.",
+		Args: checkImageOverrides,
+		Run: func(_ *cobra.Command, _ []string) {
+			exit.OnError(diagnoseRestConfigProducer.RunOnAllContexts(
+				restconfig.IfConnectivityInstalled(outOfCluster(wireGuardKernelModule)), cli.NewReporter()))
 		},
 	}
 
@@ -145,13 +281,60 @@ var (
 		},
 	}
 
+	diagnoseFirewallMSSCmd = &cobra.Command{
+		Use:   "mss-clamping --context <localcontext> --remotecontext <remotecontext>",
+		Short: "Check that the TCP MSS is clamped to fit the tunnel between the clusters",
+		Long: `This command checks that the MSS of TCP connections between pods in the two clusters is clamped to fit the tunnel,
+in both directions, and that payloads larger than the tunnel MTU can be transferred.`,
+		Args: checkFirewallArguments,
+		Run: func(_ *cobra.Command, _ []string) {
+			runLocalRemoteFirewallCommand(diagnoseFirewallMSSRestConfigProducer, diagnose.MSSClampingAcrossClusters)
+		},
+	}
+
+	diagnoseGlobalnetSourceCmd = &cobra.Command{
+		Use:   "globalnet-source --context <localcontext> --remotecontext <remotecontext>",
+		Short: "Check the source address of Globalnet traffic between the clusters",
+		Long: `This command checks that connections from the remote cluster to an exported service in the local cluster arrive
+with a source address from the remote cluster's global CIDR, and that the replies make it back. Globalnet must be enabled
+on both clusters.`,
+		Args: checkFirewallArguments,
+		Run: func(_ *cobra.Command, _ []string) {
+			runLocalRemoteFirewallCommand(diagnoseGlobalnetSourceRestConfigProducer, diagnose.GlobalnetSourceIPAcrossClusters)
+		},
+	}
+
+	diagnoseGlobalnetDatapathCmd = &cobra.Command{
+		Use:   "globalnet-datapath --context <localcontext> --remotecontext <remotecontext>",
+		Short: "Check that the local cluster can reach a remote service through its global IP",
+		Long: `This command checks that a pod in the local cluster can connect to an exported service in the remote cluster
+through the service's global ingress IP, and reports the source address the connection arrives with on the remote
+cluster's Gateway node. It uses an existing exported service if there is one, otherwise a transient service is exported
+for the duration of the check. Globalnet must be enabled on both clusters.`,
+		Args: checkFirewallArguments,
+		Run: func(_ *cobra.Command, _ []string) {
+			runLocalRemoteFirewallCommand(diagnoseGlobalnetDatapathRestConfigProducer, diagnose.GlobalnetDatapathAcrossClusters)
+		},
+	}
+
 	diagnoseAllCmd = &cobra.Command{
 		Use:   "all",
 		Short: "Run all diagnostic checks (except those requiring two kubecontexts)",
 		Long:  "This command runs all diagnostic checks (except those requiring two kubecontexts) and reports any issues",
 		Args:  checkImageOverrides,
 		Run: func(_ *cobra.Command, _ []string) {
-			exit.OnError(diagnoseAll(cli.NewReporter()))
+			exit.OnError(diagnoseAll(diagnoseFailFast, cli.NewReporter()))
+		},
+	}
+
+	diagnoseCleanupCmd = &cobra.Command{
+		Use:   "cleanup",
+		Short: "Delete the transient pods and secrets left over by interrupted diagnostics",
+		Long: "This command deletes the transient pods spawned by diagnose and verify, and the image pull secrets copied for" +
+			" them, which weren't deleted, e.g. because subctl was killed while running.",
+		Args: checkNoArguments,
+		Run: func(_ *cobra.Command, _ []string) {
+			exit.OnError(diagnoseRestConfigProducer.RunOnAllContexts(diagnose.TransientPods, cli.NewReporter()))
 		},
 	}
 
@@ -159,16 +342,37 @@ var (
 		Use:   "service-discovery",
 		Short: "Check service discovery functionality",
 		Long:  "This command checks if service discovery is functioning properly.",
+		Args:  checkImageOverrides,
 		Run: func(_ *cobra.Command, _ []string) {
 			exit.OnError(
 				diagnoseRestConfigProducer.RunOnAllContexts(
-					restconfig.IfServiceDiscoveryInstalled(diagnose.ServiceDiscovery), cli.NewReporter()))
+					restconfig.IfServiceDiscoveryInstalled(serviceDiscovery), cli.NewReporter()))
+		},
+	}
+
+	diagnoseServiceDiscoveryCrossClusterCmd = &cobra.Command{
+		Use:   "cross-cluster --context <localcontext> --remotecontext <remotecontext>",
+		Short: "Check service discovery between two clusters",
+		Long: "This command checks that the services exported from the local cluster are imported in the remote cluster," +
+			" and that they can be resolved there.",
+		Args: checkFirewallArguments,
+		Run: func(_ *cobra.Command, _ []string) {
+			serviceDiscoveryCrossCluster()
 		},
 	}
 )
 
 func init() {
 	diagnoseRestConfigProducer.SetupFlags(diagnoseCmd.PersistentFlags())
+	addFleetFlag(diagnoseCmd, diagnoseRestConfigProducer)
+	diagnoseCmd.PersistentFlags().StringVar(&pods.ServiceAccountName, "probe-service-account", "",
+		"service account to run the probe pods with, which must exist in the namespace they run in; "+pods.ServiceAccountRequirements())
+	diagnoseCmd.PersistentFlags().StringVar(&pods.CPURequest, "diagnose-pod-cpu", pods.DefaultCPURequest,
+		"CPU request of the probe pods")
+	diagnoseCmd.PersistentFlags().StringVar(&pods.MemoryRequest, "diagnose-pod-memory", pods.DefaultMemoryRequest,
+		"memory request of the probe pods")
+	diagnoseCmd.PersistentFlags().StringSliceVar(&pods.Tolerations, "diagnose-pod-tolerations", nil,
+		"comma-separated list of taints tolerated by the probe pods, as key[=value][:effect]; by default, they tolerate all taints")
 	rootCmd.AddCommand(diagnoseCmd)
 
 	addDiagnoseSubCommands()
@@ -178,32 +382,79 @@ func init() {
 func addDiagnoseSubCommands() {
 	addDiagnoseFWConfigFlags(diagnoseAllCmd)
 	addImageOverrideFlag(diagnoseAllCmd.Flags())
+	addDiagnoseDeploymentFlags(diagnoseAllCmd)
+	diagnoseAllCmd.Flags().BoolVar(&diagnoseFailFast, "fail-fast", false, "stop running the checks on a cluster at the first failure")
 
+	diagnoseCNICmd.Flags().BoolVar(&diagnoseCNIOptions.Fix, "fix", false,
+		"create or patch the Calico IPPools required for the remote clusters' CIDRs")
+	diagnoseCNICmd.Flags().BoolVar(&diagnoseCNIOptions.FixDryRun, "fix-dry-run", false,
+		"show the Calico IPPools --fix would create or patch, without changing them")
 	diagnoseCmd.AddCommand(diagnoseCNICmd)
+	diagnoseCmd.AddCommand(diagnoseCalicoIPPoolCmd)
+	diagnoseCmd.AddCommand(diagnoseOVNCmd)
 	diagnoseCmd.AddCommand(diagnoseConnectionsCmd)
+	diagnoseCmd.AddCommand(diagnoseGatewayNodesCmd)
 	addImageOverrideFlag(diagnoseDeploymentCmd.Flags())
+	addDiagnoseDeploymentFlags(diagnoseDeploymentCmd)
 	diagnoseCmd.AddCommand(diagnoseDeploymentCmd)
+	addImageOverrideFlag(diagnoseImagesCmd.Flags())
+	diagnoseImagesCmd.Flags().StringVar(&diagnoseImagesOptions.Repository, "repository", "",
+		"image repository to check, instead of the deployed one")
+	diagnoseImagesCmd.Flags().StringVar(&diagnoseImagesOptions.Version, "version", "",
+		"image version to check, instead of the deployed one")
+	diagnoseCmd.AddCommand(diagnoseImagesCmd)
 	diagnoseCmd.AddCommand(diagnoseVersionCmd)
+	diagnoseCmd.AddCommand(diagnoseBrokerComponentsCmd)
+	diagnoseCmd.AddCommand(diagnosePodSecurityCmd)
 	addImageOverrideFlag(diagnoseKubeProxyModeCmd.Flags())
 	diagnoseCmd.AddCommand(diagnoseKubeProxyModeCmd)
+	addImageOverrideFlag(diagnoseKubeProxyConflictsCmd.Flags())
+	diagnoseCmd.AddCommand(diagnoseKubeProxyConflictsCmd)
+	addImageOverrideFlag(diagnoseNATTraversalCmd.Flags())
+	diagnoseCmd.AddCommand(diagnoseNATTraversalCmd)
+	addImageOverrideFlag(diagnoseNATConfigCmd.Flags())
+	diagnoseCmd.AddCommand(diagnoseNATConfigCmd)
 	diagnoseCmd.AddCommand(diagnoseAllCmd)
+	diagnoseCmd.AddCommand(diagnoseCleanupCmd)
 	diagnoseCmd.AddCommand(diagnoseFirewallCmd)
+	addImageOverrideFlag(diagnoseServiceDiscoveryCmd.Flags())
+	diagnoseServiceDiscoveryCmd.Flags().BoolVar(&serviceDiscoveryVerbose, "verbose", false,
+		"show the EndpointSlices, endpoint addresses and ServiceImport type of each service exported successfully")
+	diagnoseServiceDiscoveryCrossClusterRestConfigProducer.SetupFlags(diagnoseServiceDiscoveryCrossClusterCmd.Flags())
+	addImageOverrideFlag(diagnoseServiceDiscoveryCrossClusterCmd.Flags())
+	diagnoseServiceDiscoveryCmd.AddCommand(diagnoseServiceDiscoveryCrossClusterCmd)
 	diagnoseCmd.AddCommand(diagnoseServiceDiscoveryCmd)
+	diagnoseGlobalnetSourceRestConfigProducer.SetupFlags(diagnoseGlobalnetSourceCmd.Flags())
+	addDiagnoseFWConfigFlags(diagnoseGlobalnetSourceCmd)
+	addImageOverrideFlag(diagnoseGlobalnetSourceCmd.Flags())
+	diagnoseCmd.AddCommand(diagnoseGlobalnetSourceCmd)
+	diagnoseGlobalnetDatapathRestConfigProducer.SetupFlags(diagnoseGlobalnetDatapathCmd.Flags())
+	addDiagnoseFWConfigFlags(diagnoseGlobalnetDatapathCmd)
+	addImageOverrideFlag(diagnoseGlobalnetDatapathCmd.Flags())
+	diagnoseCmd.AddCommand(diagnoseGlobalnetDatapathCmd)
 }
 
 func addDiagnoseFirewallSubCommands() {
 	addDiagnoseFWConfigFlags(diagnoseFirewallVxLANCmd)
 	diagnoseFirewallTunnelRestConfigProducer.SetupFlags(diagnoseFirewallTunnelCmd.Flags())
 	addDiagnoseFWConfigFlags(diagnoseFirewallTunnelCmd)
+	diagnoseFirewallTunnelCmd.Flags().BoolVar(&diagnoseFirewallOptions.TCP, "tcp", false,
+		"also check that TCP connections can be established to the gateway node")
 	diagnoseFirewallNatDiscoveryRestConfigProducer.SetupFlags(diagnoseFirewallNatDiscovery.Flags())
 	addDiagnoseFWConfigFlags(diagnoseFirewallNatDiscovery)
+	diagnoseFirewallMSSRestConfigProducer.SetupFlags(diagnoseFirewallMSSCmd.Flags())
+	addDiagnoseFWConfigFlags(diagnoseFirewallMSSCmd)
 
 	addImageOverrideFlag(diagnoseFirewallVxLANCmd.Flags())
 	addImageOverrideFlag(diagnoseFirewallTunnelCmd.Flags())
 	addImageOverrideFlag(diagnoseFirewallNatDiscovery.Flags())
+	addImageOverrideFlag(diagnoseFirewallMSSCmd.Flags())
 	diagnoseFirewallCmd.AddCommand(diagnoseFirewallVxLANCmd)
 	diagnoseFirewallCmd.AddCommand(diagnoseFirewallTunnelCmd)
 	diagnoseFirewallCmd.AddCommand(diagnoseFirewallNatDiscovery)
+	diagnoseFirewallCmd.AddCommand(diagnoseFirewallMSSCmd)
+	addImageOverrideFlag(diagnoseFirewallWireGuardCmd.Flags())
+	diagnoseFirewallCmd.AddCommand(diagnoseFirewallWireGuardCmd)
 }
 
 func addDiagnoseFWConfigFlags(command *cobra.Command) {
@@ -211,6 +462,10 @@ func addDiagnoseFWConfigFlags(command *cobra.Command) {
 		"time to run in seconds while validating the firewall")
 	command.Flags().BoolVar(&diagnoseFirewallOptions.VerboseOutput, "verbose", false,
 		"produce verbose output while validating the firewall")
+	command.Flags().UintVar(&diagnoseFirewallOptions.ProbePacketCount, "probe-packet-count", diagnose.DefaultProbePacketCount,
+		"number of datagrams sent by the inter-cluster firewall probes")
+	command.Flags().UintVar(&diagnoseFirewallOptions.ProbePort, "probe-port", diagnose.DefaultProbePort,
+		"source port of the inter-cluster firewall probes")
 }
 
 func firewallIntraVxLANConfig(clusterInfo *cluster.Info, namespace string, status reporter.Interface) error {
@@ -219,6 +474,13 @@ func firewallIntraVxLANConfig(clusterInfo *cluster.Info, namespace string, statu
 		clusterInfo, namespace, diagnoseFirewallOptions, status)
 }
 
+func addDiagnoseDeploymentFlags(command *cobra.Command) {
+	command.Flags().UintVar(&diagnoseDeploymentOptions.RestartThreshold, "restart-threshold", diagnose.DefaultRestartThreshold,
+		"number of restarts from which a Submariner container is reported (0 disables the check)")
+	command.Flags().UintVar(&diagnoseDeploymentOptions.MetricsTimeout, "metrics-timeout", diagnose.DefaultMetricsTimeout,
+		"time in seconds allowed to scrape each metrics endpoint")
+}
+
 func checkFirewallArguments(cmd *cobra.Command, args []string) error {
 	err := checkImageOverrides(cmd, args)
 	if err != nil {
@@ -228,38 +490,99 @@ func checkFirewallArguments(cmd *cobra.Command, args []string) error {
 	return checkNoArguments(cmd, args)
 }
 
+func images(clusterInfo *cluster.Info, namespace string, status reporter.Interface) error {
+	diagnoseImagesOptions.ImageOverrides = imageOverrides
+	return diagnose.Images(clusterInfo, namespace, diagnoseImagesOptions, status) //nolint:wrapcheck // No need to wrap error here
+}
+
+func wireGuardKernelModule(clusterInfo *cluster.Info, namespace string, status reporter.Interface) error {
+	//nolint:wrapcheck // No need to wrap error here
+	return diagnose.WireGuardKernelModule(clusterInfo, namespace, imageOverrides, status)
+}
+
+// outOfCluster wraps a check which can't work from inside a pod, so that it fails with an explicit error when the cluster
+// is accessed with the in-cluster configuration.
+func outOfCluster(function restconfig.PerContextFn) restconfig.PerContextFn {
+	return func(clusterInfo *cluster.Info, namespace string, status reporter.Interface) error {
+		if clusterInfo.InCluster {
+			return status.Error(errors.New("this check requires out-of-cluster execution, it can't be run with --in-cluster"), "")
+		}
+
+		return function(clusterInfo, namespace, status)
+	}
+}
+
 func kubeProxyMode(clusterInfo *cluster.Info, namespace string, status reporter.Interface) error {
 	return diagnose.KubeProxyMode(clusterInfo, namespace, imageOverrides, status) //nolint:wrapcheck // No need to wrap error here
 }
 
-func deployments(clusterInfo *cluster.Info, namespace string, status reporter.Interface) error {
-	return diagnose.Deployments(clusterInfo, namespace, imageOverrides, status) //nolint:wrapcheck // No need to wrap error here
+func kubeProxyConflicts(clusterInfo *cluster.Info, namespace string, status reporter.Interface) error {
+	return diagnose.KubeProxyConflicts(clusterInfo, namespace, imageOverrides, status) //nolint:wrapcheck // No need to wrap error here
 }
 
-var allDiagnoseCommands = []restconfig.PerContextFn{
-	diagnose.K8sVersion,
-	deployments,
-	restconfig.IfConnectivityInstalled(
-		diagnose.CNIConfig,
-		diagnose.Connections,
-		kubeProxyMode,
-		firewallIntraVxLANConfig,
-		diagnose.GlobalnetConfig),
-	restconfig.IfServiceDiscoveryInstalled(diagnose.ServiceDiscovery),
+func natTraversal(clusterInfo *cluster.Info, namespace string, status reporter.Interface) error {
+	return diagnose.NATTraversalHealth(clusterInfo, namespace, imageOverrides, status) //nolint:wrapcheck // No need to wrap error here
 }
 
-func diagnoseAll(status reporter.Interface) error {
-	err := diagnoseRestConfigProducer.RunOnAllContexts(
-		func(clusterInfo *cluster.Info, namespace string, status reporter.Interface) error {
-			diagnoseErrors := []error{}
+func natConfig(clusterInfo *cluster.Info, namespace string, status reporter.Interface) error {
+	return diagnose.NATConfig(clusterInfo, namespace, imageOverrides, status) //nolint:wrapcheck // No need to wrap error here
+}
 
-			for _, command := range allDiagnoseCommands {
-				diagnoseErrors = append(diagnoseErrors, command(clusterInfo, namespace, status))
+func serviceDiscovery(clusterInfo *cluster.Info, namespace string, status reporter.Interface) error {
+	//nolint:wrapcheck // No need to wrap error here
+	return diagnose.ServiceDiscovery(clusterInfo, namespace, imageOverrides, serviceDiscoveryVerbose, status)
+}
 
-				fmt.Println()
-			}
+func cniConfig(clusterInfo *cluster.Info, namespace string, status reporter.Interface) error {
+	return diagnose.CNIConfig(clusterInfo, namespace, diagnoseCNIOptions, status) //nolint:wrapcheck // No need to wrap error here
+}
+
+func deployments(clusterInfo *cluster.Info, namespace string, status reporter.Interface) error {
+	diagnoseDeploymentOptions.ImageOverrides = imageOverrides
+	return diagnose.Deployments(clusterInfo, namespace, diagnoseDeploymentOptions, status) //nolint:wrapcheck // No need to wrap error here
+}
+
+type diagnoseCheck struct {
+	name                  string
+	function              restconfig.PerContextFn
+	needsConnectivity     bool
+	needsServiceDiscovery bool
+	// needsOutOfCluster is set for checks which can't work from inside a pod, typically because they spawn privileged
+	// host-networked pods
+	needsOutOfCluster bool
+	// onlyForNetworkPlugin restricts the check to clusters using the given network plugin
+	onlyForNetworkPlugin string
+	// onlyForCableDriver restricts the check to clusters using the given cable driver
+	onlyForCableDriver string
+}
+
+var allDiagnoseChecks = []diagnoseCheck{
+	{name: "Kubernetes version", function: diagnose.K8sVersion},
+	{name: "deployments", function: deployments},
+	{name: "broker components", function: diagnose.BrokerComponents},
+	{name: "Pod Security admission", function: diagnose.PodSecurityAdmission, needsConnectivity: true},
+	{name: "CNI", function: cniConfig, needsConnectivity: true},
+	{name: "OVN", function: diagnose.OVNConfig, needsConnectivity: true, onlyForNetworkPlugin: cni.OVNKubernetes},
+	{name: "connections", function: diagnose.Connections, needsConnectivity: true},
+	{name: "gateway node labels", function: diagnose.GatewayNodeLabels, needsConnectivity: true},
+	{name: "gateway node pressure", function: diagnose.GatewayNodePressure, needsConnectivity: true},
+	{name: "kube-proxy mode", function: kubeProxyMode, needsConnectivity: true, needsOutOfCluster: true},
+	{name: "kube-proxy conflicts", function: kubeProxyConflicts, needsConnectivity: true, needsOutOfCluster: true},
+	{name: "intra-cluster firewall", function: firewallIntraVxLANConfig, needsConnectivity: true, needsOutOfCluster: true},
+	{name: "NAT traversal", function: natTraversal, needsConnectivity: true, needsOutOfCluster: true},
+	{name: "NAT configuration", function: natConfig, needsConnectivity: true, needsOutOfCluster: true},
+	{
+		name: "WireGuard", function: wireGuardKernelModule, needsConnectivity: true, needsOutOfCluster: true,
+		onlyForCableDriver: diagnose.Wireguard,
+	},
+	{name: "Globalnet", function: diagnose.GlobalnetConfig, needsConnectivity: true},
+	{name: "service discovery", function: serviceDiscovery, needsServiceDiscovery: true},
+}
 
-			return k8serrors.NewAggregate(diagnoseErrors)
+func diagnoseAll(stopOnError bool, status reporter.Interface) error {
+	err := diagnoseRestConfigProducer.RunOnAllContexts(
+		func(clusterInfo *cluster.Info, namespace string, status reporter.Interface) error {
+			return runDiagnoseChecks(allDiagnoseChecks, clusterInfo, namespace, stopOnError, status)
 		}, status)
 
 	fmt.Printf("Skipping inter-cluster firewall check as it requires two kubeconfigs." +
@@ -268,6 +591,90 @@ func diagnoseAll(status reporter.Interface) error {
 	return err //nolint:wrapcheck // No need to wrap errors here.
 }
 
+// runDiagnoseChecks runs the given checks which apply to the cluster, in order. With stopOnError, the checks are
+// stopped at the first failure, and the remaining ones which would have run are reported as skipped.
+func runDiagnoseChecks(checks []diagnoseCheck, clusterInfo *cluster.Info, namespace string, stopOnError bool,
+	status reporter.Interface,
+) error {
+	diagnoseErrors := []error{}
+	warned := set.New[string]()
+
+	for i := range checks {
+		check := &checks[i]
+
+		if applies, warning := check.applicability(clusterInfo); !applies {
+			if warning != "" {
+				warnOnce(warned, warning, status)
+			}
+
+			continue
+		}
+
+		err := check.function(clusterInfo, namespace, status)
+		diagnoseErrors = append(diagnoseErrors, err)
+
+		fmt.Println()
+
+		if err != nil && stopOnError {
+			for j := i + 1; j < len(checks); j++ {
+				if checks[j].appliesTo(clusterInfo) {
+					status.Warning("Skipped the %s check due to --fail-fast", checks[j].name)
+				}
+			}
+
+			break
+		}
+	}
+
+	return k8serrors.NewAggregate(diagnoseErrors)
+}
+
+// appliesTo returns true if the check would run on the given cluster.
+func (c *diagnoseCheck) appliesTo(clusterInfo *cluster.Info) bool {
+	applies, _ := c.applicability(clusterInfo)
+	return applies
+}
+
+// applicability determines whether the check would run on the given cluster: the components it needs must be installed,
+// the cluster must use the network plugin and cable driver it's restricted to, and it must be able to run from where
+// subctl runs. If it doesn't apply, the warning explaining why is returned, if any; checks which are irrelevant to the
+// cluster's network plugin or cable driver are skipped silently.
+func (c *diagnoseCheck) applicability(clusterInfo *cluster.Info) (bool, string) {
+	switch {
+	case c.needsConnectivity && clusterInfo.Submariner == nil:
+		return false, constants.ConnectivityNotInstalled
+	case c.needsServiceDiscovery && clusterInfo.ServiceDiscovery == nil:
+		return false, constants.ServiceDiscoveryNotInstalled
+	case c.onlyForNetworkPlugin != "" && !strings.EqualFold(clusterInfo.Submariner.Status.NetworkPlugin, c.onlyForNetworkPlugin):
+		return false, ""
+	case c.onlyForCableDriver != "" && clusterInfo.Submariner.Spec.CableDriver != c.onlyForCableDriver:
+		return false, ""
+	case c.needsOutOfCluster && clusterInfo.InCluster:
+		return false, fmt.Sprintf("Skipped the %s check (requires out-of-cluster execution)", c.name)
+	}
+
+	return true, ""
+}
+
+func warnOnce(warned set.Set[string], message string, status reporter.Interface) {
+	if !warned.Has(message) {
+		warned.Insert(message)
+		status.Warning(message)
+	}
+}
+
+func serviceDiscoveryCrossCluster() {
+	exit.OnErrorWithMessage(diagnoseServiceDiscoveryCrossClusterRestConfigProducer.RunOnSelectedContextWithPrefixes([]string{"remote"},
+		func(localClusterInfo *cluster.Info, localNamespace string, prefixedClusterInfos []*cluster.Info, status reporter.Interface) error {
+			if prefixedClusterInfos[0] == nil {
+				return errors.New("no remote context was specified")
+			}
+
+			return diagnose.ServiceDiscoveryCrossCluster( //nolint:wrapcheck // No need to wrap errors here.
+				localClusterInfo, prefixedClusterInfos[0], localNamespace, imageOverrides, status)
+		}, cli.NewReporter()), "Error running command")
+}
+
 func runLocalRemoteFirewallCommand(localRemoteRestConfigProducer *restconfig.Producer,
 	function func(
 		localClusterInfo, remoteClusterInfo *cluster.Info, namespace string, options diagnose.FirewallOptions, status reporter.Interface,
@@ -277,21 +684,12 @@ func runLocalRemoteFirewallCommand(localRemoteRestConfigProducer *restconfig.Pro
 
 	diagnoseFirewallOptions.ImageOverrides = imageOverrides
 
-	exit.OnErrorWithMessage(localRemoteRestConfigProducer.RunOnSelectedContext(
-		func(localClusterInfo *cluster.Info, localNamespace string, status reporter.Interface) error {
-			found, err := localRemoteRestConfigProducer.RunOnSelectedPrefixedContext(
-				"remote",
-				func(remoteClusterInfo *cluster.Info, _ string, status reporter.Interface) error {
-					return function(localClusterInfo, remoteClusterInfo, localNamespace, diagnoseFirewallOptions, status)
-				}, status)
-			if err != nil {
-				return err //nolint:wrapcheck // No need to wrap errors here.
-			}
-
-			if !found {
+	exit.OnErrorWithMessage(localRemoteRestConfigProducer.RunOnSelectedContextWithPrefixes([]string{"remote"},
+		func(localClusterInfo *cluster.Info, localNamespace string, prefixedClusterInfos []*cluster.Info, status reporter.Interface) error {
+			if prefixedClusterInfos[0] == nil {
 				return errors.New("no remote context was specified")
 			}
 
-			return nil
+			return function(localClusterInfo, prefixedClusterInfos[0], localNamespace, diagnoseFirewallOptions, status)
 		}, status), "Error running command")
 }
@@ -0,0 +1,128 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package subctl
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/admiral/pkg/reporter"
+	"github.com/submariner-io/subctl/internal/constants"
+	"github.com/submariner-io/subctl/internal/restconfig"
+	"github.com/submariner-io/subctl/pkg/cluster"
+	"github.com/submariner-io/submariner-operator/api/v1alpha1"
+	"github.com/submariner-io/submariner/pkg/cni"
+)
+
+// This package imports the E2E specs used by subctl verify, so its tests are plain Go tests rather than a Ginkgo suite
+// which would run them too.
+
+// warningsRecorder records the warnings it's given, ignoring the other reports.
+type warningsRecorder struct {
+	warnings []string
+}
+
+func (r *warningsRecorder) Start(_ string, _ ...interface{}) {}
+
+func (r *warningsRecorder) Success(_ string, _ ...interface{}) {}
+
+func (r *warningsRecorder) Failure(_ string, _ ...interface{}) {}
+
+func (r *warningsRecorder) End() {}
+
+func (r *warningsRecorder) Warning(message string, args ...interface{}) {
+	r.warnings = append(r.warnings, fmt.Sprintf(message, args...))
+}
+
+// diagnoseChecksTest runs a set of checks, one of which fails, some of them not applying to a non-OVN libreswan cluster
+// without service discovery.
+type diagnoseChecksTest struct {
+	clusterInfo *cluster.Info
+	recorder    *warningsRecorder
+	ran         []string
+}
+
+func newDiagnoseChecksTest(inCluster bool) *diagnoseChecksTest {
+	return &diagnoseChecksTest{
+		clusterInfo: &cluster.Info{
+			Name: "east",
+			Submariner: &v1alpha1.Submariner{
+				Spec:   v1alpha1.SubmarinerSpec{CableDriver: "libreswan"},
+				Status: v1alpha1.SubmarinerStatus{NetworkPlugin: cni.Generic},
+			},
+			InCluster: inCluster,
+		},
+		recorder: &warningsRecorder{},
+	}
+}
+
+func (t *diagnoseChecksTest) check(name string, err error) restconfig.PerContextFn {
+	return func(_ *cluster.Info, _ string, _ reporter.Interface) error {
+		t.ran = append(t.ran, name)
+		return err
+	}
+}
+
+func (t *diagnoseChecksTest) run(stopOnError bool) error {
+	checks := []diagnoseCheck{
+		{name: "first", function: t.check("first", nil)},
+		{name: "failing", function: t.check("failing", errors.New("mock failure"))},
+		{name: "OVN", function: t.check("OVN", nil), needsConnectivity: true, onlyForNetworkPlugin: cni.OVNKubernetes},
+		{name: "WireGuard", function: t.check("WireGuard", nil), needsConnectivity: true, onlyForCableDriver: "wireguard"},
+		{name: "service discovery", function: t.check("service discovery", nil), needsServiceDiscovery: true},
+		{name: "NAT traversal", function: t.check("NAT traversal", nil), needsConnectivity: true, needsOutOfCluster: true},
+		{name: "last", function: t.check("last", nil), needsConnectivity: true},
+	}
+
+	return runDiagnoseChecks(checks, t.clusterInfo, "", stopOnError, &reporter.Adapter{Basic: t.recorder})
+}
+
+func TestDiagnoseFailFastOnlyReportsApplicableChecks(t *testing.T) {
+	g := NewWithT(t)
+	test := newDiagnoseChecksTest(false)
+
+	g.Expect(test.run(true)).ToNot(Succeed())
+	g.Expect(test.ran).To(Equal([]string{"first", "failing"}))
+	g.Expect(test.recorder.warnings).To(Equal([]string{
+		"Skipped the NAT traversal check due to --fail-fast",
+		"Skipped the last check due to --fail-fast",
+	}))
+}
+
+func TestDiagnoseFailFastInClusterOmitsOutOfClusterChecks(t *testing.T) {
+	g := NewWithT(t)
+	test := newDiagnoseChecksTest(true)
+
+	g.Expect(test.run(true)).ToNot(Succeed())
+	g.Expect(test.recorder.warnings).To(Equal([]string{"Skipped the last check due to --fail-fast"}))
+}
+
+func TestDiagnoseWithoutFailFastRunsTheApplicableChecks(t *testing.T) {
+	g := NewWithT(t)
+	test := newDiagnoseChecksTest(true)
+
+	g.Expect(test.run(false)).ToNot(Succeed())
+	g.Expect(test.ran).To(Equal([]string{"first", "failing", "last"}))
+	g.Expect(test.recorder.warnings).To(Equal([]string{
+		constants.ServiceDiscoveryNotInstalled,
+		"Skipped the NAT traversal check (requires out-of-cluster execution)",
+	}))
+}
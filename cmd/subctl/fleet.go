@@ -0,0 +1,83 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package subctl
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/submariner-io/subctl/internal/fleet"
+	"github.com/submariner-io/subctl/internal/restconfig"
+)
+
+var fleetFile string
+
+// addFleetFlag adds the --fleet flag to the command and its sub-commands; the fleet file, if any, selects the contexts
+// processed by the producer and provides defaults for the flags which aren't specified on the command line.
+func addFleetFlag(cmd *cobra.Command, producer *restconfig.Producer) {
+	cmd.PersistentFlags().StringVar(&fleetFile, "fleet", "",
+		fmt.Sprintf("fleet file describing the clusters to process and default flag values (defaults to %s if it exists);"+
+			" context flags specified on the command line take precedence", fleet.DefaultPath()))
+
+	cmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		return applyFleet(cmd, args, producer)
+	}
+}
+
+func applyFleet(cmd *cobra.Command, args []string, producer *restconfig.Producer) error {
+	path := fleetFile
+	if path == "" {
+		path = fleet.DefaultPath()
+
+		if _, err := os.Stat(path); path == "" || err != nil {
+			return nil
+		}
+	}
+
+	clusterSet, err := fleet.Load(path)
+	if err != nil {
+		return err //nolint:wrapcheck // No need to wrap errors here.
+	}
+
+	if err := clusterSet.ApplyDefaults(cmd.Flags()); err != nil {
+		return err //nolint:wrapcheck // No need to wrap errors here.
+	}
+
+	// The argument checks may depend on flags which were just set from the defaults
+	if cmd.Args != nil {
+		if err := cmd.Args(cmd, args); err != nil {
+			return err
+		}
+	}
+
+	used, err := producer.UseFleet(clusterSet)
+	if err != nil {
+		return err //nolint:wrapcheck // No need to wrap errors here.
+	}
+
+	if used {
+		fmt.Printf("Using fleet file %q (revision %s)\n", clusterSet.Path, clusterSet.Revision)
+	} else {
+		fmt.Printf("Using the defaults from fleet file %q (revision %s), the contexts were selected on the command line\n",
+			clusterSet.Path, clusterSet.Revision)
+	}
+
+	return nil
+}
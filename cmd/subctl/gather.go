@@ -19,20 +19,45 @@ limitations under the License.
 package subctl
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/submariner-io/admiral/pkg/reporter"
 	"github.com/submariner-io/subctl/internal/cli"
+	"github.com/submariner-io/subctl/internal/env"
 	"github.com/submariner-io/subctl/internal/exit"
 	"github.com/submariner-io/subctl/internal/gather"
 	"github.com/submariner-io/subctl/internal/restconfig"
+	"github.com/submariner-io/subctl/internal/upload"
 	"github.com/submariner-io/subctl/pkg/cluster"
 )
 
-var options gather.Options
+var (
+	options                 gather.Options
+	gatherKubeConfig        string
+	gatherContextName       string
+	gatherUploadURL         string
+	gatherUploadMethod      string
+	gatherInsecureUpload    bool
+	gatherDeleteAfterUpload bool
+	// gatherTimestamp names the generated gather directory, and the archive when the directory is given with --dir
+	gatherTimestamp string
+	// gatherGeneratedDirectory is set when the gather directory wasn't given with --dir
+	gatherGeneratedDirectory bool
+	// gatheredClusters lists the per-cluster directories written by this run, which are the only ones archived; the ones
+	// this run created are listed in createdClusterDirectories, and are the only ones deleted after the upload
+	gatheredClusters          []string
+	createdClusterDirectories []string
+)
 
 var gatherRestConfigProducer = restconfig.NewProducer().WithContextsFlag()
 
@@ -41,22 +66,34 @@ var gatherCmd = &cobra.Command{
 	Short: "Gather troubleshooting information from a cluster",
 	Long: fmt.Sprintf("This command gathers information from a submariner cluster for troubleshooting. The information gathered "+
 		"can be selected by component (%v) and type (%v). Default is to capture all data.",
-		strings.Join(gather.AllModules.UnsortedList(), ","), strings.Join(gather.AllTypes.UnsortedList(), ",")),
+		strings.Join(gather.AllModules.SortedList(), ","), strings.Join(gather.AllTypes.SortedList(), ",")),
 	Args: checkNoArguments,
-	Run: func(_ *cobra.Command, _ []string) {
+	Run: func(cmd *cobra.Command, _ []string) {
+		gatherTimestamp = time.Now().UTC().Format("20060102150405")
+
 		if options.Directory == "" {
-			options.Directory = "submariner-" + time.Now().UTC().Format("20060102150405") // submariner-YYYYMMDDHHMMSS
+			options.Directory = "submariner-" + gatherTimestamp // submariner-YYYYMMDDHHMMSS
+			gatherGeneratedDirectory = true
 		}
 
-		err := checkGatherArguments()
+		err := checkGatherArguments(cmd.Flags())
 		exit.OnErrorWithMessage(err, "Invalid argument")
 
 		status := cli.NewReporter()
 
-		exit.OnError(gatherRestConfigProducer.RunOnAllContexts(
-			func(clusterInfo *cluster.Info, _ string, _ reporter.Interface) error {
-				return gather.Data(clusterInfo, options)
-			}, status))
+		if gatherKubeConfig == "" && gatherContextName == "" {
+			exit.OnError(gatherRestConfigProducer.RunOnAllContexts(
+				func(clusterInfo *cluster.Info, _ string, _ reporter.Interface) error {
+					recordClusterDirectory(clusterInfo.Name)
+					return gather.Data(clusterInfo, options)
+				}, status))
+		} else {
+			exit.OnError(gatherRestConfigProducer.RunOnSelectedContext(gatherWithOverriddenKubeConfig, status))
+		}
+
+		if gatherUploadURL != "" {
+			exit.OnError(uploadGatheredData(status))
+		}
 	},
 }
 
@@ -66,30 +103,184 @@ func init() {
 }
 
 func addGatherFlags(gatherCmd *cobra.Command) {
-	gatherCmd.Flags().StringSliceVar(&options.Types, "type", gather.AllTypes.UnsortedList(),
-		"comma-separated list of data types to gather")
-	gatherCmd.Flags().StringSliceVar(&options.Modules, "module", gather.AllModules.UnsortedList(),
-		"comma-separated list of components for which to gather data")
+	gatherCmd.Flags().StringSliceVar(&options.Types, "type", gather.AllTypes.SortedList(),
+		"comma-separated list of data types to gather ("+strings.Join(gather.AllTypes.SortedList(), ", ")+")")
+	gatherCmd.Flags().StringSliceVar(&options.Modules, "module", gather.AllModules.SortedList(),
+		"comma-separated list of components for which to gather data ("+strings.Join(gather.AllModules.SortedList(), ", ")+")")
 	gatherCmd.Flags().StringVar(&options.Directory, "dir", "",
 		"the directory in which to store files. If not specified, a directory of the form \"submariner-<timestamp>\" "+
 			"is created in the current directory")
 	gatherCmd.Flags().BoolVar(&options.IncludeSensitiveData, "include-sensitive-data", false,
 		"do not redact sensitive data such as credentials and security tokens")
+	gatherCmd.Flags().StringVar(&options.MetricsURL, "metrics-url", "",
+		"URL of the Prometheus API to query for metrics. If not specified, well-known Prometheus services are looked up in the cluster")
+	gatherCmd.Flags().StringVar(&options.MetricsURL, "prometheus-url", "", "URL of the Prometheus API to query for metrics")
+	_ = gatherCmd.Flags().MarkDeprecated("prometheus-url", "use --metrics-url instead")
+	gatherCmd.Flags().BoolVar(&options.MetricsURLToken, "metrics-url-use-cluster-token", false,
+		"send the cluster's bearer token to the --metrics-url Prometheus API; only use this with trusted endpoints")
+	gatherCmd.Flags().DurationVar(&options.MetricsHistory, "metrics-history", gather.DefaultMetricsHistory,
+		"how far back to retrieve metrics from Prometheus")
+	gatherCmd.Flags().IntVar(&options.Workers, "workers", gather.DefaultWorkers,
+		"the number of pods from which to retrieve logs concurrently")
+	gatherCmd.Flags().DurationVar(&options.EventsSince, "event-since", 0,
+		"only gather the events which occurred within this duration. If not specified, all the available events are gathered")
+	gatherCmd.Flags().StringVar(&gatherKubeConfig, "gather-kubeconfig", "",
+		"absolute path to the kubeconfig file to gather the data with, e.g. a break-glass admin kubeconfig; the Submariner"+
+			" deployment is still determined using --kubeconfig and --context")
+	gatherCmd.Flags().StringVar(&gatherContextName, "gather-context", "",
+		"the context to gather the data with; defaults to the current context of --gather-kubeconfig, or of --kubeconfig")
+	gatherCmd.Flags().StringVar(&gatherUploadURL, "upload-url", "",
+		"upload the gathered data, archived as a gzipped tarball, to the given pre-signed URL (e.g. an S3 or GCS URL)")
+	gatherCmd.Flags().StringVar(&gatherUploadMethod, "upload-method", http.MethodPut,
+		fmt.Sprintf("the HTTP method to upload with, one of %q", upload.Methods))
+	gatherCmd.Flags().BoolVar(&gatherInsecureUpload, "insecure-upload", false, "allow uploading to an http:// URL")
+	gatherCmd.Flags().BoolVar(&gatherDeleteAfterUpload, "delete-after-upload", false,
+		"delete the gathered data and its archive once they've been uploaded; only the per-cluster directories created by"+
+			" this run are deleted")
 	gatherRestConfigProducer.SetupFlags(gatherCmd.Flags())
+	addFleetFlag(gatherCmd, gatherRestConfigProducer)
 }
 
-func checkGatherArguments() error {
-	for _, t := range options.Types {
-		if !gather.AllTypes.Has(t) {
-			return fmt.Errorf("%q is not a supported type", t)
-		}
+func checkGatherArguments(flags *pflag.FlagSet) error {
+	if options.Workers < 1 {
+		return fmt.Errorf("the number of workers must be at least 1, got %d", options.Workers)
+	}
+
+	if (gatherKubeConfig != "" || gatherContextName != "") && flags.Changed("contexts") {
+		return errors.New("--gather-kubeconfig and --gather-context can't be used with --contexts, they apply to a single cluster")
 	}
 
-	for _, m := range options.Modules {
-		if !gather.AllModules.Has(m) {
-			return fmt.Errorf("%q is not a supported module", m)
+	if gatherUploadURL != "" {
+		if err := upload.ValidateURL(gatherUploadURL, gatherInsecureUpload); err != nil {
+			return err //nolint:wrapcheck // No need to wrap errors here.
+		}
+
+		if !slices.Contains(upload.Methods, gatherUploadMethod) {
+			return fmt.Errorf("unsupported upload method %q, the supported methods are %q", gatherUploadMethod, upload.Methods)
 		}
+	} else if flags.Changed("upload-method") || gatherInsecureUpload || gatherDeleteAfterUpload {
+		return errors.New("--upload-method, --insecure-upload and --delete-after-upload can only be used with --upload-url")
+	}
+
+	return gather.CheckOptions(&options) //nolint:wrapcheck // No need to wrap errors here.
+}
+
+// gatherWithOverriddenKubeConfig gathers the data from the given cluster using the gather-specific kubeconfig and
+// context, keeping the cluster's name and Submariner resources.
+func gatherWithOverriddenKubeConfig(clusterInfo *cluster.Info, _ string, status reporter.Interface) error {
+	gatherProducer := gatherRestConfigProducer.ForKubeConfig(gatherKubeConfig, gatherContextName)
+
+	return gatherProducer.RunOnSelectedContext( //nolint:wrapcheck // No need to wrap errors here.
+		func(gatherInfo *cluster.Info, _ string, _ reporter.Interface) error {
+			gatherInfo.Name = clusterInfo.Name
+			gatherInfo.Submariner = clusterInfo.Submariner
+			gatherInfo.ServiceDiscovery = clusterInfo.ServiceDiscovery
+
+			recordClusterDirectory(gatherInfo.Name)
+
+			return gather.Data(gatherInfo, options)
+		}, status)
+}
+
+// recordClusterDirectory records the directory the given cluster's data is about to be gathered in, noting whether this
+// run creates it.
+func recordClusterDirectory(clusterName string) {
+	if slices.Contains(gatheredClusters, clusterName) {
+		return
+	}
+
+	gatheredClusters = append(gatheredClusters, clusterName)
+
+	if _, err := os.Stat(filepath.Join(options.Directory, clusterName)); os.IsNotExist(err) {
+		createdClusterDirectories = append(createdClusterDirectories, clusterName)
+	}
+}
+
+// uploadGatheredData archives the per-cluster directories gathered by this run and uploads the archive. The archive is
+// stored next to the gather directory if subctl generated it, in the directory given with --dir otherwise. The local
+// copy is only deleted if requested, and then only the directories this run created, along with the generated gather
+// directory; anything else in a directory given with --dir is left as is.
+func uploadGatheredData(status reporter.Interface) error {
+	if len(gatheredClusters) == 0 {
+		return status.Error(errors.New("no data was gathered"), "Error uploading the gathered data")
+	}
+
+	archive := filepath.Join(options.Directory, "submariner-"+gatherTimestamp+".tar.gz")
+	if gatherGeneratedDirectory {
+		archive = options.Directory + ".tar.gz"
+	}
+
+	if err := archiveGatheredData(archive, status); err != nil {
+		return err
+	}
+
+	if err := uploadArchive(archive, status); err != nil {
+		return err
+	}
+
+	if !gatherDeleteAfterUpload {
+		return nil
+	}
+
+	status.Start("Deleting the local copy of the gathered data")
+	defer status.End()
+
+	deleteErrors := []error{os.Remove(archive)}
+
+	for _, clusterName := range createdClusterDirectories {
+		deleteErrors = append(deleteErrors, os.RemoveAll(filepath.Join(options.Directory, clusterName)))
+	}
+
+	if gatherGeneratedDirectory {
+		// Only the per-cluster directories are expected in the generated directory, so it should be empty by now
+		deleteErrors = append(deleteErrors, os.Remove(options.Directory))
+	} else if kept := len(gatheredClusters) - len(createdClusterDirectories); kept > 0 {
+		status.Warning("Kept %d per-cluster directories in %q which existed before this run", kept, options.Directory)
+	}
+
+	return status.Error(errors.Join(deleteErrors...), "Error deleting the gathered data")
+}
+
+func archiveGatheredData(archive string, status reporter.Interface) error {
+	status.Start("Archiving the gathered data to %q", archive)
+	defer status.End()
+
+	return status.Error(upload.Archive(options.Directory, archive, gatheredClusters...), "")
+}
+
+// uploadArchive uploads the archive, showing the upload progress when running in a terminal.
+func uploadArchive(archive string, status reporter.Interface) error {
+	uploadOptions := &upload.Options{
+		Method:     gatherUploadMethod,
+		Retries:    upload.DefaultRetries,
+		RetryDelay: upload.DefaultRetryDelay,
+	}
+
+	if !env.IsSmartTerminal(os.Stderr) {
+		status.Start("Uploading %q", archive)
+		defer status.End()
+
+		return status.Error(upload.File(context.Background(), gatherUploadURL, archive, uploadOptions),
+			"Error uploading %q, the gathered data was kept in %q", archive, options.Directory)
 	}
 
+	spinner := cli.NewSpinner(os.Stderr)
+	uploadOptions.Progress = func(sent, total int64) {
+		spinner.SetSuffix(fmt.Sprintf(" Uploading %q (%d%%) ", archive, sent*100/max(total, 1)))
+	}
+
+	spinner.Start()
+	err := upload.File(context.Background(), gatherUploadURL, archive, uploadOptions)
+	spinner.Stop()
+
+	// Clear the spinner's line
+	fmt.Fprint(os.Stderr, "\r\x1b[K")
+
+	if err != nil {
+		return status.Error(err, "Error uploading %q, the gathered data was kept in %q", archive, options.Directory)
+	}
+
+	status.Success("Uploaded %q", archive)
+
 	return nil
 }
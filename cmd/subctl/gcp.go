@@ -42,7 +42,7 @@ var (
 		Use:     "gcp",
 		Short:   "Prepare an OpenShift GCP cloud",
 		Long:    "This command prepares an OpenShift installer-provisioned infrastructure (IPI) on GCP cloud for Submariner installation.",
-		PreRunE: checkGCPFlags,
+		PreRunE: checkGCPPrepareFlags,
 		Run: func(_ *cobra.Command, _ []string) {
 			exit.OnError(cloudRestConfigProducer.RunOnSelectedContext(
 				func(clusterInfo *cluster.Info, _ string, status reporter.Interface) error {
@@ -88,6 +88,7 @@ func init() {
 	gcpPrepareCmd.Flags().StringVar(&gcpConfig.GWInstanceType, "gateway-instance", "n1-standard-4", "Type of gateway instance machine")
 	gcpPrepareCmd.Flags().IntVar(&gcpConfig.Gateways, "gateways", defaultNumGateways,
 		"Number of gateways to deploy")
+	addDedicatedGatewayFlag(gcpPrepareCmd, &gcpConfig.DedicatedGateway)
 
 	cloudPrepareCmd.AddCommand(gcpPrepareCmd)
 
@@ -104,3 +105,11 @@ func checkGCPFlags(_ *cobra.Command, _ []string) error {
 
 	return nil
 }
+
+func checkGCPPrepareFlags(cmd *cobra.Command, args []string) error {
+	if err := checkGCPFlags(cmd, args); err != nil {
+		return err
+	}
+
+	return checkDedicatedGatewayFlags(gcpConfig.DedicatedGateway, gcpConfig.Gateways)
+}
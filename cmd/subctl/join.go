@@ -28,6 +28,7 @@ import (
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/submariner-io/admiral/pkg/reporter"
 	"github.com/submariner-io/subctl/internal/cli"
 	"github.com/submariner-io/subctl/internal/constants"
@@ -38,13 +39,18 @@ import (
 	"github.com/submariner-io/subctl/pkg/client"
 	"github.com/submariner-io/subctl/pkg/cluster"
 	"github.com/submariner-io/subctl/pkg/join"
+	"github.com/submariner-io/subctl/pkg/serviceaccount"
 	"github.com/submariner-io/submariner-operator/pkg/discovery/network"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/strings/slices"
 )
 
 var (
-	joinFlags    join.Options
-	labelGateway bool
+	joinFlags        join.Options
+	labelGateway     bool
+	showCableDrivers bool
+	joinNoPrompt     bool
 )
 
 var joinRestConfigProducer = restconfig.NewProducer()
@@ -53,12 +59,18 @@ var joinCmd = &cobra.Command{
 	Use:   "join",
 	Short: "Connect a cluster to an existing broker",
 	Args:  checkJoinArguments,
-	Run: func(_ *cobra.Command, args []string) {
+	Run: func(cmd *cobra.Command, args []string) {
+		if showCableDrivers {
+			fmt.Println(strings.Join(join.ValidCableDrivers, "\n"))
+			return
+		}
+
 		status := cli.NewReporter()
 
 		brokerInfo, err := broker.ReadInfoFromFile(args[0])
 		exit.OnError(status.Error(err, "Error loading the broker information from the given file"))
 		status.Success("%s indicates broker is at %s", args[0], brokerInfo.BrokerURL)
+		brokerInfo.CheckCompatibility(status)
 
 		if joinFlags.BrokerURL != "" {
 			status.Success("Overriding broker URL using %s", joinFlags.BrokerURL)
@@ -67,12 +79,16 @@ var joinCmd = &cobra.Command{
 
 		exit.OnError(joinRestConfigProducer.RunOnSelectedContext(
 			func(clusterInfo *cluster.Info, _ string, status reporter.Interface) error {
-				return joinInContext(brokerInfo, clusterInfo, status)
+				return joinInContext(brokerInfo, clusterInfo, cmd.Flags(), status)
 			}, status))
 	},
 }
 
 func checkJoinArguments(cmd *cobra.Command, args []string) error {
+	if showCableDrivers {
+		return nil
+	}
+
 	if len(args) == 0 {
 		return errors.New("the broker-info.subm file argument generated by 'subctl deploy-broker' is missing")
 	}
@@ -82,6 +98,24 @@ func checkJoinArguments(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if joinFlags.GatewayNodeSelector != "" {
+		if _, err := labels.Parse(joinFlags.GatewayNodeSelector); err != nil {
+			return fmt.Errorf("invalid gateway node selector %q: %w", joinFlags.GatewayNodeSelector, err)
+		}
+	}
+
+	if joinFlags.SkipOperatorDeploy {
+		for _, name := range []string{"operator-debug", operatorNodeSelectorFlagName, "image-pull-secret"} {
+			if cmd.Flags().Changed(name) {
+				return fmt.Errorf("--%s configures the operator deployment, it can't be used with --skip-operator-deploy", name)
+			}
+		}
+	}
+
+	if err := checkOperatorNodeSelector(cmd, args); err != nil {
+		return err
+	}
+
 	return checkImageOverrides(cmd, args)
 }
 
@@ -101,20 +135,28 @@ func init() {
 
 func addJoinFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&joinFlags.ClusterID, "clusterid", "", "cluster ID used to identify the tunnels")
+	cmd.Flags().BoolVarP(&joinNoPrompt, "yes", "y", false,
+		"automatically answer yes to confirmation prompts, e.g. to use the cluster ID derived from the cluster name")
 	cmd.Flags().StringVar(&joinFlags.ServiceCIDR, "servicecidr", "", "service CIDR")
 	cmd.Flags().StringVar(&joinFlags.ClusterCIDR, "clustercidr", "", "cluster CIDR")
 	cmd.Flags().StringVar(&joinFlags.Repository, "repository", "", "image repository")
 	cmd.Flags().StringVar(&joinFlags.ImageVersion, "version", "", "image version")
+	cmd.Flags().IntVar(&joinFlags.NATTPort, "natt-port", 4500, "IPsec NATT port")
 	cmd.Flags().IntVar(&joinFlags.NATTPort, "nattport", 4500, "IPsec NATT port")
+	_ = cmd.Flags().MarkDeprecated("nattport", "use --natt-port instead")
 	cmd.Flags().BoolVar(&joinFlags.NATTraversal, "natt", true, "enable NAT traversal for IPsec")
 
 	cmd.Flags().BoolVar(&joinFlags.PreferredServer, "preferred-server", false,
 		"enable this cluster as a preferred server for dataplane connections")
 
 	addAirGappedFlag(cmd, &joinFlags.AirGappedDeployment)
+	cmd.Flags().BoolVar(&joinFlags.CheckImages, "check-images", false,
+		"check that all the images can be pulled before deploying anything (always done with --air-gapped)")
 	addLoadBalancerFlag(cmd, &joinFlags.LoadBalancerEnabled)
 	addImageOverrideFlag(cmd.Flags())
 	addHTTPProxyFlags(cmd.Flags())
+	addHTTPProxyAutodetectFlag(cmd.Flags())
+	addOperatorNodeSelectorFlag(cmd.Flags())
 
 	cmd.Flags().BoolVar(&joinFlags.ForceUDPEncaps, "force-udp-encaps", false, "force UDP encapsulation for IPSec")
 
@@ -122,8 +164,14 @@ func addJoinFlags(cmd *cobra.Command) {
 	cmd.Flags().BoolVar(&joinFlags.SubmarinerDebug, "pod-debug", false,
 		"enable Submariner pod debugging (verbose logging in the deployed pods)")
 	cmd.Flags().BoolVar(&joinFlags.OperatorDebug, "operator-debug", false, "enable operator debugging (verbose logging)")
+	cmd.Flags().BoolVar(&joinFlags.SkipOperatorDeploy, "skip-operator-deploy", false,
+		"use the operator already deployed in the cluster, e.g. by a GitOps tool, instead of deploying it")
 	cmd.Flags().BoolVar(&labelGateway, "label-gateway", true, "label gateways if necessary")
-	cmd.Flags().StringVar(&joinFlags.CableDriver, "cable-driver", "libreswan", "cable driver implementation")
+	cmd.Flags().StringVar(&joinFlags.GatewayNodeSelector, "gateway-node-selector", "",
+		"label selector restricting gateways to matching nodes (e.g. node-role.kubernetes.io/worker=); all matching nodes are labeled")
+	cmd.Flags().StringVar(&joinFlags.CableDriver, "cable-driver", "libreswan",
+		fmt.Sprintf("cable driver implementation, any of %s", strings.Join(join.ValidCableDrivers, ", ")))
+	cmd.Flags().BoolVar(&showCableDrivers, "show-cable-drivers", false, "show the supported cable drivers and exit")
 	cmd.Flags().UintVar(&joinFlags.GlobalnetClusterSize, "globalnet-cluster-size", 0,
 		"cluster size for GlobalCIDR allocated to this cluster (amount of global IPs)")
 	cmd.Flags().StringVar(&joinFlags.GlobalnetCIDR, "globalnet-cidr", "",
@@ -132,16 +180,22 @@ func addJoinFlags(cmd *cobra.Command) {
 		"list of domains to use for multicluster service discovery")
 	cmd.Flags().BoolVar(&joinFlags.HealthCheckEnabled, "health-check", true,
 		"enable Gateway health check")
-	cmd.Flags().Uint64Var(&joinFlags.HealthCheckInterval, "health-check-interval", 1,
+	cmd.Flags().Uint64Var(&joinFlags.HealthCheckInterval, "health-check-interval", join.DefaultHealthCheckInterval,
 		"interval in seconds between health check packets")
-	cmd.Flags().Uint64Var(&joinFlags.HealthCheckMaxPacketLossCount, "health-check-max-packet-loss-count", 5,
+	cmd.Flags().Uint64Var(&joinFlags.HealthCheckMaxPacketLossCount, "health-check-max-packet-loss-count",
+		join.DefaultHealthCheckMaxPacketLossCount,
 		"maximum number of packets lost before the connection is marked as down")
 	cmd.Flags().BoolVar(&joinFlags.GlobalnetEnabled, "globalnet", true,
 		"enable/disable Globalnet for this cluster")
 	cmd.Flags().StringVar(&joinFlags.CoreDNSCustomConfigMap, "coredns-custom-configmap", "",
 		"Name of the custom CoreDNS configmap to configure forwarding to lighthouse. It should be in "+
 			"<namespace>/<name> format where <namespace> is optional and defaults to kube-system")
+	cmd.Flags().StringVar(&joinFlags.ImagePullSecretName, "image-pull-secret", "",
+		"Name of the secret used to pull the operator image from a private registry. It should be in <namespace>/<name> format"+
+			" where <namespace> is optional and defaults to default; the secret is copied to the operator namespace if necessary")
 	cmd.Flags().BoolVar(&joinFlags.IgnoreRequirements, "ignore-requirements", false, "ignore requirement failures (unsupported)")
+	cmd.Flags().DurationVar(&joinFlags.TokenWaitTimeout, "token-wait-timeout", serviceaccount.DefaultTokenWaitTimeout,
+		"maximum time to wait for the token controller to generate the cluster's broker service account token")
 
 	cmd.Flags().BoolVar(&joinFlags.BrokerK8sSecure, "check-broker-certificate", true,
 		"check the broker certificate (disable this to allow \"insecure\" connections)")
@@ -153,19 +207,24 @@ func addJoinFlags(cmd *cobra.Command) {
 		"Clusterset IP CIDR to be allocated to the cluster")
 }
 
-func joinInContext(brokerInfo *broker.Info, clusterInfo *cluster.Info, status reporter.Interface) error {
+func joinInContext(brokerInfo *broker.Info, clusterInfo *cluster.Info, flags *pflag.FlagSet, status reporter.Interface) error {
 	determineClusterID(clusterInfo.Name, status)
 
 	joinFlags.ImageOverrideArr = imageOverrides
-	joinFlags.HTTPProxyConfig = httpProxyConfig
+	joinFlags.HTTPProxyConfig = resolveHTTPProxyConfig(flags, clusterInfo, status)
+	joinFlags.OperatorNodeSelector = operatorNodeSelector
 
 	ctx := context.TODO()
 	networkDetails := getNetworkDetails(ctx, clusterInfo.ClientProducer, status)
 	determinePodCIDR(networkDetails, status)
 	determineServiceCIDR(networkDetails, status)
 
-	if brokerInfo.IsConnectivityEnabled() && labelGateway {
-		possiblyLabelGateway(clusterInfo.ClientProducer.ForKubernetes(), status)
+	if brokerInfo.IsConnectivityEnabled() {
+		if joinFlags.GatewayNodeSelector != "" {
+			labelGatewaysMatching(clusterInfo.ClientProducer.ForKubernetes(), joinFlags.GatewayNodeSelector, status)
+		} else if labelGateway {
+			possiblyLabelGateway(clusterInfo.ClientProducer.ForKubernetes(), status)
+		}
 	}
 
 	if joinFlags.CustomDomains == nil && brokerInfo.CustomDomains != nil {
@@ -220,6 +279,38 @@ func possiblyLabelGateway(kubeClient kubernetes.Interface, status reporter.Inter
 	exit.OnError(status.Error(err, "Error labeling node %q as a gateway", nodeToLabel))
 }
 
+func labelGatewaysMatching(kubeClient kubernetes.Interface, selector string, status reporter.Interface) {
+	status.Start("Retrieving the nodes matching the gateway node selector %q", selector)
+	defer status.End()
+
+	matchingNodes, err := nodes.ListMatching(kubeClient, selector)
+	exit.OnError(status.Error(err, "Error retrieving the nodes matching %q", selector))
+
+	if len(matchingNodes) == 0 {
+		exit.OnError(status.Error(fmt.Errorf("no nodes match the gateway node selector %q", selector), ""))
+	}
+
+	gatewayNodes, err := nodes.ListGateways(kubeClient)
+	exit.OnError(status.Error(err, "Error retrieving the gateway nodes"))
+
+	for _, gatewayNode := range gatewayNodes {
+		if !slices.Contains(matchingNodes, gatewayNode) {
+			status.Warning("Node %q is labeled as a gateway but doesn't match the gateway node selector", gatewayNode)
+		}
+	}
+
+	for _, matchingNode := range matchingNodes {
+		if slices.Contains(gatewayNodes, matchingNode) {
+			continue
+		}
+
+		err = nodes.LabelAsGateway(kubeClient, matchingNode)
+		exit.OnError(status.Error(err, "Error labeling node %q as a gateway", matchingNode))
+
+		status.Success("Labeled node %q as a gateway", matchingNode)
+	}
+}
+
 func askForGatewayNode(workerNodeNames []string) (string, error) {
 	qs := []*survey.Question{
 		{
@@ -293,30 +384,60 @@ func askForCIDR(name string) (string, error) {
 }
 
 func determineClusterID(clusterName string, status reporter.Interface) {
-	var err error
+	if joinFlags.ClusterID != "" {
+		// An explicit cluster ID isn't sanitized, the user needs to know the ID that will be used
+		exit.OnError(status.Error(cluster.IsValidID(joinFlags.ClusterID), "Invalid cluster ID"))
+		return
+	}
 
-	if joinFlags.ClusterID == "" {
-		joinFlags.ClusterID = clusterName
+	joinFlags.ClusterID = clusterName
 
-		if err = cluster.IsValidID(joinFlags.ClusterID); err != nil {
-			joinFlags.ClusterID = cluster.SanitizeID(joinFlags.ClusterID)
-		}
-	}
+	if cluster.IsValidID(clusterName) != nil {
+		joinFlags.ClusterID = cluster.SanitizeID(clusterName)
 
-	if joinFlags.ClusterID != "" {
-		err = cluster.IsValidID(joinFlags.ClusterID)
-		if err != nil {
-			_ = status.Error(err, "Invalid cluster ID")
-			joinFlags.ClusterID = ""
+		if joinFlags.ClusterID != "" {
+			status.Warning("The cluster name %q isn't a valid cluster ID, using %q instead", clusterName, joinFlags.ClusterID)
+			exit.OnError(confirmSanitizedClusterID(status))
 		}
 	}
 
 	if joinFlags.ClusterID == "" {
+		var err error
+
 		joinFlags.ClusterID, err = askForClusterID()
 		exit.OnError(status.Error(err, "Error collecting cluster ID"))
 	}
 }
 
+// confirmSanitizedClusterID asks the user to confirm the cluster ID derived from the cluster name, unless they asked not
+// to be prompted; if they don't, the cluster ID is cleared so that it's asked for.
+func confirmSanitizedClusterID(status reporter.Interface) error {
+	if joinNoPrompt {
+		return nil
+	}
+
+	confirmed := false
+
+	err := survey.AskOne(&survey.Confirm{
+		Message: fmt.Sprintf("Use %q as the cluster ID?", joinFlags.ClusterID),
+		Default: true,
+	}, &confirmed)
+	if err != nil {
+		if isNonInteractive(err) {
+			return status.Error(errors.New("subctl is running non-interactively and cannot prompt for confirmation,"+
+				" specify --yes to use the derived cluster ID or --clusterid to choose one"), "")
+		}
+
+		return status.Error(err, "Prompt failure")
+	}
+
+	if !confirmed {
+		joinFlags.ClusterID = ""
+	}
+
+	return nil
+}
+
 func getNetworkDetails(ctx context.Context, clientProducer client.Producer, status reporter.Interface) *network.ClusterNetwork {
 	status.Start("Discovering network details")
 
@@ -19,29 +19,42 @@ limitations under the License.
 package subctl
 
 import (
+	"context"
 	"fmt"
+	"net/url"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
 
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
+	"github.com/submariner-io/admiral/pkg/reporter"
 	"github.com/submariner-io/shipyard/test/e2e/framework"
+	"github.com/submariner-io/subctl/internal/constants"
 	"github.com/submariner-io/subctl/internal/exit"
+	"github.com/submariner-io/subctl/internal/pods"
+	"github.com/submariner-io/subctl/internal/restconfig"
 	"github.com/submariner-io/subctl/pkg/cluster"
+	operatordeployment "github.com/submariner-io/subctl/pkg/operator/deployment"
 	"github.com/submariner-io/subctl/pkg/version"
 	submarineropv1a1 "github.com/submariner-io/submariner-operator/api/v1alpha1"
 	submarinerv1 "github.com/submariner-io/submariner/pkg/apis/submariner.io/v1"
 	"golang.org/x/net/http/httpproxy"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
+// The conventional exit code for processes terminated by SIGINT.
+const interruptedExitCode = 130
+
 type suppressWarnings struct{}
 
 func (suppressWarnings) HandleWarningHeader(code int, agent, message string) {
@@ -61,6 +74,11 @@ func init() {
 	rest.SetDefaultWarningHandler(suppressWarnings{})
 
 	log.SetLogger(logr.New(log.NullLogSink{}))
+
+	// Explain how to replace the removed legacy flags, instead of just reporting them as unknown
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return restconfig.LegacyFlagError(cmd.Flags(), err)
+	})
 }
 
 // rootCmd represents the base command when called without any subcommands.
@@ -73,7 +91,13 @@ var rootCmd = &cobra.Command{
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	handleInterrupts(cancel)
+	restconfig.RegisterContextCompletions(rootCmd)
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
@@ -82,7 +106,8 @@ func Execute() {
 var imageOverrides = []string{}
 
 func addImageOverrideFlag(flags *pflag.FlagSet) {
-	flags.StringSliceVar(&imageOverrides, "image-override", nil, "override component image")
+	flags.StringSliceVar(&imageOverrides, "image-override", nil,
+		"override component image, as component=image; \"*=repository\" overrides the repository for all components")
 }
 
 //nolint:wrapcheck // No need to wrap error here
@@ -93,6 +118,15 @@ func checkImageOverrides(_ *cobra.Command, _ []string) error {
 
 var httpProxyConfig httpproxy.Config
 
+var noHTTPProxyAutodetect bool
+
+const operatorNodeSelectorFlagName = "operator-node-selector"
+
+var (
+	operatorNodeSelectorFlag string
+	operatorNodeSelector     map[string]string
+)
+
 func addHTTPProxyFlags(flags *pflag.FlagSet) {
 	flags.StringVar(&httpProxyConfig.HTTPProxy, "http-proxy", "",
 		"Proxy URL to use for HTTP requests. Corresponds to the HTTP_PROXY environment variable.")
@@ -103,11 +137,104 @@ func addHTTPProxyFlags(flags *pflag.FlagSet) {
 			"Corresponds to the NO_PROXY environment variable.")
 }
 
+func addHTTPProxyAutodetectFlag(flags *pflag.FlagSet) {
+	flags.BoolVar(&noHTTPProxyAutodetect, "no-proxy-autodetect", false,
+		"don't use the cluster-wide proxy configuration detected on the cluster when the proxy flags aren't specified")
+}
+
+// resolveHTTPProxyConfig returns the proxy configuration to use for the operator on the given cluster: the proxy flags
+// which were specified, completed with the cluster-wide proxy configuration detected on the cluster, unless detection
+// is disabled.
+func resolveHTTPProxyConfig(flags *pflag.FlagSet, clusterInfo *cluster.Info, status reporter.Interface) httpproxy.Config {
+	proxyConfig := httpProxyConfig
+
+	if noHTTPProxyAutodetect || (flags.Changed("http-proxy") && flags.Changed("https-proxy") && flags.Changed("no-proxy")) {
+		return proxyConfig
+	}
+
+	detected, source, err := operatordeployment.DetectHTTPProxy(context.TODO(), clusterInfo.ClientProducer.ForDynamic(),
+		clusterInfo.ClientProducer.ForKubernetes(), constants.OperatorNamespace)
+	if err != nil {
+		status.Warning("Unable to detect the cluster-wide proxy configuration: %v", err)
+		return proxyConfig
+	}
+
+	if detected == nil {
+		return proxyConfig
+	}
+
+	proxyFlags := []struct {
+		name     string
+		value    *string
+		detected string
+	}{
+		{"http-proxy", &proxyConfig.HTTPProxy, detected.HTTPProxy},
+		{"https-proxy", &proxyConfig.HTTPSProxy, detected.HTTPSProxy},
+		{"no-proxy", &proxyConfig.NoProxy, detected.NoProxy},
+	}
+
+	applied := []string{}
+
+	for _, flag := range proxyFlags {
+		if flags.Changed(flag.name) || flag.detected == "" {
+			continue
+		}
+
+		*flag.value = flag.detected
+		applied = append(applied, fmt.Sprintf("--%s=%s", flag.name, redactProxyURL(flag.detected)))
+	}
+
+	if len(applied) > 0 {
+		status.Success("Using the proxy configuration detected from %s: %s (specify the proxy flags to override it, or"+
+			" --no-proxy-autodetect to disable the detection)", source, strings.Join(applied, " "))
+	}
+
+	return proxyConfig
+}
+
+// redactProxyURL hides the password in the given proxy URL, if any; values which aren't URLs, such as no-proxy lists,
+// are returned as is.
+func redactProxyURL(value string) string {
+	proxyURL, err := url.Parse(value)
+	if err != nil || proxyURL.User == nil {
+		return value
+	}
+
+	return proxyURL.Redacted()
+}
+
+func addOperatorNodeSelectorFlag(flags *pflag.FlagSet) {
+	flags.StringVar(&operatorNodeSelectorFlag, operatorNodeSelectorFlagName, "",
+		"node selector restricting the nodes the operator runs on (e.g. node-role.kubernetes.io/infra=); "+
+			"the previously configured selector is preserved if unspecified, an empty selector removes it")
+}
+
+// checkOperatorNodeSelector parses the operator node selector, if it was specified; operatorNodeSelector is nil otherwise,
+// so that the existing placement is preserved.
+func checkOperatorNodeSelector(cmd *cobra.Command, _ []string) error {
+	operatorNodeSelector = nil
+
+	if !cmd.Flags().Changed(operatorNodeSelectorFlagName) {
+		return nil
+	}
+
+	var err error
+
+	operatorNodeSelector, err = labels.ConvertSelectorToLabelsMap(operatorNodeSelectorFlag)
+	if err != nil {
+		return fmt.Errorf("invalid operator node selector %q: %w", operatorNodeSelectorFlag, err)
+	}
+
+	return nil
+}
+
 func setupTestFrameworkBeforeSuite() {
 	clusterInfo, err := cluster.NewInfo(framework.TestContext.ClusterIDs[framework.ClusterA],
 		framework.RestConfigs[framework.ClusterA])
 	exit.OnErrorWithMessage(err, "Error initializing the cluster information")
 
+	exit.OnErrorWithMessage(clusterInfo.LoadSubmariner(), "Error retrieving the Submariner resource")
+
 	if clusterInfo.Submariner == nil {
 		exit.WithMessage("The Submariner resource was not found which indicates submariner has not been deployed in this cluster.")
 	}
@@ -127,11 +254,37 @@ func expectFlag(flag, value string) {
 	}
 }
 
-// checkNoArguments checks that there are no arguments.
+// checkNoArguments checks that there are no arguments. Kubeconfig files used to be accepted as arguments by some
+// commands, so those get a specific error explaining what to use instead.
 func checkNoArguments(_ *cobra.Command, args []string) error {
-	if len(args) > 0 {
-		return errors.New("this command doesn't support any arguments")
+	if len(args) == 0 {
+		return nil
 	}
 
-	return nil
+	for _, arg := range args {
+		if info, err := os.Stat(arg); err == nil && !info.IsDir() {
+			return fmt.Errorf("kubeconfig files are no longer accepted as arguments (found %q); specify them with --kubeconfig"+
+				" and select the clusters with the context flags (e.g. --context and --tocontext or --remotecontext)", arg)
+		}
+	}
+
+	return errors.New("this command doesn't support any arguments")
+}
+
+// handleInterrupts ensures that the transient pods spawned by subctl (e.g. by diagnose and verify) are deleted if the user
+// interrupts it; a second interrupt exits immediately.
+func handleInterrupts(cancel context.CancelFunc) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		received := <-signals
+		signal.Stop(signals)
+		cancel()
+
+		fmt.Fprintf(os.Stderr, "\nReceived %s, deleting the transient pods created so far\n", received)
+		pods.DeleteAllScheduled()
+
+		os.Exit(interruptedExitCode)
+	}()
 }
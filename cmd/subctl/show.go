@@ -19,15 +19,33 @@ limitations under the License.
 package subctl
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
 	"github.com/spf13/cobra"
+	"github.com/submariner-io/admiral/pkg/reporter"
 	"github.com/submariner-io/subctl/internal/cli"
 	"github.com/submariner-io/subctl/internal/exit"
 	"github.com/submariner-io/subctl/internal/restconfig"
 	"github.com/submariner-io/subctl/internal/show"
+	"github.com/submariner-io/subctl/pkg/cluster"
+	submarinerv1 "github.com/submariner-io/submariner/pkg/apis/submariner.io/v1"
 )
 
 var (
 	showRestConfigProducer = restconfig.NewProducer().WithContextsFlag()
+	showOutput             string
+	showCheckCIDRConflicts bool
+	showConnectedClusters  bool
+	showBrokerCredentials  string
+	showFromBroker         bool
+	showGatewaysHAStatus   string
+	showWatch              bool
+	showWatchInterval      time.Duration
+	showWatchTimeout       time.Duration
+	showClusterScore       bool
 
 	// showCmd represents the show command.
 	showCmd = &cobra.Command{
@@ -38,28 +56,65 @@ var (
 	connectionsCmd = &cobra.Command{
 		Use:   "connections",
 		Short: "Show cluster connectivity information",
-		Long:  `This command shows information about Submariner endpoint connections with other clusters.`,
+		Long: `This command shows information about Submariner endpoint connections with other clusters.
+With --from-broker, it also compares the Endpoints and Clusters on the Broker with the local ones.
+With --watch, it shows the connections repeatedly until they're all established; with --timeout, it fails if they
+aren't established in time, so that it can be used to wait for the connections to be ready.`,
+		PreRunE: func(_ *cobra.Command, _ []string) error {
+			if showWatch && showFromBroker {
+				return errors.New("--from-broker can't be used with --watch")
+			}
+
+			if !showWatch && showWatchTimeout > 0 {
+				return errors.New("--timeout can only be used with --watch")
+			}
+
+			if showWatchInterval <= 0 {
+				return errors.New("--interval must be positive")
+			}
+
+			return nil
+		},
 		Run: func(_ *cobra.Command, _ []string) {
-			exit.OnError(
-				showRestConfigProducer.RunOnAllContexts(restconfig.IfConnectivityInstalled(show.Connections), cli.NewReporter()))
+			if showWatch {
+				exit.OnError(showRestConfigProducer.RunOnAllContexts(
+					restconfig.IfConnectivityInstalled(show.WatchConnections(showWatchInterval, showWatchTimeout)), cli.NewReporter()))
+
+				return
+			}
+
+			exit.OnError(showRestConfigProducer.RunOnAllContexts(withBrokerSync(show.Connections), cli.NewReporter()))
 		},
 	}
 	endpointsCmd = &cobra.Command{
 		Use:   "endpoints",
 		Short: "Show Submariner endpoint information",
-		Long:  `This command shows information about Submariner endpoints in a cluster.`,
+		Long: `This command shows information about Submariner endpoints in a cluster.
+With --from-broker, it also compares the Endpoints and Clusters on the Broker with the local ones.`,
+		PreRunE: func(_ *cobra.Command, _ []string) error {
+			if showFromBroker && show.OutputFormat(showOutput) != show.TableOutput {
+				return fmt.Errorf("--from-broker can only be used with the %q output format", show.TableOutput)
+			}
+
+			return nil
+		},
 		Run: func(_ *cobra.Command, _ []string) {
-			exit.OnError(
-				showRestConfigProducer.RunOnAllContexts(restconfig.IfConnectivityInstalled(show.Endpoints), cli.NewReporter()))
+			exit.OnError(showProducerForOutput().RunOnAllContexts(
+				withBrokerSync(show.EndpointsWithOutput(show.OutputFormat(showOutput))), cli.NewReporter()))
 		},
 	}
 	gatewaysCmd = &cobra.Command{
 		Use:   "gateways",
 		Short: "Show Submariner gateway summary information",
 		Long:  `This command shows summary information about the Submariner gateways in a cluster.`,
+		PreRunE: func(_ *cobra.Command, _ []string) error {
+			_, err := show.ParseHAStatus(showGatewaysHAStatus)
+			return err //nolint:wrapcheck // No need to wrap errors here.
+		},
 		Run: func(_ *cobra.Command, _ []string) {
-			exit.OnError(
-				showRestConfigProducer.RunOnAllContexts(restconfig.IfConnectivityInstalled(show.Gateways), cli.NewReporter()))
+			exit.OnError(showProducerForOutput().RunOnAllContexts(
+				restconfig.IfConnectivityInstalled(show.GatewaysWithOutput(show.OutputFormat(showOutput),
+					submarinerv1.HAStatus(showGatewaysHAStatus))), cli.NewReporter()))
 		},
 	}
 	networksCmd = &cobra.Command{
@@ -68,7 +123,7 @@ var (
 		Long:  `This command shows the status of Submariner in your cluster, and the relevant network details from your cluster.`,
 		Run: func(_ *cobra.Command, _ []string) {
 			exit.OnError(
-				showRestConfigProducer.RunOnAllContexts(show.Network, cli.NewReporter()))
+				showRestConfigProducer.RunOnAllContexts(show.NetworkWithConflictCheck(showCheckCIDRConflicts), cli.NewReporter()))
 		},
 	}
 	versionCmd = &cobra.Command{
@@ -76,39 +131,147 @@ var (
 		Short: "Shows Submariner component versions",
 		Long:  `This command shows the versions of the Submariner components in the cluster.`,
 		Run: func(_ *cobra.Command, _ []string) {
+			// The versions are determined from the deployed components, the Submariner resources aren't needed
 			exit.OnError(
-				showRestConfigProducer.RunOnAllContexts(show.Versions, cli.NewReporter()))
+				showRestConfigProducer.WithDeferredSubmarinerLookup().RunOnAllContexts(show.Versions, cli.NewReporter()))
 		},
 	}
 	brokersCmd = &cobra.Command{
 		Use:   "brokers",
 		Short: "Shows Broker information",
-		Long:  "This command shows information about the Broker in the cluster",
+		Long: `This command shows information about the Broker in the cluster.
+With --credentials, it shows the clusters connected to the Broker described in a broker information file instead,
+without requiring access to the cluster hosting the Broker.`,
 		Run: func(_ *cobra.Command, _ []string) {
+			if showBrokerCredentials != "" {
+				exit.OnError(show.BrokerMembership(showBrokerCredentials, cli.NewReporter()))
+				return
+			}
+
 			exit.OnError(
-				showRestConfigProducer.RunOnAllContexts(show.Brokers, cli.NewReporter()))
+				showRestConfigProducer.RunOnAllContexts(show.BrokersWithConnectedClusters(showConnectedClusters), cli.NewReporter()))
+		},
+	}
+	brokerInfoCmd = &cobra.Command{
+		Use:   "broker-info <broker-info.subm>",
+		Short: "Shows the contents of a broker information file",
+		Long:  "This command shows the contents of a broker information file, with its credentials redacted, and checks it",
+		Args:  cobra.ExactArgs(1),
+		Run: func(_ *cobra.Command, args []string) {
+			exit.OnError(show.BrokerInfo(args[0], cli.NewReporter()))
+		},
+	}
+	contextsCmd = &cobra.Command{
+		Use:   "contexts",
+		Short: "List the kubeconfig contexts and their Submariner installation status",
+		Long: `This command lists the kubeconfig contexts, whether their cluster is reachable, and whether Submariner
+and a Broker are installed in it, along with the deployed version.`,
+		Run: func(_ *cobra.Command, _ []string) {
+			exit.OnError(showContexts())
 		},
 	}
 	allCmd = &cobra.Command{
 		Use:   "all",
 		Short: "Show information related to a Submariner cluster",
 		Long: `This command shows information related to a Submariner cluster:
-		      networks, endpoints, gateways, connections, broker and component versions.`,
+		      networks, endpoints, gateways, connections, broker and component versions.
+With --cluster-score, it also shows a health score out of 100 for each cluster.`,
 		Run: func(_ *cobra.Command, _ []string) {
 			exit.OnError(
-				showRestConfigProducer.RunOnAllContexts(show.All, cli.NewReporter()))
+				showRestConfigProducer.RunOnAllContexts(show.AllWithClusterScore(showClusterScore), cli.NewReporter()))
 		},
 	}
 )
 
 func init() {
 	showRestConfigProducer.SetupFlags(showCmd.PersistentFlags())
+	addFleetFlag(showCmd, showRestConfigProducer)
+	showCmd.PersistentFlags().DurationVar(&cluster.ReadRetryTimeout, "api-retry-timeout", cluster.DefaultReadRetryTimeout,
+		"how long to retry reads when the API server is transiently unavailable (0 to disable retries)")
 	rootCmd.AddCommand(showCmd)
+	addFromBrokerFlag(connectionsCmd)
+	connectionsCmd.Flags().BoolVar(&showWatch, "watch", false, "refresh the connections until they're all established")
+	connectionsCmd.Flags().DurationVar(&showWatchInterval, "interval", 5*time.Second, "how often to refresh the connections with --watch")
+	connectionsCmd.Flags().DurationVar(&showWatchTimeout, "timeout", 0,
+		"with --watch, how long to wait for the connections to be established before failing (0 to wait indefinitely)")
 	showCmd.AddCommand(connectionsCmd)
+	addFromBrokerFlag(endpointsCmd)
+	addShowOutputFlag(endpointsCmd, show.TableOutput, show.DotOutput)
+	addShowOutputFlag(gatewaysCmd, show.TableOutput, show.DotOutput)
+	gatewaysCmd.Flags().StringVar(&showGatewaysHAStatus, "filter-ha-status", "",
+		fmt.Sprintf("only show the gateways with the given HA status (%s or %s)", submarinerv1.HAStatusActive,
+			submarinerv1.HAStatusPassive))
+	addShowOutputFlag(contextsCmd, show.TableOutput, show.JSONOutput)
+	showCmd.AddCommand(contextsCmd)
 	showCmd.AddCommand(endpointsCmd)
 	showCmd.AddCommand(gatewaysCmd)
+	networksCmd.Flags().BoolVar(&showCheckCIDRConflicts, "check-conflicts", false,
+		"check the local CIDRs for overlaps with the other clusters joined to the broker")
 	showCmd.AddCommand(networksCmd)
 	showCmd.AddCommand(versionCmd)
+	brokersCmd.Flags().BoolVar(&showConnectedClusters, "connected-clusters", false, "show the clusters connected to each broker")
+	brokersCmd.Flags().StringVar(&showBrokerCredentials, "credentials", "",
+		"show the clusters connected to the broker described in the given broker information file (broker-info.subm)")
 	showCmd.AddCommand(brokersCmd)
+	showCmd.AddCommand(brokerInfoCmd)
+	allCmd.Flags().BoolVar(&showClusterScore, "cluster-score", false,
+		"show a health score based on the connections, pod restarts, gateway HA status and component versions")
 	showCmd.AddCommand(allCmd)
 }
+
+func addShowOutputFlag(cmd *cobra.Command, formats ...show.OutputFormat) {
+	cmd.Flags().StringVarP(&showOutput, "output", "o", string(formats[0]), fmt.Sprintf("output format, one of %q", formats))
+
+	cmd.Args = func(cmd *cobra.Command, args []string) error {
+		if _, err := show.ParseOutputFormat(showOutput, formats...); err != nil {
+			return err //nolint:wrapcheck // No need to wrap errors here.
+		}
+
+		return checkNoArguments(cmd, args)
+	}
+}
+
+// showProducerForOutput returns the producer to use for the selected output format; with DOT, the per-cluster progress
+// output goes to the standard error so that the graphs can be piped into Graphviz.
+func showProducerForOutput() *restconfig.Producer {
+	if show.OutputFormat(showOutput) == show.DotOutput {
+		return showRestConfigProducer.WithProgressOutput(os.Stderr)
+	}
+
+	return showRestConfigProducer
+}
+
+func addFromBrokerFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&showFromBroker, "from-broker", false,
+		"also compare the Endpoints and Clusters on the broker with the local ones, to detect sync lag and stale resources")
+}
+
+// withBrokerSync runs the given function if connectivity is installed and, with --from-broker, compares the resources on
+// the broker; the comparison also runs on clusters which only host a broker.
+func withBrokerSync(function restconfig.PerContextFn) restconfig.PerContextFn {
+	if !showFromBroker {
+		return restconfig.IfConnectivityInstalled(function)
+	}
+
+	return func(clusterInfo *cluster.Info, namespace string, status reporter.Interface) error {
+		submariner, err := clusterInfo.GetSubmariner()
+		if err != nil {
+			return status.Error(err, "")
+		}
+
+		if submariner != nil {
+			err = function(clusterInfo, namespace, status)
+		}
+
+		return errors.Join(err, show.BrokerSync(clusterInfo, namespace, status))
+	}
+}
+
+func showContexts() error {
+	contexts, err := showRestConfigProducer.AllContextConfigs()
+	if err != nil {
+		return err //nolint:wrapcheck // No need to wrap errors here.
+	}
+
+	return show.Contexts(contexts, show.OutputFormat(showOutput)) //nolint:wrapcheck // No need to wrap errors here.
+}
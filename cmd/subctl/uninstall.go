@@ -21,7 +21,9 @@ limitations under the License.
 package subctl
 
 import (
+	"errors"
 	"fmt"
+	"sort"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/spf13/cobra"
@@ -35,42 +37,129 @@ import (
 )
 
 var uninstallOptions struct {
-	noPrompt bool
+	noPrompt      bool
+	forceCleanup  bool
+	keepDNSConfig bool
 }
 
-var uninstallRestConfigProducer = restconfig.NewProducer().WithDefaultNamespace(constants.OperatorNamespace)
+var uninstallRestConfigProducer = restconfig.NewProducer().WithDefaultNamespace(constants.OperatorNamespace).WithContextsFlag()
 
 var uninstallCmd = &cobra.Command{
 	Use:   "uninstall",
 	Short: "Uninstall Submariner and its components",
-	Long:  "This command uninstalls Submariner and its components",
+	Long: `This command uninstalls Submariner and its components.
+
+With --force-cleanup, it removes everything Submariner may have left behind after a failed uninstall, without relying on
+the Submariner resources: the finalizers are stripped from and all the submariner.io resources are deleted along with
+their CRDs, then the Submariner cluster roles and bindings, the Submariner and broker namespaces and the gateway node
+labels are removed.
+
+If service discovery is installed, the forwarding to the Lighthouse DNS server is also removed from the cluster DNS
+configuration, unless --keep-dns-config is given.
+
+With --contexts, it uninstalls from all the given contexts, processing the clusters hosting the broker last so that the
+other clusters can still remove their registration from it.`,
 	Run: func(_ *cobra.Command, _ []string) {
-		exit.OnError(uninstallRestConfigProducer.RunOnSelectedContext(uninstallInContext, cli.NewReporter()))
+		status := cli.NewReporter()
+
+		selected, err := uninstallRestConfigProducer.RunOnSelectedContexts(uninstallInContexts, status)
+		if !selected {
+			err = uninstallRestConfigProducer.RunOnSelectedContext(uninstallInContext, status)
+		}
+
+		exit.OnError(err)
 	},
 }
 
 func init() {
 	uninstallCmd.Flags().BoolVarP(&uninstallOptions.noPrompt, "yes", "y", false, "automatically answer yes to confirmation prompt")
+	uninstallCmd.Flags().BoolVar(&uninstallOptions.forceCleanup, "force-cleanup", false,
+		"forcibly remove all the Submariner artifacts left on the cluster, including the broker, e.g. after a failed uninstall")
+	uninstallCmd.Flags().BoolVar(&uninstallOptions.keepDNSConfig, "keep-dns-config", false,
+		"keep the Lighthouse forwarding configuration in the cluster DNS, which is otherwise removed if service discovery is installed")
 	uninstallRestConfigProducer.SetupFlags(uninstallCmd.Flags())
 	rootCmd.AddCommand(uninstallCmd)
 }
 
 func uninstallInContext(clusterInfo *cluster.Info, namespace string, status reporter.Interface) error {
-	if !uninstallOptions.noPrompt {
-		result := false
-		prompt := &survey.Confirm{
-			Message: fmt.Sprintf(
-				"This will completely uninstall Submariner from the cluster %q. Are you sure you want to continue?",
-				clusterInfo.Name),
-		}
+	if !confirmUninstall(fmt.Sprintf("the cluster %q", clusterInfo.Name)) {
+		return nil
+	}
+
+	return uninstallFromCluster(clusterInfo, namespace, status)
+}
+
+// uninstallInContexts uninstalls from all the given clusters, with a single confirmation prompt; the clusters hosting
+// the broker are processed last. All the clusters are processed, and any errors are aggregated.
+func uninstallInContexts(clusterInfos []*cluster.Info, namespaces []string, status reporter.Interface) error {
+	clusterNames := make([]string, len(clusterInfos))
+	for i := range clusterInfos {
+		clusterNames[i] = clusterInfos[i].Name
+	}
+
+	if !confirmUninstall(fmt.Sprintf("the clusters %q", clusterNames)) {
+		return nil
+	}
 
-		_ = survey.AskOne(prompt, &result)
+	hostsBroker := make([]bool, len(clusterInfos))
+	order := make([]int, len(clusterInfos))
 
-		if !result {
-			return nil
+	for i := range clusterInfos {
+		order[i] = i
+
+		var err error
+
+		hostsBroker[i], err = uninstall.HostsBroker(clusterInfos[i].ClientProducer, clusterInfos[i].Name, status)
+		if err != nil {
+			return err //nolint:wrapcheck // No need to wrap errors here.
 		}
 	}
 
+	sort.SliceStable(order, func(i, j int) bool {
+		return !hostsBroker[order[i]] && hostsBroker[order[j]]
+	})
+
+	var errs []error
+
+	for n, i := range order {
+		fmt.Printf("Cluster %q (%d/%d)\n", clusterInfos[i].Name, n+1, len(order))
+
+		errs = append(errs, uninstallFromCluster(clusterInfos[i], namespaces[i], status))
+
+		fmt.Println()
+	}
+
+	return errors.Join(errs...)
+}
+
+// confirmUninstall asks for confirmation before uninstalling from the given target, unless the prompt is disabled.
+func confirmUninstall(target string) bool {
+	if uninstallOptions.noPrompt {
+		return true
+	}
+
+	message := "This will completely uninstall Submariner from %s. Are you sure you want to continue?"
+	if uninstallOptions.forceCleanup {
+		message = "This will forcibly remove all the Submariner resources, including any broker, from %s." +
+			" Are you sure you want to continue?"
+	}
+
+	result := false
+	prompt := &survey.Confirm{
+		Message: fmt.Sprintf(message, target),
+	}
+
+	_ = survey.AskOne(prompt, &result)
+
+	return result
+}
+
+func uninstallFromCluster(clusterInfo *cluster.Info, namespace string, status reporter.Interface) error {
+	if uninstallOptions.forceCleanup {
+		return uninstall.ForceCleanup( //nolint:wrapcheck // No need to wrap errors here.
+			clusterInfo.ClientProducer, clusterInfo.Name, namespace, status)
+	}
+
 	return uninstall.All( //nolint:wrapcheck // No need to wrap errors here.
-		clusterInfo.ClientProducer, clusterInfo.Name, namespace, status)
+		clusterInfo.ClientProducer, clusterInfo.Name, namespace, !uninstallOptions.keepDNSConfig, status)
 }
@@ -22,17 +22,23 @@ package subctl
 
 import (
 	"context"
+	goerrors "errors"
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
-	"github.com/coreos/go-semver/semver"
+	"github.com/AlecAivazis/survey/v2"
 	"github.com/google/go-github/v54/github"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/submariner-io/admiral/pkg/names"
 	"github.com/submariner-io/admiral/pkg/reporter"
 	"github.com/submariner-io/subctl/internal/cli"
+	"github.com/submariner-io/subctl/internal/component"
 	"github.com/submariner-io/subctl/internal/constants"
 	"github.com/submariner-io/subctl/internal/exit"
 	"github.com/submariner-io/subctl/internal/restconfig"
@@ -41,14 +47,35 @@ import (
 	"github.com/submariner-io/subctl/pkg/deploy"
 	"github.com/submariner-io/subctl/pkg/image"
 	"github.com/submariner-io/subctl/pkg/operator"
+	"github.com/submariner-io/subctl/pkg/postupgrade"
 	"github.com/submariner-io/subctl/pkg/secret"
 	"github.com/submariner-io/subctl/pkg/version"
+	"golang.org/x/net/http/httpproxy"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
+// UpgradeOptions controls the upgrade of Submariner in each cluster.
+type UpgradeOptions struct {
+	force             bool
+	noPrompt          bool
+	skipPostChecks    bool
+	postChecksTimeout time.Duration
+	// UpgradeComponents restricts the upgrade to the given components; all the components are upgraded if it's empty
+	UpgradeComponents []string
+}
+
+// upgradableComponents are the components which can be upgraded individually.
+var upgradableComponents = []string{component.Broker, component.Operator, component.Connectivity, component.ServiceDiscovery}
+
 var (
+	upgradeOptions UpgradeOptions
+	// postCheckFailures lists the clusters on which the post-upgrade checks failed.
+	postCheckFailures []string
+	// subctlDowngradeVersion is set when subctl itself needs to be downgraded once Submariner has been.
+	subctlDowngradeVersion    string
+	downgradeConfirmed        bool
 	upgradeSubctlVersion      string
 	upgradeOperatorVersion    string
 	upgradeSubmarinerVersion  string
@@ -59,7 +86,14 @@ var (
 var upgradeCmd = &cobra.Command{
 	Use:   "upgrade",
 	Short: "Upgrades Submariner",
-	Run:   upgrade,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := checkUpgradeComponents(); err != nil {
+			return err
+		}
+
+		return checkOperatorNodeSelector(cmd, args)
+	},
+	Run: upgrade,
 }
 
 func init() {
@@ -68,12 +102,25 @@ func init() {
 	_ = upgradeCmd.Flags().MarkHidden("to-operator-version")
 	upgradeCmd.Flags().StringVar(&upgradeSubmarinerVersion, "to-submariner-version", "", "the version of Submariner to which to upgrade")
 	_ = upgradeCmd.Flags().MarkHidden("to-submariner-version")
+	upgradeCmd.Flags().BoolVar(&upgradeOptions.force, "force", false,
+		"allow downgrading subctl and Submariner to an older version (unsupported, migration steps will be skipped)")
+	upgradeCmd.Flags().BoolVarP(&upgradeOptions.noPrompt, "yes", "y", false, "automatically answer yes to confirmation prompts")
+	upgradeCmd.Flags().BoolVar(&upgradeOptions.skipPostChecks, "skip-post-checks", false,
+		"skip verifying that the components, connections and service discovery still work after the upgrade")
+	upgradeCmd.Flags().DurationVar(&upgradeOptions.postChecksTimeout, "post-checks-timeout", postupgrade.DefaultTimeout,
+		"how long each post-upgrade check waits for the cluster to recover")
+	upgradeCmd.Flags().StringSliceVar(&upgradeOptions.UpgradeComponents, "components", nil,
+		fmt.Sprintf("comma-separated list of the components to upgrade (%s); all the deployed components are upgraded by default."+
+			" Upgrading the broker also upgrades the operator", strings.Join(upgradableComponents, ", ")))
 	upgradeRestConfigProducer.SetupFlags(upgradeCmd.Flags())
+	addFleetFlag(upgradeCmd, upgradeRestConfigProducer)
 	addHTTPProxyFlags(upgradeCmd.Flags())
+	addHTTPProxyAutodetectFlag(upgradeCmd.Flags())
+	addOperatorNodeSelectorFlag(upgradeCmd.Flags())
 	rootCmd.AddCommand(upgradeCmd)
 }
 
-func upgrade(_ *cobra.Command, _ []string) {
+func upgrade(cmd *cobra.Command, _ []string) {
 	status := cli.NewReporter()
 
 	// Step 1: upgrade subctl to match the requested version
@@ -91,11 +138,31 @@ func upgrade(_ *cobra.Command, _ []string) {
 		}
 		// exit.OnError outputs the version of subctl, which ends up being confusing here
 		if err := cmd.Run(); err != nil {
+			// Preserve the exit code, it distinguishes post-upgrade check failures
+			var exitErr *exec.ExitError
+			if goerrors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+				os.Exit(exitErr.ExitCode())
+			}
+
 			os.Exit(1)
 		}
 	} else {
 		// Step 2b: this subctl is already the requested version, run it
-		exit.OnError(upgradeRestConfigProducer.RunOnAllContexts(upgradeSubmariner, status))
+		exit.OnError(upgradeRestConfigProducer.RunOnAllContexts(
+			func(clusterInfo *cluster.Info, namespace string, status reporter.Interface) error {
+				return upgradeSubmariner(clusterInfo, namespace, cmd.Flags(), status)
+			}, status))
+
+		// Step 3: the Submariner downgrade is done, downgrade subctl too
+		if subctlDowngradeVersion != "" {
+			_, err := installSubctl(subctlDowngradeVersion, status)
+			exit.OnError(err)
+		}
+
+		if len(postCheckFailures) > 0 {
+			exit.WithCode(fmt.Sprintf("The upgrade completed but the post-upgrade checks failed on %s",
+				strings.Join(postCheckFailures, ", ")), exit.PostCheckFailureCode)
+		}
 	}
 }
 
@@ -116,36 +183,44 @@ func upgradeSubctl(status reporter.Interface) (string, error) {
 		}
 	}
 
-	if upgradeSubctlVersion == version.Version {
-		// Already running the right version
-		return "", nil
-	}
-
 	if upgradeSubctlVersion != "" {
 		upgradeSubctlVersion = strings.TrimPrefix(upgradeSubctlVersion, "v")
 
-		toVersion, err := semver.NewVersion(upgradeSubctlVersion)
-		if toVersion == nil {
-			return "", status.Error(err, "Invalid target version")
+		direction, err := version.Compare(version.Version, upgradeSubctlVersion)
+		if err != nil {
+			return "", status.Error(err, "")
 		}
 
-		// semver needs a dotted triplet, which is at least five characters;
-		// on development or unknown versions, assume we need to upgrade
-		if len(version.Version) >= 5 && !strings.HasPrefix(version.Version, "devel") && !strings.HasPrefix(version.Version, "release") {
-			currentVersion, err := semver.NewVersion(strings.TrimPrefix(version.Version, "v"))
-			if currentVersion == nil {
-				return "", status.Error(err, "Error parsing current subctl version")
+		switch direction {
+		case version.Unchanged:
+			// Already running the right version
+			return "", nil
+		case version.Downgrade:
+			if !upgradeOptions.force {
+				return "", nil
 			}
 
-			if toVersion.LessThan(*currentVersion) || toVersion.Equal(*currentVersion) {
-				return "", nil
+			if err := confirmDowngrade("subctl", version.Version, upgradeSubctlVersion, status); err != nil {
+				return "", err
 			}
+
+			// The newer subctl knows how to handle the deployed resources, so it performs the Submariner downgrade
+			// itself and only then is replaced by the requested version
+			subctlDowngradeVersion = "v" + upgradeSubctlVersion
+
+			return "", nil
+		case version.Upgrade:
 		}
 
-		targetVersionString = "v" + toVersion.String()
+		targetVersionString = "v" + upgradeSubctlVersion
 	}
 
-	status.Start("Upgrading subctl from %s to %s, replacing %s", version.Version, targetVersionString, os.Args[0])
+	return installSubctl(targetVersionString, status)
+}
+
+// installSubctl replaces the running subctl with the given version and returns the path to it.
+func installSubctl(targetVersionString string, status reporter.Interface) (string, error) {
+	status.Start("Replacing subctl %s with %s in %s", version.Version, targetVersionString, os.Args[0])
 
 	url := "https://get.submariner.io"
 
@@ -154,10 +229,10 @@ func upgradeSubctl(status reporter.Interface) (string, error) {
 		return "", status.Error(err, "Error determining the installation path")
 	}
 
-	_, err = exec.Command( //nolint:gosec // The user-controlled variables are sanitised above
+	_, err = exec.Command( //nolint:gosec // The user-controlled variables are sanitised by the version checks
 		"sh", "-c", "curl "+url+" | VERSION="+targetVersionString+" DESTDIR="+filepath.Dir(absolutePath)+" bash").CombinedOutput()
 	if err != nil {
-		return "", status.Error(err, "Error upgrading subctl")
+		return "", status.Error(err, "Error installing subctl %s", targetVersionString)
 	}
 
 	status.End()
@@ -165,7 +240,51 @@ func upgradeSubctl(status reporter.Interface) (string, error) {
 	return absolutePath, nil
 }
 
-func upgradeSubmariner(clusterInfo *cluster.Info, _ string, status reporter.Interface) error {
+// confirmDowngrade warns about downgrading the given component and asks the user to confirm, unless they already
+// confirmed a downgrade or asked not to be prompted.
+func confirmDowngrade(component, fromVersion, toVersion string, status reporter.Interface) error {
+	status.Warning("DOWNGRADING %s from %s to %s. Downgrades are not tested and skip any migration steps a release may depend on;"+
+		" the deployment may be left in an inconsistent state.", component, fromVersion, toVersion)
+
+	if upgradeOptions.noPrompt || downgradeConfirmed {
+		return nil
+	}
+
+	err := survey.AskOne(&survey.Confirm{
+		Message: fmt.Sprintf("Are you sure you want to downgrade %s to %s?", component, toVersion),
+	}, &downgradeConfirmed)
+	if err != nil {
+		if isNonInteractive(err) {
+			return status.Error(goerrors.New("subctl is running non-interactively and cannot prompt for confirmation,"+
+				" specify --yes to downgrade"), "")
+		}
+
+		return status.Error(err, "Prompt failure")
+	}
+
+	if !downgradeConfirmed {
+		return status.Error(goerrors.New("downgrade cancelled"), "")
+	}
+
+	return nil
+}
+
+// checkDowngrade verifies whether moving the given component from its deployed version to the target version
+// is a downgrade, and if so whether the user allowed it.
+func checkDowngrade(component, deployedVersion, targetVersion string, status reporter.Interface) error {
+	if !isDowngrade(deployedVersion, targetVersion) {
+		return nil
+	}
+
+	if !upgradeOptions.force {
+		return status.Error(fmt.Errorf("%s %s is older than the deployed version %s; use --force to downgrade",
+			component, targetVersion, deployedVersion), "")
+	}
+
+	return confirmDowngrade(component, deployedVersion, targetVersion, status)
+}
+
+func upgradeSubmariner(clusterInfo *cluster.Info, _ string, flags *pflag.FlagSet, status reporter.Interface) error {
 	ctx := context.TODO()
 
 	// We only expect users to specify a subctl version, if any ("--to-version"). In such scenarios,
@@ -180,10 +299,20 @@ func upgradeSubmariner(clusterInfo *cluster.Info, _ string, status reporter.Inte
 		upgradeOperatorVersion = upgradeSubctlVersion
 	}
 
+	preUpgradeState := capturePreUpgradeState(ctx, clusterInfo, status)
+
+	proxyConfig := resolveHTTPProxyConfig(flags, clusterInfo, status)
+
 	// Upgrade Broker if installed; role updates are part of Broker redeploy
-	brokerUpgraded, err := upgradeBroker(ctx, clusterInfo, status)
-	if err != nil {
-		return err
+	brokerUpgraded := false
+
+	if shouldUpgrade(component.Broker) {
+		var err error
+
+		brokerUpgraded, err = upgradeBroker(ctx, clusterInfo, &proxyConfig, status)
+		if err != nil {
+			return err
+		}
 	}
 
 	var repository string
@@ -204,9 +333,13 @@ func upgradeSubmariner(clusterInfo *cluster.Info, _ string, status reporter.Inte
 	}
 
 	// If a Broker was upgraded in this context, the Operator has already been upgraded
-	if !brokerUpgraded {
+	if !brokerUpgraded && shouldUpgrade(component.Operator) {
+		if err := checkDowngrade("the Operator", operatorVersion(clusterInfo), upgradeOperatorVersion, status); err != nil {
+			return err
+		}
+
 		// Upgrade Operator if deployed
-		if err := upgradeOperator(ctx, clusterInfo, repository, debug, imageOverride, status); err != nil {
+		if err := upgradeOperator(ctx, clusterInfo, repository, debug, imageOverride, &proxyConfig, status); err != nil {
 			return err
 		}
 	}
@@ -221,16 +354,87 @@ func upgradeSubmariner(clusterInfo *cluster.Info, _ string, status reporter.Inte
 		logVersion = upgradeSubmarinerVersion
 	}
 
+	deployedVersion := ""
+	if clusterInfo.Submariner != nil {
+		deployedVersion = clusterInfo.Submariner.Spec.Version
+	} else {
+		deployedVersion = clusterInfo.ServiceDiscovery.Spec.Version
+	}
+
+	if shouldUpgrade(component.Connectivity) || shouldUpgrade(component.ServiceDiscovery) {
+		if err := checkDowngrade("Submariner", deployedVersion, logVersion, status); err != nil {
+			return err
+		}
+	}
+
 	// Upgrade Submariner
-	if err := upgradeConnectivity(ctx, clusterInfo, logVersion, status); err != nil {
-		return err
+	if shouldUpgrade(component.Connectivity) {
+		if err := upgradeConnectivity(ctx, clusterInfo, logVersion, status); err != nil {
+			return err
+		}
 	}
 
 	// Upgrade Service discovery
-	return upgradeServiceDiscovery(ctx, clusterInfo, logVersion, status)
+	if shouldUpgrade(component.ServiceDiscovery) {
+		if err := upgradeServiceDiscovery(ctx, clusterInfo, logVersion, status); err != nil {
+			return err
+		}
+	}
+
+	runPostUpgradeChecks(ctx, clusterInfo, preUpgradeState, status)
+
+	return nil
 }
 
-func upgradeBroker(ctx context.Context, clusterInfo *cluster.Info, status reporter.Interface) (bool, error) {
+func checkUpgradeComponents() error {
+	for _, requested := range upgradeOptions.UpgradeComponents {
+		if !slices.Contains(upgradableComponents, requested) {
+			return fmt.Errorf("%q is not a component which can be upgraded, the supported components are %s", requested,
+				strings.Join(upgradableComponents, ", "))
+		}
+	}
+
+	return nil
+}
+
+// shouldUpgrade determines whether the given component was selected for upgrade; all the components are when none is
+// selected explicitly.
+func shouldUpgrade(name string) bool {
+	return len(upgradeOptions.UpgradeComponents) == 0 || slices.Contains(upgradeOptions.UpgradeComponents, name)
+}
+
+// capturePreUpgradeState records what the post-upgrade checks compare against; it returns nil if the checks are skipped,
+// or if the state can't be determined.
+func capturePreUpgradeState(ctx context.Context, clusterInfo *cluster.Info, status reporter.Interface) *postupgrade.State {
+	if upgradeOptions.skipPostChecks || (clusterInfo.Submariner == nil && clusterInfo.ServiceDiscovery == nil) {
+		return nil
+	}
+
+	state, err := postupgrade.CaptureState(ctx, clusterInfo)
+	if err != nil {
+		status.Warning("Unable to capture the state of cluster %q before the upgrade, the post-upgrade checks will be skipped: %v",
+			clusterInfo.Name, err)
+		return nil
+	}
+
+	return state
+}
+
+func runPostUpgradeChecks(ctx context.Context, clusterInfo *cluster.Info, preUpgradeState *postupgrade.State, status reporter.Interface) {
+	if preUpgradeState == nil {
+		return
+	}
+
+	status.Start("Verifying cluster %q after the upgrade", clusterInfo.Name)
+	defer status.End()
+
+	if err := postupgrade.Verify(ctx, clusterInfo, preUpgradeState, upgradeOptions.postChecksTimeout, status); err != nil {
+		postCheckFailures = append(postCheckFailures, fmt.Sprintf("%q", clusterInfo.Name))
+	}
+}
+
+func upgradeBroker(ctx context.Context, clusterInfo *cluster.Info, proxyConfig *httpproxy.Config, status reporter.Interface,
+) (bool, error) {
 	status.Start("Checking if the Broker is installed")
 	defer status.End()
 
@@ -248,7 +452,7 @@ func upgradeBroker(ctx context.Context, clusterInfo *cluster.Info, status report
 		ImageVersion:    upgradeOperatorVersion,
 		BrokerNamespace: brokerObj.Namespace,
 		BrokerSpec:      brokerObj.Spec,
-		HTTPProxyConfig: httpProxyConfig,
+		HTTPProxyConfig: *proxyConfig,
 	}
 
 	err = deploy.Deploy(ctx, options, status, clusterInfo.ClientProducer)
@@ -299,8 +503,36 @@ func migrateBrokerSecret(ctx context.Context, kubeClient kubernetes.Interface, f
 	return newSecret.Name, nil
 }
 
+// isDowngrade returns true if both versions are known and the target is older. Versions which can't be compared
+// are used during development, so they're let through.
+func isDowngrade(fromVersion, toVersion string) bool {
+	if fromVersion == "" || toVersion == "" {
+		return false
+	}
+
+	direction, err := version.Compare(fromVersion, toVersion)
+
+	return err == nil && direction == version.Downgrade
+}
+
+// operatorVersion returns the image tag of the deployed operator, or an empty string if it can't be determined.
+func operatorVersion(clusterInfo *cluster.Info) string {
+	operatorDeployment, err := clusterInfo.ClientProducer.ForKubernetes().AppsV1().Deployments(constants.OperatorNamespace).
+		Get(context.TODO(), names.OperatorComponent, metav1.GetOptions{})
+	if err != nil || len(operatorDeployment.Spec.Template.Spec.Containers) == 0 {
+		return ""
+	}
+
+	_, tag, found := strings.Cut(operatorDeployment.Spec.Template.Spec.Containers[0].Image, ":")
+	if !found || strings.Contains(tag, "/") {
+		return ""
+	}
+
+	return tag
+}
+
 func upgradeOperator(ctx context.Context, clusterInfo *cluster.Info, repository string, debug bool, imageOverride map[string]string,
-	status reporter.Interface,
+	proxyConfig *httpproxy.Config, status reporter.Interface,
 ) error {
 	status.Start("Checking if the Operator is installed")
 	defer status.End()
@@ -320,12 +552,17 @@ func upgradeOperator(ctx context.Context, clusterInfo *cluster.Info, repository
 	repositoryInfo := image.NewRepositoryInfo(repository, upgradeOperatorVersion, imageOverride)
 
 	err = operator.Ensure(ctx, status, clusterInfo.ClientProducer, constants.OperatorNamespace, repositoryInfo.GetOperatorImage(), debug,
-		&httpProxyConfig)
+		proxyConfig, operatorNodeSelector, nil)
 
 	return status.Error(err, "Error upgrading the Operator")
 }
 
 func upgradeConnectivity(ctx context.Context, clusterInfo *cluster.Info, logVersion string, status reporter.Interface) error {
+	// The upgraded operator may already have updated the Submariner resource
+	if err := clusterInfo.RefreshSubmariner(ctx); err != nil {
+		return status.Error(err, "Error retrieving the Submariner resource")
+	}
+
 	if clusterInfo.Submariner != nil {
 		status.Start("Upgrading the Connectivity component to %s", logVersion)
 		defer status.End()
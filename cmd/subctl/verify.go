@@ -20,10 +20,13 @@ package subctl
 
 //nolint:revive // Blank imports below are intentional.
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"os"
+	"regexp"
+	"sort"
 	"strings"
 	"syscall"
 	"testing"
@@ -46,10 +49,15 @@ import (
 	"github.com/submariner-io/submariner/test/e2e/compliance"
 	"github.com/submariner-io/submariner/test/e2e/dataplane"
 	"github.com/submariner-io/submariner/test/e2e/redundancy"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/rest"
 )
 
-const globalnetLabel = "globalnet"
+const (
+	globalnetLabel = "globalnet"
+	// The E2E framework sets this label, with the test's base name, on the namespaces it creates
+	e2eFrameworkLabel = "e2e-framework"
+)
 
 var (
 	verboseConnectivityVerification bool
@@ -83,34 +91,24 @@ disruptive verifications are skipped.
 
 The following verifications are deemed disruptive:
 
-    ` + strings.Join(disruptiveVerificationNames(), "\n    "),
+    ` + strings.Join(disruptiveVerificationNames(), "\n    ") + `
+
+The service discovery verifications can be restricted to specific service types with the following verifications,
+which can only be combined with each other:
+
+    ` + strings.Join(serviceDiscoveryVerificationNames(), "\n    "),
 	Args: checkVerifyArguments,
 	Run: func(cmd *cobra.Command, _ []string) {
-		exit.OnError(verifyRestConfigProducer.RunOnSelectedContext(
-			func(fromClusterInfo *cluster.Info, namespace string, status reporter.Interface) error {
-				// Try to run using the "to" context
-				toContextPresent, err := verifyRestConfigProducer.RunOnSelectedPrefixedContext(
-					"to",
-					func(toClusterInfo *cluster.Info, _ string, status reporter.Interface) error {
-						extraContextPresent, err := verifyRestConfigProducer.RunOnSelectedPrefixedContext(
-							"extra",
-							func(extraClusterInfo *cluster.Info, _ string, _ reporter.Interface) error {
-								return runVerify(fromClusterInfo, toClusterInfo, extraClusterInfo, namespace, determineSpecLabelsToVerify())
-							}, status)
-						if extraContextPresent {
-							return err //nolint:wrapcheck // No need to wrap errors here.
-						}
-
-						return runVerify(fromClusterInfo, toClusterInfo, nil, namespace, determineSpecLabelsToVerify())
-					}, status)
-
-				if toContextPresent {
-					return err //nolint:wrapcheck // No need to wrap errors here.
+		exit.OnError(verifyRestConfigProducer.RunOnSelectedContextWithPrefixes([]string{"to", "extra"},
+			func(fromClusterInfo *cluster.Info, namespace string, prefixedClusterInfos []*cluster.Info, _ reporter.Interface) error {
+				toClusterInfo, extraClusterInfo := prefixedClusterInfos[0], prefixedClusterInfos[1]
+				if toClusterInfo == nil {
+					exit.WithMessage(fmt.Sprintf(
+						"This command requires two kube contexts corresponding to the two clusters to verify.\n%s", cmd.UsageString()))
+					return nil
 				}
 
-				exit.WithMessage(fmt.Sprintf(
-					"This command requires two kube contexts corresponding to the two clusters to verify.\n%s", cmd.UsageString()))
-				return nil
+				return runVerify(fromClusterInfo, toClusterInfo, extraClusterInfo, namespace, determineSpecLabelsToVerify())
 			}, cli.NewReporter()))
 	},
 }
@@ -168,6 +166,10 @@ func checkVerifyArguments(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if _, err := getVerifySpecFocus(verifyOnly); err != nil {
+		return err
+	}
+
 	err := checkImageOverrides(cmd, args)
 	if err != nil {
 		return err
@@ -188,6 +190,15 @@ var verifyE2EDisruptiveSpecLabels = map[string]string{
 	"gateway-failover": redundancy.TestLabel,
 }
 
+// The lighthouse tests all have the same label, so the verifications of specific service types select the tests by
+// their container's description; since that narrows down all the selected tests, they can't be combined with other
+// verifications.
+var verifyE2EServiceDiscoverySpecs = map[string]string{
+	component.ServiceDiscovery + "-clusterip":   "Test Service Discovery Across Clusters",
+	component.ServiceDiscovery + "-headless":    "Test Headless Service Discovery Across Clusters",
+	component.ServiceDiscovery + "-statefulset": "Test Stateful Sets Discovery Across Clusters",
+}
+
 type verificationType int
 
 const (
@@ -205,6 +216,17 @@ func disruptiveVerificationNames() []string {
 	return names
 }
 
+func serviceDiscoveryVerificationNames() []string {
+	names := make([]string, 0, len(verifyE2EServiceDiscoverySpecs))
+	for n := range verifyE2EServiceDiscoverySpecs {
+		names = append(names, n)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
 func extractDisruptiveVerifications(csv string) []string {
 	var disruptive []string
 
@@ -242,9 +264,36 @@ func getVerifySpecLabel(key string) (verificationType, string) {
 		return disruptiveVerification, pattern
 	}
 
+	if _, ok := verifyE2EServiceDiscoverySpecs[key]; ok {
+		return normalVerification, discovery.TestLabel
+	}
+
 	return unknownVerification, ""
 }
 
+// getVerifySpecFocus returns the focus restricting the tests to the selected service discovery verifications, if any.
+func getVerifySpecFocus(csv string) ([]string, error) {
+	focus := []string{}
+	others := []string{}
+
+	for _, verification := range strings.Split(csv, ",") {
+		verification = strings.Trim(strings.ToLower(verification), " ")
+
+		if description, ok := verifyE2EServiceDiscoverySpecs[verification]; ok {
+			focus = append(focus, "^"+regexp.QuoteMeta(description)+" ")
+		} else {
+			others = append(others, verification)
+		}
+	}
+
+	if len(focus) > 0 && len(others) > 0 {
+		return nil, fmt.Errorf("the verifications %s can't be combined with %s", strings.Join(serviceDiscoveryVerificationNames(), ", "),
+			strings.Join(others, ", "))
+	}
+
+	return focus, nil
+}
+
 func getVerifySpecLabels(csv string, includeDisruptive bool) ([]string, []string, error) {
 	outputLabels := []string{}
 	outputVerifications := []string{}
@@ -323,10 +372,22 @@ func runVerify(fromClusterInfo, toClusterInfo, extraClusterInfo *cluster.Info, n
 	// This field isn't used for verify so set it to some non-empty string to bypass shipyard's validation checking.
 	framework.TestContext.KubeConfig = "not-used"
 
+	// The operator may have updated the Submariner resource since the cluster information was retrieved
+	if err := fromClusterInfo.RefreshSubmariner(context.TODO()); err != nil {
+		return err //nolint:wrapcheck // No need to wrap errors here.
+	}
+
+	if fromClusterInfo.Submariner == nil {
+		return fmt.Errorf("the Submariner connectivity components are not installed on cluster %q", fromClusterInfo.Name)
+	}
+
 	suiteConfig, reporterConfig := ginkgo.GinkgoConfiguration()
 	suiteConfig.RandomSeed = 1
 	suiteConfig.LabelFilter = strings.Join(specLabels, "||")
 
+	// The focus was validated along with the arguments
+	suiteConfig.FocusStrings, _ = getVerifySpecFocus(verifyOnly)
+
 	if fromClusterInfo.Submariner.Spec.GlobalCIDR != "" {
 		suiteConfig.LabelFilter = strings.ReplaceAll(suiteConfig.LabelFilter, "!"+globalnetLabel, globalnetLabel)
 	}
@@ -354,7 +415,11 @@ func runVerify(fromClusterInfo, toClusterInfo, extraClusterInfo *cluster.Info, n
 	gomega.RegisterFailHandler(ginkgo.Fail)
 
 	framework.BeforeSuite()
-	defer framework.RunCleanupActions()
+
+	defer func() {
+		framework.RunCleanupActions()
+		reportLeakedNamespaces(cli.NewReporter(), fromClusterInfo, toClusterInfo, extraClusterInfo)
+	}()
 
 	if !ginkgo.RunSpecs(&testing.T{}, "Submariner E2E suite", suiteConfig, reporterConfig) {
 		return fmt.Errorf("E2E failed")
@@ -362,3 +427,45 @@ func runVerify(fromClusterInfo, toClusterInfo, extraClusterInfo *cluster.Info, n
 
 	return nil
 }
+
+// reportLeakedNamespaces warns about the namespaces created by the E2E framework which aren't being deleted; these are
+// typically left behind by interrupted runs and would otherwise accumulate silently.
+func reportLeakedNamespaces(status reporter.Interface, clusterInfos ...*cluster.Info) {
+	status.Start("Checking for E2E namespaces left behind")
+	defer status.End()
+
+	leaked := false
+
+	for _, clusterInfo := range clusterInfos {
+		if clusterInfo == nil {
+			continue
+		}
+
+		namespaces, err := clusterInfo.ClientProducer.ForKubernetes().CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{
+			LabelSelector: e2eFrameworkLabel,
+		})
+		if err != nil {
+			status.Warning("Error listing the E2E namespaces in cluster %q: %v", clusterInfo.Name, err)
+			continue
+		}
+
+		names := []string{}
+
+		for i := range namespaces.Items {
+			if namespaces.Items[i].DeletionTimestamp == nil {
+				names = append(names, namespaces.Items[i].Name)
+			}
+		}
+
+		if len(names) > 0 {
+			leaked = true
+
+			status.Warning("Found %d E2E namespace(s) left behind in cluster %q: %s; they can be removed with"+
+				" \"kubectl delete namespace -l %s\"", len(names), clusterInfo.Name, strings.Join(names, ", "), e2eFrameworkLabel)
+		}
+	}
+
+	if !leaked {
+		status.Success("No E2E namespaces were left behind")
+	}
+}
@@ -22,21 +22,41 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
+	"github.com/submariner-io/subctl/internal/cli"
+	"github.com/submariner-io/subctl/internal/exit"
+	"github.com/submariner-io/subctl/internal/restconfig"
+	"github.com/submariner-io/subctl/internal/show"
 	"github.com/submariner-io/subctl/pkg/version"
 )
 
+var (
+	serverVersions bool
+
+	// The server versions are compared with subctl's explicitly, the Submariner lookup is deferred to skip the standard
+	// version check
+	versionRestConfigProducer = restconfig.NewProducer().WithContextsFlag().WithDeferredSubmarinerLookup().WithoutServerFlag()
+)
+
 var VersionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Get version information on subctl",
 	Long: `This command shows the version tag, and git commit for your
-subctl binary.`,
+subctl binary. With --server, it also shows the versions deployed in
+the selected clusters, and fails if any of them is newer than subctl.`,
 	Run: subctlVersion,
 }
 
 func subctlVersion(_ *cobra.Command, _ []string) {
 	version.PrintSubctlVersion(os.Stdout)
+
+	if serverVersions {
+		exit.OnError(versionRestConfigProducer.RunOnAllContexts(show.ServerVersion, cli.NewReporter()))
+	}
 }
 
 func init() {
+	VersionCmd.Flags().BoolVar(&serverVersions, "server", false,
+		"also show the operator and Submariner versions deployed in the clusters, and their brokers' versions")
+	versionRestConfigProducer.SetupFlags(VersionCmd.Flags())
 	rootCmd.AddCommand(VersionCmd)
 }
@@ -23,6 +23,9 @@ package benchmark
 import (
 	"context"
 	"fmt"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/onsi/gomega"
 	"github.com/submariner-io/shipyard/test/e2e/framework"
@@ -30,7 +33,28 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-func StartThroughputTests(intraCluster, verbose bool) error {
+const (
+	TCPProtocol = "tcp"
+	UDPProtocol = "udp"
+
+	DefaultThroughputDuration = 10 * time.Second
+	DefaultThroughputWarmup   = 2 * time.Second
+)
+
+type ThroughputOptions struct {
+	Protocol string
+	// Duration is the length of the measured run
+	Duration time.Duration
+	// Warmup is the length of the run preceding the measured run, whose results are discarded; it avoids skewing the
+	// measurements with the connection establishment. No warmup run is performed if it's zero.
+	Warmup time.Duration
+}
+
+// udpReceiverRegexp matches the receiver summary line of an iperf3 UDP test, e.g.
+// "[  5]   0.00-10.04  sec   812 MBytes   679 Mbits/sec  0.023 ms  1412/603337 (0.23%)  receiver".
+var udpReceiverRegexp = regexp.MustCompile(`([\d.]+ [KMG]?bits/sec)\s+[\d.]+ ms\s+(\d+)/(\d+) \(([\d.e+-]+)%\)\s+receiver`)
+
+func StartThroughputTests(intraCluster, verbose bool, options *ThroughputOptions) error {
 	var f *framework.Framework
 
 	if verbose {
@@ -52,6 +76,9 @@ func StartThroughputTests(intraCluster, verbose bool) error {
 
 	clusterAName := framework.TestContext.ClusterIDs[framework.ClusterA]
 
+	fmt.Printf("Throughput test parameters: protocol %s, duration %s, warmup %s\n", options.Protocol, options.Duration,
+		options.Warmup)
+
 	if !intraCluster {
 		testParams := benchmarkTestParams{
 			ClientCluster:       framework.ClusterA,
@@ -63,14 +90,14 @@ func StartThroughputTests(intraCluster, verbose bool) error {
 		clusterBName := framework.TestContext.ClusterIDs[framework.ClusterB]
 		fmt.Printf("Performing throughput tests from Gateway pod on cluster %q to Gateway pod on cluster %q\n",
 			clusterAName, clusterBName)
-		runThroughputTest(f, testParams, verbose)
+		runThroughputTest(f, testParams, options, verbose)
 
 		testParams.ServerPodScheduling = framework.NonGatewayNode
 		testParams.ClientPodScheduling = framework.NonGatewayNode
 
 		fmt.Printf("Performing throughput tests from Non-Gateway pod on cluster %q to Non-Gateway pod on cluster %q\n",
 			clusterAName, clusterBName)
-		runThroughputTest(f, testParams, verbose)
+		runThroughputTest(f, testParams, options, verbose)
 	} else {
 		testIntraClusterParams := benchmarkTestParams{
 			ClientCluster:       framework.ClusterA,
@@ -80,7 +107,7 @@ func StartThroughputTests(intraCluster, verbose bool) error {
 		}
 
 		fmt.Printf("Performing throughput tests from Non-Gateway pod to Gateway pod on cluster %q\n", clusterAName)
-		runThroughputTest(f, testIntraClusterParams, verbose)
+		runThroughputTest(f, testIntraClusterParams, options, verbose)
 	}
 
 	return nil
@@ -105,7 +132,7 @@ func cleanupFramework(f *framework.Framework) {
 	framework.RunCleanupActions()
 }
 
-func runThroughputTest(f *framework.Framework, testParams benchmarkTestParams, verbose bool) {
+func runThroughputTest(f *framework.Framework, testParams benchmarkTestParams, options *ThroughputOptions, verbose bool) {
 	clientClusterName := framework.TestContext.ClusterIDs[testParams.ClientCluster]
 	serverClusterName := framework.TestContext.ClusterIDs[testParams.ServerCluster]
 	var connectionTimeout uint = 10
@@ -142,14 +169,19 @@ func runThroughputTest(f *framework.Framework, testParams benchmarkTestParams, v
 		remoteIP = f.AwaitGlobalIngressIP(testParams.ServerCluster, service.Name, service.Namespace)
 	}
 
+	// The framework's throughput client only runs fixed-length TCP tests, so a custom client is used; the server handles
+	// both protocols and successive runs.
 	nettestClientPod := f.NewNetworkPod(&framework.NetworkPodConfig{
-		Type:               framework.ThroughputClientPod,
+		Type:               framework.CustomPod,
 		Cluster:            testParams.ClientCluster,
 		Scheduling:         testParams.ClientPodScheduling,
 		RemoteIP:           remoteIP,
 		ConnectionTimeout:  connectionTimeout,
 		ConnectionAttempts: connectionAttempts,
 		Port:               iperf3Port,
+		ContainerName:      "nettest-client-pod",
+		ImageName:          framework.TestContext.NettestImageURL,
+		Command:            throughputClientCommand(remoteIP, iperf3Port, connectionTimeout, connectionAttempts, options),
 	})
 
 	framework.By(fmt.Sprintf("Nettest Client Pod %q was created on cluster %q, node %q; connect to server pod ip %q",
@@ -163,6 +195,10 @@ func runThroughputTest(f *framework.Framework, testParams benchmarkTestParams, v
 		nettestClientPod.CheckSuccessfulFinish()
 		fmt.Println(nettestClientPod.TerminationMessage)
 	}
+
+	if options.Protocol == UDPProtocol {
+		printUDPSummary(nettestClientPod.TerminationMessage)
+	}
 	// In Globalnet deployments, when backend pods finish their execution, kubeproxy-iptables driver tries
 	// to delete the iptables-chain associated with the service (even when the service is present) as there are
 	// no active backend pods. Since the iptables-chain is also referenced by Globalnet Ingress rules, the chain
@@ -176,3 +212,48 @@ func runThroughputTest(f *framework.Framework, testParams benchmarkTestParams, v
 		f.DeleteServiceExport(testParams.ServerCluster, service.Name)
 	}
 }
+
+// throughputClientCommand runs the optional warmup test, discarding its results, then the measured test, and writes the
+// results to the termination log. TCP tests use 10 parallel streams like the framework's client; UDP tests use a single
+// stream without a bandwidth limit, since UDP has no congestion control.
+func throughputClientCommand(remoteIP string, port int32, connectionTimeout, connectionAttempts uint,
+	options *ThroughputOptions,
+) []string {
+	iperf3 := fmt.Sprintf("iperf3 -w 256K --connect-timeout %d -p %d -c %s", connectionTimeout*1000, port, remoteIP)
+	if options.Protocol == UDPProtocol {
+		iperf3 += " -u -b 0"
+	} else {
+		iperf3 += " -P 10"
+	}
+
+	test := fmt.Sprintf("%s -t %d", iperf3, int(options.Duration.Seconds()))
+	if options.Warmup > 0 {
+		test = fmt.Sprintf("%s -t %d >/dev/null && %s", iperf3, int(options.Warmup.Seconds()), test)
+	}
+
+	return []string{
+		"sh", "-c", fmt.Sprintf("for i in $(seq %d);"+
+			" do if %s;"+
+			" then break;"+
+			" else echo [going to retry]; sleep %d;"+
+			" fi; done >/dev/termination-log 2>&1", connectionAttempts, test, connectionTimeout),
+	}
+}
+
+func printUDPSummary(output string) {
+	var match []string
+
+	// Use the last receiver line, i.e. the one from the successful attempt
+	for _, line := range strings.Split(output, "\n") {
+		if m := udpReceiverRegexp.FindStringSubmatch(line); m != nil {
+			match = m
+		}
+	}
+
+	if match == nil {
+		fmt.Println("Unable to find the UDP receiver summary in the iperf3 output")
+		return
+	}
+
+	fmt.Printf("UDP throughput: %s, packet loss: %s%% (%s/%s datagrams lost)\n", match[1], match[4], match[2], match[3])
+}
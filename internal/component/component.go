@@ -24,4 +24,5 @@ const (
 	Globalnet        = "globalnet"
 	Broker           = "broker"
 	Operator         = "operator"
+	Metrics          = "metrics"
 )
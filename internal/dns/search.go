@@ -0,0 +1,103 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"github.com/submariner-io/submariner-operator/api/v1alpha1"
+)
+
+const (
+	// ClusterSetDomain is the domain under which service discovery always serves the exported services.
+	ClusterSetDomain = "clusterset.local"
+
+	// MaxSearchDomains and MaxSearchChars are the limits of the search list historically supported by the glibc
+	// resolver; domains beyond them are silently ignored by affected resolvers.
+	MaxSearchDomains = 6
+	MaxSearchChars   = 256
+)
+
+// ServiceDiscoveryDomains returns the domains served by the given service discovery deployment.
+func ServiceDiscoveryDomains(serviceDiscovery *v1alpha1.ServiceDiscovery) []string {
+	return append([]string{ClusterSetDomain}, serviceDiscovery.Spec.CustomDomains...)
+}
+
+// SearchDomains returns the search domains configured in the given resolv.conf contents. As in the resolver, "search"
+// and "domain" lines override each other and the last one wins.
+func SearchDomains(resolvConf string) []string {
+	var domains []string
+
+	scanner := bufio.NewScanner(strings.NewReader(resolvConf))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "search":
+			domains = fields[1:]
+		case "domain":
+			domains = fields[1:min(len(fields), 2)]
+		}
+	}
+
+	return domains
+}
+
+// ProjectedSearchPath returns the pods' search path once the given service discovery domains are appended to it, which
+// is what users do to resolve exported services by their short names.
+func ProjectedSearchPath(podSearchDomains, serviceDiscoveryDomains []string) []string {
+	projected := append([]string{}, podSearchDomains...)
+
+	for _, domain := range serviceDiscoveryDomains {
+		found := false
+
+		for _, existing := range projected {
+			if strings.EqualFold(strings.TrimSuffix(existing, "."), strings.TrimSuffix(domain, ".")) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			projected = append(projected, domain)
+		}
+	}
+
+	return projected
+}
+
+// SearchPathProblems returns the ways in which the given search path exceeds the glibc resolver limits, if any.
+func SearchPathProblems(searchPath []string) []string {
+	problems := []string{}
+
+	if len(searchPath) > MaxSearchDomains {
+		problems = append(problems, fmt.Sprintf("it has %d domains, more than the limit of %d", len(searchPath), MaxSearchDomains))
+	}
+
+	if chars := len(strings.Join(searchPath, " ")); chars > MaxSearchChars {
+		problems = append(problems, fmt.Sprintf("it is %d characters long, more than the limit of %d", chars, MaxSearchChars))
+	}
+
+	return problems
+}
@@ -0,0 +1,102 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/subctl/internal/dns"
+	"github.com/submariner-io/submariner-operator/api/v1alpha1"
+)
+
+var _ = Describe("SearchDomains", func() {
+	When("resolv.conf has a search line", func() {
+		It("should return its domains", func() {
+			Expect(dns.SearchDomains("nameserver 10.96.0.10\nsearch default.svc.cluster.local svc.cluster.local cluster.local\n" +
+				"options ndots:5\n")).To(Equal([]string{"default.svc.cluster.local", "svc.cluster.local", "cluster.local"}))
+		})
+	})
+
+	When("resolv.conf has several search and domain lines", func() {
+		It("should use the last one", func() {
+			Expect(dns.SearchDomains("search a.example b.example\ndomain c.example\n")).To(Equal([]string{"c.example"}))
+			Expect(dns.SearchDomains("domain c.example\nsearch a.example b.example\n")).To(Equal([]string{"a.example", "b.example"}))
+		})
+	})
+
+	When("resolv.conf has no search line", func() {
+		It("should return no domains", func() {
+			Expect(dns.SearchDomains("nameserver 8.8.8.8\n")).To(BeEmpty())
+		})
+	})
+})
+
+var _ = Describe("ServiceDiscoveryDomains", func() {
+	It("should include the clusterset domain and the custom domains", func() {
+		serviceDiscovery := &v1alpha1.ServiceDiscovery{}
+		serviceDiscovery.Spec.CustomDomains = []string{"multi.example"}
+
+		Expect(dns.ServiceDiscoveryDomains(serviceDiscovery)).To(Equal([]string{dns.ClusterSetDomain, "multi.example"}))
+	})
+})
+
+var _ = Describe("Projected search path limits", func() {
+	kubernetesDomains := []string{"default.svc.cluster.local", "svc.cluster.local", "cluster.local"}
+
+	problems := func(resolvConf string, customDomains ...string) []string {
+		return dns.SearchPathProblems(dns.ProjectedSearchPath(dns.SearchDomains(resolvConf),
+			append([]string{dns.ClusterSetDomain}, customDomains...)))
+	}
+
+	When("the pods use the default Kubernetes search path", func() {
+		It("should be within the limits", func() {
+			Expect(problems("search default.svc.cluster.local svc.cluster.local cluster.local\n")).To(BeEmpty())
+		})
+	})
+
+	When("the nodes add search domains", func() {
+		resolvConf := "search default.svc.cluster.local svc.cluster.local cluster.local ec2.internal\n"
+
+		It("should be within the limits with one custom domain", func() {
+			Expect(problems(resolvConf, "multi.example")).To(BeEmpty())
+		})
+
+		It("should exceed the domain limit with two custom domains", func() {
+			Expect(problems(resolvConf, "multi.example", "other.example")).To(ConsistOf(ContainSubstring("7 domains")))
+		})
+	})
+
+	When("a service discovery domain is already in the search path", func() {
+		It("should not be counted twice", func() {
+			projected := dns.ProjectedSearchPath(append(kubernetesDomains, "clusterset.local."), []string{dns.ClusterSetDomain})
+			Expect(projected).To(HaveLen(4))
+		})
+	})
+
+	When("the search path uses long domains", func() {
+		It("should exceed the length limit", func() {
+			resolvConf := "search my-application-namespace.svc.cluster.example.corporate.internal svc.cluster.example.corporate.internal" +
+				" cluster.example.corporate.internal datacenter-east-1.example.corporate.internal\n"
+
+			Expect(problems(resolvConf)).To(BeEmpty())
+			Expect(problems(resolvConf, "services.multicluster.east-region-production.example.corporate.internal")).To(
+				ConsistOf(ContainSubstring("characters long")))
+		})
+	})
+})
@@ -25,6 +25,9 @@ import (
 	"github.com/submariner-io/subctl/pkg/version"
 )
 
+// PostCheckFailureCode is the exit code used when an operation completed, but the checks run afterwards failed.
+const PostCheckFailureCode = 2
+
 // OnError exits in case of error.
 func OnError(err error) {
 	if err != nil {
@@ -35,9 +38,14 @@ func OnError(err error) {
 
 // WithMessage will print the message and quit the program with an error code.
 func WithMessage(message string) {
+	WithCode(message, 1)
+}
+
+// WithCode will print the message and quit the program with the given exit code.
+func WithCode(message string, code int) {
 	fmt.Fprintln(os.Stderr, message)
 	printVersion()
-	os.Exit(1)
+	os.Exit(code)
 }
 
 // OnErrorWithMessage will print the message and quit the program with an error code.
@@ -0,0 +1,167 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fleet handles fleet files, which describe a clusterset: the kubeconfig contexts of the broker and member
+// clusters, their expected attributes, and defaults for command flags.
+package fleet
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+	"k8s.io/utils/set"
+	"sigs.k8s.io/yaml"
+)
+
+// revisionLength is the number of hexadecimal digits of the file's hash used to identify its revision.
+const revisionLength = 12
+
+type Broker struct {
+	Context string `json:"context"`
+}
+
+// Cluster describes a member cluster; the optional attributes are checked against the deployment when set.
+type Cluster struct {
+	Context   string `json:"context"`
+	ClusterID string `json:"clusterID,omitempty"`
+	AirGapped *bool  `json:"airGapped,omitempty"`
+	Globalnet *bool  `json:"globalnet,omitempty"`
+}
+
+type Fleet struct {
+	Broker   Broker    `json:"broker"`
+	Clusters []Cluster `json:"clusters"`
+	// Defaults are flag values used when the flags aren't specified on the command line
+	Defaults map[string]string `json:"defaults,omitempty"`
+
+	// Path and Revision identify the file the fleet was loaded from; the revision is derived from its contents
+	Path     string `json:"-"`
+	Revision string `json:"-"`
+}
+
+// DefaultPath returns the location of the fleet file used when none is specified.
+func DefaultPath() string {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(configDir, "subctl", "fleet.yaml")
+}
+
+// Load reads and validates the given fleet file. Unknown keys are rejected.
+func Load(path string) (*Fleet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error reading the fleet file %q", path)
+	}
+
+	fleet := &Fleet{}
+
+	if err := yaml.UnmarshalStrict(data, fleet); err != nil {
+		return nil, errors.Wrapf(err, "error parsing the fleet file %q", path)
+	}
+
+	if err := fleet.validate(); err != nil {
+		return nil, errors.Wrapf(err, "invalid fleet file %q", path)
+	}
+
+	hash := sha256.Sum256(data)
+	fleet.Path = path
+	fleet.Revision = hex.EncodeToString(hash[:])[:revisionLength]
+
+	return fleet, nil
+}
+
+func (f *Fleet) validate() error {
+	if f.Broker.Context == "" && len(f.Clusters) == 0 {
+		return errors.New("no broker or member clusters are defined")
+	}
+
+	contexts := set.New[string]()
+
+	for i := range f.Clusters {
+		if f.Clusters[i].Context == "" {
+			return fmt.Errorf("member cluster %d has no context", i+1)
+		}
+
+		if contexts.Has(f.Clusters[i].Context) {
+			return fmt.Errorf("context %q is listed more than once", f.Clusters[i].Context)
+		}
+
+		contexts.Insert(f.Clusters[i].Context)
+	}
+
+	return nil
+}
+
+// Contexts returns all the contexts of the fleet, broker included, sorted.
+func (f *Fleet) Contexts() []string {
+	contexts := set.New[string]()
+
+	if f.Broker.Context != "" {
+		contexts.Insert(f.Broker.Context)
+	}
+
+	for i := range f.Clusters {
+		contexts.Insert(f.Clusters[i].Context)
+	}
+
+	return contexts.SortedList()
+}
+
+// Cluster returns the member cluster using the given context, nil if there is none.
+func (f *Fleet) Cluster(context string) *Cluster {
+	for i := range f.Clusters {
+		if f.Clusters[i].Context == context {
+			return &f.Clusters[i]
+		}
+	}
+
+	return nil
+}
+
+// ApplyDefaults sets the flags which weren't specified on the command line to the fleet's defaults. Defaults for flags
+// which the command doesn't have are ignored, since the defaults are shared by all commands.
+func (f *Fleet) ApplyDefaults(flags *pflag.FlagSet) error {
+	names := make([]string, 0, len(f.Defaults))
+	for name := range f.Defaults {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		flag := flags.Lookup(name)
+		if flag == nil || flag.Changed {
+			continue
+		}
+
+		if err := flags.Set(name, f.Defaults[name]); err != nil {
+			return errors.Wrapf(err, "invalid default %q for --%s in the fleet file %q", f.Defaults[name], name, f.Path)
+		}
+	}
+
+	return nil
+}
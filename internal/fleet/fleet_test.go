@@ -0,0 +1,130 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fleet_test
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/pflag"
+	"github.com/submariner-io/subctl/internal/fleet"
+	"k8s.io/utils/ptr"
+)
+
+const validFleet = `
+broker:
+  context: broker
+clusters:
+- context: east
+  clusterID: east
+  globalnet: true
+- context: broker
+  airGapped: true
+defaults:
+  context-timeout: 10s
+  image-override: "*=quay.example.com"
+`
+
+var _ = Describe("Fleet", func() {
+	var path string
+
+	writeFleet := func(contents string) {
+		path = filepath.Join(GinkgoT().TempDir(), "fleet.yaml")
+		Expect(os.WriteFile(path, []byte(contents), 0o600)).To(Succeed())
+	}
+
+	Describe("Load", func() {
+		It("should load a valid file and derive its revision from the contents", func() {
+			writeFleet(validFleet)
+
+			f, err := fleet.Load(path)
+			Expect(err).To(Succeed())
+			Expect(f.Path).To(Equal(path))
+			Expect(f.Revision).To(HaveLen(12))
+			Expect(f.Contexts()).To(Equal([]string{"broker", "east"}))
+			Expect(f.Cluster("east")).To(Equal(&fleet.Cluster{Context: "east", ClusterID: "east", Globalnet: ptr.To(true)}))
+			Expect(f.Cluster("west")).To(BeNil())
+
+			writeFleet(validFleet + "# Updated\n")
+
+			changed, err := fleet.Load(path)
+			Expect(err).To(Succeed())
+			Expect(changed.Revision).ToNot(Equal(f.Revision))
+		})
+
+		It("should reject unknown keys", func() {
+			writeFleet(validFleet + "region: eu\n")
+
+			_, err := fleet.Load(path)
+			Expect(err).To(MatchError(ContainSubstring(`unknown field "region"`)))
+		})
+
+		It("should reject contexts listed more than once", func() {
+			writeFleet("clusters:\n- context: east\n- context: east\n")
+
+			_, err := fleet.Load(path)
+			Expect(err).To(MatchError(ContainSubstring(`context "east" is listed more than once`)))
+		})
+
+		It("should reject members without a context", func() {
+			writeFleet("clusters:\n- clusterID: east\n")
+
+			_, err := fleet.Load(path)
+			Expect(err).To(MatchError(ContainSubstring("member cluster 1 has no context")))
+		})
+
+		It("should reject empty fleets", func() {
+			writeFleet("defaults:\n  context-timeout: 10s\n")
+
+			_, err := fleet.Load(path)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("ApplyDefaults", func() {
+		var (
+			flags          *pflag.FlagSet
+			contextTimeout time.Duration
+			verbose        bool
+		)
+
+		BeforeEach(func() {
+			flags = pflag.NewFlagSet("test", pflag.ContinueOnError)
+			flags.DurationVar(&contextTimeout, "context-timeout", time.Minute, "")
+			flags.BoolVar(&verbose, "verbose", false, "")
+		})
+
+		It("should set the unspecified flags and ignore those the command doesn't have", func() {
+			Expect(flags.Parse([]string{"--verbose"})).To(Succeed())
+
+			f := &fleet.Fleet{Defaults: map[string]string{"context-timeout": "10s", "verbose": "false", "image-override": "x"}}
+			Expect(f.ApplyDefaults(flags)).To(Succeed())
+			Expect(contextTimeout).To(Equal(10 * time.Second))
+			Expect(verbose).To(BeTrue())
+		})
+
+		It("should fail on invalid values", func() {
+			f := &fleet.Fleet{Defaults: map[string]string{"context-timeout": "soon"}}
+			Expect(f.ApplyDefaults(flags)).To(MatchError(ContainSubstring("--context-timeout")))
+		})
+	})
+})
@@ -20,18 +20,30 @@ package gather
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/submariner-io/subctl/internal/pods"
+	subv1 "github.com/submariner-io/submariner/pkg/apis/submariner.io/v1"
 	"github.com/submariner-io/submariner/pkg/cni"
+	"github.com/submariner-io/submariner/pkg/port"
 	v1 "k8s.io/api/core/v1"
 )
 
+const (
+	defaultGatewayMetricsPort = "32780"
+	gatewayMetricsPortEnv     = "SUBMARINER_METRICSPORT"
+)
+
 const (
 	typeIPTables = "iptables"
 	typeOvn      = "ovn"
 	typeUnknown  = "unknown"
 	libreswan    = "libreswan"
 	vxlan        = "vxlan"
+	wireguard    = "wireguard"
+	// The name of the network device created by the WireGuard cable driver
+	wireguardDevice = "submariner"
 )
 
 var systemCmds = map[string]string{
@@ -52,7 +64,6 @@ var ipTablesCmds = map[string]string{
 	"iptables":        "iptables -L -n -v --line-numbers",
 	"iptables-nat":    "iptables -L -n -v --line-numbers -t nat",
 	"iptables-mangle": "iptables -L -n -v --line-numbers -t mangle",
-	"iptables-save":   "iptables-save -c",
 }
 
 var libreswanCmds = map[string]string{
@@ -66,6 +77,65 @@ var vxlanCmds = map[string]string{
 	"ip-routes-table100": "ip route show table 100",
 }
 
+var wireguardCmds = map[string]string{
+	"wg-show":           "wg show",
+	"wg-show-all-dump":  "wg show all dump",
+	"ip-link-wireguard": "ip -d link show " + wireguardDevice,
+}
+
+// wireguardDumpCmd is the wireguardCmds entry whose output includes the private and preshared keys.
+const wireguardDumpCmd = "wg-show-all-dump"
+
+// netfilterSnapshotCmds capture the complete netfilter rules, with their ordering and counters which the iptables
+// listings lose, and a summary of the conntrack entries. They're only run from the route agent pods, which run on every
+// node including the gateway nodes, so that each node is captured once. The rules can be managed by iptables or nftables
+// depending on the distribution, so each binary may be missing from the image.
+var netfilterSnapshotCmds = []alternativeCmds{
+	{name: "iptables-save", alternatives: []alternativeCmd{{binary: "iptables-save", cmd: "iptables-save -c"}}},
+	{name: "ip6tables-save", alternatives: []alternativeCmd{{binary: "ip6tables-save", cmd: "ip6tables-save -c"}}},
+	{name: "nft-ruleset", alternatives: []alternativeCmd{{binary: "nft", cmd: "nft list ruleset"}}},
+	// The IPsec NAT-T connections
+	{name: "conntrack-ipsec-natt", alternatives: []alternativeCmd{{
+		binary: "conntrack", cmd: "conntrack -L -p udp --dport 4500 2>/dev/null | head -200",
+	}}},
+	// The number of entries per protocol, and per state for TCP
+	{name: "conntrack-states", alternatives: []alternativeCmd{{
+		binary: "conntrack",
+		cmd: "conntrack -L 2>/dev/null | awk '$1 == \"tcp\" { count[\"tcp \" $4]++; next } { count[$1]++ }" +
+			" END { for (key in count) print count[key], key }' | sort -rn",
+	}}},
+}
+
+// gatewayPerformanceCmds collect the data needed to analyze throughput issues on the gateway nodes: conntrack table
+// occupancy, socket buffer and backlog drops, and qdisc statistics. Each command is listed with the alternatives to
+// use if the binaries it requires are missing from the image.
+var gatewayPerformanceCmds = []alternativeCmds{
+	{name: "conntrack-count", alternatives: []alternativeCmd{{binary: "conntrack", cmd: "conntrack -C"}}},
+	{name: "conntrack-stats", alternatives: []alternativeCmd{{binary: "conntrack", cmd: "conntrack -S"}}},
+	{name: "conntrack-occupancy", alternatives: []alternativeCmd{{
+		cmd: "echo \"count: $(cat /proc/sys/net/netfilter/nf_conntrack_count) max: $(cat /proc/sys/net/netfilter/nf_conntrack_max)\"",
+	}}},
+	{name: "net-stats-drops", alternatives: []alternativeCmd{
+		{binary: "netstat", cmd: "netstat -s | grep -i -E 'drop|overflow' || true"},
+		{binary: "nstat", cmd: "nstat -a -z | grep -i -E 'drop|overflow' || true"},
+	}},
+	{name: "tc-qdisc-stats", alternatives: []alternativeCmd{{binary: "tc", cmd: "tc -s qdisc show"}}},
+	{name: "softnet-stat", alternatives: []alternativeCmd{{cmd: "cat /proc/net/softnet_stat"}}},
+}
+
+// alternativeCmds is a command whose output is stored under the given name, with the alternatives to run it with; the
+// first alternative whose binary is available in the image is used.
+type alternativeCmds struct {
+	name         string
+	alternatives []alternativeCmd
+}
+
+type alternativeCmd struct {
+	// binary is the command required to run cmd, if any
+	binary string
+	cmd    string
+}
+
 const ovnNbctlShowCmd = "ovn-nbctl --no-leader-only show"
 
 var ovnCmds = map[string]string{
@@ -98,6 +168,7 @@ var networkPluginCNIType = map[string]string{
 func gatherCNIResources(info *Info, networkPlugin string) {
 	logPodInfo(info, "CNI data", routeagentPodLabel, func(info *Info, pod *v1.Pod) {
 		logSystemCmds(info, pod)
+		logAlternativeCmds(info, pod, netfilterSnapshotCmds)
 
 		switch networkPluginCNIType[networkPlugin] {
 		case typeIPTables, typeOvn:
@@ -111,7 +182,9 @@ func gatherCNIResources(info *Info, networkPlugin string) {
 }
 
 func logCNIGatewayNodeResources(info *Info) {
-	logPodInfo(info, "CNI data", gatewayPodLabel, logIPGatewayCmds)
+	logPodInfo(info, "CNI data", gatewayPodLabel, func(info *Info, pod *v1.Pod) {
+		logIPGatewayCmds(info, pod)
+	})
 }
 
 func logSystemCmds(info *Info, pod *v1.Pod) {
@@ -184,6 +257,14 @@ func getOVNCmdsPod(info *Info) []v1.Pod {
 }
 
 func gatherCableDriverResources(info *Info, cableDriver string) {
+	nattPort := int32(port.NATTDiscovery)
+
+	if endpoint, err := info.GetLocalEndpoint(); err == nil {
+		if configuredPort, err := endpoint.Spec.GetBackendPort(subv1.NATTDiscoveryPortConfig, port.NATTDiscovery); err == nil {
+			nattPort = configuredPort
+		}
+	}
+
 	logPodInfo(info, "cable driver data", gatewayPodLabel, func(info *Info, pod *v1.Pod) {
 		if cableDriver == libreswan || cableDriver == "" { // If none specified, use libreswan as default
 			logLibreswanCmds(info, pod)
@@ -192,9 +273,87 @@ func gatherCableDriverResources(info *Info, cableDriver string) {
 		if cableDriver == vxlan {
 			logVxlanCmds(info, pod)
 		}
+
+		if cableDriver == wireguard {
+			logWireguardCmds(info, pod)
+		}
+
+		logNATTDiscoveryState(info, pod, nattPort)
+		logAlternativeCmds(info, pod, gatewayPerformanceCmds)
 	})
 }
 
+// logAlternativeCmds runs the given commands, recording that a command was skipped if none of its binaries are available.
+func logAlternativeCmds(info *Info, pod *v1.Pod, cmds []alternativeCmds) {
+	for _, command := range cmds {
+		missing := []string{}
+		found := false
+
+		for _, alternative := range command.alternatives {
+			if alternative.binary != "" {
+				if _, _, err := execCmdInBash(info, pod, "command -v "+alternative.binary); err != nil {
+					missing = append(missing, alternative.binary)
+					continue
+				}
+			}
+
+			logCmdOutput(info, pod, alternative.cmd, command.name, true)
+
+			found = true
+
+			break
+		}
+
+		if !found {
+			storeCmdOutput(info, pod, "command -v "+strings.Join(missing, " "), command.name,
+				fmt.Sprintf("Skipped, %s isn't available in the %q pod's image", strings.Join(missing, " or "), pod.Name))
+		}
+	}
+}
+
+// The NAT-T discovery state only lives in the gateway's memory and logs, which rotate; this captures a snapshot of the
+// discovery sockets and of the gateway's metrics (which include its error counters).
+func logNATTDiscoveryState(info *Info, pod *v1.Pod, nattPort int32) {
+	logCmdOutput(info, pod, fmt.Sprintf("ss -u -a -n '( sport = :%[1]d or dport = :%[1]d )'", nattPort), "natt-discovery-sockets",
+		true)
+
+	metricsPort := defaultGatewayMetricsPort
+
+	for _, env := range pod.Spec.Containers[0].Env {
+		if env.Name == gatewayMetricsPortEnv && env.Value != "" {
+			metricsPort = env.Value
+		}
+	}
+
+	cmd := fmt.Sprintf("curl -sf http://localhost:%[1]s/metrics || wget -qO- http://localhost:%[1]s/metrics", metricsPort)
+
+	metrics, _, err := execCmdInBash(info, pod, cmd)
+	if err != nil || metrics == "" {
+		// Older gateways don't serve metrics; record that rather than leaving the reader to guess
+		storeCmdOutput(info, pod, cmd, "gateway-metrics",
+			fmt.Sprintf("The gateway doesn't expose metrics on port %s (error: %v)", metricsPort, err))
+
+		return
+	}
+
+	storeCmdOutput(info, pod, cmd, "gateway-metrics", metrics)
+
+	relevant := []string{}
+
+	for _, line := range strings.Split(metrics, "\n") {
+		lowerLine := strings.ToLower(line)
+		if strings.Contains(lowerLine, "nat") || strings.Contains(lowerLine, "error") || strings.Contains(lowerLine, "fail") {
+			relevant = append(relevant, line)
+		}
+	}
+
+	if len(relevant) == 0 {
+		relevant = append(relevant, "The gateway metrics don't include any NAT discovery or error counters")
+	}
+
+	storeCmdOutput(info, pod, cmd, "gateway-natt-error-metrics", strings.Join(relevant, "\n"))
+}
+
 func logLibreswanCmds(info *Info, pod *v1.Pod) {
 	for name, cmd := range libreswanCmds {
 		logCmdOutput(info, pod, cmd, name, true)
@@ -207,6 +366,47 @@ func logVxlanCmds(info *Info, pod *v1.Pod) {
 	}
 }
 
+func logWireguardCmds(info *Info, pod *v1.Pod) {
+	for name, cmd := range wireguardCmds {
+		if name != wireguardDumpCmd || info.IncludeSensitiveData {
+			logCmdOutput(info, pod, cmd, name, true)
+			continue
+		}
+
+		stdOut, _, _ := execCmdInBash(info, pod, cmd)
+		if stdOut != "" {
+			storeCmdOutput(info, pod, cmd, name, redactWireguardDump(stdOut))
+		}
+	}
+}
+
+// redactWireguardDump hides the keys in the output of "wg show all dump". Interface lines contain the interface name,
+// private key, public key, listen port and fwmark; peer lines contain the interface name, public key, preshared key,
+// endpoint, allowed IPs, latest handshake, transfer counters and keepalive interval. Public keys are kept since they
+// identify the peers.
+func redactWireguardDump(dump string) string {
+	lines := strings.Split(dump, "\n")
+
+	for i, line := range lines {
+		fields := strings.Split(line, "\t")
+
+		switch len(fields) {
+		case 5:
+			fields[1] = "##redacted-private-key##"
+		case 9:
+			if fields[2] != "(none)" {
+				fields[2] = "##redacted-preshared-key##"
+			}
+		default:
+			continue
+		}
+
+		lines[i] = strings.Join(fields, "\t")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
 //nolint:wrapcheck // No need to wrap errors here.
 func execCmdInBash(info *Info, pod *v1.Pod, cmd string) (string, string, error) {
 	execOptions := pods.ExecOptionsFromPod(pod)
@@ -229,19 +429,23 @@ func logCmdOutput(info *Info, pod *v1.Pod, cmd, cmdName string, ignoreError bool
 	}
 
 	if stdOut != "" {
-		// the first line contains the executed command
-		stdOut = cmd + "\n" + stdOut
+		storeCmdOutput(info, pod, cmd, cmdName, stdOut)
+	}
+}
 
-		fileName, err := writeLogToFile(stdOut, pod.Spec.NodeName+"_"+cmdName, info, ".log")
-		if err != nil {
-			info.Status.Failure("Error writing output from command %q on pod %q: %v", cmd, pod.Name, err)
-		}
+func storeCmdOutput(info *Info, pod *v1.Pod, cmd, cmdName, output string) {
+	// the first line contains the executed command
+	output = cmd + "\n" + output
 
-		info.Summary.Resources = append(info.Summary.Resources, ResourceInfo{
-			Namespace: pod.Namespace,
-			Name:      pod.Spec.NodeName,
-			FileName:  fileName,
-			Type:      cmdName,
-		})
+	fileName, err := writeLogToFile(output, pod.Spec.NodeName+"_"+cmdName, info, ".log")
+	if err != nil {
+		info.Status.Failure("Error writing output from command %q on pod %q: %v", cmd, pod.Name, err)
 	}
+
+	info.Summary.Resources = append(info.Summary.Resources, ResourceInfo{
+		Namespace: pod.Namespace,
+		Name:      pod.Spec.NodeName,
+		FileName:  fileName,
+		Type:      cmdName,
+	})
 }
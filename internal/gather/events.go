@@ -0,0 +1,197 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gather
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/utils/set"
+)
+
+const (
+	eventsFileName           = "events.jsonl"
+	podRestartEventsFileName = "pod-restart-events.json"
+)
+
+// podRestartReasons are the reasons of the events recording pods being restarted, killed or evicted.
+var podRestartReasons = set.New("BackOff", "OOMKilling", "Evicted", "Killing")
+
+// eventKeywords select the events relevant to Submariner; they're matched case-insensitively in the reason and message.
+var eventKeywords = []string{"submariner", "gateway", "route", "tunnel"}
+
+// gatherEvents stores the events relevant to Submariner from the namespace, oldest first, as JSON Lines. Both Normal and
+// Warning events are kept: the Normal events record the tunnel, connection and gateway transitions needed to reconstruct
+// timelines. Events are short-lived, so they're often the only trace of transient problems such as connection drops.
+func gatherEvents(info *Info, namespace string) {
+	list, err := info.ClientProducer.ForKubernetes().CoreV1().Events(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		info.Status.Failure("Failed to gather events: %s", err)
+		return
+	}
+
+	// The API doesn't support selecting events by time, so that's done here
+	var since time.Time
+	if info.EventsSince > 0 {
+		since = time.Now().Add(-info.EventsSince)
+	}
+
+	events := []*corev1.Event{}
+
+	for i := range list.Items {
+		event := &list.Items[i]
+
+		if isSubmarinerEvent(event) && !eventTime(event).Before(since) {
+			events = append(events, event)
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return eventTime(events[i]).Before(eventTime(events[j]))
+	})
+
+	var buffer bytes.Buffer
+
+	for _, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			info.Status.Failure("Error marshaling event %q to JSON: %s", event.Name, err)
+			return
+		}
+
+		buffer.Write(data)
+		buffer.WriteByte('\n')
+	}
+
+	path := filepath.Join(info.DirName, eventsFileName)
+
+	if err := os.WriteFile(path, []byte(scrubSensitiveData(info, buffer.String())), 0o600); err != nil {
+		info.Status.Failure("Error writing file %q: %s", path, err)
+		return
+	}
+
+	info.Summary.Resources = append(info.Summary.Resources, ResourceInfo{
+		Name:      "events",
+		Namespace: namespace,
+		Type:      "events",
+		FileName:  eventsFileName,
+	})
+
+	info.Status.Success("Found %d Submariner events in namespace %q", len(events), namespace)
+}
+
+// gatherPodRestartEvents stores the events recording the connectivity pods being restarted, killed or evicted, most recent
+// first, as JSON. These events often outlive the pods they concern, which makes them the only record of why those pods
+// were replaced.
+func gatherPodRestartEvents(info *Info) {
+	namespace := info.OperatorNamespace()
+
+	list, err := info.ClientProducer.ForKubernetes().CoreV1().Events(namespace).List(context.TODO(), metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("involvedObject.kind", "Pod").String(),
+	})
+	if err != nil {
+		info.Status.Failure("Failed to gather the pod restart events: %s", err)
+		return
+	}
+
+	events := []*corev1.Event{}
+
+	for i := range list.Items {
+		event := &list.Items[i]
+
+		if event.InvolvedObject.Kind == "Pod" && podRestartReasons.Has(event.Reason) && isConnectivityPod(event.InvolvedObject.Name) {
+			events = append(events, event)
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return eventTime(events[i]).After(eventTime(events[j]))
+	})
+
+	data, err := json.MarshalIndent(events, "", "  ")
+	if err != nil {
+		info.Status.Failure("Error marshaling the pod restart events to JSON: %s", err)
+		return
+	}
+
+	path := filepath.Join(info.DirName, podRestartEventsFileName)
+
+	if err := os.WriteFile(path, []byte(scrubSensitiveData(info, string(data))), 0o600); err != nil {
+		info.Status.Failure("Error writing file %q: %s", path, err)
+		return
+	}
+
+	info.Summary.Resources = append(info.Summary.Resources, ResourceInfo{
+		Name:      "pod-restart-events",
+		Namespace: namespace,
+		Type:      "events",
+		FileName:  podRestartEventsFileName,
+	})
+
+	info.Status.Success("Found %d events about Submariner pods restarting in namespace %q", len(events), namespace)
+}
+
+// isConnectivityPod determines whether the named pod belongs to one of the connectivity components. The pods may no longer
+// exist, so this is determined from their name, which starts with their component's name, i.e. the value of their app
+// label.
+func isConnectivityPod(name string) bool {
+	for _, label := range []string{gatewayPodLabel, routeagentPodLabel, globalnetPodLabel, metricsProxyPodLabel, addonPodLabel} {
+		if strings.HasPrefix(name, strings.TrimPrefix(label, "app=")+"-") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isSubmarinerEvent(event *corev1.Event) bool {
+	reason := strings.ToLower(event.Reason)
+	message := strings.ToLower(event.Message)
+
+	for _, keyword := range eventKeywords {
+		if strings.Contains(reason, keyword) || strings.Contains(message, keyword) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// eventTime returns the time at which the event last occurred; older events only have some of the timestamps set.
+func eventTime(event *corev1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	case !event.FirstTimestamp.IsZero():
+		return event.FirstTimestamp.Time
+	default:
+		return event.CreationTimestamp.Time
+	}
+}
@@ -24,7 +24,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/pkg/errors"
 	"github.com/submariner-io/admiral/pkg/resource"
 	"github.com/submariner-io/subctl/internal/cli"
 	"github.com/submariner-io/subctl/internal/component"
@@ -47,25 +51,61 @@ type Options struct {
 	IncludeSensitiveData bool
 	Modules              []string
 	Types                []string
+	MetricsURL           string
+	MetricsURLToken      bool
+	MetricsHistory       time.Duration
+	Workers              int
+	EventsSince          time.Duration
 }
 
 const (
 	Logs      = "logs"
 	Resources = "resources"
+	Events    = "events"
+	// DefaultWorkers is the default number of pods whose logs are retrieved concurrently
+	DefaultWorkers = 10
 )
 
-var AllModules = set.New(component.Connectivity, component.ServiceDiscovery, component.Broker, component.Operator)
+var AllModules = set.New(component.Connectivity, component.ServiceDiscovery, component.Broker, component.Operator, component.Metrics)
 
-var AllTypes = set.New(Logs, Resources)
+var AllTypes = set.New(Logs, Resources, Events)
 
 var gatherFuncs = map[string]func(string, Info) bool{
 	component.Connectivity:     gatherConnectivity,
 	component.ServiceDiscovery: gatherDiscovery,
 	component.Broker:           gatherBroker,
 	component.Operator:         gatherOperator,
+	component.Metrics:          gatherMetrics,
+}
+
+// CheckOptions verifies that the requested types and modules are supported.
+func CheckOptions(options *Options) error {
+	for _, t := range options.Types {
+		if !AllTypes.Has(t) {
+			return fmt.Errorf("%q is not a supported type, the supported types are %s", t, strings.Join(AllTypes.SortedList(), ", "))
+		}
+	}
+
+	for _, m := range options.Modules {
+		if !AllModules.Has(m) {
+			return fmt.Errorf("%q is not a supported module, the supported modules are %s", m,
+				strings.Join(AllModules.SortedList(), ", "))
+		}
+	}
+
+	if options.MetricsURLToken && options.MetricsURL == "" {
+		return errors.New("the cluster token can only be sent to an explicitly given metrics URL")
+	}
+
+	return nil
 }
 
 func Data(clusterInfo *cluster.Info, options Options) error {
+	// Check before creating the directory, so that invalid options don't leave an empty directory behind
+	if err := CheckOptions(&options); err != nil {
+		return err
+	}
+
 	var warningsBuf bytes.Buffer
 
 	rest.SetDefaultWarningHandler(rest.NewWarningWriter(&warningsBuf, rest.WarningWriterOptions{
@@ -104,6 +144,11 @@ func gatherDataByCluster(clusterInfo *cluster.Info, options Options) {
 		ClusterName:          clusterName,
 		DirName:              options.Directory,
 		IncludeSensitiveData: options.IncludeSensitiveData,
+		MetricsURL:           options.MetricsURL,
+		MetricsURLToken:      options.MetricsURLToken,
+		MetricsHistory:       options.MetricsHistory,
+		Workers:              options.Workers,
+		EventsSince:          options.EventsSince,
 		Summary:              &Summary{},
 	}
 
@@ -116,6 +161,14 @@ func gatherDataByCluster(clusterInfo *cluster.Info, options Options) {
 		}
 	}
 
+	// The events are gathered with the resources of any module, once the namespaces of the pods are known
+	if slices.Contains(options.Types, Resources) {
+		info.Status = cli.NewReporter()
+		info.Status.Start("Gathering the events in the Submariner namespaces")
+		gatherNamespaceEvents(&info)
+		info.Status.End()
+	}
+
 	gatherClusterSummary(&info)
 }
 
@@ -144,6 +197,8 @@ func gatherConnectivity(dataType string, info Info) bool {
 		gatherClusterGlobalEgressIPs(&info)
 		gatherGlobalEgressIPs(&info)
 		gatherGlobalIngressIPs(&info)
+	case Events:
+		gatherPodRestartEvents(&info)
 	default:
 		return false
 	}
@@ -210,6 +265,9 @@ func gatherBroker(dataType string, info Info) bool {
 			}
 
 			brokerNamespace = metav1.NamespaceAll
+
+			// Only the cluster hosting the broker can read the Broker resources, which record the enabled components
+			gatherBrokers(&info, brokerNamespace)
 		}
 
 		info.ClusterName = "broker"
@@ -242,6 +300,8 @@ func gatherOperator(dataType string, info Info) bool {
 		gatherLighthouseAgentDeployment(&info, info.OperatorNamespace())
 		gatherLighthouseCoreDNSDeployment(&info, info.OperatorNamespace())
 		gatherGatewayLBService(&info, info.OperatorNamespace())
+	case Events:
+		gatherEvents(&info, info.OperatorNamespace())
 	default:
 		return false
 	}
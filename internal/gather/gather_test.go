@@ -0,0 +1,232 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gather_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/subctl/internal/component"
+	"github.com/submariner-io/subctl/internal/constants"
+	"github.com/submariner-io/subctl/internal/gather"
+	"github.com/submariner-io/subctl/pkg/client"
+	"github.com/submariner-io/subctl/pkg/cluster"
+	"github.com/submariner-io/submariner-operator/api/v1alpha1"
+	opnames "github.com/submariner-io/submariner-operator/pkg/names"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+)
+
+const clusterName = "east"
+
+var _ = Describe("Data", func() {
+	var (
+		clusterInfo *cluster.Info
+		options     gather.Options
+	)
+
+	BeforeEach(func() {
+		submariner := &v1alpha1.Submariner{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      opnames.SubmarinerCrName,
+				Namespace: constants.OperatorNamespace,
+			},
+		}
+
+		scheme := runtime.NewScheme()
+		Expect(v1alpha1.AddToScheme(scheme)).To(Succeed())
+
+		clusterInfo = &cluster.Info{
+			Name: clusterName,
+			ClientProducer: &client.DefaultProducer{
+				KubeClient: fakeclientset.NewClientset(),
+				DynamicClient: fakedynamic.NewSimpleDynamicClientWithCustomListKinds(scheme,
+					map[schema.GroupVersionResource]string{
+						v1alpha1.GroupVersion.WithResource("servicediscoveries"): "ServiceDiscoveryList",
+						v1alpha1.GroupVersion.WithResource("brokers"):            "BrokerList",
+						{Group: "apps", Version: "v1", Resource: "deployments"}:  "DeploymentList",
+						{Group: "apps", Version: "v1", Resource: "daemonsets"}:   "DaemonSetList",
+						{Version: "v1", Resource: "services"}:                    "ServiceList",
+					}, submariner),
+			},
+			Submariner: submariner,
+		}
+
+		options = gather.Options{
+			Directory: GinkgoT().TempDir(),
+			Modules:   []string{component.Operator},
+			Workers:   1,
+		}
+	})
+
+	gatheredFiles := func() []string {
+		files, err := filepath.Glob(filepath.Join(options.Directory, clusterName, "*.yaml"))
+		Expect(err).To(Succeed())
+
+		return files
+	}
+
+	When("only logs are requested", func() {
+		It("should not gather any resources", func() {
+			options.Types = []string{gather.Logs}
+			Expect(gather.Data(clusterInfo, options)).To(Succeed())
+			Expect(gatheredFiles()).To(BeEmpty())
+		})
+	})
+
+	When("resources are requested", func() {
+		It("should gather them", func() {
+			options.Types = []string{gather.Resources}
+			Expect(gather.Data(clusterInfo, options)).To(Succeed())
+			Expect(gatheredFiles()).ToNot(BeEmpty())
+		})
+	})
+
+	When("resources are requested for any module", func() {
+		It("should gather the events in the operator namespace", func() {
+			_, err := clusterInfo.ClientProducer.ForKubernetes().CoreV1().Events(constants.OperatorNamespace).Create(context.TODO(),
+				&corev1.Event{
+					ObjectMeta:     metav1.ObjectMeta{Name: "gateway.1", Namespace: constants.OperatorNamespace},
+					InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "submariner-gateway-abcde"},
+					Type:           corev1.EventTypeWarning,
+					Reason:         "BackOff",
+					Message:        "Back-off restarting failed container",
+					Count:          3,
+				}, metav1.CreateOptions{})
+			Expect(err).To(Succeed())
+
+			options.Modules = []string{component.Metrics}
+			options.Types = []string{gather.Resources}
+			Expect(gather.Data(clusterInfo, options)).To(Succeed())
+
+			clusterDir := filepath.Join(options.Directory, clusterName)
+			Expect(filepath.Join(clusterDir, "events_"+constants.OperatorNamespace+".yaml")).To(BeAnExistingFile())
+			Expect(filepath.Join(clusterDir, "events.events.k8s.io_"+constants.OperatorNamespace+".yaml")).To(BeAnExistingFile())
+
+			text, err := os.ReadFile(filepath.Join(clusterDir, "events_"+constants.OperatorNamespace+".txt"))
+			Expect(err).To(Succeed())
+			Expect(string(text)).To(And(ContainSubstring("pod/submariner-gateway-abcde"), ContainSubstring("BackOff"),
+				ContainSubstring("Back-off restarting failed container")))
+		})
+	})
+
+	When("events are requested for the connectivity module", func() {
+		It("should gather the connectivity pod restart events, most recent first", func() {
+			for _, event := range []*corev1.Event{
+				newPodEvent("gateway.1", "submariner-gateway-abcde", "BackOff", 1),
+				newPodEvent("routeagent.1", "submariner-routeagent-fghij", "OOMKilling", 2),
+				newPodEvent("gateway.2", "submariner-gateway-abcde", "Pulled", 3),
+				newPodEvent("other.1", "other-klmno", "BackOff", 4),
+			} {
+				_, err := clusterInfo.ClientProducer.ForKubernetes().CoreV1().Events(constants.OperatorNamespace).Create(context.TODO(),
+					event, metav1.CreateOptions{})
+				Expect(err).To(Succeed())
+			}
+
+			options.Modules = []string{component.Connectivity}
+			options.Types = []string{gather.Events}
+			Expect(gather.Data(clusterInfo, options)).To(Succeed())
+
+			data, err := os.ReadFile(filepath.Join(options.Directory, clusterName, "pod-restart-events.json"))
+			Expect(err).To(Succeed())
+
+			events := []corev1.Event{}
+			Expect(json.Unmarshal(data, &events)).To(Succeed())
+
+			names := []string{}
+			for i := range events {
+				names = append(names, events[i].Name)
+			}
+
+			Expect(names).To(Equal([]string{"gateway.1", "routeagent.1"}))
+		})
+	})
+
+	When("events are requested for the operator module", func() {
+		It("should gather both the Normal and the Warning Submariner events", func() {
+			warning := newPodEvent("gateway.1", "submariner-gateway-abcde", "BackOff", 2)
+			warning.Message = "Back-off restarting the failed gateway container"
+
+			for _, event := range []*corev1.Event{
+				warning,
+				{
+					ObjectMeta:     metav1.ObjectMeta{Name: "gateway.2", Namespace: constants.OperatorNamespace},
+					InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "submariner-gateway-abcde"},
+					Type:           corev1.EventTypeNormal,
+					Reason:         "ConnectionEstablished",
+					Message:        "Established the tunnel to cluster west",
+					LastTimestamp:  metav1.NewTime(time.Now().Add(-time.Minute)),
+				},
+			} {
+				_, err := clusterInfo.ClientProducer.ForKubernetes().CoreV1().Events(constants.OperatorNamespace).Create(context.TODO(),
+					event, metav1.CreateOptions{})
+				Expect(err).To(Succeed())
+			}
+
+			options.Types = []string{gather.Events}
+			Expect(gather.Data(clusterInfo, options)).To(Succeed())
+
+			data, err := os.ReadFile(filepath.Join(options.Directory, clusterName, "events.jsonl"))
+			Expect(err).To(Succeed())
+			Expect(strings.Split(strings.TrimSpace(string(data)), "\n")).To(HaveExactElements(
+				ContainSubstring(`"name":"gateway.1"`), ContainSubstring(`"name":"gateway.2"`)))
+		})
+	})
+
+	When("an invalid type is requested", func() {
+		It("should return an error without creating the cluster directory", func() {
+			options.Types = []string{gather.Logs, "configs"}
+
+			err := gather.Data(clusterInfo, options)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(`"configs" is not a supported type`))
+
+			_, err = os.Stat(filepath.Join(options.Directory, clusterName))
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+	})
+
+	When("the cluster token is requested for the metrics without a metrics URL", func() {
+		It("should return an error", func() {
+			options.MetricsURLToken = true
+
+			Expect(gather.CheckOptions(&options)).To(MatchError(ContainSubstring("explicitly given metrics URL")))
+		})
+	})
+})
+
+func newPodEvent(name, podName, reason string, minutesAgo int) *corev1.Event {
+	return &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: constants.OperatorNamespace},
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: podName, Namespace: constants.OperatorNamespace},
+		Type:           corev1.EventTypeWarning,
+		Reason:         reason,
+		LastTimestamp:  metav1.NewTime(time.Now().Add(-time.Duration(minutesAgo) * time.Minute)),
+	}
+}
@@ -19,13 +19,15 @@ limitations under the License.
 package gather
 
 import (
-	"bytes"
+	"bufio"
 	"context"
 	"io"
 	"os"
 	"path/filepath"
+	"sync"
 
 	"github.com/pkg/errors"
+	"github.com/submariner-io/admiral/pkg/reporter"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
@@ -47,9 +49,8 @@ func gatherPodLogsByContainer(podLabelSelector, container string, info *Info) {
 		podLogOptions := corev1.PodLogOptions{
 			Container: container,
 		}
-		for i := range pods.Items {
-			info.Summary.PodLogs = append(info.Summary.PodLogs, outputPodLogs(&pods.Items[i], podLogOptions, info))
-		}
+
+		info.Summary.PodLogs = append(info.Summary.PodLogs, outputAllPodLogs(pods.Items, podLogOptions, info)...)
 
 		return nil
 	}()
@@ -59,6 +60,52 @@ func gatherPodLogsByContainer(podLabelSelector, container string, info *Info) {
 	}
 }
 
+// outputAllPodLogs retrieves the logs of the given pods concurrently, using up to info.Workers workers. Each pod's
+// reports are buffered and replayed in order once all the logs have been retrieved, so that they don't interleave.
+//
+//nolint:gocritic // hugeParam: podLogOptions - purposely passed by value.
+func outputAllPodLogs(pods []corev1.Pod, podLogOptions corev1.PodLogOptions, info *Info) []LogInfo {
+	workers := info.Workers
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+
+	podLogInfos := make([]LogInfo, len(pods))
+	reports := make([]*bufferedReporter, len(pods))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+
+	for range min(workers, len(pods)) {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range indexes {
+				podInfo := *info
+				reports[i] = &bufferedReporter{}
+				podInfo.Status = &reporter.Adapter{Basic: reports[i]}
+
+				podLogInfos[i] = outputPodLogs(&pods[i], podLogOptions, &podInfo)
+			}
+		}()
+	}
+
+	for i := range pods {
+		indexes <- i
+	}
+
+	close(indexes)
+	wg.Wait()
+
+	for _, report := range reports {
+		report.replay(info.Status)
+	}
+
+	return podLogInfos
+}
+
 //nolint:gocritic // hugeParam: podLogOptions - purposely passed by value.
 func outputPodLogs(pod *corev1.Pod, podLogOptions corev1.PodLogOptions, info *Info) (podLogInfo LogInfo) {
 	podLogInfo.Namespace = pod.Namespace
@@ -79,26 +126,38 @@ func outputPodLogs(pod *corev1.Pod, podLogOptions corev1.PodLogOptions, info *In
 	return podLogInfo
 }
 
+// writePodLogToFile streams the log to its file line by line, scrubbing each line, so that logs are never held in memory
+// in their entirety.
 func writePodLogToFile(logStream io.ReadCloser, info *Info, podName, fileExtension string) (string, error) {
-	logs, err := getLogFromStream(logStream)
+	fileName := escapeFileName(podName) + fileExtension
+	filePath := filepath.Join(info.DirName, fileName)
+
+	f, err := os.Create(filePath)
 	if err != nil {
-		return "", err
+		return "", errors.WithMessagef(err, "error opening file %s", filePath)
 	}
+	defer f.Close()
 
-	logs = scrubSensitiveData(info, logs)
+	reader := bufio.NewReader(logStream)
+	writer := bufio.NewWriter(f)
 
-	return writeLogToFile(logs, podName, info, fileExtension)
-}
+	for {
+		line, readErr := reader.ReadString('\n')
 
-func getLogFromStream(logStream io.ReadCloser) (string, error) {
-	logs := new(bytes.Buffer)
+		if _, err := writer.WriteString(scrubSensitiveData(info, line)); err != nil {
+			return "", errors.WithMessagef(err, "error writing to file %s", filePath)
+		}
 
-	_, err := io.Copy(logs, logStream)
-	if err != nil {
-		return "", errors.WithMessage(err, "error copying the log stream")
+		if errors.Is(readErr, io.EOF) {
+			break
+		}
+
+		if readErr != nil {
+			return "", errors.WithMessage(readErr, "error copying the log stream")
+		}
 	}
 
-	return logs.String(), nil
+	return fileName, errors.WithMessagef(writer.Flush(), "error writing to file %s", filePath)
 }
 
 func writeLogToFile(data, podName string, info *Info, fileExtension string) (string, error) {
@@ -196,3 +255,41 @@ func logPodInfo(info *Info, what, podLabelSelector string, process func(info *In
 			what, podLabelSelector, err)
 	}
 }
+
+type report struct {
+	failure bool
+	message string
+	args    []interface{}
+}
+
+// bufferedReporter records failures and warnings so that they can be reported later.
+type bufferedReporter struct {
+	reports []report
+}
+
+func (r *bufferedReporter) Start(_ string, _ ...interface{}) {
+}
+
+func (r *bufferedReporter) End() {
+}
+
+func (r *bufferedReporter) Success(_ string, _ ...interface{}) {
+}
+
+func (r *bufferedReporter) Failure(message string, args ...interface{}) {
+	r.reports = append(r.reports, report{failure: true, message: message, args: args})
+}
+
+func (r *bufferedReporter) Warning(message string, args ...interface{}) {
+	r.reports = append(r.reports, report{message: message, args: args})
+}
+
+func (r *bufferedReporter) replay(status reporter.Interface) {
+	for _, report := range r.reports {
+		if report.failure {
+			status.Failure(report.message, report.args...)
+		} else {
+			status.Warning(report.message, report.args...)
+		}
+	}
+}
@@ -0,0 +1,341 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gather
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	DefaultMetricsHistory = time.Hour
+	metricsDirName        = "metrics"
+	queryRangePath        = "/api/v1/query_range"
+	// Number of samples to retrieve per series over the history window
+	metricsSamples = 240
+	minMetricsStep = 15 * time.Second
+)
+
+type prometheusService struct {
+	namespace string
+	name      string
+	scheme    string
+	port      string
+}
+
+// knownPrometheusServices lists the services commonly used to expose Prometheus, in order of preference.
+var knownPrometheusServices = []prometheusService{
+	{namespace: "openshift-monitoring", name: "prometheus-k8s", scheme: "https", port: "9091"},
+	{namespace: "monitoring", name: "prometheus-operated", scheme: "http", port: "9090"},
+	{namespace: "monitoring", name: "prometheus-k8s", scheme: "http", port: "9090"},
+	{namespace: "prometheus", name: "prometheus-operated", scheme: "http", port: "9090"},
+}
+
+// metricsQueries maps the name of the file in which to store each query's results to the query. Counters are matched
+// with and without the "_total" suffix, since that depends on the exposition format negotiated by Prometheus.
+var metricsQueries = map[string]string{
+	"gateways":              "submariner_gateways",
+	"connections":           "submariner_connections",
+	"requested-connections": "submariner_requested_connections",
+	"connection-latency":    "submariner_connection_latency_seconds",
+	"connection-timestamp":  "submariner_connection_established_timestamp",
+	"gateway-rx-bytes":      `{__name__=~"submariner_gateway_rx_bytes(_total)?"}`,
+	"gateway-tx-bytes":      `{__name__=~"submariner_gateway_tx_bytes(_total)?"}`,
+	"gateway-sync-iters":    "submariner_gateway_sync_iterations",
+	"global-ip-available":   "submariner_global_IP_availability",
+	"global-ip-allocated":   "submariner_global_IP_allocated",
+	"global-egress-ips":     "submariner_global_egress_IP_allocated",
+	"global-ingress-ips":    "submariner_global_ingress_IP_allocated",
+	"cluster-egress-ips":    "submariner_cluster_global_egress_IP_allocated",
+}
+
+// prometheusQuerier performs a GET request against the Prometheus HTTP API and returns the response body.
+type prometheusQuerier struct {
+	description string
+	get         func(ctx context.Context, path string, params map[string]string) ([]byte, error)
+}
+
+type queryResponse struct {
+	Status    string    `json:"status"`
+	Data      queryData `json:"data"`
+	ErrorType string    `json:"errorType"`
+	Error     string    `json:"error"`
+}
+
+type queryData struct {
+	ResultType string `json:"resultType"`
+	// Each series is kept as returned by Prometheus, i.e. a "metric" object with the labels and a "values" array
+	Result []json.RawMessage `json:"result"`
+}
+
+//nolint:gocritic // hugeParam: info - purposely passed by value.
+func gatherMetrics(dataType string, info Info) bool {
+	switch dataType {
+	case Resources:
+		DataFromMetrics(&info)
+	default:
+		return false
+	}
+
+	return true
+}
+
+// DataFromMetrics queries Prometheus for the Submariner metrics over the configured history and stores the results.
+// Prometheus being unavailable isn't an error, since it's an optional component.
+func DataFromMetrics(info *Info) {
+	querier, err := findPrometheus(info)
+	if err != nil {
+		info.Status.Warning("Unable to determine the Prometheus endpoint, skipping metrics: %s", err)
+		return
+	}
+
+	if querier == nil {
+		info.Status.Warning("No Prometheus service was found in the usual namespaces, skipping metrics;" +
+			" use --metrics-url to specify the Prometheus endpoint")
+
+		return
+	}
+
+	dirName := filepath.Join(info.DirName, metricsDirName)
+
+	if err := os.MkdirAll(dirName, 0o700); err != nil {
+		info.Status.Failure("Error creating directory %q: %s", dirName, err)
+		return
+	}
+
+	history := info.MetricsHistory
+	if history <= 0 {
+		history = DefaultMetricsHistory
+	}
+
+	end := time.Now()
+	step := history / metricsSamples
+
+	if step < minMetricsStep {
+		step = minMetricsStep
+	}
+
+	params := map[string]string{
+		"start": strconv.FormatInt(end.Add(-history).Unix(), 10),
+		"end":   strconv.FormatInt(end.Unix(), 10),
+		"step":  strconv.FormatFloat(step.Seconds(), 'f', -1, 64),
+	}
+
+	names := make([]string, 0, len(metricsQueries))
+	for name := range metricsQueries {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	stored := 0
+
+	for _, name := range names {
+		params["query"] = metricsQueries[name]
+
+		series, err := queryRange(querier, params)
+		if err != nil {
+			info.Status.Warning("Error querying %q from %s: %s", metricsQueries[name], querier.description, err)
+
+			// If Prometheus can't be reached at all, there's no point in trying the other queries
+			if !isPrometheusQueryError(err) {
+				return
+			}
+
+			continue
+		}
+
+		fileName := filepath.Join(metricsDirName, name+".jsonl")
+
+		if err := os.WriteFile(filepath.Join(info.DirName, fileName), toNDJSON(series), 0o600); err != nil {
+			info.Status.Failure("Error writing file %q: %s", fileName, err)
+			continue
+		}
+
+		stored++
+	}
+
+	info.Status.Success("Stored %d metrics (last %s) from %s in %q", stored, history, querier.description, dirName)
+}
+
+type prometheusQueryError struct {
+	message string
+}
+
+func (e *prometheusQueryError) Error() string {
+	return e.message
+}
+
+func isPrometheusQueryError(err error) bool {
+	var queryErr *prometheusQueryError
+	return errors.As(err, &queryErr)
+}
+
+// toNDJSON writes each series on its own line, so that large results can be processed as a stream.
+func toNDJSON(series []json.RawMessage) []byte {
+	var buffer bytes.Buffer
+
+	for _, s := range series {
+		if err := json.Compact(&buffer, s); err != nil {
+			// Prometheus returned valid JSON, so this can't happen; keep the series as-is regardless
+			buffer.Write(s)
+		}
+
+		buffer.WriteByte('\n')
+	}
+
+	return buffer.Bytes()
+}
+
+func queryRange(querier *prometheusQuerier, params map[string]string) ([]json.RawMessage, error) {
+	body, err := querier.get(context.TODO(), queryRangePath, params)
+	if err != nil {
+		return nil, err
+	}
+
+	response := queryResponse{}
+
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, errors.Wrap(err, "error parsing the Prometheus response")
+	}
+
+	if response.Status != "success" {
+		return nil, &prometheusQueryError{message: fmt.Sprintf("query failed (%s): %s", response.ErrorType, response.Error)}
+	}
+
+	if response.Data.ResultType != "matrix" {
+		return nil, &prometheusQueryError{message: fmt.Sprintf("unexpected result type %q", response.Data.ResultType)}
+	}
+
+	return response.Data.Result, nil
+}
+
+func findPrometheus(info *Info) (*prometheusQuerier, error) {
+	if info.MetricsURL != "" {
+		return directQuerier(info)
+	}
+
+	for i := range knownPrometheusServices {
+		candidate := knownPrometheusServices[i]
+
+		_, err := info.ClientProducer.ForKubernetes().CoreV1().Services(candidate.namespace).Get(context.TODO(), candidate.name,
+			metav1.GetOptions{})
+		if apierrors.IsNotFound(err) || apierrors.IsForbidden(err) {
+			continue
+		}
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "error retrieving Service %s/%s", candidate.namespace, candidate.name)
+		}
+
+		return &prometheusQuerier{
+			description: fmt.Sprintf("Service %s/%s", candidate.namespace, candidate.name),
+			get: func(ctx context.Context, path string, params map[string]string) ([]byte, error) {
+				//nolint:wrapcheck // The caller wraps the error
+				return info.ClientProducer.ForKubernetes().CoreV1().Services(candidate.namespace).
+					ProxyGet(candidate.scheme, candidate.name, candidate.port, path, params).DoRaw(ctx)
+			},
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// directQuerier queries the user-provided Prometheus URL. The cluster credentials are only sent to it if explicitly
+// requested with MetricsURLToken, since the URL can point anywhere; the bearer token from the cluster configuration is
+// used, in-cluster that's the service account token.
+func directQuerier(info *Info) (*prometheusQuerier, error) {
+	baseURL, err := url.Parse(info.MetricsURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid Prometheus URL %q", info.MetricsURL)
+	}
+
+	token := ""
+	if info.MetricsURLToken {
+		token = info.RestConfig.BearerToken
+	}
+
+	if info.MetricsURLToken && token == "" && info.RestConfig.BearerTokenFile != "" {
+		tokenBytes, err := os.ReadFile(info.RestConfig.BearerTokenFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error reading the token file %q", info.RestConfig.BearerTokenFile)
+		}
+
+		token = strings.TrimSpace(string(tokenBytes))
+	}
+
+	httpClient := &http.Client{Timeout: 30 * time.Second}
+
+	return &prometheusQuerier{
+		description: baseURL.Redacted(),
+		get: func(ctx context.Context, path string, params map[string]string) ([]byte, error) {
+			query := url.Values{}
+			for k, v := range params {
+				query.Set(k, v)
+			}
+
+			requestURL := baseURL.JoinPath(path)
+			requestURL.RawQuery = query.Encode()
+
+			request, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL.String(), http.NoBody)
+			if err != nil {
+				return nil, errors.Wrap(err, "error creating the request")
+			}
+
+			if token != "" {
+				request.Header.Set("Authorization", "Bearer "+token)
+			}
+
+			response, err := httpClient.Do(request)
+			if err != nil {
+				return nil, errors.Wrap(err, "error querying Prometheus")
+			}
+
+			defer response.Body.Close()
+
+			body, err := io.ReadAll(response.Body)
+			if err != nil {
+				return nil, errors.Wrap(err, "error reading the Prometheus response")
+			}
+
+			// Prometheus reports query errors as JSON with a 4xx status, let the caller parse those
+			if response.StatusCode >= http.StatusInternalServerError ||
+				response.StatusCode == http.StatusUnauthorized || response.StatusCode == http.StatusForbidden {
+				return nil, fmt.Errorf("unexpected status %q", response.Status)
+			}
+
+			return body, nil
+		},
+	}, nil
+}
@@ -0,0 +1,180 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gather
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/submariner-io/admiral/pkg/resource"
+	"github.com/submariner-io/submariner-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/set"
+	"sigs.k8s.io/yaml"
+)
+
+// gatherNamespaceEvents stores all the events from the namespaces relevant to Submariner: the operator namespace, the
+// broker namespace if the cluster hosts the broker, and the namespaces of the pods whose logs were gathered. The events
+// are stored as YAML, from both the core and events.k8s.io APIs, along with a condensed text version, oldest first.
+// Events expire after an hour by default, so they need to be gathered before they're asked for.
+func gatherNamespaceEvents(info *Info) {
+	for _, namespace := range eventNamespaces(info) {
+		gatherEventsInNamespace(info, namespace)
+	}
+}
+
+func eventNamespaces(info *Info) []string {
+	namespaces := set.New(info.OperatorNamespace())
+
+	brokers, err := info.ClientProducer.ForDynamic().Resource(v1alpha1.GroupVersion.WithResource("brokers")).
+		Namespace(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+	if err != nil && !resource.IsNotFoundErr(err) {
+		info.Status.Warning("Error listing the Broker resources, the broker namespace's events won't be gathered: %s", err)
+	}
+
+	if brokers != nil {
+		for i := range brokers.Items {
+			namespaces.Insert(brokers.Items[i].GetNamespace())
+		}
+	}
+
+	for i := range info.Summary.PodLogs {
+		namespaces.Insert(info.Summary.PodLogs[i].Namespace)
+	}
+
+	return namespaces.SortedList()
+}
+
+func gatherEventsInNamespace(info *Info, namespace string) {
+	kubeClient := info.ClientProducer.ForKubernetes()
+
+	events, err := kubeClient.CoreV1().Events(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		info.Status.Failure("Failed to gather the events in namespace %q: %s", namespace, err)
+		return
+	}
+
+	sort.SliceStable(events.Items, func(i, j int) bool {
+		return eventTime(&events.Items[i]).Before(eventTime(&events.Items[j]))
+	})
+
+	err = writeEventsFile(info, namespace, escapeFileName("events_"+namespace)+".yaml", "events", events.Items)
+	if err == nil {
+		err = writeEventsFile(info, namespace, escapeFileName("events_"+namespace)+".txt", "events (text)", condenseEvents(events.Items))
+	}
+
+	if err != nil {
+		info.Status.Failure("Failed to store the events in namespace %q: %s", namespace, err)
+		return
+	}
+
+	newEvents, err := kubeClient.EventsV1().Events(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		info.Status.Warning("Failed to gather the events.k8s.io events in namespace %q: %s", namespace, err)
+	} else {
+		sort.SliceStable(newEvents.Items, func(i, j int) bool {
+			return newEventTime(&newEvents.Items[i]).Before(newEventTime(&newEvents.Items[j]))
+		})
+
+		err = writeEventsFile(info, namespace, escapeFileName("events.events.k8s.io_"+namespace)+".yaml", "events.events.k8s.io",
+			newEvents.Items)
+		if err != nil {
+			info.Status.Failure("Failed to store the events.k8s.io events in namespace %q: %s", namespace, err)
+		}
+	}
+
+	info.Status.Success("Found %d events in namespace %q", len(events.Items), namespace)
+}
+
+// writeEventsFile stores the given events, marshaled as YAML unless they're already a string.
+func writeEventsFile(info *Info, namespace, name, resourceType string, events interface{}) error {
+	data, ok := events.(string)
+	if !ok {
+		marshaled, err := yaml.Marshal(events)
+		if err != nil {
+			return errors.WithMessage(err, "error marshaling to YAML")
+		}
+
+		data = string(marshaled)
+	}
+
+	path := filepath.Join(info.DirName, name)
+
+	if err := os.WriteFile(path, []byte(scrubSensitiveData(info, data)), 0o600); err != nil {
+		return errors.WithMessagef(err, "error writing file %s", path)
+	}
+
+	info.Summary.Resources = append(info.Summary.Resources, ResourceInfo{
+		Name:      "events",
+		Namespace: namespace,
+		Type:      resourceType,
+		FileName:  name,
+	})
+
+	return nil
+}
+
+// condenseEvents formats the events like "kubectl get events", one per line.
+func condenseEvents(events []corev1.Event) string {
+	var builder strings.Builder
+
+	writer := tabwriter.NewWriter(&builder, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "LAST SEEN\tTYPE\tREASON\tOBJECT\tCOUNT\tMESSAGE")
+
+	for i := range events {
+		event := &events[i]
+
+		object := strings.ToLower(event.InvolvedObject.Kind) + "/" + event.InvolvedObject.Name
+		count := event.Count
+
+		if event.Series != nil {
+			count = event.Series.Count
+		}
+
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%d\t%s\n", eventTime(event).UTC().Format(time.RFC3339), event.Type, event.Reason, object,
+			max(count, 1), strings.ReplaceAll(strings.TrimSpace(event.Message), "\n", " "))
+	}
+
+	_ = writer.Flush()
+
+	return builder.String()
+}
+
+// newEventTime returns the time at which the events.k8s.io event last occurred.
+func newEventTime(event *eventsv1.Event) time.Time {
+	switch {
+	case event.Series != nil && !event.Series.LastObservedTime.IsZero():
+		return event.Series.LastObservedTime.Time
+	case !event.DeprecatedLastTimestamp.IsZero():
+		return event.DeprecatedLastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	default:
+		return event.CreationTimestamp.Time
+	}
+}
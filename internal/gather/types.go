@@ -19,6 +19,8 @@ limitations under the License.
 package gather
 
 import (
+	"time"
+
 	"github.com/submariner-io/admiral/pkg/reporter"
 	"github.com/submariner-io/subctl/pkg/cluster"
 	"github.com/submariner-io/submariner-operator/api/v1alpha1"
@@ -32,6 +34,11 @@ type Info struct {
 	ClusterName          string
 	DirName              string
 	IncludeSensitiveData bool
+	MetricsURL           string
+	MetricsURLToken      bool
+	MetricsHistory       time.Duration
+	Workers              int
+	EventsSince          time.Duration
 	Summary              *Summary
 }
 
@@ -95,6 +95,16 @@ func ListGateways(clientset kubernetes.Interface) ([]string, error) {
 	return getNodeNames(labeledNodes), nil
 }
 
+// ListMatching returns the names of all nodes matching the given label selector.
+func ListMatching(clientset kubernetes.Interface, selector string) ([]string, error) {
+	matchingNodes, err := clientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing Nodes")
+	}
+
+	return getNodeNames(matchingNodes), nil
+}
+
 // this function was sourced from:
 // https://github.com/kubernetes/kubernetes/blob/a3ccea9d8743f2ff82e41b6c2af6dc2c41dc7b10/test/utils/density_utils.go#L36
 func addLabels(clientset kubernetes.Interface, nodeName string, labelsToAdd map[string]string) error {
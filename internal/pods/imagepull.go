@@ -0,0 +1,133 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pods
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/submariner-io/subctl/internal/constants"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const imagePullCheckInterval = 2 * time.Second
+
+// The waiting reasons reported by the kubelet when it can't pull an image.
+var imagePullFailureReasons = []string{"ErrImagePull", "ImagePullBackOff", "InvalidImageName", "ErrImageNeverPull", "RegistryUnavailable"}
+
+// The waiting reasons reported by the kubelet before it's done pulling an image.
+var imagePullPendingReasons = []string{"", "ContainerCreating", "PodInitializing"}
+
+// CheckImagePull checks that the given image can be pulled, by running a short-lived pod with it. It returns an empty
+// string if the image was pulled, otherwise the reason it couldn't be. The pod only runs "true", whether it succeeds
+// doesn't matter once the image has been pulled; it's deleted before returning. The configured scheduling, networking
+// and command are ignored.
+func CheckImagePull(config *Config, image string, timeout time.Duration) (string, error) {
+	if config.Namespace == "" {
+		config.Namespace = constants.OperatorNamespace
+	}
+
+	if config.ServiceAccountName == "" {
+		config.ServiceAccountName = ServiceAccountName
+	}
+
+	if err := setResourcesAndTolerations(config); err != nil {
+		return "", err
+	}
+
+	if err := checkServiceAccount(config); err != nil {
+		return "", err
+	}
+
+	np := &Scheduled{Config: config}
+	np.track()
+
+	defer np.Delete()
+
+	pullSecrets, err := np.preparePullSecrets()
+	if err != nil {
+		return "", err
+	}
+
+	pc := config.ClientSet.CoreV1().Pods(config.Namespace)
+
+	np.Pod, err = pc.Create(context.TODO(), &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: config.Name,
+			Labels: map[string]string{
+				"app":                    config.Name,
+				constants.TransientLabel: constants.TrueLabel,
+			},
+		},
+		Spec: v1.PodSpec{
+			RestartPolicy:      v1.RestartPolicyNever,
+			ServiceAccountName: config.ServiceAccountName,
+			Containers: []v1.Container{
+				{
+					Name:            config.Name,
+					Image:           image,
+					ImagePullPolicy: v1.PullIfNotPresent,
+					Command:         []string{"true"},
+					Resources:       *config.Resources,
+				},
+			},
+			Tolerations:      config.Tolerations,
+			ImagePullSecrets: pullSecrets,
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return "", errors.Wrap(err, "error creating Pod")
+	}
+
+	failure := ""
+
+	err = wait.PollUntilContextTimeout(context.TODO(), imagePullCheckInterval, timeout, true, func(ctx context.Context) (bool, error) {
+		pod, err := pc.Get(ctx, np.Pod.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, errors.Wrapf(err, "error retrieving pod %q", np.Pod.Name)
+		}
+
+		if len(pod.Status.ContainerStatuses) == 0 {
+			return false, nil
+		}
+
+		waiting := pod.Status.ContainerStatuses[0].State.Waiting
+		if waiting == nil {
+			// The container is running or has terminated, so the image was pulled
+			return true, nil
+		}
+
+		if slices.Contains(imagePullFailureReasons, waiting.Reason) {
+			failure = fmt.Sprintf("%s: %s", waiting.Reason, waiting.Message)
+			return true, nil
+		}
+
+		return !slices.Contains(imagePullPendingReasons, waiting.Reason), nil
+	})
+	if wait.Interrupted(err) {
+		return "", fmt.Errorf("timed out waiting for pod %q to pull image %q", np.Pod.Name, image)
+	}
+
+	return failure, errors.Wrapf(err, "error waiting for pod %q to pull image %q", np.Pod.Name, image)
+}
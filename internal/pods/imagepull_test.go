@@ -0,0 +1,107 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pods_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/subctl/internal/pods"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/testing"
+)
+
+const missingImage = "quay.io/mirror/missing:devel"
+
+var _ = Describe("CheckImagePull", func() {
+	var kubeClient *fakeclientset.Clientset
+
+	BeforeEach(func() {
+		kubeClient = fakeclientset.NewClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: podNamespace}})
+
+		// The fake clientset doesn't generate names, and the containers need a status for the pull to be checked
+		kubeClient.PrependReactor("create", "pods", func(action testing.Action) (bool, runtime.Object, error) {
+			pod := action.(testing.CreateAction).GetObject().(*corev1.Pod)
+			pod.Name = pod.GenerateName + "1"
+
+			state := corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}
+			if pod.Spec.Containers[0].Image == missingImage {
+				state = corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{
+					Reason:  "ErrImagePull",
+					Message: "manifest unknown",
+				}}
+			}
+
+			pod.Status.ContainerStatuses = []corev1.ContainerStatus{{State: state}}
+
+			return false, nil, nil
+		})
+	})
+
+	checkImagePull := func(image string) (string, error) {
+		return pods.CheckImagePull(&pods.Config{
+			Name:      "validate-image-pull",
+			ClientSet: kubeClient,
+			Namespace: podNamespace,
+		}, image, time.Second)
+	}
+
+	listPods := func() []corev1.Pod {
+		podList, err := kubeClient.CoreV1().Pods(podNamespace).List(context.TODO(), metav1.ListOptions{})
+		Expect(err).To(Succeed())
+
+		return podList.Items
+	}
+
+	When("the image can be pulled", func() {
+		It("should return no failure and delete the pod", func() {
+			Expect(checkImagePull("quay.io/mirror/submariner-gateway:devel")).To(BeEmpty())
+			Expect(listPods()).To(BeEmpty())
+		})
+	})
+
+	When("the image can't be pulled", func() {
+		It("should return the reason and delete the pod", func() {
+			failure, err := checkImagePull(missingImage)
+			Expect(err).To(Succeed())
+			Expect(failure).To(Equal("ErrImagePull: manifest unknown"))
+			Expect(listPods()).To(BeEmpty())
+		})
+	})
+
+	It("should run the image with the default resources and only pull it if it's not present", func() {
+		var created *corev1.Pod
+
+		kubeClient.PrependReactor("create", "pods", func(action testing.Action) (bool, runtime.Object, error) {
+			created = action.(testing.CreateAction).GetObject().(*corev1.Pod)
+			return false, nil, nil
+		})
+
+		_, err := checkImagePull("quay.io/mirror/lighthouse-agent:devel")
+		Expect(err).To(Succeed())
+		Expect(created).ToNot(BeNil())
+		Expect(created.Spec.Containers[0].ImagePullPolicy).To(Equal(corev1.PullIfNotPresent))
+		Expect(created.Spec.Containers[0].Resources.Requests).To(HaveKey(corev1.ResourceMemory))
+	})
+})
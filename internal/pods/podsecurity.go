@@ -0,0 +1,59 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pods
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PodSecurityModes are the Pod Security admission modes, each of which is configured by a namespace label.
+var PodSecurityModes = []string{"enforce", "audit", "warn"}
+
+// PrivilegedPodSecurityLevel is the Pod Security level required by the privileged Submariner pods.
+const PrivilegedPodSecurityLevel = "privileged"
+
+// PodSecurityLabel returns the namespace label configuring the given Pod Security admission mode.
+func PodSecurityLabel(mode string) string {
+	return "pod-security.kubernetes.io/" + mode
+}
+
+// AllowsPrivilegedPods determines whether any of the Pod Security admission modes is explicitly configured at the
+// privileged level in the given namespace labels.
+func AllowsPrivilegedPods(namespaceLabels map[string]string) bool {
+	for _, mode := range PodSecurityModes {
+		if namespaceLabels[PodSecurityLabel(mode)] == PrivilegedPodSecurityLevel {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PrivilegedPodSecurityLabels lists the namespace labels configuring all the Pod Security admission modes at the
+// privileged level, one per line.
+func PrivilegedPodSecurityLabels() string {
+	var labels strings.Builder
+
+	for _, mode := range PodSecurityModes {
+		fmt.Fprintf(&labels, "  %s=%s\n", PodSecurityLabel(mode), PrivilegedPodSecurityLevel)
+	}
+
+	return labels.String()
+}
@@ -0,0 +1,44 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pods_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/subctl/internal/pods"
+)
+
+var _ = DescribeTable("AllowsPrivilegedPods",
+	func(labels map[string]string, expected bool) {
+		Expect(pods.AllowsPrivilegedPods(labels)).To(Equal(expected))
+	},
+	Entry("without labels", nil, false),
+	Entry("with the enforce mode at the privileged level", map[string]string{
+		"pod-security.kubernetes.io/enforce": "privileged",
+	}, true),
+	Entry("with only the warn mode at the privileged level", map[string]string{
+		"pod-security.kubernetes.io/enforce": "baseline",
+		"pod-security.kubernetes.io/warn":    "privileged",
+	}, true),
+	Entry("with all the modes at a restricted level", map[string]string{
+		"pod-security.kubernetes.io/enforce": "restricted",
+		"pod-security.kubernetes.io/audit":   "baseline",
+		"pod-security.kubernetes.io/warn":    "restricted",
+	}, false),
+)
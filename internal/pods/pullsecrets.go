@@ -0,0 +1,91 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pods
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/submariner-io/subctl/internal/constants"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// preparePullSecrets returns the pull secrets to attach to the pod. Secrets which are in another namespace than the pod
+// are copied to the pod's namespace; each pod gets its own transient copies, which are deleted along with it.
+func (np *Scheduled) preparePullSecrets() ([]v1.LocalObjectReference, error) {
+	repositoryInfo := &np.Config.ImageRepositoryInfo
+	pullSecrets := make([]v1.LocalObjectReference, 0, len(repositoryInfo.PullSecrets))
+
+	if repositoryInfo.PullSecretsNamespace == np.Config.Namespace {
+		for _, name := range repositoryInfo.PullSecrets {
+			pullSecrets = append(pullSecrets, v1.LocalObjectReference{Name: name})
+		}
+
+		return pullSecrets, nil
+	}
+
+	secrets := np.Config.ClientSet.CoreV1().Secrets(repositoryInfo.PullSecretsNamespace)
+
+	for _, name := range repositoryInfo.PullSecrets {
+		source, err := secrets.Get(context.TODO(), name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			np.deletePullSecretCopies()
+
+			return nil, fmt.Errorf("the image pull secret %q configured on the operator doesn't exist in namespace %q",
+				name, repositoryInfo.PullSecretsNamespace)
+		}
+
+		if err != nil {
+			np.deletePullSecretCopies()
+
+			return nil, errors.Wrapf(err, "error retrieving the image pull secret %q in namespace %q; it's needed to copy it to"+
+				" namespace %q for the pods", name, repositoryInfo.PullSecretsNamespace, np.Config.Namespace)
+		}
+
+		secretCopy, err := np.Config.ClientSet.CoreV1().Secrets(np.Config.Namespace).Create(context.TODO(), &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: name + "-",
+				Labels:       map[string]string{constants.TransientLabel: constants.TrueLabel},
+			},
+			Type: source.Type,
+			Data: source.Data,
+		}, metav1.CreateOptions{})
+		if err != nil {
+			np.deletePullSecretCopies()
+
+			return nil, errors.Wrapf(err, "error copying the image pull secret %q to namespace %q", name, np.Config.Namespace)
+		}
+
+		np.pullSecretCopies = append(np.pullSecretCopies, secretCopy.Name)
+		pullSecrets = append(pullSecrets, v1.LocalObjectReference{Name: secretCopy.Name})
+	}
+
+	return pullSecrets, nil
+}
+
+func (np *Scheduled) deletePullSecretCopies() {
+	for _, name := range np.pullSecretCopies {
+		_ = np.Config.ClientSet.CoreV1().Secrets(np.Config.Namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+	}
+
+	np.pullSecretCopies = nil
+}
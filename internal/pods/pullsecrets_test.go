@@ -0,0 +1,211 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pods_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/subctl/internal/constants"
+	"github.com/submariner-io/subctl/internal/pods"
+	"github.com/submariner-io/subctl/pkg/image"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/testing"
+)
+
+const (
+	pullSecretName = "registry-credentials"
+	podNamespace   = "probes"
+)
+
+var _ = Describe("Schedule with image pull secrets", func() {
+	var (
+		kubeClient *fakeclientset.Clientset
+		config     *pods.Config
+	)
+
+	BeforeEach(func() {
+		kubeClient = fakeclientset.NewClientset(
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+				Name:   podNamespace,
+				Labels: map[string]string{"pod-security.kubernetes.io/enforce": "privileged"},
+			}},
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: pullSecretName, Namespace: constants.OperatorNamespace},
+				Type:       corev1.SecretTypeDockerConfigJson,
+				Data:       map[string][]byte{corev1.DockerConfigJsonKey: []byte("{}")},
+			},
+		)
+
+		generated := 0
+
+		// The fake clientset doesn't generate names, and pods need to run to be scheduled
+		kubeClient.PrependReactor("create", "*", func(action testing.Action) (bool, runtime.Object, error) {
+			obj := action.(testing.CreateAction).GetObject().(metav1.Object)
+			if obj.GetName() == "" {
+				generated++
+				obj.SetName(fmt.Sprintf("%s%d", obj.GetGenerateName(), generated))
+			}
+
+			if pod, ok := obj.(*corev1.Pod); ok {
+				pod.Status.Phase = corev1.PodRunning
+			}
+
+			return false, nil, nil
+		})
+
+		config = &pods.Config{
+			Name:       "probe",
+			ClientSet:  kubeClient,
+			Scheduling: pods.Scheduling{ScheduleOn: pods.CustomNode, NodeName: "node1"},
+			Namespace:  podNamespace,
+			ImageRepositoryInfo: image.RepositoryInfo{
+				PullSecrets:          []string{pullSecretName},
+				PullSecretsNamespace: constants.OperatorNamespace,
+			},
+		}
+	})
+
+	listSecretCopies := func() []corev1.Secret {
+		secrets, err := kubeClient.CoreV1().Secrets(podNamespace).List(context.TODO(), metav1.ListOptions{})
+		Expect(err).To(Succeed())
+
+		return secrets.Items
+	}
+
+	When("the pull secret is in another namespace", func() {
+		It("should attach a transient copy to the pod and delete it along with the pod", func() {
+			scheduled, err := pods.Schedule(config)
+			Expect(err).To(Succeed())
+
+			copies := listSecretCopies()
+			Expect(copies).To(HaveLen(1))
+			Expect(copies[0].Labels).To(HaveKeyWithValue(constants.TransientLabel, constants.TrueLabel))
+			Expect(copies[0].Type).To(Equal(corev1.SecretTypeDockerConfigJson))
+			Expect(copies[0].Data).To(HaveKeyWithValue(corev1.DockerConfigJsonKey, []byte("{}")))
+			Expect(scheduled.Pod.Spec.ImagePullSecrets).To(Equal([]corev1.LocalObjectReference{{Name: copies[0].Name}}))
+
+			scheduled.Delete()
+
+			Expect(listSecretCopies()).To(BeEmpty())
+
+			_, err = kubeClient.CoreV1().Secrets(constants.OperatorNamespace).Get(context.TODO(), pullSecretName, metav1.GetOptions{})
+			Expect(err).To(Succeed())
+		})
+	})
+
+	When("subctl is interrupted while the pod is being created", func() {
+		It("should delete the copies", func() {
+			remainingCopies := -1
+			config.ClientSet = &interruptingClient{Interface: kubeClient, interrupt: func() {
+				Expect(listSecretCopies()).To(HaveLen(1))
+
+				pods.DeleteAllScheduled()
+
+				remainingCopies = len(listSecretCopies())
+			}}
+
+			_, err := pods.Schedule(config)
+			Expect(err).To(HaveOccurred())
+			Expect(remainingCopies).To(BeZero())
+		})
+	})
+
+	When("the pull secret is in the pod's namespace", func() {
+		BeforeEach(func() {
+			config.ImageRepositoryInfo.PullSecretsNamespace = podNamespace
+		})
+
+		It("should attach it to the pod as is", func() {
+			scheduled, err := pods.Schedule(config)
+			Expect(err).To(Succeed())
+
+			defer scheduled.Delete()
+
+			Expect(scheduled.Pod.Spec.ImagePullSecrets).To(Equal([]corev1.LocalObjectReference{{Name: pullSecretName}}))
+			Expect(listSecretCopies()).To(BeEmpty())
+		})
+
+		It("should request the default resources and tolerate all taints", func() {
+			scheduled, err := pods.Schedule(config)
+			Expect(err).To(Succeed())
+
+			defer scheduled.Delete()
+
+			Expect(scheduled.Pod.Spec.Containers[0].Resources.Requests).To(HaveKey(corev1.ResourceCPU))
+			Expect(scheduled.Pod.Spec.Containers[0].Resources.Requests).To(HaveKey(corev1.ResourceMemory))
+			Expect(scheduled.Pod.Spec.Tolerations).To(Equal([]corev1.Toleration{{Operator: corev1.TolerationOpExists}}))
+		})
+	})
+
+	When("the pull secret doesn't exist", func() {
+		BeforeEach(func() {
+			config.ImageRepositoryInfo.PullSecrets = []string{pullSecretName, "missing"}
+		})
+
+		It("should fail without leaving any copies or pods", func() {
+			_, err := pods.Schedule(config)
+			Expect(err).To(MatchError(ContainSubstring(`"missing"`)))
+			Expect(listSecretCopies()).To(BeEmpty())
+
+			podList, err := kubeClient.CoreV1().Pods(podNamespace).List(context.TODO(), metav1.ListOptions{})
+			Expect(err).To(Succeed())
+			Expect(podList.Items).To(BeEmpty())
+		})
+	})
+})
+
+// interruptingClient calls interrupt instead of creating pods, to simulate subctl being interrupted at that point; the
+// fake clientset's reactors can't be used for that since they can't access the clientset.
+type interruptingClient struct {
+	kubernetes.Interface
+	interrupt func()
+}
+
+func (c *interruptingClient) CoreV1() corev1client.CoreV1Interface {
+	return &interruptingCoreV1{CoreV1Interface: c.Interface.CoreV1(), interrupt: c.interrupt}
+}
+
+type interruptingCoreV1 struct {
+	corev1client.CoreV1Interface
+	interrupt func()
+}
+
+func (c *interruptingCoreV1) Pods(namespace string) corev1client.PodInterface {
+	return &interruptingPods{PodInterface: c.CoreV1Interface.Pods(namespace), interrupt: c.interrupt}
+}
+
+type interruptingPods struct {
+	corev1client.PodInterface
+	interrupt func()
+}
+
+func (p *interruptingPods) Create(_ context.Context, _ *corev1.Pod, _ metav1.CreateOptions) (*corev1.Pod, error) {
+	p.interrupt()
+
+	return nil, errors.New("interrupted")
+}
@@ -0,0 +1,115 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pods
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	DefaultCPURequest    = "50m"
+	DefaultMemoryRequest = "64Mi"
+)
+
+var (
+	// CPURequest and MemoryRequest are the resource requests of the pods which don't specify their own resources.
+	CPURequest    = DefaultCPURequest
+	MemoryRequest = DefaultMemoryRequest
+	// Tolerations are the tolerations of the pods which don't specify their own, in the format accepted by
+	// ParseTolerations; if empty, the pods tolerate all taints.
+	Tolerations []string
+)
+
+// DefaultResources returns the resource requirements built from CPURequest and MemoryRequest.
+func DefaultResources() (*v1.ResourceRequirements, error) {
+	requests := v1.ResourceList{}
+
+	for name, value := range map[v1.ResourceName]string{v1.ResourceCPU: CPURequest, v1.ResourceMemory: MemoryRequest} {
+		if value == "" {
+			continue
+		}
+
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid %s request %q", name, value)
+		}
+
+		requests[name] = quantity
+	}
+
+	return &v1.ResourceRequirements{Requests: requests}, nil
+}
+
+// ParseTolerations parses tolerations in the same format as kubectl taint, key[=value][:effect]; a toleration without a
+// value tolerates any value of the key, and one without an effect tolerates all the effects. The result tolerates all
+// taints if no tolerations are given.
+func ParseTolerations(specs []string) ([]v1.Toleration, error) {
+	if len(specs) == 0 {
+		return []v1.Toleration{{Operator: v1.TolerationOpExists}}, nil
+	}
+
+	tolerations := make([]v1.Toleration, 0, len(specs))
+
+	for _, spec := range specs {
+		keyValue, effect, _ := strings.Cut(spec, ":")
+		key, value, hasValue := strings.Cut(keyValue, "=")
+
+		if key == "" {
+			return nil, errors.Errorf("invalid toleration %q, the key is missing", spec)
+		}
+
+		toleration := v1.Toleration{Key: key, Operator: v1.TolerationOpExists, Effect: v1.TaintEffect(effect)}
+		if hasValue {
+			toleration.Operator = v1.TolerationOpEqual
+			toleration.Value = value
+		}
+
+		switch toleration.Effect {
+		case "", v1.TaintEffectNoSchedule, v1.TaintEffectPreferNoSchedule, v1.TaintEffectNoExecute:
+		default:
+			return nil, errors.Errorf("invalid toleration %q, the effect must be one of %s, %s or %s", spec,
+				v1.TaintEffectNoSchedule, v1.TaintEffectPreferNoSchedule, v1.TaintEffectNoExecute)
+		}
+
+		tolerations = append(tolerations, toleration)
+	}
+
+	return tolerations, nil
+}
+
+func setResourcesAndTolerations(config *Config) error {
+	var err error
+
+	if config.Resources == nil {
+		config.Resources, err = DefaultResources()
+		if err != nil {
+			return err
+		}
+	}
+
+	if config.Tolerations == nil {
+		config.Tolerations, err = ParseTolerations(Tolerations)
+	}
+
+	return err
+}
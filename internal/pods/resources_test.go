@@ -0,0 +1,92 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pods_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/subctl/internal/pods"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+var _ = Describe("DefaultResources", func() {
+	AfterEach(func() {
+		pods.CPURequest = pods.DefaultCPURequest
+		pods.MemoryRequest = pods.DefaultMemoryRequest
+	})
+
+	It("should request the default CPU and memory", func() {
+		resources, err := pods.DefaultResources()
+		Expect(err).To(Succeed())
+		Expect(resources.Requests).To(Equal(corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(pods.DefaultCPURequest),
+			corev1.ResourceMemory: resource.MustParse(pods.DefaultMemoryRequest),
+		}))
+		Expect(resources.Limits).To(BeEmpty())
+	})
+
+	When("a request is empty", func() {
+		BeforeEach(func() {
+			pods.CPURequest = ""
+		})
+
+		It("should not request that resource", func() {
+			resources, err := pods.DefaultResources()
+			Expect(err).To(Succeed())
+			Expect(resources.Requests).To(HaveLen(1))
+			Expect(resources.Requests).To(HaveKey(corev1.ResourceMemory))
+		})
+	})
+
+	When("a request is invalid", func() {
+		BeforeEach(func() {
+			pods.MemoryRequest = "lots"
+		})
+
+		It("should return an error", func() {
+			_, err := pods.DefaultResources()
+			Expect(err).To(MatchError(ContainSubstring(`"lots"`)))
+		})
+	})
+})
+
+var _ = DescribeTable("ParseTolerations",
+	func(specs []string, expected []corev1.Toleration) {
+		Expect(pods.ParseTolerations(specs)).To(Equal(expected))
+	},
+	Entry("without tolerations", nil, []corev1.Toleration{{Operator: corev1.TolerationOpExists}}),
+	Entry("with a key", []string{"dedicated"}, []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpExists}}),
+	Entry("with a key and an effect", []string{"dedicated:NoSchedule"}, []corev1.Toleration{
+		{Key: "dedicated", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+	}),
+	Entry("with a key, a value and an effect", []string{"dedicated=gateway:NoExecute", "spot=true"}, []corev1.Toleration{
+		{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "gateway", Effect: corev1.TaintEffectNoExecute},
+		{Key: "spot", Operator: corev1.TolerationOpEqual, Value: "true"},
+	}),
+)
+
+var _ = DescribeTable("ParseTolerations with invalid tolerations",
+	func(spec string) {
+		_, err := pods.ParseTolerations([]string{spec})
+		Expect(err).To(MatchError(ContainSubstring(spec)))
+	},
+	Entry("without a key", "=value:NoSchedule"),
+	Entry("with an unknown effect", "dedicated:NoRun"),
+)
@@ -22,6 +22,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sync"
 
 	"github.com/pkg/errors"
 	"github.com/submariner-io/shipyard/test/e2e/framework"
@@ -65,24 +66,31 @@ type Config struct {
 	Command             string
 	Timeout             uint
 	ImageRepositoryInfo image.RepositoryInfo
+	// ServiceAccountName defaults to the ServiceAccountName package variable
+	ServiceAccountName string
+	// Resources defaults to requesting CPURequest and MemoryRequest
+	Resources *v1.ResourceRequirements
+	// Tolerations defaults to the Tolerations package variable
+	Tolerations []v1.Toleration
 }
 
 type Scheduled struct {
 	Pod       *v1.Pod
 	Config    *Config
 	PodOutput string
+	// pullSecretCopies are the image pull secrets copied to the pod's namespace, to delete along with the pod
+	pullSecretCopies []string
 }
 
-func ScheduleAndAwaitCompletion(config *Config) (string, error) {
-	if config.Scheduling.ScheduleOn == InvalidScheduling {
-		config.Scheduling.ScheduleOn = GatewayNode
-	}
-
-	if config.Namespace == "" {
-		config.Namespace = constants.OperatorNamespace
-	}
+// The pods which are being created or have been created and not deleted yet, along with their image pull secret copies,
+// so that they can be cleaned up if subctl is interrupted.
+var (
+	scheduledMutex sync.Mutex
+	scheduledPods  = map[*Scheduled]bool{}
+)
 
-	if err := checkNSLabels(config); err != nil {
+func ScheduleAndAwaitCompletion(config *Config) (string, error) {
+	if err := prepare(config); err != nil {
 		return "", err
 	}
 
@@ -101,6 +109,20 @@ func ScheduleAndAwaitCompletion(config *Config) (string, error) {
 }
 
 func Schedule(config *Config) (*Scheduled, error) {
+	if err := prepare(config); err != nil {
+		return nil, err
+	}
+
+	np := &Scheduled{Config: config}
+	if err := np.schedule(); err != nil {
+		return nil, err
+	}
+
+	return np, nil
+}
+
+// prepare sets the defaults in the given configuration, and checks that the pods can run in its namespace.
+func prepare(config *Config) error {
 	if config.Scheduling.ScheduleOn == InvalidScheduling {
 		config.Scheduling.ScheduleOn = GatewayNode
 	}
@@ -109,16 +131,19 @@ func Schedule(config *Config) (*Scheduled, error) {
 		config.Namespace = constants.OperatorNamespace
 	}
 
-	if err := checkNSLabels(config); err != nil {
-		return nil, err
+	if config.ServiceAccountName == "" {
+		config.ServiceAccountName = ServiceAccountName
 	}
 
-	np := &Scheduled{Config: config}
-	if err := np.schedule(); err != nil {
-		return nil, err
+	if err := setResourcesAndTolerations(config); err != nil {
+		return err
 	}
 
-	return np, nil
+	if err := checkNSLabels(config); err != nil {
+		return err
+	}
+
+	return checkServiceAccount(config)
 }
 
 func (np *Scheduled) schedule() error {
@@ -126,6 +151,15 @@ func (np *Scheduled) schedule() error {
 		return fmt.Errorf("CustomNode is specified for scheduling, but nodeName is missing")
 	}
 
+	// Track the pod before copying its pull secrets, so that the copies are cleaned up if subctl is interrupted
+	np.track()
+
+	pullSecrets, err := np.preparePullSecrets()
+	if err != nil {
+		np.Delete()
+		return err
+	}
+
 	networkPod := v1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
 			GenerateName: np.Config.Name,
@@ -135,8 +169,9 @@ func (np *Scheduled) schedule() error {
 			},
 		},
 		Spec: v1.PodSpec{
-			RestartPolicy: v1.RestartPolicyNever,
-			HostNetwork:   bool(np.Config.Scheduling.Networking),
+			RestartPolicy:      v1.RestartPolicyNever,
+			ServiceAccountName: np.Config.ServiceAccountName,
+			HostNetwork:        bool(np.Config.Scheduling.Networking),
 			Containers: []v1.Container{
 				{
 					Name:    np.Config.Name,
@@ -145,16 +180,18 @@ func (np *Scheduled) schedule() error {
 					Env: []v1.EnvVar{
 						{Name: "COMMAND", Value: np.Config.Command},
 					},
+					Resources: *np.Config.Resources,
 				},
 			},
-			Tolerations: []v1.Toleration{{Operator: v1.TolerationOpExists}},
+			Tolerations:      np.Config.Tolerations,
+			ImagePullSecrets: pullSecrets,
 		},
 	}
 
 	if np.Config.Scheduling.Networking == HostNetworking {
 		networkPod.Spec.Containers[0].SecurityContext = &v1.SecurityContext{
 			Capabilities: &v1.Capabilities{
-				Add:  []v1.Capability{"NET_ADMIN", "NET_RAW"},
+				Add:  HostNetworkingCapabilities,
 				Drop: []v1.Capability{"all"},
 			},
 			// Some containers which run os like rhel/fedora runs tcpdump
@@ -174,10 +211,9 @@ func (np *Scheduled) schedule() error {
 
 	pc := np.Config.ClientSet.CoreV1().Pods(np.Config.Namespace)
 
-	var err error
-
 	np.Pod, err = pc.Create(context.TODO(), &networkPod, metav1.CreateOptions{})
 	if err != nil {
+		np.Delete()
 		return errors.Wrap(err, "error creating Pod")
 	}
 
@@ -190,9 +226,41 @@ func (np *Scheduled) schedule() error {
 	return nil
 }
 
+func (np *Scheduled) track() {
+	scheduledMutex.Lock()
+	scheduledPods[np] = true
+	scheduledMutex.Unlock()
+}
+
+// Delete deletes the pod, if it was created, and its image pull secret copies.
 func (np *Scheduled) Delete() {
-	pc := np.Config.ClientSet.CoreV1().Pods(np.Config.Namespace)
-	_ = pc.Delete(context.TODO(), np.Pod.Name, metav1.DeleteOptions{})
+	scheduledMutex.Lock()
+	delete(scheduledPods, np)
+	scheduledMutex.Unlock()
+
+	if np.Pod != nil {
+		pc := np.Config.ClientSet.CoreV1().Pods(np.Config.Namespace)
+		_ = pc.Delete(context.TODO(), np.Pod.Name, metav1.DeleteOptions{})
+	}
+
+	np.deletePullSecretCopies()
+}
+
+// DeleteAllScheduled deletes all the pods which were scheduled and haven't been deleted yet.
+// It's intended to be called when subctl is interrupted.
+func DeleteAllScheduled() {
+	scheduledMutex.Lock()
+
+	remaining := make([]*Scheduled, 0, len(scheduledPods))
+	for np := range scheduledPods {
+		remaining = append(remaining, np)
+	}
+
+	scheduledMutex.Unlock()
+
+	for _, np := range remaining {
+		np.Delete()
+	}
 }
 
 //nolint:wrapcheck // No need to wrap errors here.
@@ -310,35 +378,14 @@ func checkNSLabels(config *Config) error {
 		return errors.Wrap(err, fmt.Sprintf("error fetching %s namespace", config.Namespace))
 	}
 
-	expectedLabels := map[string]string{
-		"pod-security.kubernetes.io/enforce": "privileged",
-		"pod-security.kubernetes.io/audit":   "privileged",
-		"pod-security.kubernetes.io/warn":    "privileged",
-	}
-
-	foundOne := false
-
-	for key, expectedLabel := range expectedLabels {
-		actualLabel, found := ns.Labels[key]
-		if found && actualLabel == expectedLabel {
-			foundOne = true
-			break
-		}
-	}
-
-	if foundOne {
+	if AllowsPrivilegedPods(ns.Labels) {
 		return nil
 	}
 
-	labelStr := ""
-	for key, val := range expectedLabels {
-		labelStr += fmt.Sprintf("  %s=%s", key, val) + "\n"
-	}
-
 	status := cli.NewReporter()
 	status.Warning("Starting with Kubernetes 1.23, the Pod Security admission controller expects namespaces to have security labels."+
 		" Without these, you will see warnings in subctl's output. subctl should work fine, but you can avoid the warnings and ensure "+
-		"correct behavior by adding at least one of these labels to the namespace %q:\n%s", config.Namespace, labelStr)
+		"correct behavior by adding at least one of these labels to the namespace %q:\n%s", config.Namespace, PrivilegedPodSecurityLabels())
 
 	return nil
 }
@@ -0,0 +1,64 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pods
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ServiceAccountName is the service account used by the pods which don't specify one; if empty, they run with the
+// namespace's default service account.
+var ServiceAccountName string
+
+// HostNetworkingCapabilities are the capabilities added to the host-networked pods.
+var HostNetworkingCapabilities = []v1.Capability{"NET_ADMIN", "NET_RAW"}
+
+// ServiceAccountRequirements describes the permissions needed by a service account running the pods.
+func ServiceAccountRequirements() string {
+	capabilities := make([]string, len(HostNetworkingCapabilities))
+	for i := range HostNetworkingCapabilities {
+		capabilities[i] = string(HostNetworkingCapabilities[i])
+	}
+
+	return fmt.Sprintf("the pods don't access the Kubernetes API, but the service account must be allowed to run privileged,"+
+		" host-networked pods running as root with the %s capabilities (on OpenShift, to use the privileged SCC)",
+		strings.Join(capabilities, " and "))
+}
+
+func checkServiceAccount(config *Config) error {
+	if config.ServiceAccountName == "" {
+		return nil
+	}
+
+	_, err := config.ClientSet.CoreV1().ServiceAccounts(config.Namespace).Get(context.TODO(), config.ServiceAccountName,
+		metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return fmt.Errorf("the service account %q doesn't exist in namespace %q; create it, or specify an existing"+
+			" service account, before running the pods", config.ServiceAccountName, config.Namespace)
+	}
+
+	return errors.Wrapf(err, "error retrieving service account %q in namespace %q", config.ServiceAccountName, config.Namespace)
+}
@@ -0,0 +1,98 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restconfig
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// contextFlagAnnotation marks the flags which take kubeconfig context names; its values are the flags giving the
+// kubeconfig to load the contexts from, in order of precedence.
+const contextFlagAnnotation = "subctl-kubeconfig-context"
+
+func markContextFlag(flags *pflag.FlagSet, name string, kubeConfigFlags ...string) {
+	if name == "" || flags.Lookup(name) == nil {
+		return
+	}
+
+	_ = flags.SetAnnotation(name, contextFlagAnnotation, kubeConfigFlags)
+}
+
+// RegisterContextCompletions sets up shell completion of the kubeconfig context names for all the context flags
+// configured by producers on the given command and its sub-commands.
+func RegisterContextCompletions(cmd *cobra.Command) {
+	cmd.LocalFlags().VisitAll(func(flag *pflag.Flag) {
+		kubeConfigFlags, ok := flag.Annotations[contextFlagAnnotation]
+		if !ok {
+			return
+		}
+
+		if _, registered := cmd.GetFlagCompletionFunc(flag.Name); !registered {
+			_ = cmd.RegisterFlagCompletionFunc(flag.Name,
+				contextCompletion(kubeConfigFlags, flag.Value.Type() == "stringSlice"))
+		}
+	})
+
+	for _, subCommand := range cmd.Commands() {
+		RegisterContextCompletions(subCommand)
+	}
+}
+
+type completionFunc func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective)
+
+// contextCompletion completes context names from the kubeconfig given by the first set flag among kubeConfigFlags, or the
+// default kubeconfig; with multiple, the last of the comma-separated names is completed.
+func contextCompletion(kubeConfigFlags []string, multiple bool) completionFunc {
+	return func(cmd *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+
+		for _, name := range kubeConfigFlags {
+			if flag := cmd.Flag(name); flag != nil && flag.Value.String() != "" {
+				loadingRules.ExplicitPath = flag.Value.String()
+				break
+			}
+		}
+
+		rawConfig, err := loadingRules.Load()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+
+		selected := ""
+		if multiple {
+			if i := strings.LastIndex(toComplete, ","); i >= 0 {
+				selected, toComplete = toComplete[:i+1], toComplete[i+1:]
+			}
+		}
+
+		completions := []string{}
+
+		for _, name := range contextNames(rawConfig) {
+			if strings.HasPrefix(name, toComplete) {
+				completions = append(completions, selected+name)
+			}
+		}
+
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+}
@@ -0,0 +1,80 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restconfig_test
+
+import (
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+	"github.com/submariner-io/subctl/internal/restconfig"
+	"github.com/submariner-io/subctl/internal/restconfig/fake"
+)
+
+var _ = Describe("RegisterContextCompletions", func() {
+	var (
+		kubeConfig string
+		command    *cobra.Command
+	)
+
+	BeforeEach(func() {
+		kubeConfig = filepath.Join(GinkgoT().TempDir(), "kubeconfig")
+		Expect(fake.WriteKubeConfig(kubeConfig, "east-admin",
+			fake.Context{Name: "east-admin", Cluster: "east"},
+			fake.Context{Name: "west-admin", Cluster: "west"},
+			fake.Context{Name: "west-user", Cluster: "west"})).To(Succeed())
+
+		root := &cobra.Command{Use: "root"}
+		command = &cobra.Command{Use: "sub", Run: func(_ *cobra.Command, _ []string) {}}
+		root.AddCommand(command)
+
+		restconfig.NewProducer().WithContextsFlag().WithPrefixedContext("remote").SetupFlags(command.Flags())
+		restconfig.RegisterContextCompletions(root)
+
+		Expect(command.ParseFlags([]string{"--kubeconfig", kubeConfig})).To(Succeed())
+	})
+
+	complete := func(flagName, toComplete string) []string {
+		completion, ok := command.GetFlagCompletionFunc(flagName)
+		Expect(ok).To(BeTrue())
+
+		completions, directive := completion(command, nil, toComplete)
+		Expect(directive).To(Equal(cobra.ShellCompDirectiveNoFileComp))
+
+		return completions
+	}
+
+	It("should complete --context with the matching context names", func() {
+		Expect(complete("context", "")).To(Equal([]string{"east-admin", "west-admin", "west-user"}))
+		Expect(complete("context", "west")).To(Equal([]string{"west-admin", "west-user"}))
+	})
+
+	It("should complete the last of the names given to --contexts", func() {
+		Expect(complete("contexts", "east-admin,west-a")).To(Equal([]string{"east-admin,west-admin"}))
+	})
+
+	It("should complete the prefixed context flags", func() {
+		Expect(complete("remotecontext", "e")).To(Equal([]string{"east-admin"}))
+	})
+
+	It("should complete the legacy context flags", func() {
+		Expect(complete("kubecontexts", "west-u")).To(Equal([]string{"west-user"}))
+	})
+})
@@ -0,0 +1,35 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restconfig
+
+// SetLegacyContextFlagsRemoved controls whether the legacy context flags are removed, returning a function restoring
+// the default.
+func SetLegacyContextFlagsRemoved(removed bool) func() {
+	origRemoved := legacyContextFlagsRemoved
+	legacyContextFlagsRemoved = removed
+
+	return func() {
+		legacyContextFlagsRemoved = origRemoved
+	}
+}
+
+// SelectedContexts returns the context selected with --context, and those selected with --contexts.
+func (rcp *Producer) SelectedContexts() (string, []string) {
+	return rcp.defaultClientConfig.overrides.CurrentContext, rcp.contexts
+}
@@ -0,0 +1,225 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fake provides a harness to test code using the restconfig producer without a live kubeconfig or cluster:
+// kubeconfig files are generated with arbitrary contexts, and connections to the clusters they reference, or to the
+// in-cluster configuration, are served by fake clients.
+package fake
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/submariner-io/subctl/internal/constants"
+	"github.com/submariner-io/subctl/internal/restconfig"
+	"github.com/submariner-io/subctl/pkg/client"
+	"github.com/submariner-io/subctl/pkg/cluster"
+	"github.com/submariner-io/submariner-operator/api/v1alpha1"
+	opnames "github.com/submariner-io/submariner-operator/pkg/names"
+	submarinerv1 "github.com/submariner-io/submariner/pkg/apis/submariner.io/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+	controllerClient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const serverDomain = ".cluster.test"
+
+// Context describes a kubeconfig context; the user defaults to the context name.
+type Context struct {
+	Name      string
+	Cluster   string
+	User      string
+	Namespace string
+}
+
+// WriteKubeConfig writes a kubeconfig containing the given contexts, and the clusters and users they reference, to the
+// given file. Each cluster's server is given by ServerURL.
+func WriteKubeConfig(filename, currentContext string, contexts ...Context) error {
+	config := api.NewConfig()
+	config.CurrentContext = currentContext
+
+	for _, kubeContext := range contexts {
+		user := kubeContext.User
+		if user == "" {
+			user = kubeContext.Name
+		}
+
+		config.Clusters[kubeContext.Cluster] = &api.Cluster{Server: ServerURL(kubeContext.Cluster)}
+		config.AuthInfos[user] = &api.AuthInfo{Token: user + "-token"}
+		config.Contexts[kubeContext.Name] = &api.Context{
+			Cluster:   kubeContext.Cluster,
+			AuthInfo:  user,
+			Namespace: kubeContext.Namespace,
+		}
+	}
+
+	return errors.Wrapf(clientcmd.WriteToFile(*config, filename), "error writing the kubeconfig to %q", filename)
+}
+
+// ServerURL returns the API server URL used for the given cluster.
+func ServerURL(clusterName string) string {
+	return "https://" + clusterName + serverDomain + ":6443"
+}
+
+// Clusters serves connections to fake clusters, identified by the name used in the kubeconfig, or the in-cluster name
+// for the in-cluster configuration. Clusters which haven't been added are empty.
+type Clusters struct {
+	mutex     sync.Mutex
+	objects   map[string][]controllerClient.Object
+	failures  map[string]error
+	connected []string
+}
+
+// NewClusters returns an empty set of fake clusters.
+func NewClusters() *Clusters {
+	return &Clusters{
+		objects:  map[string][]controllerClient.Object{},
+		failures: map[string]error{},
+	}
+}
+
+// Add adds the given objects to the named cluster.
+func (c *Clusters) Add(clusterName string, objects ...controllerClient.Object) *Clusters {
+	c.objects[clusterName] = append(c.objects[clusterName], objects...)
+
+	return c
+}
+
+// Fail makes connections to the named cluster fail with the given error.
+func (c *Clusters) Fail(clusterName string, err error) *Clusters {
+	c.failures[clusterName] = err
+
+	return c
+}
+
+// Connected returns the names of the clusters connected to, in order.
+func (c *Clusters) Connected() []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return append([]string{}, c.connected...)
+}
+
+// Install makes the restconfig producers connect to these fake clusters, with the in-cluster configuration connecting
+// to the cluster with the given name; it returns a function restoring the defaults.
+func (c *Clusters) Install(inClusterName string) func() {
+	restoreInClusterConfig := restconfig.SetInClusterConfig(func() (*rest.Config, error) {
+		return &rest.Config{Host: ServerURL(inClusterName)}, nil
+	})
+	restoreNewInfo := restconfig.SetNewInfo(c.NewInfo)
+
+	return func() {
+		restoreNewInfo()
+		restoreInClusterConfig()
+	}
+}
+
+// NewInfo connects to the fake cluster served at the configuration's host, like cluster.NewInfo.
+func (c *Clusters) NewInfo(clusterName string, config *rest.Config) (*cluster.Info, error) {
+	name, err := clusterFromHost(config.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mutex.Lock()
+	c.connected = append(c.connected, name)
+	objects := c.objects[name]
+	failure := c.failures[name]
+	c.mutex.Unlock()
+
+	if failure != nil {
+		return nil, failure
+	}
+
+	return cluster.NewInfoFromProducer(clusterName, config, NewProducer(objects...)) //nolint:wrapcheck // No need to wrap
+}
+
+func clusterFromHost(host string) (string, error) {
+	serverURL, err := url.Parse(host)
+	if err != nil {
+		return "", errors.Wrapf(err, "error parsing the server URL %q", host)
+	}
+
+	name, found := strings.CutSuffix(serverURL.Hostname(), serverDomain)
+	if !found {
+		return "", fmt.Errorf("the server URL %q doesn't reference a fake cluster", host)
+	}
+
+	return name, nil
+}
+
+// NewProducer returns a client producer whose clients are backed by fakes, pre-populated with the given objects.
+func NewProducer(objects ...controllerClient.Object) client.Producer {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(v1alpha1.AddToScheme(scheme))
+	utilruntime.Must(submarinerv1.AddToScheme(scheme))
+
+	runtimeObjects := make([]runtime.Object, len(objects))
+	for i := range objects {
+		runtimeObjects[i] = objects[i]
+	}
+
+	return &client.DefaultProducer{
+		KubeClient:    fakeclientset.NewSimpleClientset(),
+		DynamicClient: fakedynamic.NewSimpleDynamicClient(scheme, runtimeObjects...),
+		GeneralClient: fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+	}
+}
+
+// NewClusterInfo returns the information for a fake cluster with the given name and objects, with the Submariner and
+// ServiceDiscovery resources loaded.
+func NewClusterInfo(clusterName string, objects ...controllerClient.Object) (*cluster.Info, error) {
+	info, err := cluster.NewInfoFromProducer(clusterName, &rest.Config{Host: ServerURL(clusterName)}, NewProducer(objects...))
+	if err != nil {
+		return nil, err //nolint:wrapcheck // No need to wrap
+	}
+
+	return info, info.LoadSubmariner() //nolint:wrapcheck // No need to wrap
+}
+
+// NewSubmariner returns a Submariner resource for the given cluster ID.
+func NewSubmariner(clusterID string) *v1alpha1.Submariner {
+	return &v1alpha1.Submariner{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      opnames.SubmarinerCrName,
+			Namespace: constants.OperatorNamespace,
+		},
+		Spec: v1alpha1.SubmarinerSpec{ClusterID: clusterID},
+	}
+}
+
+// NewServiceDiscovery returns a ServiceDiscovery resource for the given cluster ID.
+func NewServiceDiscovery(clusterID string) *v1alpha1.ServiceDiscovery {
+	return &v1alpha1.ServiceDiscovery{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      opnames.ServiceDiscoveryCrName,
+			Namespace: constants.OperatorNamespace,
+		},
+		Spec: v1alpha1.ServiceDiscoverySpec{ClusterID: clusterID},
+	}
+}
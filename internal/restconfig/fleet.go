@@ -0,0 +1,119 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restconfig
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/submariner-io/admiral/pkg/reporter"
+	"github.com/submariner-io/subctl/internal/fleet"
+	"github.com/submariner-io/subctl/pkg/cluster"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// UseFleet selects the contexts of the given fleet for RunOnAllContexts, unless contexts were selected on the command
+// line, which always take precedence. The fleet's contexts must exist in the kubeconfig. Returns true if the fleet's
+// contexts are used.
+func (rcp *Producer) UseFleet(f *fleet.Fleet) (bool, error) {
+	if rcp.inCluster || rcp.defaultClientConfig == nil || rcp.defaultClientConfig.overrides.CurrentContext != "" ||
+		len(rcp.contexts) > 0 {
+		return false, nil
+	}
+
+	rawConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		rcp.defaultClientConfig.loadingRules, rcp.defaultClientConfig.overrides).RawConfig()
+	if err != nil {
+		return false, errors.Wrap(err, "error retrieving the raw kubeconfig setup")
+	}
+
+	missing := []string{}
+
+	for _, contextName := range f.Contexts() {
+		if _, ok := rawConfig.Contexts[contextName]; !ok {
+			missing = append(missing, contextName)
+		}
+	}
+
+	if len(missing) > 0 {
+		return false, fmt.Errorf("the fleet file %q refers to contexts which aren't in the kubeconfig: %s", f.Path,
+			strings.Join(missing, ", "))
+	}
+
+	rcp.contexts = f.Contexts()
+	rcp.fleet = f
+
+	return true, nil
+}
+
+// withFleetChecks wraps the function so that the cluster is checked against the attributes given for its context in the
+// fleet file, if any.
+func (rcp *Producer) withFleetChecks(contextName string, function PerContextFn) PerContextFn {
+	if rcp.fleet == nil {
+		return function
+	}
+
+	member := rcp.fleet.Cluster(contextName)
+	if member == nil {
+		return function
+	}
+
+	return func(clusterInfo *cluster.Info, namespace string, status reporter.Interface) error {
+		checkFleetAttributes(member, clusterInfo, status)
+		return function(clusterInfo, namespace, status)
+	}
+}
+
+func checkFleetAttributes(member *fleet.Cluster, clusterInfo *cluster.Info, status reporter.Interface) {
+	submariner, err := clusterInfo.GetSubmariner()
+	if err != nil {
+		status.Warning("Unable to check the fleet file's expectations for context %q: %v", member.Context, err)
+		return
+	}
+
+	if submariner == nil {
+		return
+	}
+
+	spec := &submariner.Spec
+
+	if member.ClusterID != "" && member.ClusterID != spec.ClusterID {
+		status.Warning("The fleet file expects cluster ID %q for context %q, but Submariner is deployed with cluster ID %q",
+			member.ClusterID, member.Context, spec.ClusterID)
+	}
+
+	if member.Globalnet != nil && *member.Globalnet != (spec.GlobalCIDR != "") {
+		status.Warning("The fleet file expects Globalnet to be %s for context %q, but it isn't", enabledOrDisabled(*member.Globalnet),
+			member.Context)
+	}
+
+	if member.AirGapped != nil && *member.AirGapped != spec.AirGappedDeployment {
+		status.Warning("The fleet file expects the air-gapped deployment mode to be %s for context %q, but it isn't",
+			enabledOrDisabled(*member.AirGapped), member.Context)
+	}
+}
+
+func enabledOrDisabled(enabled bool) string {
+	if enabled {
+		return "enabled"
+	}
+
+	return "disabled"
+}
@@ -0,0 +1,75 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restconfig
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// legacyContextFlagsRemoved stops accepting the legacy context flags as aliases; they are then rejected with an error
+// giving their replacement, see LegacyFlagError.
+var legacyContextFlagsRemoved = false
+
+// legacyContextFlags maps the legacy context flags to the clientcmd-based flags which replace them.
+var legacyContextFlags = map[string]string{
+	"kubecontext":  "context",
+	"kubecontexts": "contexts",
+}
+
+// setupLegacyContextFlags sets up the legacy context flags as hidden aliases of their replacements; using them prints
+// a deprecation notice.
+func (rcp *Producer) setupLegacyContextFlags(flags *pflag.FlagSet) {
+	if legacyContextFlagsRemoved {
+		return
+	}
+
+	flags.StringVar(&rcp.defaultClientConfig.overrides.CurrentContext, "kubecontext", "", "kubeconfig context to use")
+	_ = flags.MarkDeprecated("kubecontext", "use --context instead")
+	markContextFlag(flags, "kubecontext", "kubeconfig")
+
+	if rcp.contextsFlag {
+		flags.StringSliceVar(&rcp.contexts, "kubecontexts", nil, "comma-separated list of kubeconfig contexts to use")
+		_ = flags.MarkDeprecated("kubecontexts", "use --contexts instead")
+		markContextFlag(flags, "kubecontexts", "kubeconfig")
+	}
+}
+
+// LegacyFlagError replaces the error produced when parsing one of the removed legacy context flags with one giving the
+// flag to use instead; other errors are returned as is. It's intended for use as a cobra flag error function.
+func LegacyFlagError(flags *pflag.FlagSet, err error) error {
+	name, found := strings.CutPrefix(err.Error(), "unknown flag: --")
+	if !found {
+		return err
+	}
+
+	replacement, ok := legacyContextFlags[name]
+	if !ok {
+		return err
+	}
+
+	// Commands which only handle a single context never had --kubecontexts
+	if flags.Lookup(replacement) == nil {
+		replacement = legacyContextFlags["kubecontext"]
+	}
+
+	return fmt.Errorf("the --%s flag is no longer supported, use --%s instead", name, replacement)
+}
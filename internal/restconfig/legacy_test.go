@@ -0,0 +1,110 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restconfig_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/pflag"
+	"github.com/submariner-io/subctl/internal/restconfig"
+)
+
+var _ = Describe("Legacy context flags", func() {
+	var (
+		producer *restconfig.Producer
+		flags    *pflag.FlagSet
+	)
+
+	setupFlags := func() {
+		producer = restconfig.NewProducer().WithContextsFlag()
+		flags = pflag.NewFlagSet("test", pflag.ContinueOnError)
+		producer.SetupFlags(flags)
+	}
+
+	When("the legacy flags are still supported", func() {
+		BeforeEach(setupFlags)
+
+		It("should map --kubecontext to --context", func() {
+			Expect(flags.Parse([]string{"--kubecontext", "east"})).To(Succeed())
+
+			context, _ := producer.SelectedContexts()
+			Expect(context).To(Equal("east"))
+		})
+
+		It("should map --kubecontexts to --contexts", func() {
+			Expect(flags.Parse([]string{"--kubecontexts", "east,west"})).To(Succeed())
+
+			_, contexts := producer.SelectedContexts()
+			Expect(contexts).To(Equal([]string{"east", "west"}))
+		})
+
+		It("should hide them", func() {
+			Expect(flags.Lookup("kubecontext").Hidden).To(BeTrue())
+			Expect(flags.Lookup("kubecontexts").Hidden).To(BeTrue())
+		})
+	})
+
+	When("the legacy flags have been removed", func() {
+		BeforeEach(func() {
+			DeferCleanup(restconfig.SetLegacyContextFlagsRemoved(true))
+			setupFlags()
+		})
+
+		It("should give the replacement for --kubecontext", func() {
+			err := flags.Parse([]string{"--kubecontext", "east"})
+			Expect(err).To(HaveOccurred())
+			Expect(restconfig.LegacyFlagError(flags, err)).To(MatchError(
+				"the --kubecontext flag is no longer supported, use --context instead"))
+		})
+
+		It("should give the replacement for --kubecontexts", func() {
+			err := flags.Parse([]string{"--kubecontexts=east,west"})
+			Expect(err).To(HaveOccurred())
+			Expect(restconfig.LegacyFlagError(flags, err)).To(MatchError(
+				"the --kubecontexts flag is no longer supported, use --contexts instead"))
+		})
+
+		Context("and the command doesn't support multiple contexts", func() {
+			It("should suggest --context for --kubecontexts", func() {
+				producer = restconfig.NewProducer()
+				flags = pflag.NewFlagSet("test", pflag.ContinueOnError)
+				producer.SetupFlags(flags)
+
+				err := flags.Parse([]string{"--kubecontexts", "east"})
+				Expect(err).To(HaveOccurred())
+				Expect(restconfig.LegacyFlagError(flags, err)).To(MatchError(
+					"the --kubecontexts flag is no longer supported, use --context instead"))
+			})
+		})
+	})
+
+	When("the error doesn't concern a legacy flag", func() {
+		It("should return it as is", func() {
+			setupFlags()
+
+			err := flags.Parse([]string{"--kubecluster", "east"})
+			Expect(restconfig.LegacyFlagError(flags, err)).To(Equal(err))
+
+			err = errors.New("invalid argument")
+			Expect(restconfig.LegacyFlagError(flags, err)).To(Equal(err))
+		})
+	})
+})
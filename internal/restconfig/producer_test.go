@@ -0,0 +1,519 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restconfig_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/pflag"
+	"github.com/submariner-io/admiral/pkg/reporter"
+	"github.com/submariner-io/subctl/internal/restconfig"
+	"github.com/submariner-io/subctl/internal/restconfig/fake"
+	"github.com/submariner-io/subctl/pkg/cluster"
+	"k8s.io/client-go/rest"
+)
+
+// invocation records a call to a PerContextFn.
+type invocation struct {
+	clusterName string
+	namespace   string
+}
+
+type producerTest struct {
+	clusters    *fake.Clusters
+	kubeConfig  string
+	invocations []invocation
+}
+
+func newProducerTest(currentContext string, contexts ...fake.Context) *producerTest {
+	t := &producerTest{
+		clusters:   fake.NewClusters(),
+		kubeConfig: filepath.Join(GinkgoT().TempDir(), "kubeconfig"),
+	}
+
+	Expect(fake.WriteKubeConfig(t.kubeConfig, currentContext, contexts...)).To(Succeed())
+	DeferCleanup(t.clusters.Install("local"))
+
+	return t
+}
+
+// parse sets up the producer's flags and parses the given arguments, along with --kubeconfig.
+func (t *producerTest) parse(producer *restconfig.Producer, args ...string) *restconfig.Producer {
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	producer.SetupFlags(flags)
+	Expect(flags.Parse(append([]string{"--kubeconfig", t.kubeConfig}, args...))).To(Succeed())
+
+	return producer
+}
+
+func (t *producerTest) record(clusterInfo *cluster.Info, namespace string, _ reporter.Interface) error {
+	t.invocations = append(t.invocations, invocation{clusterName: clusterInfo.Name, namespace: namespace})
+
+	return nil
+}
+
+var _ = Describe("Producer", func() {
+	eastWestNorth := []fake.Context{
+		{Name: "east-admin", Cluster: "east", Namespace: "east-ns"},
+		{Name: "west-admin", Cluster: "west", Namespace: "west-ns"},
+		{Name: "north-admin", Cluster: "north"},
+	}
+
+	DescribeTable("RunOnSelectedContext context selection",
+		func(args []string, expectedCluster string) {
+			t := newProducerTest("west-admin", eastWestNorth...)
+			producer := t.parse(restconfig.NewProducer().WithInClusterFlag(), args...)
+
+			Expect(producer.RunOnSelectedContext(t.record, reporter.Silent())).To(Succeed())
+			Expect(t.invocations).To(HaveLen(1))
+			Expect(t.invocations[0].clusterName).To(Equal(expectedCluster))
+		},
+		Entry("without --context, uses the current context", nil, "west"),
+		Entry("with --context, uses the given context", []string{"--context", "east-admin"}, "east"),
+		Entry("with --in-cluster, uses the in-cluster configuration", []string{"--in-cluster", "--context", "east-admin"},
+			cluster.InClusterName),
+	)
+
+	When("a context timeout is set", func() {
+		It("should bound the requests made while connecting, but not the returned clients", func() {
+			t := newProducerTest("west-admin", eastWestNorth...)
+			producer := t.parse(restconfig.NewProducer(), "--context-timeout", "5s")
+
+			var connectTimeout time.Duration
+
+			DeferCleanup(restconfig.SetNewInfo(func(clusterName string, config *rest.Config) (*cluster.Info, error) {
+				connectTimeout = config.Timeout
+				return t.clusters.NewInfo(clusterName, config)
+			}))
+
+			Expect(producer.RunOnSelectedContext(func(clusterInfo *cluster.Info, _ string, _ reporter.Interface) error {
+				Expect(clusterInfo.RestConfig.Timeout).To(BeZero())
+				return nil
+			}, reporter.Silent())).To(Succeed())
+			Expect(connectTimeout).To(Equal(5 * time.Second))
+		})
+	})
+
+	When("the selected context doesn't exist", func() {
+		It("should return an error listing the available contexts", func() {
+			t := newProducerTest("west-admin", eastWestNorth...)
+			producer := t.parse(restconfig.NewProducer(), "--context", "prod-eu")
+
+			err := producer.RunOnSelectedContext(t.record, reporter.Silent())
+			Expect(err).To(MatchError(ContainSubstring(
+				`context "prod-eu" not found in kubeconfig; available contexts: [east-admin, north-admin, west-admin]`)))
+			Expect(err.Error()).ToNot(ContainSubstring(fake.ServerURL("east")))
+			Expect(t.invocations).To(BeEmpty())
+		})
+
+		It("should list at most 20 contexts", func() {
+			contexts := make([]fake.Context, 25)
+			for i := range contexts {
+				contexts[i] = fake.Context{Name: fmt.Sprintf("context-%02d", i), Cluster: "east"}
+			}
+
+			t := newProducerTest("context-00", contexts...)
+			producer := t.parse(restconfig.NewProducer(), "--context", "prod-eu")
+
+			err := producer.RunOnSelectedContext(t.record, reporter.Silent())
+			Expect(err).To(MatchError(ContainSubstring("context-19, ... (5 more)]")))
+			Expect(err.Error()).ToNot(ContainSubstring("context-20"))
+		})
+	})
+
+	DescribeTable("RunOnAllContexts context selection",
+		func(args []string, expectedClusters []string) {
+			t := newProducerTest("west-admin", eastWestNorth...)
+			producer := t.parse(restconfig.NewProducer().WithContextsFlag(), args...)
+
+			Expect(producer.RunOnAllContexts(t.record, reporter.Silent())).To(Succeed())
+			Expect(clusterNames(t.invocations)).To(Equal(expectedClusters))
+		},
+		Entry("without selection, uses all the clusters in name order", nil, []string{"east", "north", "west"}),
+		Entry("with --context, uses the given context only", []string{"--context", "north-admin"}, []string{"north"}),
+		Entry("with --contexts, uses the given contexts in the given order", []string{"--contexts", "west-admin,east-admin"},
+			[]string{"west", "east"}),
+		Entry("with both --context and --contexts, uses the --context context", []string{
+			"--context", "north-admin", "--contexts", "west-admin,east-admin",
+		}, []string{"north"}),
+	)
+
+	When("multiple contexts reference the same cluster", func() {
+		It("should use the context whose user has the most contexts", func() {
+			t := newProducerTest("", []fake.Context{
+				{Name: "east-viewer", Cluster: "east", User: "viewer"},
+				{Name: "east-admin", Cluster: "east", User: "admin", Namespace: "admin-ns"},
+				{Name: "west-admin", Cluster: "west", User: "admin"},
+			}...)
+			producer := t.parse(restconfig.NewProducer().WithNamespace())
+
+			Expect(producer.RunOnAllContexts(t.record, reporter.Silent())).To(Succeed())
+			Expect(t.invocations).To(Equal([]invocation{
+				{clusterName: "east", namespace: "admin-ns"},
+				{clusterName: "west", namespace: "default"},
+			}))
+		})
+	})
+
+	When("processing multiple contexts fails", func() {
+		It("should process the remaining contexts and aggregate the errors", func() {
+			t := newProducerTest("", eastWestNorth...)
+			t.clusters.Fail("east", errors.New("east is down"))
+
+			producer := t.parse(restconfig.NewProducer().WithContextsFlag(), "--contexts", "east-admin,missing,west-admin,north-admin")
+
+			err := producer.RunOnAllContexts(func(clusterInfo *cluster.Info, namespace string, status reporter.Interface) error {
+				Expect(t.record(clusterInfo, namespace, status)).To(Succeed())
+
+				if clusterInfo.Name == "north" {
+					return errors.New("north failed")
+				}
+
+				return nil
+			}, reporter.Silent())
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(And(ContainSubstring("east is down"), ContainSubstring("missing"),
+				ContainSubstring("north failed")))
+			Expect(clusterNames(t.invocations)).To(Equal([]string{"west", "north"}))
+			Expect(t.clusters.Connected()).To(Equal([]string{"east", "west", "north"}))
+		})
+	})
+
+	When("no context is found", func() {
+		It("should return an error", func() {
+			t := newProducerTest("")
+			producer := t.parse(restconfig.NewProducer())
+
+			Expect(producer.RunOnAllContexts(t.record, reporter.Silent())).ToNot(Succeed())
+			Expect(t.invocations).To(BeEmpty())
+		})
+	})
+
+	DescribeTable("namespace resolution",
+		func(producer *restconfig.Producer, args []string, expectedNamespace string) {
+			t := newProducerTest("east-admin", eastWestNorth...)
+			producer = t.parse(producer, args...)
+
+			Expect(producer.RunOnSelectedContext(t.record, reporter.Silent())).To(Succeed())
+			Expect(t.invocations).To(Equal([]invocation{{clusterName: "east", namespace: expectedNamespace}}))
+		},
+		Entry("without a default, uses the context's namespace", restconfig.NewProducer().WithNamespace(), nil, "east-ns"),
+		Entry("without a default, uses the --namespace flag", restconfig.NewProducer().WithNamespace(),
+			[]string{"--namespace", "flag-ns"}, "flag-ns"),
+		Entry("with a default, uses it instead of the context's namespace", restconfig.NewProducer().WithDefaultNamespace("default-ns"),
+			nil, "default-ns"),
+		Entry("with a default, uses the --namespace flag", restconfig.NewProducer().WithDefaultNamespace("default-ns"),
+			[]string{"-n", "flag-ns"}, "flag-ns"),
+	)
+
+	Describe("RunOnSelectedPrefixedContext", func() {
+		var (
+			t        *producerTest
+			producer *restconfig.Producer
+		)
+
+		BeforeEach(func() {
+			t = newProducerTest("east-admin", eastWestNorth...)
+			producer = restconfig.NewProducer().WithDefaultNamespace("default-ns").WithPrefixedContext("remote")
+		})
+
+		run := func() (bool, error) {
+			return producer.RunOnSelectedPrefixedContext("remote", t.record, reporter.Silent())
+		}
+
+		DescribeTable("context and namespace resolution",
+			func(args []string, expected invocation) {
+				producer = t.parse(producer, args...)
+
+				Expect(run()).To(BeTrue())
+				Expect(t.invocations).To(Equal([]invocation{expected}))
+			},
+			Entry("with --remotecontext, uses the given context", []string{"--remotecontext", "west-admin"},
+				invocation{clusterName: "west", namespace: "default-ns"}),
+			Entry("with --remotenamespace, uses the given namespace", []string{
+				"--remotecontext", "west-admin", "--remotenamespace", "remote-ns",
+			}, invocation{clusterName: "west", namespace: "remote-ns"}),
+		)
+
+		When("the prefix has its own default namespace", func() {
+			It("should use it", func() {
+				producer = t.parse(producer.WithPrefixedNamespace("remote", "prefixed-ns"), "--remotecontext", "west-admin")
+
+				Expect(run()).To(BeTrue())
+				Expect(t.invocations).To(Equal([]invocation{{clusterName: "west", namespace: "prefixed-ns"}}))
+			})
+		})
+
+		When("--remoteconfig is given", func() {
+			It("should use its current context", func() {
+				remoteConfig := filepath.Join(GinkgoT().TempDir(), "remote")
+				Expect(fake.WriteKubeConfig(remoteConfig, "south-admin", fake.Context{Name: "south-admin", Cluster: "south"})).
+					To(Succeed())
+
+				producer = t.parse(producer, "--remoteconfig", remoteConfig)
+
+				Expect(run()).To(BeTrue())
+				Expect(clusterNames(t.invocations)).To(Equal([]string{"south"}))
+			})
+		})
+
+		When("another prefix's kubeconfig is given", func() {
+			It("should not run the function", func() {
+				otherConfig := filepath.Join(GinkgoT().TempDir(), "other")
+				Expect(fake.WriteKubeConfig(otherConfig, "south-admin", fake.Context{Name: "south-admin", Cluster: "south"})).
+					To(Succeed())
+
+				producer = t.parse(producer.WithPrefixedContext("other"), "--otherconfig", otherConfig)
+
+				Expect(producer.RunOnSelectedPrefixedContext("other", t.record, reporter.Silent())).To(BeTrue())
+				Expect(run()).To(BeFalse())
+				Expect(clusterNames(t.invocations)).To(Equal([]string{"south"}))
+			})
+		})
+
+		When("no prefixed context is given", func() {
+			It("should not run the function", func() {
+				producer = t.parse(producer, "--context", "west-admin")
+
+				Expect(run()).To(BeFalse())
+				Expect(t.invocations).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("RunOnSelectedContextWithPrefixes", func() {
+		var (
+			t        *producerTest
+			producer *restconfig.Producer
+			prefixed []string
+		)
+
+		BeforeEach(func() {
+			t = newProducerTest("east-admin", eastWestNorth...)
+			producer = restconfig.NewProducer().WithInClusterFlag().WithPrefixedContext("to").WithPrefixedContext("extra")
+			prefixed = nil
+		})
+
+		run := func(args ...string) error {
+			return t.parse(producer, args...).RunOnSelectedContextWithPrefixes([]string{"to", "extra"},
+				func(clusterInfo *cluster.Info, namespace string, prefixedClusterInfos []*cluster.Info, status reporter.Interface) error {
+					for _, prefixedClusterInfo := range prefixedClusterInfos {
+						name := ""
+						if prefixedClusterInfo != nil {
+							name = prefixedClusterInfo.Name
+						}
+
+						prefixed = append(prefixed, name)
+					}
+
+					return t.record(clusterInfo, namespace, status)
+				}, reporter.Silent())
+		}
+
+		When("all the prefixed contexts are given", func() {
+			It("should pass them in order", func() {
+				Expect(run("--tocontext", "west-admin", "--extracontext", "north-admin")).To(Succeed())
+				Expect(clusterNames(t.invocations)).To(Equal([]string{"east"}))
+				Expect(prefixed).To(Equal([]string{"west", "north"}))
+			})
+		})
+
+		When("a prefixed context is missing", func() {
+			It("should pass nil for it", func() {
+				Expect(run("--extracontext", "north-admin")).To(Succeed())
+				Expect(clusterNames(t.invocations)).To(Equal([]string{"east"}))
+				Expect(prefixed).To(Equal([]string{"", "north"}))
+			})
+		})
+
+		When("no prefixed context is given", func() {
+			It("should still run the function", func() {
+				Expect(run()).To(Succeed())
+				Expect(clusterNames(t.invocations)).To(Equal([]string{"east"}))
+				Expect(prefixed).To(Equal([]string{"", ""}))
+			})
+		})
+
+		When("the in-cluster configuration is combined with a prefixed context", func() {
+			It("should use both", func() {
+				Expect(run("--in-cluster", "--tocontext", "west-admin")).To(Succeed())
+				Expect(clusterNames(t.invocations)).To(Equal([]string{cluster.InClusterName}))
+				Expect(prefixed).To(Equal([]string{"west", ""}))
+			})
+		})
+
+		When("a prefixed context doesn't exist", func() {
+			It("should return an error without running the function", func() {
+				Expect(run("--tocontext", "missing")).ToNot(Succeed())
+				Expect(t.invocations).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("ForKubeConfig", func() {
+		var (
+			t        *producerTest
+			producer *restconfig.Producer
+		)
+
+		BeforeEach(func() {
+			t = newProducerTest("west-admin", eastWestNorth...)
+			producer = t.parse(restconfig.NewProducer(), "--context", "north-admin")
+		})
+
+		When("only a context is given", func() {
+			It("should use it from the producer's kubeconfig", func() {
+				Expect(producer.ForKubeConfig("", "east-admin").RunOnSelectedContext(t.record, reporter.Silent())).To(Succeed())
+				Expect(clusterNames(t.invocations)).To(Equal([]string{"east"}))
+			})
+		})
+
+		When("a kubeconfig is given", func() {
+			It("should use its current context", func() {
+				otherConfig := filepath.Join(GinkgoT().TempDir(), "other")
+				Expect(fake.WriteKubeConfig(otherConfig, "south-admin", fake.Context{Name: "south-admin", Cluster: "south"})).
+					To(Succeed())
+
+				Expect(producer.ForKubeConfig(otherConfig, "").RunOnSelectedContext(t.record, reporter.Silent())).To(Succeed())
+				Expect(clusterNames(t.invocations)).To(Equal([]string{"south"}))
+			})
+		})
+
+		It("should leave the original producer unchanged", func() {
+			producer.ForKubeConfig(filepath.Join(GinkgoT().TempDir(), "missing"), "east-admin")
+
+			Expect(producer.RunOnSelectedContext(t.record, reporter.Silent())).To(Succeed())
+			Expect(clusterNames(t.invocations)).To(Equal([]string{"north"}))
+		})
+	})
+
+	When("configured without the server flag", func() {
+		It("should not set up --server", func() {
+			flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			restconfig.NewProducer().WithoutServerFlag().SetupFlags(flags)
+
+			Expect(flags.Lookup("server")).To(BeNil())
+			Expect(flags.Lookup("context")).ToNot(BeNil())
+		})
+	})
+
+	DescribeTable("in-cluster naming",
+		func(setup func(clusters *fake.Clusters), expectedName string) {
+			t := newProducerTest("")
+			setup(t.clusters)
+
+			producer := t.parse(restconfig.NewProducer().WithInClusterFlag().WithDefaultNamespace("default-ns"), "--in-cluster")
+
+			Expect(producer.RunOnAllContexts(t.record, reporter.Silent())).To(Succeed())
+			Expect(t.invocations).To(Equal([]invocation{{clusterName: expectedName, namespace: ""}}))
+		},
+		Entry("uses the Submariner cluster ID", func(clusters *fake.Clusters) {
+			clusters.Add("local", fake.NewSubmariner("submariner-id"), fake.NewServiceDiscovery("service-discovery-id"))
+		}, "submariner-id"),
+		Entry("falls back to the ServiceDiscovery cluster ID", func(clusters *fake.Clusters) {
+			clusters.Add("local", fake.NewServiceDiscovery("service-discovery-id"))
+		}, "service-discovery-id"),
+		Entry("falls back to the in-cluster name", func(_ *fake.Clusters) {}, cluster.InClusterName),
+	)
+
+	DescribeTable("in-cluster marking",
+		func(args []string, expectedName string, expectedInCluster bool) {
+			t := newProducerTest("in-cluster-admin", fake.Context{Name: "in-cluster-admin", Cluster: cluster.InClusterName})
+			t.clusters.Add("local", fake.NewSubmariner("submariner-id"))
+
+			producer := t.parse(restconfig.NewProducer().WithInClusterFlag(), args...)
+
+			var clusterInfo *cluster.Info
+
+			Expect(producer.RunOnSelectedContext(func(info *cluster.Info, _ string, _ reporter.Interface) error {
+				clusterInfo = info
+				return nil
+			}, reporter.Silent())).To(Succeed())
+
+			Expect(clusterInfo.Name).To(Equal(expectedName))
+			Expect(clusterInfo.InCluster).To(Equal(expectedInCluster))
+		},
+		Entry("with --in-cluster, marks the cluster as in-cluster", []string{"--in-cluster"}, "submariner-id", true),
+		Entry("with a kubeconfig cluster named like the in-cluster configuration, keeps its name", nil,
+			cluster.InClusterName, false),
+	)
+
+	Describe("--in-cluster-service-account", func() {
+		var (
+			t         *producerTest
+			tokenFile string
+		)
+
+		BeforeEach(func() {
+			t = newProducerTest("")
+			tokenFile = filepath.Join(GinkgoT().TempDir(), "token")
+			Expect(os.WriteFile(tokenFile, []byte("diagnose-token"), 0o600)).To(Succeed())
+		})
+
+		It("should authenticate with the given token file", func() {
+			producer := t.parse(restconfig.NewProducer().WithInClusterFlag(), "--in-cluster", "--in-cluster-service-account", tokenFile)
+
+			var restConfig *rest.Config
+
+			Expect(producer.RunOnSelectedContext(func(clusterInfo *cluster.Info, _ string, _ reporter.Interface) error {
+				restConfig = clusterInfo.RestConfig
+				return nil
+			}, reporter.Silent())).To(Succeed())
+
+			Expect(restConfig).ToNot(BeNil())
+			Expect(restConfig.BearerTokenFile).To(Equal(tokenFile))
+			Expect(restConfig.BearerToken).To(BeEmpty())
+		})
+
+		When("the token file doesn't exist", func() {
+			It("should fail to parse the flags", func() {
+				flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+				restconfig.NewProducer().WithInClusterFlag().SetupFlags(flags)
+
+				Expect(flags.Parse([]string{"--in-cluster", "--in-cluster-service-account", tokenFile + "-missing"})).ToNot(Succeed())
+			})
+		})
+
+		When("--in-cluster isn't given", func() {
+			It("should return an error without running the function", func() {
+				producer := t.parse(restconfig.NewProducer().WithInClusterFlag(), "--in-cluster-service-account", tokenFile)
+
+				Expect(producer.RunOnAllContexts(t.record, reporter.Silent())).ToNot(Succeed())
+				Expect(t.invocations).To(BeEmpty())
+			})
+		})
+	})
+})
+
+func clusterNames(invocations []invocation) []string {
+	names := make([]string, 0, len(invocations))
+
+	for i := range invocations {
+		names = append(names, invocations[i].clusterName)
+	}
+
+	return names
+}
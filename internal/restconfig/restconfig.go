@@ -19,16 +19,22 @@ limitations under the License.
 package restconfig
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"os"
+	"sort"
 	"strings"
+	"time"
 
-	"github.com/coreos/go-semver/semver"
 	"github.com/pkg/errors"
 	"github.com/spf13/pflag"
 	"github.com/submariner-io/admiral/pkg/reporter"
 	"github.com/submariner-io/admiral/pkg/resource"
 	"github.com/submariner-io/subctl/internal/constants"
+	"github.com/submariner-io/subctl/internal/fleet"
 	"github.com/submariner-io/subctl/internal/gvr"
+	"github.com/submariner-io/subctl/pkg/client"
 	"github.com/submariner-io/subctl/pkg/cluster"
 	"github.com/submariner-io/subctl/pkg/version"
 	"github.com/submariner-io/submariner-operator/api/v1alpha1"
@@ -58,12 +64,32 @@ type Producer struct {
 	prefixedKubeConfigs       map[string]*string
 	inClusterFlag             bool
 	inCluster                 bool
+	inClusterTokenFile        string
+	kubeConfigSecrets         []string
+	fleet                     *fleet.Fleet
 	namespaceFlag             bool
 	contextsFlag              bool
 	defaultNamespace          *string
 	prefixedDefaultNamespaces map[string]*string
+	deferSubmarinerLookup     bool
+	noServerFlag              bool
+	progressOutput            io.Writer
+	// ContextTimeout bounds how long connecting to each cluster may take; zero disables the limit
+	ContextTimeout time.Duration
 }
 
+// DefaultContextTimeout is the default limit on the time taken to connect to each cluster.
+const DefaultContextTimeout = 30 * time.Second
+
+// inClusterConfig retrieves the in-cluster configuration, newInfo connects to a cluster, and useConfig switches a
+// connected cluster.Info to another configuration; tests replace them to run without a live cluster (see
+// SetInClusterConfig and SetNewInfo).
+var (
+	inClusterConfig = rest.InClusterConfig
+	newInfo         = cluster.NewInfo
+	useConfig       = useClientsForConfig
+)
+
 // NewProducer initialises a blank producer which needs to be set up with flags (see SetupFlags).
 func NewProducer() *Producer {
 	return &Producer{prefixedDefaultNamespaces: make(map[string]*string)}
@@ -119,10 +145,49 @@ func (rcp *Producer) WithInClusterFlag() *Producer {
 	return rcp
 }
 
+// WithDeferredSubmarinerLookup configures the producer not to retrieve the Submariner and ServiceDiscovery resources
+// before running the PerContextFn, for functions which only need them in some cases, if at all. They are then only
+// retrieved by the If...Installed wrappers, or on demand using cluster.Info's accessors; this also skips the version
+// check against the deployed Submariner.
+func (rcp *Producer) WithDeferredSubmarinerLookup() *Producer {
+	rcp.deferSubmarinerLookup = true
+
+	return rcp
+}
+
+// WithoutServerFlag configures the producer not to set up the --server flag overriding the API server address, for
+// commands which define their own --server flag.
+func (rcp *Producer) WithoutServerFlag() *Producer {
+	rcp.noServerFlag = true
+
+	return rcp
+}
+
+// WithProgressOutput configures the producer to write the per-cluster headers and the summary printed when running on
+// multiple contexts to the given writer instead of the standard output, e.g. to keep the standard output machine-readable.
+func (rcp *Producer) WithProgressOutput(w io.Writer) *Producer {
+	rcp.progressOutput = w
+
+	return rcp
+}
+
+func (rcp *Producer) progressWriter() io.Writer {
+	if rcp.progressOutput == nil {
+		return os.Stdout
+	}
+
+	return rcp.progressOutput
+}
+
 // SetupFlags configures the given flags to control the producer settings.
 func (rcp *Producer) SetupFlags(flags *pflag.FlagSet) {
 	if rcp.inClusterFlag {
 		flags.BoolVar(&rcp.inCluster, "in-cluster", false, "use the in-cluster configuration to connect to Kubernetes")
+		flags.StringArrayVar(&rcp.kubeConfigSecrets, "kubeconfig-secret", nil,
+			"with --in-cluster, connect to the cluster whose kubeconfig is stored in the given Secret, as namespace/name[#key]"+
+				" (the key defaults to \""+defaultKubeConfigSecretKey+"\"); can be repeated to process multiple clusters")
+		flags.Var((*tokenFileValue)(&rcp.inClusterTokenFile), "in-cluster-service-account",
+			"with --in-cluster, the path to the token of the service account to connect as, instead of the pod's service account")
 	}
 
 	// The base loading rules are shared across all clientcmd setups.
@@ -134,6 +199,8 @@ func (rcp *Producer) SetupFlags(flags *pflag.FlagSet) {
 	loadingRules.DefaultClientConfig = &clientcmd.DefaultClientConfig
 
 	flags.StringVar(&loadingRules.ExplicitPath, "kubeconfig", "", "absolute path(s) to the kubeconfig file(s)")
+	flags.DurationVar(&rcp.ContextTimeout, "context-timeout", DefaultContextTimeout,
+		"how long to wait when connecting to each cluster before giving up on it (0 to wait indefinitely)")
 
 	// Default prefix
 	rcp.defaultClientConfig = rcp.setupContextFlags(loadingRules, flags, "")
@@ -141,8 +208,11 @@ func (rcp *Producer) SetupFlags(flags *pflag.FlagSet) {
 	// Multiple contexts (only on the default prefix)
 	if rcp.contextsFlag {
 		flags.StringSliceVar(&rcp.contexts, "contexts", nil, "comma-separated list of contexts to use")
+		markContextFlag(flags, "contexts", "kubeconfig")
 	}
 
+	rcp.setupLegacyContextFlags(flags)
+
 	// Other prefixes
 	rcp.prefixedClientConfigs = make(map[string]*loadingRulesAndOverrides, len(rcp.contextPrefixes))
 	rcp.prefixedKubeConfigs = make(map[string]*string, len(rcp.contextPrefixes))
@@ -159,6 +229,11 @@ func (rcp *Producer) setupContextFlags(
 	// Default un-prefixed context
 	overrides := clientcmd.ConfigOverrides{ClusterDefaults: clientcmd.ClusterDefaults}
 	kflags := clientcmd.RecommendedConfigOverrideFlags(prefix)
+	kubeConfigFlags := []string{"kubeconfig"}
+
+	if prefix != "" {
+		kubeConfigFlags = []string{prefix + "config", "kubeconfig"}
+	}
 
 	if !rcp.namespaceFlag {
 		// Drop the namespace flag (an empty long name disables a flag)
@@ -168,7 +243,12 @@ func (rcp *Producer) setupContextFlags(
 		kflags.ContextOverrideFlags.Namespace.ShortName = ""
 	}
 
+	if rcp.noServerFlag {
+		kflags.ClusterOverrideFlags.APIServer.LongName = ""
+	}
+
 	clientcmd.BindOverrideFlags(&overrides, flags, kflags)
+	markContextFlag(flags, kflags.CurrentContext.LongName, kubeConfigFlags...)
 
 	return &loadingRulesAndOverrides{
 		loadingRules: loadingRules,
@@ -176,14 +256,40 @@ func (rcp *Producer) setupContextFlags(
 	}
 }
 
+// ForKubeConfig returns a separate producer for the given kubeconfig and context, for operations which need to access a
+// cluster using different credentials from those used to determine its Submariner deployment. An empty kubeconfig
+// uses this producer's kubeconfig, an empty context the kubeconfig's current context. The returned producer doesn't
+// retrieve the Submariner resources; it must be obtained after this producer's flags have been parsed.
+func (rcp *Producer) ForKubeConfig(kubeConfig, contextName string) *Producer {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if rcp.defaultClientConfig != nil {
+		copied := *rcp.defaultClientConfig.loadingRules
+		loadingRules = &copied
+	}
+
+	if kubeConfig != "" {
+		loadingRules.ExplicitPath = kubeConfig
+	}
+
+	return &Producer{
+		defaultClientConfig: &loadingRulesAndOverrides{
+			loadingRules: loadingRules,
+			overrides:    &clientcmd.ConfigOverrides{ClusterDefaults: clientcmd.ClusterDefaults, CurrentContext: contextName},
+		},
+		prefixedDefaultNamespaces: make(map[string]*string),
+		deferSubmarinerLookup:     true,
+		ContextTimeout:            rcp.ContextTimeout,
+	}
+}
+
 type AllContextFn func(clusterInfos []*cluster.Info, namespaces []string, status reporter.Interface) error
 
 type PerContextFn func(clusterInfo *cluster.Info, namespace string, status reporter.Interface) error
 
 // RunOnSelectedContext runs the given function on the selected context.
 func (rcp *Producer) RunOnSelectedContext(function PerContextFn, status reporter.Interface) error {
-	if rcp.inCluster {
-		return runInCluster(function, status)
+	if rcp.inClusterRequested() {
+		return rcp.runInCluster(function, status)
 	}
 
 	if rcp.defaultClientConfig == nil {
@@ -199,7 +305,7 @@ func (rcp *Producer) RunOnSelectedContext(function PerContextFn, status reporter
 		return status.Error(err, "error retrieving the default configuration")
 	}
 
-	clusterInfo, err := cluster.NewInfo(restConfig.ClusterName, restConfig.Config)
+	clusterInfo, err := rcp.newClusterInfo(restConfig.ClusterName, restConfig.Config, false)
 	if err != nil {
 		return status.Error(err, "error building the cluster.Info for the default configuration")
 	}
@@ -212,7 +318,7 @@ func (rcp *Producer) RunOnSelectedContext(function PerContextFn, status reporter
 	}
 
 	if submVersion != "" {
-		if err = checkVersionMismatch(submVersion); err != nil {
+		if err = CheckVersionMismatch(submVersion); err != nil {
 			return status.Error(err, "")
 		}
 	}
@@ -229,14 +335,27 @@ func (rcp *Producer) RunOnSelectedContext(function PerContextFn, status reporter
 	return function(clusterInfo, namespace, status)
 }
 
-func runInCluster(function PerContextFn, status reporter.Interface) error {
-	restConfig, err := rest.InClusterConfig()
+func (rcp *Producer) inClusterRequested() bool {
+	return rcp.inCluster || len(rcp.kubeConfigSecrets) > 0 || rcp.inClusterTokenFile != ""
+}
+
+func (rcp *Producer) runInCluster(function PerContextFn, status reporter.Interface) error {
+	if !rcp.inCluster {
+		return status.Error(
+			errors.New("--kubeconfig-secret and --in-cluster-service-account can only be used with --in-cluster"), "")
+	}
+
+	restConfig, err := rcp.inClusterRestConfig()
 	if err != nil {
 		return status.Error(err, "error retrieving the in-cluster configuration")
 	}
 
-	// In-cluster configurations don't give a cluster name, use "in-cluster"
-	clusterInfo, err := cluster.NewInfo("in-cluster", restConfig)
+	if len(rcp.kubeConfigSecrets) > 0 {
+		return rcp.runOnKubeConfigSecrets(restConfig, function, status)
+	}
+
+	// In-cluster configurations don't give a cluster name, cluster.Info.SetInCluster uses the cluster ID instead
+	clusterInfo, err := rcp.newClusterInfo(cluster.InClusterName, restConfig, true)
 	if err != nil {
 		return status.Error(err, "error building the cluster.Info for the in-cluster configuration")
 	}
@@ -246,32 +365,153 @@ func runInCluster(function PerContextFn, status reporter.Interface) error {
 	return function(clusterInfo, "", status)
 }
 
+// inClusterRestConfig retrieves the in-cluster configuration, authenticating with the token given by
+// --in-cluster-service-account if any.
+func (rcp *Producer) inClusterRestConfig() (*rest.Config, error) {
+	restConfig, err := inClusterConfig()
+	if err != nil {
+		return nil, err //nolint:wrapcheck // No need to wrap errors here.
+	}
+
+	if rcp.inClusterTokenFile != "" {
+		// The token file takes precedence over the token, but the latter would still be used until the file is read
+		restConfig.BearerToken = ""
+		restConfig.BearerTokenFile = rcp.inClusterTokenFile
+	}
+
+	return restConfig, nil
+}
+
+// tokenFileValue is a flag value holding the path to a token file, which must be readable when the flag is parsed.
+type tokenFileValue string
+
+func (t *tokenFileValue) String() string {
+	return string(*t)
+}
+
+func (t *tokenFileValue) Set(path string) error {
+	if _, err := os.ReadFile(path); err != nil {
+		return errors.Wrap(err, "error reading the token file")
+	}
+
+	*t = tokenFileValue(path)
+
+	return nil
+}
+
+func (t *tokenFileValue) Type() string {
+	return "string"
+}
+
+// newClusterInfo retrieves the information for the given cluster, giving up after ContextTimeout so that an unresponsive
+// cluster doesn't block the processing of the others. inCluster is set when the configuration is the in-cluster one.
+func (rcp *Producer) newClusterInfo(clusterName string, config *rest.Config, inCluster bool) (*cluster.Info, error) {
+	if rcp.ContextTimeout <= 0 {
+		return rcp.loadClusterInfo(clusterName, config, inCluster)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rcp.ContextTimeout)
+	defer cancel()
+
+	type result struct {
+		clusterInfo *cluster.Info
+		err         error
+	}
+
+	// The channel is buffered so that the goroutine can complete even if the result is no longer expected
+	results := make(chan result, 1)
+
+	go func() {
+		clusterInfo, err := rcp.loadClusterInfo(clusterName, config, inCluster)
+		results <- result{clusterInfo: clusterInfo, err: err}
+	}()
+
+	select {
+	case r := <-results:
+		return r.clusterInfo, r.err
+	case <-ctx.Done():
+		return nil, cluster.NewUnreachableError(fmt.Errorf("timed out connecting to cluster %q: %w", clusterName, ctx.Err()))
+	}
+}
+
+// loadClusterInfo connects to the cluster. With a context timeout, the requests made while connecting are bounded by it,
+// so that they don't keep blocking on an unresponsive API server once newClusterInfo has given up on them; the returned
+// cluster.Info uses clients without that limit, since later operations such as log retrieval can legitimately take longer.
+func (rcp *Producer) loadClusterInfo(clusterName string, config *rest.Config, inCluster bool) (*cluster.Info, error) {
+	connectConfig := config
+	if rcp.ContextTimeout > 0 && (config.Timeout == 0 || config.Timeout > rcp.ContextTimeout) {
+		connectConfig = rest.CopyConfig(config)
+		connectConfig.Timeout = rcp.ContextTimeout
+	}
+
+	clusterInfo, err := newInfo(clusterName, connectConfig)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // The caller wraps the error
+	}
+
+	if inCluster {
+		if err := clusterInfo.SetInCluster(); err != nil {
+			return nil, err //nolint:wrapcheck // The caller wraps the error
+		}
+	}
+
+	if !rcp.deferSubmarinerLookup {
+		if err := clusterInfo.LoadSubmariner(); err != nil {
+			return nil, err //nolint:wrapcheck // The caller wraps the error
+		}
+	}
+
+	if connectConfig != config {
+		if err := useConfig(clusterInfo, config); err != nil {
+			return nil, err
+		}
+	}
+
+	return clusterInfo, nil
+}
+
+// useClientsForConfig switches the given cluster.Info to clients created from the given configuration.
+func useClientsForConfig(clusterInfo *cluster.Info, config *rest.Config) error {
+	clientProducer, err := client.NewProducerFromRestConfig(config)
+	if err != nil {
+		return errors.Wrap(err, "error creating client producer")
+	}
+
+	clusterInfo.RestConfig = config
+	clusterInfo.ClientProducer = clientProducer
+
+	return nil
+}
+
 // RunOnSelectedPrefixedContext runs the given function on the selected prefixed context.
 // Returns true if there was a selected prefix context, false otherwise.
 func (rcp *Producer) RunOnSelectedPrefixedContext(prefix string, function PerContextFn, status reporter.Interface) (bool, error) {
 	clientConfig, ok := rcp.prefixedClientConfigs[prefix]
 	if ok {
-		loadingRules := clientConfig.loadingRules
+		// The loading rules are shared with the other prefixes, copy them so that the prefixed kubeconfig doesn't leak
+		loadingRules := *clientConfig.loadingRules
 
 		// If the user specified a kubeconfig for this prefix, use that instead
 		contextKubeConfig, ok := rcp.prefixedKubeConfigs[prefix]
-		if ok && contextKubeConfig != nil && *contextKubeConfig != "" {
+		prefixedKubeConfig := ok && contextKubeConfig != nil && *contextKubeConfig != ""
+
+		if prefixedKubeConfig {
 			loadingRules.ExplicitPath = *contextKubeConfig
 		}
 
-		// Has the user actually specified a value for the prefixed context?
-		if loadingRules.ExplicitPath == "" && areOverridesEmpty(clientConfig.overrides) {
+		// Has the user actually specified a value for the prefixed context? --kubeconfig on its own doesn't select one
+		if !prefixedKubeConfig && areOverridesEmpty(clientConfig.overrides) {
 			return false, nil
 		}
 
-		contextClientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, clientConfig.overrides)
+		contextClientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(&loadingRules, clientConfig.overrides)
 
 		restConfig, err := getRestConfigFromConfig(contextClientConfig, clientConfig.overrides)
 		if err != nil {
 			return true, status.Error(err, "error retrieving the configuration for prefix %s", prefix)
 		}
 
-		clusterInfo, err := cluster.NewInfo(restConfig.ClusterName, restConfig.Config)
+		clusterInfo, err := rcp.newClusterInfo(restConfig.ClusterName, restConfig.Config, false)
 		if err != nil {
 			return true, status.Error(err, "error building the cluster.Info for the configuration for prefix %s", prefix)
 		}
@@ -295,12 +535,65 @@ func (rcp *Producer) RunOnSelectedPrefixedContext(prefix string, function PerCon
 	return false, nil
 }
 
+// PrefixedContextsFn is a function run on the selected context along with the selected prefixed contexts, see
+// RunOnSelectedContextWithPrefixes.
+type PrefixedContextsFn func(clusterInfo *cluster.Info, namespace string, prefixedClusterInfos []*cluster.Info,
+	status reporter.Interface) error
+
+// RunOnSelectedContextWithPrefixes runs the given function on the selected context, along with the selected prefixed
+// contexts for the given prefixes, in the same order. The cluster.Info for a prefix whose context wasn't selected is nil;
+// it's up to the function to decide whether that's acceptable.
+func (rcp *Producer) RunOnSelectedContextWithPrefixes(prefixes []string, function PrefixedContextsFn,
+	status reporter.Interface,
+) error {
+	return rcp.RunOnSelectedContext(func(clusterInfo *cluster.Info, namespace string, status reporter.Interface) error {
+		return rcp.runOnSelectedPrefixedContexts(prefixes, make([]*cluster.Info, 0, len(prefixes)),
+			func(prefixedClusterInfos []*cluster.Info, status reporter.Interface) error {
+				return function(clusterInfo, namespace, prefixedClusterInfos, status)
+			}, status)
+	}, status)
+}
+
+// runOnSelectedPrefixedContexts nests the runs on each of the given prefixes' contexts, accumulating the cluster.Info
+// for each prefix, and runs the given function within the innermost run.
+func (rcp *Producer) runOnSelectedPrefixedContexts(prefixes []string, clusterInfos []*cluster.Info,
+	function func(clusterInfos []*cluster.Info, status reporter.Interface) error, status reporter.Interface,
+) error {
+	if len(prefixes) == 0 {
+		return function(clusterInfos, status)
+	}
+
+	found, err := rcp.RunOnSelectedPrefixedContext(prefixes[0],
+		func(clusterInfo *cluster.Info, _ string, status reporter.Interface) error {
+			return rcp.runOnSelectedPrefixedContexts(prefixes[1:], append(clusterInfos, clusterInfo), function, status)
+		}, status)
+	if found {
+		return err
+	}
+
+	return rcp.runOnSelectedPrefixedContexts(prefixes[1:], append(clusterInfos, nil), function, status)
+}
+
 // RunOnSelectedContexts runs the given function on all selected contexts, passing them simultaneously.
 // This specifically handles the "--contexts" (plural) flag.
 // Returns true if there was at least one selected context, false otherwise.
 func (rcp *Producer) RunOnSelectedContexts(function AllContextFn, status reporter.Interface) (bool, error) {
-	if rcp.inCluster {
-		return true, runInCluster(func(clusterInfo *cluster.Info, namespace string, status reporter.Interface) error {
+	if rcp.inCluster && len(rcp.kubeConfigSecrets) > 0 {
+		restConfig, err := rcp.inClusterRestConfig()
+		if err != nil {
+			return true, status.Error(err, "error retrieving the in-cluster configuration")
+		}
+
+		clusterInfos, namespaces, err := rcp.allClustersFromKubeConfigSecrets(restConfig)
+		if err != nil {
+			return true, status.Error(err, "")
+		}
+
+		return true, function(clusterInfos, namespaces, status)
+	}
+
+	if rcp.inClusterRequested() {
+		return true, rcp.runInCluster(func(clusterInfo *cluster.Info, namespace string, status reporter.Interface) error {
 			return function([]*cluster.Info{clusterInfo}, []string{namespace}, status)
 		}, status)
 	}
@@ -321,7 +614,7 @@ func (rcp *Producer) RunOnSelectedContexts(function AllContextFn, status reporte
 					return true, status.Error(err, "error retrieving the configuration for context %s", contextName)
 				}
 
-				clusterInfo, err := cluster.NewInfo(restConfig.ClusterName, restConfig.Config)
+				clusterInfo, err := rcp.newClusterInfo(restConfig.ClusterName, restConfig.Config, false)
 				if err != nil {
 					return true, status.Error(err, "error building the cluster.Info for context %s", contextName)
 				}
@@ -352,8 +645,8 @@ func (rcp *Producer) RunOnSelectedContexts(function AllContextFn, status reporte
 // All appropriate contexts are processed, and any errors are aggregated.
 // Returns an error if no contexts are found.
 func (rcp *Producer) RunOnAllContexts(function PerContextFn, status reporter.Interface) error {
-	if rcp.inCluster {
-		return runInCluster(function, status)
+	if rcp.inClusterRequested() {
+		return rcp.runInCluster(function, status)
 	}
 
 	if rcp.defaultClientConfig == nil {
@@ -374,22 +667,20 @@ func (rcp *Producer) RunOnAllContexts(function PerContextFn, status reporter.Int
 		return status.Error(err, "error retrieving the raw kubeconfig setup")
 	}
 
+	selectedContexts := []clusterContext{}
 	contextErrors := []error{}
-	processedContexts := 0
 
 	if len(rcp.contexts) > 0 {
 		// Loop over explicitly-chosen contexts
 		for _, contextName := range rcp.contexts {
-			processedContexts++
-
 			chosenContext, ok := rawConfig.Contexts[contextName]
 			if !ok {
-				contextErrors = append(contextErrors, status.Error(fmt.Errorf("no Kubernetes context found named %s", contextName), ""))
+				contextErrors = append(contextErrors, status.Error(contextNotFoundError(&rawConfig, contextName), ""))
 
 				continue
 			}
 
-			contextErrors = append(contextErrors, rcp.overrideContextAndRun(chosenContext.Cluster, contextName, function, status))
+			selectedContexts = append(selectedContexts, clusterContext{clusterName: chosenContext.Cluster, contextName: contextName})
 		}
 	} else {
 		// Loop over all accessible contexts and de-duplicate by cluster name. If there's multiple contexts for a cluster, bias towards the
@@ -399,15 +690,25 @@ func (rcp *Producer) RunOnAllContexts(function PerContextFn, status reporter.Int
 		usageByUser := map[string]int{}
 
 		for contextName, context := range rawConfig.Contexts {
-			processedContexts++
-
 			contextsByCluster[context.Cluster] = append(contextsByCluster[context.Cluster], contextName)
 			usageByUser[context.AuthInfo]++
 		}
 
-		for cluster, contextNames := range contextsByCluster {
+		// The clusters are processed in name order, and contexts are considered in name order so that ties are resolved
+		// consistently
+		clusterNames := make([]string, 0, len(contextsByCluster))
+		for cluster := range contextsByCluster {
+			clusterNames = append(clusterNames, cluster)
+		}
+
+		sort.Strings(clusterNames)
+
+		for _, cluster := range clusterNames {
+			contextNames := contextsByCluster[cluster]
+			sort.Strings(contextNames)
+
 			if len(contextNames) == 1 {
-				contextErrors = append(contextErrors, rcp.overrideContextAndRun(cluster, contextNames[0], function, status))
+				selectedContexts = append(selectedContexts, clusterContext{clusterName: cluster, contextName: contextNames[0]})
 				continue
 			}
 
@@ -425,26 +726,108 @@ func (rcp *Producer) RunOnAllContexts(function PerContextFn, status reporter.Int
 				" associated user account does not have sufficient privileges, please re-run the command with the suitable context.\n",
 				cluster, strings.Join(contextNames, "\n    "), selectedContextName)
 
-			contextErrors = append(contextErrors, rcp.overrideContextAndRun(cluster, selectedContextName, function, status))
+			selectedContexts = append(selectedContexts, clusterContext{clusterName: cluster, contextName: selectedContextName})
 		}
 	}
 
-	if processedContexts == 0 {
+	if len(selectedContexts) == 0 && len(contextErrors) == 0 {
 		return status.Error(errors.New("no Kubernetes configuration or context was found"), "")
 	}
 
+	outcomes := make([]*contextOutcome, 0, len(selectedContexts))
+
+	for i, selected := range selectedContexts {
+		outcome := &contextOutcome{clusterName: selected.clusterName}
+		outcomes = append(outcomes, outcome)
+
+		fmt.Fprintf(rcp.progressWriter(), "Cluster %q (%d/%d)\n", selected.clusterName, i+1, len(selectedContexts))
+
+		err := rcp.overrideContextAndRun(selected.contextName, rcp.withFleetChecks(selected.contextName, function),
+			newOutcomeReporter(status, outcome))
+		outcome.recordError(err)
+		contextErrors = append(contextErrors, err)
+	}
+
+	if len(outcomes) > 1 {
+		printSummary(rcp.progressWriter(), outcomes)
+	}
+
 	return k8serrors.NewAggregate(contextErrors)
 }
 
-func (rcp *Producer) overrideContextAndRun(clusterName, contextName string, function PerContextFn, status reporter.Interface) error {
-	fmt.Printf("Cluster %q\n", clusterName)
+type clusterContext struct {
+	clusterName string
+	contextName string
+}
+
+// ContextConfig is the configuration for one of the kubeconfig contexts, as returned by AllContextConfigs.
+type ContextConfig struct {
+	ContextName string
+	ClusterName string
+	Config      *rest.Config
+	Err         error
+}
+
+// AllContextConfigs returns the configuration of each selected context, sorted by context name, without connecting to
+// the clusters. The selected contexts are those given with --contexts, otherwise the --context context, otherwise all
+// the contexts in the kubeconfig.
+func (rcp *Producer) AllContextConfigs() ([]ContextConfig, error) {
+	if rcp.defaultClientConfig == nil {
+		// If we get here, no context was set up, which means SetupFlags() wasn't called
+		return nil, errors.New("no context provided (this is a programming error)")
+	}
+
+	rawConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		rcp.defaultClientConfig.loadingRules, rcp.defaultClientConfig.overrides).RawConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "error retrieving the raw kubeconfig setup")
+	}
+
+	contextNames := rcp.contexts
+	if len(contextNames) == 0 && rcp.defaultClientConfig.overrides.CurrentContext != "" {
+		contextNames = []string{rcp.defaultClientConfig.overrides.CurrentContext}
+	}
+
+	if len(contextNames) == 0 {
+		for contextName := range rawConfig.Contexts {
+			contextNames = append(contextNames, contextName)
+		}
+	}
+
+	sort.Strings(contextNames)
+
+	configs := make([]ContextConfig, 0, len(contextNames))
+
+	for _, contextName := range contextNames {
+		contextConfig := ContextConfig{ContextName: contextName}
+
+		kubeContext, ok := rawConfig.Contexts[contextName]
+		if !ok {
+			contextConfig.Err = contextNotFoundError(&rawConfig, contextName)
+		} else {
+			contextConfig.ClusterName = kubeContext.Cluster
 
+			overrides := *rcp.defaultClientConfig.overrides
+			overrides.CurrentContext = contextName
+
+			contextConfig.Config, err = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+				rcp.defaultClientConfig.loadingRules, &overrides).ClientConfig()
+			contextConfig.Err = errors.Wrapf(err, "error creating the client configuration for context %s", contextName)
+		}
+
+		configs = append(configs, contextConfig)
+	}
+
+	return configs, nil
+}
+
+func (rcp *Producer) overrideContextAndRun(contextName string, function PerContextFn, status reporter.Interface) error {
 	rcp.defaultClientConfig.overrides.CurrentContext = contextName
 	if err := rcp.RunOnSelectedContext(function, status); err != nil {
 		return err
 	}
 
-	fmt.Println()
+	fmt.Fprintln(rcp.progressWriter())
 
 	return nil
 }
@@ -481,44 +864,97 @@ func ForBroker(submariner *v1alpha1.Submariner, serviceDisc *v1alpha1.ServiceDis
 }
 
 func getRestConfigFromConfig(config clientcmd.ClientConfig, overrides *clientcmd.ConfigOverrides) (RestConfig, error) {
-	clientConfig, err := config.ClientConfig()
-	if err != nil {
-		return RestConfig{}, errors.Wrap(err, "error creating client config")
-	}
-
 	raw, err := config.RawConfig()
 	if err != nil {
 		return RestConfig{}, errors.Wrap(err, "error creating rest config")
 	}
 
-	clusterName := clusterNameFromContext(&raw, overrides.CurrentContext)
+	// Report a missing context before attempting to build the client configuration, which would fail less helpfully
+	if _, ok := raw.Contexts[overrides.CurrentContext]; !ok && overrides.CurrentContext != "" {
+		return RestConfig{}, contextNotFoundError(&raw, overrides.CurrentContext)
+	}
+
+	clientConfig, err := config.ClientConfig()
+	if err != nil {
+		return RestConfig{}, errors.Wrap(err, "error creating client config")
+	}
 
-	if clusterName == nil {
-		return RestConfig{}, fmt.Errorf("could not obtain the cluster name from kube config: %#v", raw)
+	clusterName, err := clusterNameFromContext(&raw, overrides.CurrentContext)
+	if err != nil {
+		return RestConfig{}, err
 	}
 
-	return RestConfig{Config: clientConfig, ClusterName: *clusterName}, nil
+	return RestConfig{Config: clientConfig, ClusterName: clusterName}, nil
 }
 
-func clusterNameFromContext(rawConfig *api.Config, overridesContext string) *string {
+// clusterNameFromContext returns the name of the cluster used by the given context, or by the current context if none is
+// given. The raw configuration mustn't be included in the error, it contains credentials.
+func clusterNameFromContext(rawConfig *api.Config, overridesContext string) (string, error) {
 	if overridesContext == "" {
 		// No context provided, use the current context.
 		overridesContext = rawConfig.CurrentContext
 	}
 
+	if overridesContext == "" {
+		return "", fmt.Errorf("no context specified and no current context set in kubeconfig; available contexts: %s",
+			availableContexts(rawConfig))
+	}
+
 	configContext, ok := rawConfig.Contexts[overridesContext]
 	if !ok {
-		return nil
+		return "", contextNotFoundError(rawConfig, overridesContext)
 	}
 
-	return &configContext.Cluster
+	return configContext.Cluster, nil
+}
+
+func contextNotFoundError(rawConfig *api.Config, contextName string) error {
+	return fmt.Errorf("context %q not found in kubeconfig; available contexts: %s", contextName, availableContexts(rawConfig))
 }
 
-func checkVersionMismatch(submVersion string) error {
-	subctlVer, _ := semver.NewVersion(version.Version)
-	submarinerVer, _ := semver.NewVersion(submVersion)
+// maxListedContexts is the maximum number of contexts listed in errors.
+const maxListedContexts = 20
 
-	if subctlVer != nil && submarinerVer != nil && subctlVer.LessThan(*submarinerVer) {
+// availableContexts formats the sorted context names in the given configuration, up to maxListedContexts.
+func availableContexts(rawConfig *api.Config) string {
+	names := contextNames(rawConfig)
+
+	if len(names) > maxListedContexts {
+		names = append(names[:maxListedContexts], fmt.Sprintf("... (%d more)", len(names)-maxListedContexts))
+	}
+
+	return "[" + strings.Join(names, ", ") + "]"
+}
+
+// contextNames returns the sorted names of the contexts in the given configuration.
+func contextNames(rawConfig *api.Config) []string {
+	names := make([]string, 0, len(rawConfig.Contexts))
+	for name := range rawConfig.Contexts {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// CompareSubctlVersion compares the subctl version with a deployed Submariner version: the result is negative if subctl
+// is older, positive if it's newer, and zero if they're the same. ok is false if either version can't be compared, e.g.
+// for development builds.
+func CompareSubctlVersion(deployedVersion string) (comparison int, ok bool) {
+	subctlVer := version.Parse(version.Version)
+	submarinerVer := version.Parse(deployedVersion)
+
+	if subctlVer == nil || submarinerVer == nil {
+		return 0, false
+	}
+
+	return subctlVer.Compare(*submarinerVer), true
+}
+
+// CheckVersionMismatch returns an error if subctl is older than the given deployed Submariner version.
+func CheckVersionMismatch(submVersion string) error {
+	if comparison, ok := CompareSubctlVersion(submVersion); ok && comparison < 0 {
 		return fmt.Errorf(
 			"the subctl version %q is older than the deployed Submariner version %q. Please upgrade your subctl version",
 			version.Version, submVersion)
@@ -529,7 +965,12 @@ func checkVersionMismatch(submVersion string) error {
 
 func IfConnectivityInstalled(functions ...PerContextFn) PerContextFn {
 	return func(clusterInfo *cluster.Info, namespace string, status reporter.Interface) error {
-		if clusterInfo.Submariner == nil {
+		submariner, err := clusterInfo.GetSubmariner()
+		if err != nil {
+			return status.Error(err, "")
+		}
+
+		if submariner == nil {
 			status.Warning(constants.ConnectivityNotInstalled)
 
 			return nil
@@ -547,7 +988,12 @@ func IfConnectivityInstalled(functions ...PerContextFn) PerContextFn {
 
 func IfServiceDiscoveryInstalled(functions ...PerContextFn) PerContextFn {
 	return func(clusterInfo *cluster.Info, namespace string, status reporter.Interface) error {
-		if clusterInfo.ServiceDiscovery == nil {
+		serviceDiscovery, err := clusterInfo.GetServiceDiscovery()
+		if err != nil {
+			return status.Error(err, "")
+		}
+
+		if serviceDiscovery == nil {
 			status.Warning(constants.ServiceDiscoveryNotInstalled)
 
 			return nil
@@ -0,0 +1,192 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restconfig
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/submariner-io/admiral/pkg/reporter"
+	"github.com/submariner-io/subctl/pkg/cluster"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8serrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const defaultKubeConfigSecretKey = "kubeconfig"
+
+// kubeConfigSecret references a kubeconfig stored in a Secret, given as namespace/name[#key].
+type kubeConfigSecret struct {
+	namespace string
+	name      string
+	key       string
+}
+
+func parseKubeConfigSecret(reference string) (*kubeConfigSecret, error) {
+	namespacedName, key, found := strings.Cut(reference, "#")
+	if !found {
+		key = defaultKubeConfigSecretKey
+	}
+
+	namespace, name, _ := strings.Cut(namespacedName, "/")
+	if namespace == "" || name == "" || key == "" || strings.Contains(name, "/") {
+		return nil, fmt.Errorf("invalid kubeconfig Secret reference %q, expected namespace/name[#key]", reference)
+	}
+
+	return &kubeConfigSecret{namespace: namespace, name: name, key: key}, nil
+}
+
+func (s *kubeConfigSecret) String() string {
+	return s.namespace + "/" + s.name
+}
+
+// clientConfig retrieves the Secret and parses the kubeconfig stored in it.
+func (s *kubeConfigSecret) clientConfig(kubeClient kubernetes.Interface) (clientcmd.ClientConfig, error) {
+	secret, err := kubeClient.CoreV1().Secrets(s.namespace).Get(context.TODO(), s.name, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "error retrieving the kubeconfig Secret %q", s)
+	}
+
+	data := secret.Data[s.key]
+	if len(data) == 0 {
+		return nil, fmt.Errorf("the kubeconfig Secret %q has no data in key %q", s, s.key)
+	}
+
+	clientConfig, err := clientcmd.NewClientConfigFromBytes(data)
+	if err == nil {
+		_, err = clientConfig.RawConfig()
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "error parsing the kubeconfig in key %q of Secret %q", s.key, s)
+	}
+
+	return clientConfig, nil
+}
+
+// clusterFromKubeConfigSecret builds the cluster.Info for the cluster whose kubeconfig is stored in the referenced
+// Secret, and returns it along with the namespace to use.
+func (rcp *Producer) clusterFromKubeConfigSecret(kubeClient kubernetes.Interface, reference string) (*cluster.Info, string, error) {
+	secret, err := parseKubeConfigSecret(reference)
+	if err != nil {
+		return nil, "", err
+	}
+
+	clientConfig, err := secret.clientConfig(kubeClient)
+	if err != nil {
+		return nil, "", err
+	}
+
+	restConfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "error creating the client configuration from the kubeconfig in key %q of Secret %q",
+			secret.key, secret)
+	}
+
+	// The raw configuration was parsed successfully when retrieving the client configuration
+	raw, _ := clientConfig.RawConfig()
+
+	clusterName := secret.name
+	if name, err := clusterNameFromContext(&raw, ""); err == nil && name != "" {
+		clusterName = name
+	}
+
+	clusterInfo, err := rcp.newClusterInfo(clusterName, restConfig, false)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "error building the cluster.Info for the kubeconfig in Secret %q", secret)
+	}
+
+	namespace, overridden, err := clientConfig.Namespace()
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "error retrieving the namespace from the kubeconfig in Secret %q", secret)
+	}
+
+	if !overridden && rcp.defaultNamespace != nil {
+		namespace = *rcp.defaultNamespace
+	}
+
+	return clusterInfo, namespace, nil
+}
+
+// runOnKubeConfigSecrets runs the given function on each of the clusters whose kubeconfigs are stored in the
+// --kubeconfig-secret Secrets, which are retrieved using the in-cluster configuration. Errors are aggregated.
+func (rcp *Producer) runOnKubeConfigSecrets(inClusterConfig *rest.Config, function PerContextFn, status reporter.Interface) error {
+	kubeClient, err := kubernetes.NewForConfig(inClusterConfig)
+	if err != nil {
+		return status.Error(err, "error creating the in-cluster Kubernetes client")
+	}
+
+	outcomes := make([]*contextOutcome, 0, len(rcp.kubeConfigSecrets))
+	secretErrors := []error{}
+
+	for i, reference := range rcp.kubeConfigSecrets {
+		outcome := &contextOutcome{clusterName: reference}
+		outcomes = append(outcomes, outcome)
+		outcomeStatus := newOutcomeReporter(status, outcome)
+
+		fmt.Fprintf(rcp.progressWriter(), "Kubeconfig Secret %q (%d/%d)\n", reference, i+1, len(rcp.kubeConfigSecrets))
+
+		clusterInfo, namespace, err := rcp.clusterFromKubeConfigSecret(kubeClient, reference)
+		if err != nil {
+			err = outcomeStatus.Error(err, "")
+		} else {
+			outcome.clusterName = clusterInfo.Name
+			err = function(clusterInfo, namespace, outcomeStatus)
+		}
+
+		outcome.recordError(err)
+		secretErrors = append(secretErrors, err)
+
+		fmt.Fprintln(rcp.progressWriter())
+	}
+
+	if len(outcomes) > 1 {
+		printSummary(rcp.progressWriter(), outcomes)
+	}
+
+	return k8serrors.NewAggregate(secretErrors)
+}
+
+// allClustersFromKubeConfigSecrets builds the cluster.Info for all the clusters whose kubeconfigs are stored in the
+// --kubeconfig-secret Secrets, along with their namespaces.
+func (rcp *Producer) allClustersFromKubeConfigSecrets(inClusterConfig *rest.Config) ([]*cluster.Info, []string, error) {
+	kubeClient, err := kubernetes.NewForConfig(inClusterConfig)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error creating the in-cluster Kubernetes client")
+	}
+
+	clusterInfos := make([]*cluster.Info, 0, len(rcp.kubeConfigSecrets))
+	namespaces := make([]string, 0, len(rcp.kubeConfigSecrets))
+
+	for _, reference := range rcp.kubeConfigSecrets {
+		clusterInfo, namespace, err := rcp.clusterFromKubeConfigSecret(kubeClient, reference)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		clusterInfos = append(clusterInfos, clusterInfo)
+		namespaces = append(namespaces, namespace)
+	}
+
+	return clusterInfos, namespaces, nil
+}
@@ -0,0 +1,140 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restconfig
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/submariner-io/admiral/pkg/reporter"
+	"github.com/submariner-io/subctl/internal/show/table"
+	"github.com/submariner-io/subctl/pkg/cluster"
+)
+
+// contextOutcome records the failures and warnings reported while processing a single context.
+type contextOutcome struct {
+	clusterName  string
+	failures     int
+	warnings     int
+	firstFailure string
+	unreachable  bool
+}
+
+func (o *contextOutcome) String() string {
+	if o.unreachable {
+		return cluster.UnreachableMessage
+	}
+
+	if o.failures == 0 && o.warnings == 0 {
+		return "OK"
+	}
+
+	result := ""
+
+	if o.failures > 0 {
+		result = pluralize(o.failures, "failure")
+	}
+
+	if o.warnings > 0 {
+		if result != "" {
+			result += ", "
+		}
+
+		result += pluralize(o.warnings, "warning")
+	}
+
+	return result
+}
+
+func pluralize(count int, noun string) string {
+	if count == 1 {
+		return fmt.Sprintf("%d %s", count, noun)
+	}
+
+	return fmt.Sprintf("%d %ss", count, noun)
+}
+
+// outcomeReporter forwards all reports to the wrapped reporter, counting failures and warnings along the way.
+type outcomeReporter struct {
+	delegate reporter.Interface
+	outcome  *contextOutcome
+}
+
+func newOutcomeReporter(delegate reporter.Interface, outcome *contextOutcome) reporter.Interface {
+	return &reporter.Adapter{Basic: &outcomeReporter{delegate: delegate, outcome: outcome}}
+}
+
+func (r *outcomeReporter) Start(message string, args ...interface{}) {
+	r.delegate.Start(message, args...)
+}
+
+func (r *outcomeReporter) Success(message string, args ...interface{}) {
+	r.delegate.Success(message, args...)
+}
+
+func (r *outcomeReporter) Failure(message string, args ...interface{}) {
+	if message != "" {
+		r.outcome.failures++
+
+		if r.outcome.firstFailure == "" {
+			r.outcome.firstFailure = fmt.Sprintf(message, args...)
+		}
+	}
+
+	r.delegate.Failure(message, args...)
+}
+
+func (r *outcomeReporter) Warning(message string, args ...interface{}) {
+	if message != "" {
+		r.outcome.warnings++
+	}
+
+	r.delegate.Warning(message, args...)
+}
+
+func (r *outcomeReporter) End() {
+	r.delegate.End()
+}
+
+// recordError accounts for an error returned by a context function which wasn't reported as a failure.
+func (o *contextOutcome) recordError(err error) {
+	if cluster.IsUnreachable(err) {
+		o.unreachable = true
+	}
+
+	if err != nil && o.failures == 0 {
+		o.failures++
+		o.firstFailure = err.Error()
+	}
+}
+
+func printSummary(w io.Writer, outcomes []*contextOutcome) {
+	printer := table.Printer{Columns: []table.Column{
+		{Name: "CLUSTER", MaxLength: 40},
+		{Name: "RESULT"},
+		{Name: "FIRST FAILURE", MaxLength: 80},
+	}}
+
+	for _, outcome := range outcomes {
+		printer.Add(outcome.clusterName, outcome.String(), outcome.firstFailure)
+	}
+
+	fmt.Fprintln(w, "Summary:")
+	printer.Fprint(w)
+}
@@ -0,0 +1,52 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restconfig
+
+import (
+	"github.com/submariner-io/subctl/pkg/cluster"
+	"k8s.io/client-go/rest"
+)
+
+// SetInClusterConfig replaces the function used to retrieve the in-cluster configuration, returning a function restoring
+// the default. This is intended for tests, see the fake package.
+func SetInClusterConfig(function func() (*rest.Config, error)) func() {
+	orig := inClusterConfig
+	inClusterConfig = function
+
+	return func() {
+		inClusterConfig = orig
+	}
+}
+
+// SetNewInfo replaces the function used to connect to clusters, returning a function restoring the default; the clients
+// of the cluster.Info it returns are kept as is. This is intended for tests, see the fake package.
+func SetNewInfo(function func(clusterName string, config *rest.Config) (*cluster.Info, error)) func() {
+	origNewInfo := newInfo
+	origUseConfig := useConfig
+	newInfo = function
+	useConfig = func(clusterInfo *cluster.Info, config *rest.Config) error {
+		clusterInfo.RestConfig = config
+		return nil
+	}
+
+	return func() {
+		newInfo = origNewInfo
+		useConfig = origUseConfig
+	}
+}
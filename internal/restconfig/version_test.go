@@ -0,0 +1,58 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restconfig_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/subctl/internal/restconfig"
+	"github.com/submariner-io/subctl/pkg/version"
+)
+
+var _ = Describe("CompareSubctlVersion", func() {
+	BeforeEach(func() {
+		subctlVersion := version.Version
+		version.Version = "0.18.2"
+
+		DeferCleanup(func() {
+			version.Version = subctlVersion
+		})
+	})
+
+	DescribeTable("comparing with a deployed version",
+		func(deployedVersion string, expectedComparison int, expectedOK, expectMismatch bool) {
+			comparison, ok := restconfig.CompareSubctlVersion(deployedVersion)
+			Expect(ok).To(Equal(expectedOK))
+			Expect(comparison).To(Equal(expectedComparison))
+
+			if expectMismatch {
+				Expect(restconfig.CheckVersionMismatch(deployedVersion)).ToNot(Succeed())
+			} else {
+				Expect(restconfig.CheckVersionMismatch(deployedVersion)).To(Succeed())
+			}
+		},
+		Entry("with the same version", "0.18.2", 0, true, false),
+		Entry("with an older deployed version", "0.17.4", 1, true, false),
+		Entry("with a newer deployed version", "0.19.0", -1, true, true),
+		Entry("with a v-prefixed version", "v0.18.2", 0, true, false),
+		Entry("with a newer pre-release deployed version", "0.19.0-rc1", -1, true, true),
+		Entry("with a development version", "devel", 0, false, false),
+		Entry("with a release branch version", "release-0.18", 0, false, false),
+	)
+})
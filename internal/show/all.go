@@ -36,6 +36,18 @@ var showAllSubmarinerFunctions = []restconfig.PerContextFn{
 	Versions,
 }
 
+// AllWithClusterScore returns a function showing all the information about the cluster, followed by its health score
+// (see ScoreCluster) if requested.
+func AllWithClusterScore(showScore bool) restconfig.PerContextFn {
+	return func(clusterInfo *cluster.Info, namespace string, status reporter.Interface) error {
+		if err := All(clusterInfo, namespace, status); err != nil || !showScore || clusterInfo.Submariner == nil {
+			return err
+		}
+
+		return showClusterScore(clusterInfo, status)
+	}
+}
+
 func All(clusterInfo *cluster.Info, namespace string, status reporter.Interface) error {
 	allErrors := []error{}
 
@@ -61,3 +73,16 @@ func All(clusterInfo *cluster.Info, namespace string, status reporter.Interface)
 
 	return errors.NewAggregate(allErrors)
 }
+
+func showClusterScore(clusterInfo *cluster.Info, status reporter.Interface) error {
+	summary, err := summarizeCluster(clusterInfo)
+	if err != nil {
+		return status.Error(err, "Error computing the cluster health score")
+	}
+
+	fmt.Printf("Cluster health score: %d/100 (%d/%d connections established, %d pod restarts, %d/%d active gateways,"+
+		" %d/%d components outdated)\n", ScoreCluster(summary), summary.ActiveConnections, summary.ExpectedConnections,
+		summary.PodRestarts, summary.ActiveGateways, summary.Gateways, summary.OutdatedComponents, summary.Components)
+
+	return nil
+}
@@ -0,0 +1,254 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package show
+
+import (
+	"context"
+	"sort"
+
+	"github.com/submariner-io/admiral/pkg/reporter"
+	"github.com/submariner-io/admiral/pkg/resource"
+	"github.com/submariner-io/subctl/internal/restconfig"
+	"github.com/submariner-io/subctl/internal/show/table"
+	"github.com/submariner-io/subctl/pkg/client"
+	"github.com/submariner-io/subctl/pkg/cluster"
+	"github.com/submariner-io/submariner-operator/api/v1alpha1"
+	submarinerv1 "github.com/submariner-io/submariner/pkg/apis/submariner.io/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	controllerClient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// syncedResource identifies an Endpoint or Cluster resource, which the gateways sync between the broker and the clusters.
+type syncedResource struct {
+	clusterID string
+	kind      string
+	name      string
+}
+
+type syncedResources struct {
+	endpoints []submarinerv1.Endpoint
+	clusters  []submarinerv1.Cluster
+}
+
+func (r *syncedResources) keys() map[syncedResource]bool {
+	keys := map[syncedResource]bool{}
+
+	for i := range r.endpoints {
+		keys[syncedResource{clusterID: r.endpoints[i].Spec.ClusterID, kind: "Endpoint", name: r.endpoints[i].Name}] = true
+	}
+
+	for i := range r.clusters {
+		keys[syncedResource{clusterID: r.clusters[i].Spec.ClusterID, kind: "Cluster", name: r.clusters[i].Name}] = true
+	}
+
+	return keys
+}
+
+// BrokerSync compares the Endpoint and Cluster resources on the broker with those synced to the cluster, grouped by
+// cluster ID, to highlight synchronization lag and stale resources. On a cluster which only hosts a broker, the
+// resources on the broker are listed without comparison.
+func BrokerSync(clusterInfo *cluster.Info, _ string, status reporter.Interface) error {
+	status.Start("Comparing the Endpoints and Clusters on the broker with the local ones")
+
+	if _, err := clusterInfo.GetSubmariner(); err != nil {
+		return status.Error(err, "Error retrieving the Submariner resource")
+	}
+
+	brokerProducer, brokerNamespace, err := brokerClientFor(clusterInfo)
+	if err != nil {
+		return status.Error(err, "Error determining the broker")
+	}
+
+	if brokerProducer == nil {
+		status.Warning("This cluster neither is joined to a broker nor hosts one")
+		return nil
+	}
+
+	onBroker, err := listSyncedResources(brokerProducer, brokerNamespace)
+	if err != nil {
+		return status.Error(err, "Error listing the resources on the broker in namespace %q", brokerNamespace)
+	}
+
+	var local *syncedResources
+
+	if clusterInfo.Submariner != nil {
+		local, err = listSyncedResources(clusterInfo.ClientProducer, clusterInfo.OperatorNamespace())
+		if err != nil {
+			return status.Error(err, "Error listing the local resources")
+		}
+	}
+
+	status.End()
+
+	printBrokerSync(clusterInfo, onBroker, local, status)
+
+	return nil
+}
+
+// brokerClientFor returns a client for the broker the cluster is joined to, or the broker it hosts if it isn't joined;
+// nil is returned if there is neither.
+func brokerClientFor(clusterInfo *cluster.Info) (client.Producer, string, error) {
+	if clusterInfo.Submariner != nil {
+		brokerRestConfig, brokerNamespace, err := restconfig.ForBroker(clusterInfo.Submariner, nil)
+		if err != nil {
+			return nil, "", err //nolint:wrapcheck // No need to wrap errors here.
+		}
+
+		brokerProducer, err := client.NewProducerFromRestConfig(brokerRestConfig)
+
+		return brokerProducer, brokerNamespace, err //nolint:wrapcheck // No need to wrap errors here.
+	}
+
+	brokerList := &v1alpha1.BrokerList{}
+
+	err := clusterInfo.ClientProducer.ForGeneral().List(context.TODO(), brokerList, controllerClient.InNamespace(metav1.NamespaceAll))
+	if err != nil && !resource.IsNotFoundErr(err) {
+		return nil, "", err //nolint:wrapcheck // No need to wrap errors here.
+	}
+
+	if len(brokerList.Items) == 0 {
+		return nil, "", nil
+	}
+
+	return clusterInfo.ClientProducer, brokerList.Items[0].Namespace, nil
+}
+
+func listSyncedResources(clientProducer client.Producer, namespace string) (*syncedResources, error) {
+	endpoints := &submarinerv1.EndpointList{}
+
+	err := clientProducer.ForGeneral().List(context.TODO(), endpoints, controllerClient.InNamespace(namespace))
+	if err != nil && !resource.IsNotFoundErr(err) {
+		return nil, err //nolint:wrapcheck // No need to wrap errors here.
+	}
+
+	clusters := &submarinerv1.ClusterList{}
+
+	err = clientProducer.ForGeneral().List(context.TODO(), clusters, controllerClient.InNamespace(namespace))
+	if err != nil && !resource.IsNotFoundErr(err) {
+		return nil, err //nolint:wrapcheck // No need to wrap errors here.
+	}
+
+	return &syncedResources{endpoints: endpoints.Items, clusters: clusters.Items}, nil
+}
+
+func printBrokerSync(clusterInfo *cluster.Info, onBroker, local *syncedResources, status reporter.Interface) {
+	brokerKeys := onBroker.keys()
+	localKeys := map[syncedResource]bool{}
+	localClusterID := ""
+
+	if local != nil {
+		localKeys = local.keys()
+		localClusterID = clusterInfo.Submariner.Spec.ClusterID
+	}
+
+	all := make([]syncedResource, 0, len(brokerKeys)+len(localKeys))
+
+	for key := range brokerKeys {
+		all = append(all, key)
+	}
+
+	for key := range localKeys {
+		if !brokerKeys[key] {
+			all = append(all, key)
+		}
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].clusterID != all[j].clusterID {
+			return all[i].clusterID < all[j].clusterID
+		}
+
+		if all[i].kind != all[j].kind {
+			return all[i].kind < all[j].kind
+		}
+
+		return all[i].name < all[j].name
+	})
+
+	columns := []table.Column{
+		{Name: "CLUSTER", MaxLength: 24},
+		{Name: "KIND"},
+		{Name: "NAME", MaxLength: 50},
+	}
+
+	if local != nil {
+		columns = append(columns, table.Column{Name: "BROKER"}, table.Column{Name: "LOCAL"}, table.Column{Name: "STATE"})
+	}
+
+	printer := table.Printer{Columns: columns}
+	notSynced, stale := 0, 0
+
+	for _, key := range all {
+		if local == nil {
+			printer.Add(key.clusterID, key.kind, key.name)
+			continue
+		}
+
+		state := syncState(brokerKeys[key], localKeys[key], key.clusterID == localClusterID)
+
+		switch state {
+		case "not synced to the broker", "not synced locally":
+			notSynced++
+		case "stale on the broker", "stale locally":
+			stale++
+		}
+
+		printer.Add(key.clusterID, key.kind, key.name, presence(brokerKeys[key]), presence(localKeys[key]), state)
+	}
+
+	if printer.Empty() {
+		status.Success("There are no Endpoints or Clusters on the broker")
+		return
+	}
+
+	printer.Print()
+
+	if notSynced > 0 {
+		status.Warning("%d resource(s) haven't been synced yet; if this persists, check the gateway logs for sync errors", notSynced)
+	}
+
+	if stale > 0 {
+		status.Warning("%d resource(s) are stale, they're left over from clusters or endpoints which no longer exist", stale)
+	}
+}
+
+// syncState describes the synchronization of a resource: the local cluster's own resources are synced to the broker,
+// the other clusters' resources are synced from the broker.
+func syncState(onBroker, onLocal, ownResource bool) string {
+	switch {
+	case onBroker && onLocal:
+		return "in sync"
+	case ownResource && onLocal:
+		return "not synced to the broker"
+	case ownResource:
+		return "stale on the broker"
+	case onBroker:
+		return "not synced locally"
+	default:
+		return "stale locally"
+	}
+}
+
+func presence(present bool) string {
+	if present {
+		return "yes"
+	}
+
+	return "no"
+}
@@ -0,0 +1,91 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package show
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/submariner-io/admiral/pkg/reporter"
+	"github.com/submariner-io/subctl/pkg/broker"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// BrokerInfo shows the contents of a broker information file, with the credentials redacted, and reports any problems
+// which would prevent it from being used to join a cluster.
+func BrokerInfo(filename string, status reporter.Interface) error {
+	status.Start("Reading the broker information from %q", filename)
+
+	info, err := broker.DecodeInfoFromFile(filename)
+	if err != nil {
+		return status.Error(err, "Error reading the broker information")
+	}
+
+	status.End()
+
+	fmt.Printf("    Broker URL:         %s\n", info.BrokerURL)
+	fmt.Printf("    Written by subctl:  %s\n", valueOrUnknown(info.SubctlVersion))
+	fmt.Printf("    Format version:     %d\n", info.SchemaVersion)
+	fmt.Printf("    Components:         %s\n", valueOrUnknown(strings.Join(info.Components, ", ")))
+	fmt.Printf("    Service discovery:  %t\n", info.IsServiceDiscoveryEnabled())
+
+	if info.CustomDomains != nil {
+		fmt.Printf("    Custom domains:     %s\n", strings.Join(*info.CustomDomains, ", "))
+	}
+
+	if info.ClientToken != nil {
+		fmt.Printf("    Broker namespace:   %s\n", valueOrUnknown(string(info.ClientToken.Data[corev1.ServiceAccountNamespaceKey])))
+	}
+
+	fmt.Printf("    Client token:       %s\n", redactedSecretData(info.ClientToken, corev1.ServiceAccountTokenKey))
+	fmt.Printf("    Broker CA:          %s\n", redactedSecretData(info.ClientToken, corev1.ServiceAccountRootCAKey))
+	fmt.Printf("    IPsec PSK:          %s\n", redactedSecretData(info.IPSecPSK, "psk"))
+
+	status.Start("Checking the broker information")
+	defer status.End()
+
+	if err := info.Validate(); err != nil {
+		return status.Error(err, "The broker information can't be used to join a cluster")
+	}
+
+	tracker := reporter.NewTracker(status)
+	info.CheckCompatibility(tracker)
+
+	if !tracker.HasWarnings() {
+		status.Success("The broker information can be used to join a cluster")
+	}
+
+	return nil
+}
+
+func valueOrUnknown(value string) string {
+	if value == "" {
+		return "unknown"
+	}
+
+	return value
+}
+
+func redactedSecretData(secret *corev1.Secret, key string) string {
+	if secret == nil || len(secret.Data[key]) == 0 {
+		return "missing"
+	}
+
+	return fmt.Sprintf("present (%d bytes, redacted)", len(secret.Data[key]))
+}
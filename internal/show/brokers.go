@@ -20,17 +20,39 @@ package show
 
 import (
 	"context"
+	"sort"
+	"strings"
 
 	"github.com/submariner-io/admiral/pkg/reporter"
 	"github.com/submariner-io/admiral/pkg/resource"
+	"github.com/submariner-io/subctl/internal/restconfig"
 	"github.com/submariner-io/subctl/internal/show/table"
+	"github.com/submariner-io/subctl/pkg/broker"
 	"github.com/submariner-io/subctl/pkg/cluster"
 	"github.com/submariner-io/submariner-operator/api/v1alpha1"
+	submarinerv1 "github.com/submariner-io/submariner/pkg/apis/submariner.io/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-func Brokers(clusterInfo *cluster.Info, _ string, status reporter.Interface) error {
+// brokerSummary is a Broker along with the clusters connected to it, if requested.
+type brokerSummary struct {
+	*v1alpha1.Broker
+	ConnectedClusters []string
+}
+
+func Brokers(clusterInfo *cluster.Info, namespace string, status reporter.Interface) error {
+	return showBrokers(clusterInfo, namespace, false, status)
+}
+
+// BrokersWithConnectedClusters returns a function showing the brokers and, if requested, the clusters connected to each.
+func BrokersWithConnectedClusters(connectedClusters bool) restconfig.PerContextFn {
+	return func(clusterInfo *cluster.Info, namespace string, status reporter.Interface) error {
+		return showBrokers(clusterInfo, namespace, connectedClusters, status)
+	}
+}
+
+func showBrokers(clusterInfo *cluster.Info, _ string, connectedClusters bool, status reporter.Interface) error {
 	status.Start("Detecting broker(s)")
 
 	brokerList := &v1alpha1.BrokerList{}
@@ -42,13 +64,29 @@ func Brokers(clusterInfo *cluster.Info, _ string, status reporter.Interface) err
 
 	status.End()
 
-	brokers := brokerList.Items
-	if len(brokers) == 0 {
+	if len(brokerList.Items) == 0 {
 		status.Success("No brokers found")
-		return nil
+		return showJoinedBrokerComponents(clusterInfo, status)
 	}
 
-	printer := table.Printer{Columns: []table.Column{
+	brokers := make([]brokerSummary, len(brokerList.Items))
+
+	for i := range brokerList.Items {
+		brokers[i].Broker = &brokerList.Items[i]
+
+		if !connectedClusters {
+			continue
+		}
+
+		clusters, err := clusterInfo.GetClusters(brokerList.Items[i].Namespace)
+		if err != nil {
+			return status.Error(err, "Error retrieving the clusters connected to broker %q", brokerList.Items[i].Name)
+		}
+
+		brokers[i].ConnectedClusters = clusterIDs(clusters)
+	}
+
+	columns := []table.Column{
 		{Name: "NAMESPACE", MaxLength: 24},
 		{Name: "NAME", MaxLength: 24},
 		{Name: "COMPONENTS"},
@@ -56,10 +94,16 @@ func Brokers(clusterInfo *cluster.Info, _ string, status reporter.Interface) err
 		{Name: "GLOBALNET CIDR"},
 		{Name: "DEFAULT GLOBALNET SIZE"},
 		{Name: "DEFAULT DOMAINS", MaxLength: 40},
-	}}
+	}
+
+	if connectedClusters {
+		columns = append(columns, table.Column{Name: "CONNECTED CLUSTERS", MaxLength: 40})
+	}
+
+	printer := table.Printer{Columns: columns}
 
 	for i := range brokers {
-		printer.Add(
+		values := []interface{}{
 			brokers[i].Namespace,
 			brokers[i].Name,
 			brokers[i].Spec.Components,
@@ -67,11 +111,86 @@ func Brokers(clusterInfo *cluster.Info, _ string, status reporter.Interface) err
 			brokers[i].Spec.GlobalnetCIDRRange,
 			brokers[i].Spec.DefaultGlobalnetClusterSize,
 			brokers[i].Spec.DefaultCustomDomains,
-		)
+		}
+
+		if connectedClusters {
+			values = append(values, brokers[i].ConnectedClusters)
+		}
+
+		printer.Add(values...)
 	}
 
 	status.End()
 	printer.Print()
 
+	return showJoinedBrokerComponents(clusterInfo, status)
+}
+
+// showJoinedBrokerComponents shows the components which were enabled at the broker the cluster joined, as recorded when
+// it joined.
+func showJoinedBrokerComponents(clusterInfo *cluster.Info, status reporter.Interface) error {
+	submariner, err := clusterInfo.GetSubmariner()
+	if err != nil {
+		return status.Error(err, "Error retrieving the Submariner resource")
+	}
+
+	if submariner == nil && clusterInfo.ServiceDiscovery == nil {
+		return nil
+	}
+
+	components, err := broker.JoinedComponents(context.TODO(), clusterInfo)
+	if err != nil {
+		return status.Error(err, "Error retrieving the components of the joined broker")
+	}
+
+	if components == nil {
+		status.Warning("The components of the joined broker weren't recorded when the cluster joined")
+		return nil
+	}
+
+	status.Success("The broker this cluster joined has the following components enabled: %s",
+		strings.Join(components.SortedList(), ", "))
+
+	return nil
+}
+
+// BrokerMembership shows the clusters connected to the broker described in the given broker information file; this only
+// requires the broker information, not access to a cluster hosting the broker.
+func BrokerMembership(filename string, status reporter.Interface) error {
+	status.Start("Retrieving the clusters connected to the broker described in %q", filename)
+
+	info, err := broker.ReadInfoFromFile(filename)
+	if err != nil {
+		return status.Error(err, "Error reading the broker information")
+	}
+
+	clusters, err := info.ListClusters(context.TODO())
+	if err != nil {
+		return status.Error(err, "Error retrieving the connected clusters")
+	}
+
+	status.End()
+
+	printer := table.Printer{Columns: []table.Column{
+		{Name: "NAMESPACE", MaxLength: 24},
+		{Name: "BROKER URL", MaxLength: 60},
+		{Name: "CONNECTED CLUSTERS", MaxLength: 40},
+	}}
+
+	printer.Add(info.GetNamespace(), info.BrokerURL, clusterIDs(clusters))
+	printer.Print()
+
 	return nil
 }
+
+func clusterIDs(clusters []submarinerv1.Cluster) []string {
+	ids := make([]string, 0, len(clusters))
+
+	for i := range clusters {
+		ids = append(ids, clusters[i].Spec.ClusterID)
+	}
+
+	sort.Strings(ids)
+
+	return ids
+}
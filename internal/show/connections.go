@@ -20,8 +20,13 @@ package show
 
 import (
 	"errors"
+	"fmt"
+	"os"
+	"time"
 
 	"github.com/submariner-io/admiral/pkg/reporter"
+	"github.com/submariner-io/subctl/internal/env"
+	"github.com/submariner-io/subctl/internal/restconfig"
 	"github.com/submariner-io/subctl/internal/show/table"
 	"github.com/submariner-io/subctl/pkg/cluster"
 	submv1 "github.com/submariner-io/submariner/pkg/apis/submariner.io/v1"
@@ -39,7 +44,85 @@ func Connections(clusterInfo *cluster.Info, _ string, status reporter.Interface)
 		return status.Error(errors.New("no gateways detected"), "")
 	}
 
-	printer := table.Printer{Columns: []table.Column{
+	printer, _, _ := connectionsTable(gateways)
+	if printer.Empty() {
+		return status.Error(errors.New("no connections found"), "")
+	}
+
+	status.End()
+	printer.Print()
+
+	return nil
+}
+
+// WatchConnections returns a function showing the connections every interval until they're all established; if timeout
+// isn't zero, it fails once the timeout expires. On smart terminals, the connections are shown in place, otherwise each
+// snapshot is appended to the output.
+func WatchConnections(interval, timeout time.Duration) restconfig.PerContextFn {
+	return func(clusterInfo *cluster.Info, _ string, status reporter.Interface) error {
+		return watchConnections(clusterInfo, interval, timeout, status)
+	}
+}
+
+func watchConnections(clusterInfo *cluster.Info, interval, timeout time.Duration, status reporter.Interface) error {
+	inPlace := env.IsSmartTerminal(os.Stdout)
+	printedLines := 0
+	start := time.Now()
+
+	for {
+		gateways, err := clusterInfo.GetGateways()
+		if err != nil {
+			return status.Error(err, "Error retrieving gateways")
+		}
+
+		printer, connected, total := connectionsTable(gateways)
+
+		if printedLines > 0 {
+			// Move back up to the previous snapshot and clear it
+			fmt.Printf("\x1b[%dA\x1b[J", printedLines)
+		}
+
+		switch {
+		case len(gateways) == 0:
+			fmt.Printf("%s: no gateways detected yet\n", time.Now().Format(time.TimeOnly))
+		case total == 0:
+			fmt.Printf("%s: no connections found yet\n", time.Now().Format(time.TimeOnly))
+		default:
+			fmt.Printf("%s: %d connections out of %d are established\n", time.Now().Format(time.TimeOnly), connected, total)
+		}
+
+		printer.Print()
+
+		if inPlace {
+			printedLines = printer.Lines() + 1
+		} else {
+			fmt.Println()
+		}
+
+		if total > 0 && connected == total {
+			status.Success("All the connections are established")
+			return nil
+		}
+
+		wait := interval
+
+		if timeout > 0 {
+			remaining := timeout - time.Since(start)
+			if remaining <= 0 {
+				return status.Error(fmt.Errorf("timed out after %v waiting for the connections to be established", timeout), "")
+			}
+
+			wait = min(wait, remaining)
+		}
+
+		time.Sleep(wait)
+	}
+}
+
+// connectionsTable returns a table listing the connections of the given gateways, along with the number of connections
+// which are established and the total number of connections.
+func connectionsTable(gateways []submv1.Gateway) (printer *table.Printer, connected, total int) {
+	printer = &table.Printer{Columns: []table.Column{
 		{Name: "GATEWAY", MaxLength: 30},
 		{Name: "CLUSTER", MaxLength: 24},
 		{Name: "REMOTE IP"},
@@ -65,17 +148,16 @@ func Connections(clusterInfo *cluster.Info, _ string, status reporter.Interface)
 				connection.Status,
 				getAverageRTTForConnection(connection),
 			)
-		}
-	}
 
-	if printer.Empty() {
-		return status.Error(errors.New("no connections found"), "")
-	}
+			total++
 
-	status.End()
-	printer.Print()
+			if connection.Status == submv1.Connected {
+				connected++
+			}
+		}
+	}
 
-	return nil
+	return printer, connected, total
 }
 
 func getAverageRTTForConnection(connection *submv1.Connection) string {
@@ -0,0 +1,165 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package show
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/submariner-io/subctl/internal/constants"
+	"github.com/submariner-io/subctl/internal/restconfig"
+	"github.com/submariner-io/subctl/internal/show/table"
+	"github.com/submariner-io/submariner-operator/api/v1alpha1"
+	opnames "github.com/submariner-io/submariner-operator/pkg/names"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+)
+
+// Used unless a timeout is given with --request-timeout, so that unreachable clusters don't stall the listing.
+const defaultContextTimeout = 5 * time.Second
+
+const (
+	Reachable   = "ok"
+	Unreachable = "unreachable"
+	AuthError   = "auth-error"
+)
+
+type ContextStatus struct {
+	Context      string `json:"context"`
+	Cluster      string `json:"cluster"`
+	Reachability string `json:"reachability"`
+	Submariner   bool   `json:"submariner"`
+	Broker       bool   `json:"broker"`
+	Version      string `json:"version,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// Contexts probes the given contexts in parallel and prints their Submariner installation status.
+func Contexts(contexts []restconfig.ContextConfig, output OutputFormat) error {
+	statuses := make([]ContextStatus, len(contexts))
+
+	var wg sync.WaitGroup
+
+	for i := range contexts {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			statuses[i] = probeContext(&contexts[i])
+		}(i)
+	}
+
+	wg.Wait()
+
+	if output == JSONOutput {
+		encoded, err := json.MarshalIndent(statuses, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "error encoding the context statuses")
+		}
+
+		fmt.Println(string(encoded))
+
+		return nil
+	}
+
+	printer := table.Printer{Columns: []table.Column{
+		{Name: "CONTEXT", MaxLength: 40},
+		{Name: "CLUSTER", MaxLength: 40},
+		{Name: "REACHABILITY"},
+		{Name: "SUBMARINER"},
+		{Name: "BROKER"},
+		{Name: "VERSION"},
+	}}
+
+	for i := range statuses {
+		status := &statuses[i]
+		printer.Add(status.Context, status.Cluster, status.Reachability, status.Submariner, status.Broker, status.Version)
+	}
+
+	printer.Print()
+
+	return nil
+}
+
+func probeContext(contextConfig *restconfig.ContextConfig) ContextStatus {
+	status := ContextStatus{
+		Context:      contextConfig.ContextName,
+		Cluster:      contextConfig.ClusterName,
+		Reachability: Unreachable,
+	}
+
+	if contextConfig.Err != nil {
+		status.Error = contextConfig.Err.Error()
+		return status
+	}
+
+	config := rest.CopyConfig(contextConfig.Config)
+	if config.Timeout == 0 {
+		config.Timeout = defaultContextTimeout
+	}
+
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.Timeout)
+	defer cancel()
+
+	// A single Get tells us both whether the cluster is reachable and whether Submariner is installed
+	submariner, err := client.Resource(v1alpha1.GroupVersion.WithResource("submariners")).Namespace(constants.OperatorNamespace).
+		Get(ctx, opnames.SubmarinerCrName, metav1.GetOptions{})
+
+	switch {
+	case err == nil:
+		status.Submariner = true
+		status.Version, _, _ = unstructured.NestedString(submariner.Object, "spec", "version")
+	case apierrors.IsNotFound(err):
+	case apierrors.IsUnauthorized(err) || apierrors.IsForbidden(err):
+		status.Reachability = AuthError
+		status.Error = err.Error()
+
+		return status
+	default:
+		status.Error = err.Error()
+		return status
+	}
+
+	status.Reachability = Reachable
+
+	// Brokers live in their own namespace, submariner-k8s-broker by default, so look for them in all namespaces
+	brokers, err := client.Resource(v1alpha1.GroupVersion.WithResource("brokers")).Namespace(metav1.NamespaceAll).
+		List(ctx, metav1.ListOptions{Limit: 1})
+	if err == nil {
+		status.Broker = len(brokers.Items) > 0
+	} else if !apierrors.IsNotFound(err) {
+		status.Error = err.Error()
+	}
+
+	return status
+}
@@ -24,12 +24,24 @@ import (
 	"github.com/submariner-io/admiral/pkg/reporter"
 	"github.com/submariner-io/subctl/internal/show/table"
 	"github.com/submariner-io/subctl/pkg/cluster"
+	submv1 "github.com/submariner-io/submariner/pkg/apis/submariner.io/v1"
 )
 
 func Endpoints(clusterInfo *cluster.Info, _ string, status reporter.Interface) error {
-	status.Start("Showing Endpoints")
+	return showEndpoints(clusterInfo, TableOutput, status)
+}
+
+func showEndpoints(clusterInfo *cluster.Info, output OutputFormat, status reporter.Interface) error {
+	// The DOT output is meant to be piped into Graphviz, so only errors are reported
+	if output != DotOutput {
+		status.Start("Showing Endpoints")
+	}
 
 	gateways, err := clusterInfo.GetGateways()
+	if cluster.IsUnreachable(err) {
+		return reportUnavailableAs(clusterInfo, err, output, status)
+	}
+
 	if err != nil {
 		return status.Error(err, "Error retrieving gateways")
 	}
@@ -38,6 +50,11 @@ func Endpoints(clusterInfo *cluster.Info, _ string, status reporter.Interface) e
 		return status.Error(errors.New("no gateways detected"), "")
 	}
 
+	if output == DotOutput {
+		printEndpointsGraph(clusterInfo.Name, gateways)
+		return nil
+	}
+
 	printer := table.Printer{Columns: []table.Column{
 		{Name: "CLUSTER", MaxLength: 24},
 		{Name: "ENDPOINT IP"},
@@ -73,3 +90,34 @@ func Endpoints(clusterInfo *cluster.Info, _ string, status reporter.Interface) e
 
 	return nil
 }
+
+func endpointNodeID(endpoint *submv1.EndpointSpec) string {
+	if endpoint.CableName != "" {
+		return endpoint.CableName
+	}
+
+	return endpoint.ClusterID + "/" + endpoint.Hostname
+}
+
+func addEndpointNode(graph *dotGraph, endpoint *submv1.EndpointSpec) string {
+	id := endpointNodeID(endpoint)
+	graph.addNode(id, endpoint.ClusterID, endpoint.Backend, endpoint.PrivateIP)
+
+	return id
+}
+
+func printEndpointsGraph(clusterName string, gateways []submv1.Gateway) {
+	graph := newDotGraph(clusterName + " endpoints")
+
+	for i := range gateways {
+		gateway := &gateways[i]
+		local := addEndpointNode(graph, &gateway.Status.LocalEndpoint)
+
+		for j := range gateway.Status.Connections {
+			connection := &gateway.Status.Connections[j]
+			graph.addEdge(local, addEndpointNode(graph, &connection.Endpoint), string(connection.Status))
+		}
+	}
+
+	graph.print()
+}
@@ -21,17 +21,41 @@ package show
 import (
 	"errors"
 	"fmt"
+	"slices"
 
 	"github.com/submariner-io/admiral/pkg/reporter"
 	"github.com/submariner-io/subctl/internal/show/table"
 	"github.com/submariner-io/subctl/pkg/cluster"
 	submv1 "github.com/submariner-io/submariner/pkg/apis/submariner.io/v1"
+	"k8s.io/utils/set"
 )
 
 func Gateways(clusterInfo *cluster.Info, _ string, status reporter.Interface) error {
-	status.Start("Showing Gateways")
+	return showGateways(clusterInfo, TableOutput, "", status)
+}
+
+// ParseHAStatus checks that the given HA status is one which gateways can be filtered on.
+func ParseHAStatus(haStatus string) (submv1.HAStatus, error) {
+	switch submv1.HAStatus(haStatus) {
+	case "", submv1.HAStatusActive, submv1.HAStatusPassive:
+		return submv1.HAStatus(haStatus), nil
+	}
+
+	return "", fmt.Errorf("unsupported HA status %q, the supported statuses are %q and %q", haStatus, submv1.HAStatusActive,
+		submv1.HAStatusPassive)
+}
+
+func showGateways(clusterInfo *cluster.Info, output OutputFormat, haStatus submv1.HAStatus, status reporter.Interface) error {
+	// The DOT output is meant to be piped into Graphviz, so only errors are reported
+	if output != DotOutput {
+		status.Start("Showing Gateways")
+	}
 
 	gateways, err := clusterInfo.GetGateways()
+	if cluster.IsUnreachable(err) {
+		return reportUnavailableAs(clusterInfo, err, output, status)
+	}
+
 	if err != nil {
 		return status.Error(err, "Error retrieving gateways")
 	}
@@ -40,9 +64,30 @@ func Gateways(clusterInfo *cluster.Info, _ string, status reporter.Interface) er
 		return status.Error(errors.New("no gateways detected"), "")
 	}
 
+	if haStatus != "" {
+		gateways = slices.DeleteFunc(gateways, func(gateway submv1.Gateway) bool {
+			return gateway.Status.HAStatus != haStatus
+		})
+
+		if len(gateways) == 0 {
+			if output != DotOutput {
+				status.Warning("There are no %s gateways", haStatus)
+				status.End()
+			}
+
+			return nil
+		}
+	}
+
+	if output == DotOutput {
+		printGatewaysGraph(clusterInfo.Name, gateways)
+		return nil
+	}
+
 	printer := table.Printer{Columns: []table.Column{
 		{Name: "NODE", MaxLength: 30},
-		{Name: "HA STATUS"},
+		{Name: "HA STATUS", Color: haStatusColor},
+		{Name: "CONNECTIONS"},
 		{Name: "SUMMARY"},
 	}}
 
@@ -68,7 +113,13 @@ func Gateways(clusterInfo *cluster.Info, _ string, status reporter.Interface) er
 			summary = fmt.Sprintf("%d connections out of %d are established", countConnected, totalConnections)
 		}
 
-		printer.Add(gateway.Status.LocalEndpoint.Hostname, gateway.Status.HAStatus, summary)
+		// Only the active gateway maintains connections
+		var connectedClusters interface{}
+		if gateway.Status.HAStatus == submv1.HAStatusActive {
+			connectedClusters = countConnectedClusters(&gateway)
+		}
+
+		printer.Add(gateway.Status.LocalEndpoint.Hostname, haStatusOrUnknown(gateway.Status.HAStatus), connectedClusters, summary)
 	}
 
 	status.End()
@@ -76,3 +127,58 @@ func Gateways(clusterInfo *cluster.Info, _ string, status reporter.Interface) er
 
 	return nil
 }
+
+func haStatusOrUnknown(haStatus submv1.HAStatus) string {
+	if haStatus == "" {
+		return "unknown"
+	}
+
+	return string(haStatus)
+}
+
+func haStatusColor(haStatus string) string {
+	switch submv1.HAStatus(haStatus) {
+	case submv1.HAStatusActive:
+		return table.Green
+	case submv1.HAStatusPassive:
+		return table.Yellow
+	}
+
+	return ""
+}
+
+// countConnectedClusters returns the number of remote clusters the gateway has an established connection to.
+func countConnectedClusters(gateway *submv1.Gateway) int {
+	clusters := set.New[string]()
+
+	for i := range gateway.Status.Connections {
+		if gateway.Status.Connections[i].Status == submv1.Connected {
+			clusters.Insert(gateway.Status.Connections[i].Endpoint.ClusterID)
+		}
+	}
+
+	return clusters.Len()
+}
+
+func printGatewaysGraph(clusterName string, gateways []submv1.Gateway) {
+	graph := newDotGraph(clusterName + " gateways")
+
+	for i := range gateways {
+		gateway := &gateways[i]
+		local := &gateway.Status.LocalEndpoint
+		localID := local.ClusterID + "/" + local.Hostname
+
+		graph.addNode(localID, local.Hostname, local.ClusterID, string(gateway.Status.HAStatus))
+
+		for j := range gateway.Status.Connections {
+			connection := &gateway.Status.Connections[j]
+			remote := &connection.Endpoint
+			remoteID := remote.ClusterID + "/" + remote.Hostname
+
+			graph.addNode(remoteID, remote.Hostname, remote.ClusterID)
+			graph.addEdge(localID, remoteID, string(connection.Status))
+		}
+	}
+
+	graph.print()
+}
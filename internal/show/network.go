@@ -20,37 +20,65 @@ package show
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"regexp"
+	"strings"
 
 	"github.com/submariner-io/admiral/pkg/reporter"
 	"github.com/submariner-io/subctl/internal/constants"
+	"github.com/submariner-io/subctl/internal/dns"
+	"github.com/submariner-io/subctl/internal/restconfig"
+	"github.com/submariner-io/subctl/pkg/client"
 	"github.com/submariner-io/subctl/pkg/cluster"
 	"github.com/submariner-io/submariner-operator/pkg/discovery/network"
+	submarinerv1 "github.com/submariner-io/submariner/pkg/apis/submariner.io/v1"
+	"github.com/submariner-io/submariner/pkg/cidr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/set"
+	controllerClient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
 )
 
+const (
+	userSpecified  = "user-specified"
+	autoDiscovered = "auto-discovered"
+)
+
+// liveNetwork holds the CIDRs configured in the cluster itself; either may be empty if they couldn't be determined.
+type liveNetwork struct {
+	serviceCIDRs []string
+	podCIDRs     []string
+	source       string
+}
+
+// NetworkWithConflictCheck returns a function showing the network details and, if requested, checking the local CIDRs
+// against those of the other clusters joined to the broker.
+func NetworkWithConflictCheck(checkConflicts bool) restconfig.PerContextFn {
+	return func(clusterInfo *cluster.Info, namespace string, status reporter.Interface) error {
+		if err := Network(clusterInfo, namespace, status); err != nil || !checkConflicts {
+			return err
+		}
+
+		return checkCIDRConflicts(clusterInfo, status)
+	}
+}
+
 func Network(clusterInfo *cluster.Info, _ string, status reporter.Interface) error {
 	status.Start("Showing Network details")
 
-	var clusterNetwork *network.ClusterNetwork
-	var msg string
-	var err error
-
 	if clusterInfo.Submariner != nil {
-		msg = "    Discovered network details via Submariner:"
-		clusterNetwork = &network.ClusterNetwork{
-			PodCIDRs:         []string{clusterInfo.Submariner.Status.ClusterCIDR},
-			ServiceCIDRs:     []string{clusterInfo.Submariner.Status.ServiceCIDR},
-			NetworkPlugin:    clusterInfo.Submariner.Status.NetworkPlugin,
-			GlobalCIDR:       clusterInfo.Submariner.Status.GlobalCIDR,
-			ClustersetIPCIDR: clusterInfo.Submariner.Status.ClustersetIPCIDR,
-		}
-	} else {
-		msg = "    Discovered network details"
+		status.End()
+		showSubmarinerNetwork(clusterInfo, status)
 
-		clusterNetwork, err = network.Discover(context.TODO(), clusterInfo.ClientProducer.ForGeneral(), constants.OperatorNamespace)
-		if err != nil {
-			return status.Error(err, "Error discovering network details for this cluster")
-		}
+		return nil
+	}
+
+	clusterNetwork, err := network.Discover(context.TODO(), clusterInfo.ClientProducer.ForGeneral(), constants.OperatorNamespace)
+	if err != nil {
+		return status.Error(err, "Error discovering network details for this cluster")
 	}
 
 	if clusterNetwork == nil {
@@ -60,9 +88,246 @@ func Network(clusterInfo *cluster.Info, _ string, status reporter.Interface) err
 	status.End()
 
 	if clusterNetwork != nil {
-		fmt.Println(msg)
+		fmt.Println("    Discovered network details")
 		clusterNetwork.Show()
 	}
 
+	if clusterInfo.ServiceDiscovery != nil {
+		fmt.Printf("    Service discovery DNS domains: %v\n", dns.ServiceDiscoveryDomains(clusterInfo.ServiceDiscovery))
+	}
+
+	return nil
+}
+
+// showSubmarinerNetwork shows the CIDRs used by Submariner, along with whether each one was specified when joining or
+// discovered by the operator, and checks them against the CIDRs configured in the cluster.
+func showSubmarinerNetwork(clusterInfo *cluster.Info, status reporter.Interface) {
+	spec := &clusterInfo.Submariner.Spec
+	current := &clusterInfo.Submariner.Status
+
+	fmt.Println("    Discovered network details via Submariner:")
+	fmt.Printf("        Network plugin:     %s\n", current.NetworkPlugin)
+	fmt.Printf("        Service CIDRs:      %v (%s)\n", []string{current.ServiceCIDR}, cidrSource(spec.ServiceCIDR))
+	fmt.Printf("        Cluster CIDRs:      %v (%s)\n", []string{current.ClusterCIDR}, cidrSource(spec.ClusterCIDR))
+
+	if current.GlobalCIDR != "" {
+		fmt.Printf("        Global CIDR:        %s (%s)\n", current.GlobalCIDR, cidrSource(spec.GlobalCIDR))
+	}
+
+	if current.ClustersetIPCIDR != "" {
+		fmt.Printf("        ClustersetIP CIDR:  %s (%s)\n", current.ClustersetIPCIDR, cidrSource(spec.ClustersetIPCIDR))
+	}
+
+	if clusterInfo.ServiceDiscovery != nil {
+		fmt.Printf("        DNS domains:        %v\n", dns.ServiceDiscoveryDomains(clusterInfo.ServiceDiscovery))
+	}
+
+	tracker := reporter.NewTracker(status)
+
+	tracker.Start("Checking the Submariner CIDRs against the cluster configuration")
+	defer tracker.End()
+
+	warnIfSpecDiffers("service", spec.ServiceCIDR, current.ServiceCIDR, tracker)
+	warnIfSpecDiffers("cluster", spec.ClusterCIDR, current.ClusterCIDR, tracker)
+	warnIfSpecDiffers("global", spec.GlobalCIDR, current.GlobalCIDR, tracker)
+	warnIfSpecDiffers("clusterset IP", spec.ClustersetIPCIDR, current.ClustersetIPCIDR, tracker)
+
+	live := discoverLiveNetwork(clusterInfo)
+
+	if live.serviceCIDRs == nil && live.podCIDRs == nil {
+		tracker.Warning("Unable to determine the CIDRs configured in the cluster, they can't be checked")
+		return
+	}
+
+	warnIfLiveDiffers("service", current.ServiceCIDR, live.serviceCIDRs, live.source, tracker)
+	warnIfLiveDiffers("cluster", current.ClusterCIDR, live.podCIDRs, live.source, tracker)
+
+	if !tracker.HasWarnings() {
+		tracker.Success("The Submariner CIDRs match the cluster configuration (from %s)", live.source)
+	}
+}
+
+func cidrSource(specified string) string {
+	if specified != "" {
+		return userSpecified
+	}
+
+	return autoDiscovered
+}
+
+func warnIfSpecDiffers(kind, specified, current string, status reporter.Interface) {
+	if specified != "" && current != "" && specified != current {
+		status.Warning("The %s CIDR specified in the Submariner spec (%s) differs from the one in use (%s)", kind, specified, current)
+	}
+}
+
+func warnIfLiveDiffers(kind, current string, live []string, source string, status reporter.Interface) {
+	if current == "" || live == nil || set.New(live...).Has(current) {
+		return
+	}
+
+	status.Warning("The %s CIDR used by Submariner (%s) doesn't match the one configured in the cluster (%s, from %s);"+
+		" routes to this cluster will be incorrect", kind, current, strings.Join(live, ","), source)
+}
+
+// discoverLiveNetwork determines the CIDRs configured in the cluster, preferring the kubeadm configuration, then the
+// kube-apiserver and kube-controller-manager parameters, and finally probing the API server with an invalid Service.
+func discoverLiveNetwork(clusterInfo *cluster.Info) liveNetwork {
+	if live, ok := networkFromKubeadmConfig(clusterInfo); ok {
+		return live
+	}
+
+	live := liveNetwork{source: "the control plane pods"}
+	client := clusterInfo.ClientProducer.ForGeneral()
+
+	serviceCIDRs, err := network.FindPodCommandParameter(context.TODO(), client, "component=kube-apiserver", "--service-cluster-ip-range")
+	if err == nil && serviceCIDRs != "" {
+		live.serviceCIDRs = strings.Split(serviceCIDRs, ",")
+	}
+
+	podCIDRs, err := network.FindPodCommandParameter(context.TODO(), client, "component=kube-controller-manager", "--cluster-cidr")
+	if err == nil && podCIDRs != "" {
+		live.podCIDRs = strings.Split(podCIDRs, ",")
+	}
+
+	if live.serviceCIDRs == nil {
+		if serviceCIDRs := serviceCIDRsFromAllocationProbe(clusterInfo); serviceCIDRs != nil {
+			live.serviceCIDRs = serviceCIDRs
+			live.source = "a Service allocation probe"
+		}
+	}
+
+	return live
+}
+
+type kubeadmClusterConfiguration struct {
+	Networking struct {
+		ServiceSubnet string `json:"serviceSubnet"`
+		PodSubnet     string `json:"podSubnet"`
+	} `json:"networking"`
+}
+
+func networkFromKubeadmConfig(clusterInfo *cluster.Info) (liveNetwork, bool) {
+	configMap, err := clusterInfo.ClientProducer.ForKubernetes().CoreV1().ConfigMaps(metav1.NamespaceSystem).Get(context.TODO(),
+		"kubeadm-config", metav1.GetOptions{})
+	if err != nil {
+		return liveNetwork{}, false
+	}
+
+	config := kubeadmClusterConfiguration{}
+	if err := yaml.Unmarshal([]byte(configMap.Data["ClusterConfiguration"]), &config); err != nil {
+		return liveNetwork{}, false
+	}
+
+	live := liveNetwork{source: "the kubeadm-config ConfigMap"}
+
+	if config.Networking.ServiceSubnet != "" {
+		live.serviceCIDRs = strings.Split(config.Networking.ServiceSubnet, ",")
+	}
+
+	if config.Networking.PodSubnet != "" {
+		live.podCIDRs = strings.Split(config.Networking.PodSubnet, ",")
+	}
+
+	return live, live.serviceCIDRs != nil || live.podCIDRs != nil
+}
+
+var validServiceIPsRegexp = regexp.MustCompile(`valid IPs is (\S+)`)
+
+// serviceCIDRsFromAllocationProbe tries to create a Service with an IP outside any valid range, in dry-run mode; the API server
+// then reports the valid range in its error.
+func serviceCIDRsFromAllocationProbe(clusterInfo *cluster.Info) []string {
+	_, err := clusterInfo.ClientProducer.ForKubernetes().CoreV1().Services(metav1.NamespaceDefault).Create(context.TODO(),
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "submariner-cidr-probe"},
+			Spec: corev1.ServiceSpec{
+				ClusterIP: "1.1.1.1",
+				Ports:     []corev1.ServicePort{{Port: 443}},
+			},
+		}, metav1.CreateOptions{DryRun: []string{metav1.DryRunAll}})
+	if !apierrors.IsInvalid(err) {
+		return nil
+	}
+
+	match := validServiceIPsRegexp.FindStringSubmatch(err.Error())
+	if match == nil {
+		return nil
+	}
+
+	return strings.Split(match[1], ",")
+}
+
+// checkCIDRConflicts verifies that the local CIDRs don't overlap with the subnets advertised by the other clusters'
+// Endpoints on the broker. With Globalnet, only the global CIDR needs to be unique.
+func checkCIDRConflicts(clusterInfo *cluster.Info, status reporter.Interface) error {
+	status.Start("Checking the local CIDRs against the other clusters joined to the broker")
+	defer status.End()
+
+	if clusterInfo.Submariner == nil {
+		status.Warning("Submariner connectivity isn't installed, the broker can't be determined to check for CIDR conflicts")
+		return nil
+	}
+
+	brokerRestConfig, brokerNamespace, err := restconfig.ForBroker(clusterInfo.Submariner, nil)
+	if err != nil {
+		return status.Error(err, "Error getting the broker's REST config")
+	}
+
+	brokerProducer, err := client.NewProducerFromRestConfig(brokerRestConfig)
+	if err != nil {
+		return status.Error(err, "Error creating the broker client producer")
+	}
+
+	endpoints := &submarinerv1.EndpointList{}
+
+	err = brokerProducer.ForGeneral().List(context.TODO(), endpoints, controllerClient.InNamespace(brokerNamespace))
+	if err != nil {
+		return status.Error(err, "Error listing the Endpoints on the broker")
+	}
+
+	current := &clusterInfo.Submariner.Status
+
+	localCIDRs := map[string]string{
+		"service": current.ServiceCIDR,
+		"cluster": current.ClusterCIDR,
+	}
+
+	if current.GlobalCIDR != "" {
+		localCIDRs = map[string]string{"global": current.GlobalCIDR}
+	}
+
+	tracker := reporter.NewTracker(status)
+
+	for i := range endpoints.Items {
+		remote := &endpoints.Items[i].Spec
+		if remote.ClusterID == clusterInfo.Submariner.Spec.ClusterID {
+			continue
+		}
+
+		for _, kind := range []string{"global", "service", "cluster"} {
+			localCIDR := localCIDRs[kind]
+			if localCIDR == "" {
+				continue
+			}
+
+			overlap, err := cidr.IsOverlapping(remote.Subnets, localCIDR)
+			if err != nil {
+				tracker.Failure("Error checking the %s CIDR %q against cluster %q: %v", kind, localCIDR, remote.ClusterID, err)
+				continue
+			}
+
+			if overlap {
+				tracker.Failure("The local %s CIDR %q overlaps with cluster %q (subnets: %v)", kind, localCIDR, remote.ClusterID,
+					remote.Subnets)
+			}
+		}
+	}
+
+	if tracker.HasFailures() {
+		return errors.New("the local CIDRs conflict with other clusters")
+	}
+
+	status.Success("The local CIDRs don't overlap with any of the other clusters joined to the broker")
+
 	return nil
 }
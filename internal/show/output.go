@@ -0,0 +1,122 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package show
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/submariner-io/admiral/pkg/reporter"
+	"github.com/submariner-io/subctl/internal/restconfig"
+	"github.com/submariner-io/subctl/pkg/cluster"
+	submv1 "github.com/submariner-io/submariner/pkg/apis/submariner.io/v1"
+)
+
+type OutputFormat string
+
+const (
+	TableOutput OutputFormat = "table"
+	DotOutput   OutputFormat = "dot"
+	JSONOutput  OutputFormat = "json"
+)
+
+// ParseOutputFormat checks that the given output format is one of the supported formats.
+func ParseOutputFormat(output string, supported ...OutputFormat) (OutputFormat, error) {
+	for _, format := range supported {
+		if string(format) == output {
+			return format, nil
+		}
+	}
+
+	return "", fmt.Errorf("unsupported output format %q, the supported formats are %q", output, supported)
+}
+
+// reportUnavailableAs reports an unreachable cluster in the given output format; with DOT, only the warning is reported,
+// so the output can still be piped into Graphviz.
+func reportUnavailableAs(clusterInfo *cluster.Info, err error, output OutputFormat, status reporter.Interface) error {
+	if output != DotOutput {
+		return reportUnavailable(clusterInfo, err, status)
+	}
+
+	status.Warning("%s", err)
+
+	return nil
+}
+
+// EndpointsWithOutput returns a function showing the endpoints in the given format.
+func EndpointsWithOutput(output OutputFormat) restconfig.PerContextFn {
+	return func(clusterInfo *cluster.Info, _ string, status reporter.Interface) error {
+		return showEndpoints(clusterInfo, output, status)
+	}
+}
+
+// GatewaysWithOutput returns a function showing the gateways in the given format; if haStatus isn't empty, only the
+// gateways with that HA status are shown.
+func GatewaysWithOutput(output OutputFormat, haStatus submv1.HAStatus) restconfig.PerContextFn {
+	return func(clusterInfo *cluster.Info, _ string, status reporter.Interface) error {
+		return showGateways(clusterInfo, output, haStatus, status)
+	}
+}
+
+// dotGraph accumulates the nodes and edges of a directed graph in the Graphviz DOT language, preserving their order.
+type dotGraph struct {
+	name  string
+	nodes []string
+	ids   map[string]bool
+	edges []string
+}
+
+func newDotGraph(name string) *dotGraph {
+	return &dotGraph{name: name, ids: map[string]bool{}}
+}
+
+func (g *dotGraph) addNode(id string, labelLines ...string) {
+	if g.ids[id] {
+		return
+	}
+
+	g.ids[id] = true
+	g.nodes = append(g.nodes, fmt.Sprintf("  %s [label=%s];", dotQuote(id), dotQuote(strings.Join(labelLines, "\n"))))
+}
+
+func (g *dotGraph) addEdge(from, to, label string) {
+	g.edges = append(g.edges, fmt.Sprintf("  %s -> %s [label=%s];", dotQuote(from), dotQuote(to), dotQuote(label)))
+}
+
+// dotEscaper escapes strings for DOT: only backslashes and double quotes need escaping, and line breaks are written as
+// the \n label escape.
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func dotQuote(value string) string {
+	return `"` + dotEscaper.Replace(value) + `"`
+}
+
+func (g *dotGraph) print() {
+	fmt.Printf("digraph %s {\n", dotQuote(g.name))
+
+	for _, node := range g.nodes {
+		fmt.Println(node)
+	}
+
+	for _, edge := range g.edges {
+		fmt.Println(edge)
+	}
+
+	fmt.Println("}")
+}
@@ -0,0 +1,196 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package show
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/submariner-io/subctl/internal/constants"
+	"github.com/submariner-io/subctl/pkg/cluster"
+	"github.com/submariner-io/subctl/pkg/version"
+	"github.com/submariner-io/submariner-operator/pkg/images"
+	submv1 "github.com/submariner-io/submariner/pkg/apis/submariner.io/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	controllerClient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// The points awarded by ScoreCluster to each aspect of the cluster's health.
+const (
+	connectionsScore = 40
+	restartsScore    = 20
+	gatewayHAScore   = 20
+	versionsScore    = 20
+	// Each pod restart loses this many points, up to restartsScore
+	pointsPerRestart = 2
+)
+
+// ClusterSummary holds the data the health score of a cluster is based on.
+type ClusterSummary struct {
+	// ExpectedConnections is the number of remote clusters, ActiveConnections the number of those which are connected
+	ExpectedConnections int `json:"expectedConnections"`
+	ActiveConnections   int `json:"activeConnections"`
+	// PodRestarts is the total number of container restarts in the Submariner pods
+	PodRestarts int `json:"podRestarts"`
+	// ActiveGateways is the number of Gateways whose HA status is active, out of Gateways
+	ActiveGateways int `json:"activeGateways"`
+	Gateways       int `json:"gateways"`
+	// OutdatedComponents is the number of components older than subctl, out of Components
+	OutdatedComponents int `json:"outdatedComponents"`
+	Components         int `json:"components"`
+}
+
+// ScoreCluster computes a health score between 0 and 100 for the summarized cluster: 40 points for the established
+// connections, 20 points for pods not restarting, 20 points for a single active gateway, and 20 points for the
+// components being up to date.
+func ScoreCluster(summary *ClusterSummary) int {
+	score := connectionsScore
+	if summary.ExpectedConnections > 0 {
+		score = connectionsScore * min(summary.ActiveConnections, summary.ExpectedConnections) / summary.ExpectedConnections
+	}
+
+	score += max(restartsScore-pointsPerRestart*summary.PodRestarts, 0)
+
+	switch {
+	case summary.ActiveGateways == 1:
+		score += gatewayHAScore
+	case summary.ActiveGateways > 1:
+		// Multiple active gateways are better than none, but indicate that the HA election is confused
+		score += gatewayHAScore / 2
+	}
+
+	if summary.Components > 0 {
+		score += versionsScore * (summary.Components - summary.OutdatedComponents) / summary.Components
+	} else {
+		score += versionsScore
+	}
+
+	return score
+}
+
+func summarizeCluster(clusterInfo *cluster.Info) (*ClusterSummary, error) {
+	summary := &ClusterSummary{}
+
+	gateways, err := clusterInfo.GetGateways()
+	if err != nil {
+		return nil, errors.Wrap(err, "error retrieving the Gateways")
+	}
+
+	summary.Gateways = len(gateways)
+
+	for i := range gateways {
+		if gateways[i].Status.HAStatus != submv1.HAStatusActive {
+			continue
+		}
+
+		summary.ActiveGateways++
+
+		for j := range gateways[i].Status.Connections {
+			if gateways[i].Status.Connections[j].Status == submv1.Connected {
+				summary.ActiveConnections++
+			}
+		}
+	}
+
+	endpoints := &submv1.EndpointList{}
+
+	err = cluster.RetryOnTransientError(func() error {
+		return clusterInfo.ClientProducer.ForGeneral().List(context.TODO(), endpoints,
+			controllerClient.InNamespace(constants.OperatorNamespace))
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing the Endpoints")
+	}
+
+	remoteClusters := map[string]bool{}
+
+	for i := range endpoints.Items {
+		if endpoints.Items[i].Spec.ClusterID != clusterInfo.Submariner.Spec.ClusterID {
+			remoteClusters[endpoints.Items[i].Spec.ClusterID] = true
+		}
+	}
+
+	summary.ExpectedConnections = len(remoteClusters)
+
+	if err := summarizePods(clusterInfo, summary); err != nil {
+		return nil, err
+	}
+
+	return summary, summarizeComponentVersions(clusterInfo, summary)
+}
+
+func summarizePods(clusterInfo *cluster.Info, summary *ClusterSummary) error {
+	podList, err := clusterInfo.ClientProducer.ForKubernetes().CoreV1().Pods(constants.OperatorNamespace).List(
+		context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrap(err, "error listing the Submariner pods")
+	}
+
+	for i := range podList.Items {
+		for j := range podList.Items[i].Status.ContainerStatuses {
+			summary.PodRestarts += int(podList.Items[i].Status.ContainerStatuses[j].RestartCount)
+		}
+	}
+
+	return nil
+}
+
+// summarizeComponentVersions counts the components deployed with an image older than subctl's release; the versions
+// are only compared if subctl is a release build.
+func summarizeComponentVersions(clusterInfo *cluster.Info, summary *ClusterSummary) error {
+	componentImages := []string{}
+	apps := clusterInfo.ClientProducer.ForKubernetes().AppsV1()
+
+	daemonSets, err := apps.DaemonSets(constants.OperatorNamespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrap(err, "error listing the Submariner DaemonSets")
+	}
+
+	for i := range daemonSets.Items {
+		componentImages = append(componentImages, daemonSets.Items[i].Spec.Template.Spec.Containers[0].Image)
+	}
+
+	deployments, err := apps.Deployments(constants.OperatorNamespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrap(err, "error listing the Submariner Deployments")
+	}
+
+	for i := range deployments.Items {
+		componentImages = append(componentImages, deployments.Items[i].Spec.Template.Spec.Containers[0].Image)
+	}
+
+	summary.Components = len(componentImages)
+
+	subctlVersion := version.Parse(version.Version)
+	if subctlVersion == nil {
+		return nil
+	}
+
+	for _, image := range componentImages {
+		imageVersion, _ := images.ParseOperatorImage(image)
+
+		parsed := version.Parse(imageVersion)
+		if parsed != nil && (parsed.Major < subctlVersion.Major ||
+			(parsed.Major == subctlVersion.Major && parsed.Minor < subctlVersion.Minor)) {
+			summary.OutdatedComponents++
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package show_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/subctl/internal/show"
+)
+
+// healthy returns the summary of a cluster which gets the full score, modified by the given function.
+func healthy(modify func(summary *show.ClusterSummary)) *show.ClusterSummary {
+	summary := &show.ClusterSummary{
+		ExpectedConnections: 2,
+		ActiveConnections:   2,
+		ActiveGateways:      1,
+		Gateways:            2,
+		Components:          4,
+	}
+
+	modify(summary)
+
+	return summary
+}
+
+var _ = DescribeTable("ScoreCluster",
+	func(summary *show.ClusterSummary, expectedScore int) {
+		Expect(show.ScoreCluster(summary)).To(Equal(expectedScore))
+	},
+	Entry("gives the full score to a healthy cluster", healthy(func(_ *show.ClusterSummary) {}), 100),
+	Entry("gives 40 points to the connections, in proportion to the active ones", healthy(func(s *show.ClusterSummary) {
+		s.ActiveConnections = 1
+	}), 80),
+	Entry("gives no connection points when none is active", healthy(func(s *show.ClusterSummary) {
+		s.ActiveConnections = 0
+	}), 60),
+	Entry("doesn't give more than 40 points to more active connections than expected", healthy(func(s *show.ClusterSummary) {
+		s.ActiveConnections = 3
+	}), 100),
+	Entry("gives the connection points when no connections are expected", healthy(func(s *show.ClusterSummary) {
+		s.ExpectedConnections = 0
+		s.ActiveConnections = 0
+	}), 100),
+	Entry("takes 2 of the 20 restart points per pod restart", healthy(func(s *show.ClusterSummary) {
+		s.PodRestarts = 3
+	}), 94),
+	Entry("takes no more than the 20 restart points", healthy(func(s *show.ClusterSummary) {
+		s.PodRestarts = 50
+	}), 80),
+	Entry("gives half of the 20 gateway points to multiple active gateways", healthy(func(s *show.ClusterSummary) {
+		s.ActiveGateways = 2
+	}), 90),
+	Entry("gives no gateway points when no gateway is active", healthy(func(s *show.ClusterSummary) {
+		s.ActiveGateways = 0
+	}), 80),
+	Entry("gives 20 points to the components, in proportion to the up-to-date ones", healthy(func(s *show.ClusterSummary) {
+		s.OutdatedComponents = 1
+	}), 95),
+	Entry("gives the component points when there are no components", healthy(func(s *show.ClusterSummary) {
+		s.Components = 0
+	}), 100),
+	Entry("gives no points to a cluster failing on all counts", &show.ClusterSummary{
+		ExpectedConnections: 2,
+		PodRestarts:         100,
+		Gateways:            1,
+		OutdatedComponents:  3,
+		Components:          3,
+	}, 0),
+)
@@ -0,0 +1,158 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package show
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/submariner-io/admiral/pkg/names"
+	"github.com/submariner-io/admiral/pkg/reporter"
+	"github.com/submariner-io/subctl/internal/restconfig"
+	"github.com/submariner-io/subctl/pkg/cluster"
+	"github.com/submariner-io/submariner-operator/api/v1alpha1"
+	"github.com/submariner-io/submariner-operator/pkg/images"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// brokerVersionTimeout bounds the time taken to retrieve the broker's version, so that an unreachable broker doesn't
+// hold up the report.
+const brokerVersionTimeout = 10 * time.Second
+
+// ServerVersion prints a single line with the versions of the operator and Submariner deployed in the cluster, how they
+// compare to subctl's, and the Kubernetes version of the broker cluster if it's reachable. Like the version check
+// performed by the other commands, an error is returned if the deployed Submariner is newer than subctl.
+func ServerVersion(clusterInfo *cluster.Info, _ string, status reporter.Interface) error {
+	status.Start("Retrieving the deployed versions")
+
+	submariner, err := clusterInfo.GetSubmariner()
+	if cluster.IsUnreachable(err) {
+		return reportUnavailable(clusterInfo, err, status)
+	}
+
+	if err != nil {
+		return status.Error(err, "Error retrieving the Submariner resource")
+	}
+
+	serviceDiscovery, err := clusterInfo.GetServiceDiscovery()
+	if err != nil {
+		return status.Error(err, "Error retrieving the ServiceDiscovery resource")
+	}
+
+	operatorVersion, err := getOperatorVersion(clusterInfo)
+	if err != nil {
+		return status.Error(err, "Error retrieving the operator version")
+	}
+
+	deployedVersion := ""
+	if submariner != nil {
+		deployedVersion = submariner.Spec.Version
+	} else if serviceDiscovery != nil {
+		deployedVersion = serviceDiscovery.Spec.Version
+	}
+
+	brokerVersion := getBrokerVersion(submariner, serviceDiscovery)
+
+	status.End()
+
+	submarinerVersion := "none"
+	if deployedVersion != "" {
+		submarinerVersion = deployedVersion + " (" + compareWithSubctl(deployedVersion) + ")"
+	}
+
+	fmt.Printf("Cluster %q: operator %s, Submariner %s, broker %s\n", clusterInfo.Name, orNone(operatorVersion),
+		submarinerVersion, brokerVersion)
+
+	if err := restconfig.CheckVersionMismatch(deployedVersion); err != nil {
+		return status.Error(err, "")
+	}
+
+	return nil
+}
+
+func getOperatorVersion(clusterInfo *cluster.Info) (string, error) {
+	deployment, err := clusterInfo.ClientProducer.ForKubernetes().AppsV1().Deployments(clusterInfo.OperatorNamespace()).Get(
+		context.TODO(), names.OperatorComponent, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", errors.Wrapf(err, "error retrieving the %s Deployment", names.OperatorComponent)
+	}
+
+	version, _ := images.ParseOperatorImage(deployment.Spec.Template.Spec.Containers[0].Image)
+
+	return version, nil
+}
+
+// getBrokerVersion returns the Kubernetes version of the broker the cluster is joined to.
+func getBrokerVersion(submariner *v1alpha1.Submariner, serviceDiscovery *v1alpha1.ServiceDiscovery) string {
+	if submariner == nil && serviceDiscovery == nil {
+		return "none"
+	}
+
+	brokerRestConfig, _, err := restconfig.ForBroker(submariner, serviceDiscovery)
+	if err != nil || brokerRestConfig == nil {
+		return "unreachable"
+	}
+
+	brokerRestConfig = rest.CopyConfig(brokerRestConfig)
+	brokerRestConfig.Timeout = brokerVersionTimeout
+
+	brokerClient, err := kubernetes.NewForConfig(brokerRestConfig)
+	if err != nil {
+		return "unreachable"
+	}
+
+	serverVersion, err := brokerClient.Discovery().ServerVersion()
+	if err != nil {
+		return "unreachable"
+	}
+
+	return "Kubernetes " + serverVersion.GitVersion
+}
+
+func compareWithSubctl(deployedVersion string) string {
+	comparison, ok := restconfig.CompareSubctlVersion(deployedVersion)
+
+	switch {
+	case !ok:
+		return "unknown"
+	case comparison < 0:
+		return "newer than subctl"
+	case comparison > 0:
+		return "older than subctl"
+	default:
+		return "same as subctl"
+	}
+}
+
+func orNone(value string) string {
+	if value == "" {
+		return "none"
+	}
+
+	return value
+}
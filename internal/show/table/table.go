@@ -20,7 +20,18 @@ package table
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"strings"
+	"unicode/utf8"
+
+	"github.com/submariner-io/subctl/internal/env"
+)
+
+// Color codes which can be returned by a column's Color function.
+const (
+	Green  = "32"
+	Yellow = "33"
 )
 
 type Column struct {
@@ -28,6 +39,10 @@ type Column struct {
 
 	// Maximum column length (if unspecified then output won't be truncated)
 	MaxLength int
+
+	// Color returns the color code to print the given value with, or an empty string to print it uncolored; the values are
+	// only colored when the output is a smart terminal
+	Color func(value string) string
 }
 
 type Printer struct {
@@ -67,27 +82,56 @@ func (p *Printer) Empty() bool {
 	return len(p.rows) == 0
 }
 
+// Lines returns the number of lines Print outputs, including the header.
+func (p *Printer) Lines() int {
+	if p.Empty() {
+		return 0
+	}
+
+	return len(p.rows) + 1
+}
+
 // Print out the table; if it's empty then nothing gets printed.
 func (p *Printer) Print() {
+	p.Fprint(os.Stdout)
+}
+
+// Fprint prints the table to the given writer; if it's empty then nothing gets printed.
+func (p *Printer) Fprint(w io.Writer) {
 	if p.Empty() {
 		return
 	}
 
-	template := p.initTemplate()
-	printRow(template, p.columnNames())
+	columnLengths := p.findColumnLengths()
+	p.printRow(w, columnLengths, p.columnNames(), false)
+
+	colored := env.IsSmartTerminal(w)
 
 	for _, row := range p.rows {
-		printRow(template, row)
+		p.printRow(w, columnLengths, row, colored)
 	}
 }
 
-func printRow(template string, row []string) {
-	values := make([]interface{}, len(row))
-	for i, v := range row {
-		values[i] = v
+func (p *Printer) printRow(w io.Writer, columnLengths []int, row []string, colored bool) {
+	line := ""
+
+	for i, value := range row {
+		var color string
+		if colored && p.Columns[i].Color != nil {
+			color = p.Columns[i].Color(value)
+		}
+
+		if color == "" {
+			line += fmt.Sprintf("%-*.*s", columnLengths[i]+3, columnLengths[i], value)
+			continue
+		}
+
+		// The escape codes don't take any room, so the padding is added separately
+		text := fmt.Sprintf("%.*s", columnLengths[i], value)
+		line += "\x1b[" + color + "m" + text + "\x1b[0m" + strings.Repeat(" ", columnLengths[i]+3-utf8.RuneCountInString(text))
 	}
 
-	fmt.Printf(template, values...)
+	fmt.Fprintln(w, line)
 }
 
 func (p *Printer) columnNames() []string {
@@ -99,17 +143,6 @@ func (p *Printer) columnNames() []string {
 	return columns
 }
 
-func (p *Printer) initTemplate() string {
-	columnLengths := p.findColumnLengths()
-
-	sprintfTemplate := ""
-	for _, length := range columnLengths {
-		sprintfTemplate += fmt.Sprintf("%%-%d.%ds", length+3, length)
-	}
-
-	return sprintfTemplate + "\n"
-}
-
 func (p *Printer) findColumnLengths() []int {
 	columnLengths := make([]int, len(p.Columns))
 	for i, column := range p.Columns {
@@ -0,0 +1,42 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package show
+
+import (
+	"github.com/submariner-io/admiral/pkg/reporter"
+	"github.com/submariner-io/subctl/internal/show/table"
+	"github.com/submariner-io/subctl/pkg/cluster"
+)
+
+// reportUnavailable prints a clearly marked row in place of the cluster's data when the API server couldn't be reached
+// within the retry window, instead of failing; this allows multi-cluster runs and pollers to carry on.
+func reportUnavailable(clusterInfo *cluster.Info, err error, status reporter.Interface) error {
+	status.Warning("%s", err)
+	status.End()
+
+	printer := table.Printer{Columns: []table.Column{
+		{Name: "CLUSTER", MaxLength: 24},
+		{Name: "STATUS"},
+	}}
+
+	printer.Add(clusterInfo.Name, cluster.UnreachableMessage)
+	printer.Print()
+
+	return nil
+}
@@ -32,6 +32,7 @@ import (
 	"github.com/submariner-io/subctl/internal/show/table"
 	"github.com/submariner-io/subctl/pkg/cluster"
 	"github.com/submariner-io/submariner-operator/pkg/images"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -55,7 +56,14 @@ func printDaemonSetVersions(clusterInfo *cluster.Info, printer *table.Printer, c
 	daemonSets := clusterInfo.ClientProducer.ForKubernetes().AppsV1().DaemonSets(constants.OperatorNamespace)
 
 	for _, component := range components {
-		daemonSet, err := daemonSets.Get(context.TODO(), component, metav1.GetOptions{})
+		var daemonSet *appsv1.DaemonSet
+
+		err := cluster.RetryOnTransientError(func() error {
+			var err error
+			daemonSet, err = daemonSets.Get(context.TODO(), component, metav1.GetOptions{})
+
+			return err //nolint:wrapcheck // Wrapped below.
+		})
 		if err != nil {
 			if apierrors.IsNotFound(err) {
 				continue
@@ -83,7 +91,14 @@ func printDeploymentVersions(clusterInfo *cluster.Info, printer *table.Printer,
 	deployments := clusterInfo.ClientProducer.ForKubernetes().AppsV1().Deployments(constants.OperatorNamespace)
 
 	for _, component := range components {
-		deployment, err := deployments.Get(context.TODO(), component, metav1.GetOptions{})
+		var deployment *appsv1.Deployment
+
+		err := cluster.RetryOnTransientError(func() error {
+			var err error
+			deployment, err = deployments.Get(context.TODO(), component, metav1.GetOptions{})
+
+			return err //nolint:wrapcheck // Wrapped below.
+		})
 		if err != nil {
 			if apierrors.IsNotFound(err) {
 				continue
@@ -108,8 +123,15 @@ func printDeploymentVersions(clusterInfo *cluster.Info, printer *table.Printer,
 
 func getVersionAndArchForComponent(clusterInfo *cluster.Info, component string, labelSelector labels.Selector) (string, string, error) {
 	podsClient := clusterInfo.ClientProducer.ForKubernetes().CoreV1().Pods(constants.OperatorNamespace)
-	podList, err := podsClient.List(context.TODO(), metav1.ListOptions{LabelSelector: labelSelector.String()})
 
+	var podList *corev1.PodList
+
+	err := cluster.RetryOnTransientError(func() error {
+		var err error
+		podList, err = podsClient.List(context.TODO(), metav1.ListOptions{LabelSelector: labelSelector.String()})
+
+		return err //nolint:wrapcheck // Wrapped below.
+	})
 	if err != nil || len(podList.Items) < 1 {
 		return "", "", errors.Wrapf(err, "failed to find pods for component %s", component)
 	}
@@ -217,12 +239,20 @@ func Versions(clusterInfo *cluster.Info, _ string, status reporter.Interface) er
 
 	err := printDaemonSetVersions(clusterInfo, &printer, names.GatewayComponent, names.RouteAgentComponent, names.GlobalnetComponent,
 		names.MetricsProxyComponent)
+	if cluster.IsUnreachable(err) {
+		return reportUnavailable(clusterInfo, err, status)
+	}
+
 	if err != nil {
 		return status.Error(err, "Error retrieving DaemonSet versions")
 	}
 
 	err = printDeploymentVersions(
 		clusterInfo, &printer, names.OperatorComponent, names.ServiceDiscoveryComponent, names.LighthouseCoreDNSComponent)
+	if cluster.IsUnreachable(err) {
+		return reportUnavailable(clusterInfo, err, status)
+	}
+
 	if err != nil {
 		return status.Error(err, "Error retrieving Deployment versions")
 	}
@@ -0,0 +1,272 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package upload sends files, such as gathered troubleshooting data, to pre-signed upload URLs.
+package upload
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultRetries    = 3
+	DefaultRetryDelay = 2 * time.Second
+)
+
+// Methods lists the supported HTTP methods; pre-signed S3 and GCS URLs use PUT.
+var Methods = []string{http.MethodPut, http.MethodPost}
+
+type Options struct {
+	// Method is the HTTP method to upload with, PUT if empty
+	Method string
+	// Retries is the number of times an upload failing with a 5xx status is retried
+	Retries int
+	// RetryDelay is the delay before the first retry, it increases with each retry
+	RetryDelay time.Duration
+	// Progress, if set, is called as the file is sent with the number of bytes sent so far and the file size
+	Progress func(sent, total int64)
+	// Client is the HTTP client to use, http.DefaultClient if nil
+	Client *http.Client
+}
+
+// ValidateURL checks that the given URL can be uploaded to; only HTTPS URLs are allowed unless insecure is true.
+func ValidateURL(rawURL string, insecure bool) error {
+	uploadURL, err := url.Parse(rawURL)
+	if err != nil {
+		return errors.Wrapf(err, "invalid upload URL %q", rawURL)
+	}
+
+	switch uploadURL.Scheme {
+	case "https":
+	case "http":
+		if !insecure {
+			return fmt.Errorf("the upload URL %q doesn't use HTTPS, use --insecure-upload to allow it", uploadURL.Redacted())
+		}
+	default:
+		return fmt.Errorf("unsupported scheme %q in the upload URL, only HTTPS is supported", uploadURL.Scheme)
+	}
+
+	if uploadURL.Host == "" {
+		return fmt.Errorf("the upload URL %q doesn't specify a host", uploadURL.Redacted())
+	}
+
+	return nil
+}
+
+// File uploads the given file to the given URL, retrying on server errors.
+func File(ctx context.Context, uploadURL, path string, options *Options) error {
+	method := options.Method
+	if method == "" {
+		method = http.MethodPut
+	}
+
+	if !slices.Contains(Methods, method) {
+		return fmt.Errorf("unsupported upload method %q, the supported methods are %q", method, Methods)
+	}
+
+	client := options.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	delay := options.RetryDelay
+
+	for attempt := 0; ; attempt++ {
+		retry, err := sendFile(ctx, client, method, uploadURL, path, options.Progress)
+		if !retry || attempt >= options.Retries {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Wrap(ctx.Err(), "the upload was interrupted")
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+	}
+}
+
+// sendFile sends the file once, and returns true with the error if the server failed in a way which may be transient.
+func sendFile(ctx context.Context, client *http.Client, method, uploadURL, path string, progress func(sent, total int64),
+) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, errors.Wrapf(err, "error opening %q", path)
+	}
+
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return false, errors.Wrapf(err, "error retrieving the size of %q", path)
+	}
+
+	var body io.Reader = file
+	if progress != nil {
+		body = &progressReader{reader: file, total: info.Size(), progress: progress}
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, uploadURL, body)
+	if err != nil {
+		return false, errors.Wrap(err, "error creating the upload request")
+	}
+
+	// Pre-signed URLs require the length to be known, the request can't be chunked
+	request.ContentLength = info.Size()
+	request.Header.Set("Content-Type", "application/octet-stream")
+
+	response, err := client.Do(request)
+	if err != nil {
+		// The error includes the URL, which contains the pre-signed credentials
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+
+		return false, errors.Wrap(err, "error uploading the file")
+	}
+
+	defer response.Body.Close()
+
+	// The response body usually explains the failure, but it can be arbitrarily long
+	message, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+
+	if response.StatusCode >= http.StatusInternalServerError {
+		return true, fmt.Errorf("the upload failed with status %q: %s", response.Status, message)
+	}
+
+	if response.StatusCode >= http.StatusMultipleChoices {
+		return false, fmt.Errorf("the upload was rejected with status %q: %s", response.Status, message)
+	}
+
+	return false, nil
+}
+
+type progressReader struct {
+	reader   io.Reader
+	sent     int64
+	total    int64
+	progress func(sent, total int64)
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.sent += int64(n)
+	r.progress(r.sent, r.total)
+
+	return n, err //nolint:wrapcheck // The reader's errors, including io.EOF, must be returned as-is.
+}
+
+// Archive writes the given subdirectories of the given directory, or all of it if none are given, to a gzipped tarball,
+// with paths relative to the directory's parent so that it extracts to a directory of the same name.
+func Archive(dir, archive string, subdirs ...string) error {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return errors.Wrapf(err, "error determining the absolute path of %q", dir)
+	}
+
+	file, err := os.Create(archive)
+	if err != nil {
+		return errors.Wrapf(err, "error creating %q", archive)
+	}
+
+	gzipWriter := gzip.NewWriter(file)
+	tarWriter := tar.NewWriter(gzipWriter)
+	parent := filepath.Dir(absDir)
+
+	roots := []string{absDir}
+	if len(subdirs) > 0 {
+		roots = make([]string, len(subdirs))
+		for i := range subdirs {
+			roots[i] = filepath.Join(absDir, subdirs[i])
+		}
+	}
+
+	for _, root := range roots {
+		err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			return addToArchive(tarWriter, parent, path, entry)
+		})
+		if err != nil {
+			break
+		}
+	}
+
+	for _, closer := range []io.Closer{tarWriter, gzipWriter, file} {
+		if closeErr := closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+
+	return errors.Wrapf(err, "error archiving %q to %q", dir, archive)
+}
+
+func addToArchive(tarWriter *tar.Writer, parent, path string, entry fs.DirEntry) error {
+	info, err := entry.Info()
+	if err != nil {
+		return err //nolint:wrapcheck // Wrapped by the caller.
+	}
+
+	if !info.Mode().IsRegular() && !info.IsDir() {
+		return nil
+	}
+
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err //nolint:wrapcheck // Wrapped by the caller.
+	}
+
+	header.Name, err = filepath.Rel(parent, path)
+	if err != nil {
+		return err //nolint:wrapcheck // Wrapped by the caller.
+	}
+
+	header.Name = filepath.ToSlash(header.Name)
+
+	if err = tarWriter.WriteHeader(header); err != nil || info.IsDir() {
+		return err //nolint:wrapcheck // Wrapped by the caller.
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err //nolint:wrapcheck // Wrapped by the caller.
+	}
+
+	defer file.Close()
+
+	_, err = io.Copy(tarWriter, file)
+
+	return err //nolint:wrapcheck // Wrapped by the caller.
+}
@@ -0,0 +1,222 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package upload_test
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/subctl/internal/upload"
+)
+
+var _ = Describe("ValidateURL", func() {
+	It("should accept HTTPS URLs", func() {
+		Expect(upload.ValidateURL("https://bucket.s3.amazonaws.com/gather.tar.gz?X-Amz-Signature=abc", false)).To(Succeed())
+	})
+
+	It("should reject HTTP URLs unless insecure uploads are allowed", func() {
+		Expect(upload.ValidateURL("http://uploads.example.com/gather.tar.gz", false)).ToNot(Succeed())
+		Expect(upload.ValidateURL("http://uploads.example.com/gather.tar.gz", true)).To(Succeed())
+	})
+
+	It("should reject other schemes and URLs without a host", func() {
+		Expect(upload.ValidateURL("ftp://uploads.example.com/gather.tar.gz", true)).ToNot(Succeed())
+		Expect(upload.ValidateURL("https:///gather.tar.gz", false)).ToNot(Succeed())
+	})
+})
+
+var _ = Describe("File", func() {
+	const content = "gathered data"
+
+	var (
+		path     string
+		statuses []int
+		received atomic.Value
+		attempts atomic.Int32
+		server   *httptest.Server
+	)
+
+	BeforeEach(func() {
+		path = filepath.Join(GinkgoT().TempDir(), "gather.tar.gz")
+		Expect(os.WriteFile(path, []byte(content), 0o600)).To(Succeed())
+
+		statuses = nil
+		attempts.Store(0)
+		received.Store("")
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempt := int(attempts.Add(1)) - 1
+
+			body, _ := io.ReadAll(r.Body)
+			received.Store(r.Method + " " + string(body))
+
+			if attempt < len(statuses) {
+				w.WriteHeader(statuses[attempt])
+			}
+		}))
+		DeferCleanup(server.Close)
+	})
+
+	uploadFile := func(options *upload.Options) error {
+		return upload.File(context.Background(), server.URL+"/gather.tar.gz", path, options)
+	}
+
+	It("should PUT the file and report the progress", func() {
+		var sent, total int64
+
+		Expect(uploadFile(&upload.Options{Progress: func(s, t int64) {
+			sent, total = s, t
+		}})).To(Succeed())
+		Expect(received.Load()).To(Equal(http.MethodPut + " " + content))
+		Expect(sent).To(Equal(int64(len(content))))
+		Expect(total).To(Equal(int64(len(content))))
+	})
+
+	It("should use the given method", func() {
+		Expect(uploadFile(&upload.Options{Method: http.MethodPost})).To(Succeed())
+		Expect(received.Load()).To(Equal(http.MethodPost + " " + content))
+	})
+
+	When("the server fails transiently", func() {
+		It("should retry the upload", func() {
+			statuses = []int{http.StatusServiceUnavailable, http.StatusInternalServerError}
+
+			Expect(uploadFile(&upload.Options{Retries: 2})).To(Succeed())
+			Expect(attempts.Load()).To(Equal(int32(3)))
+			Expect(received.Load()).To(Equal(http.MethodPut + " " + content))
+		})
+
+		It("should give up after the given number of retries", func() {
+			statuses = []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway}
+
+			Expect(uploadFile(&upload.Options{Retries: 1})).To(MatchError(ContainSubstring("502")))
+			Expect(attempts.Load()).To(Equal(int32(2)))
+		})
+	})
+
+	When("the server rejects the upload", func() {
+		It("should fail without retrying", func() {
+			statuses = []int{http.StatusForbidden}
+
+			Expect(uploadFile(&upload.Options{Retries: 2})).To(MatchError(ContainSubstring("403")))
+			Expect(attempts.Load()).To(Equal(int32(1)))
+		})
+	})
+
+	When("the method isn't supported", func() {
+		It("should fail without uploading", func() {
+			Expect(uploadFile(&upload.Options{Method: http.MethodGet})).ToNot(Succeed())
+			Expect(attempts.Load()).To(BeZero())
+		})
+	})
+})
+
+var _ = Describe("Archive", func() {
+	It("should archive the directory's files under its name", func() {
+		base := GinkgoT().TempDir()
+		dir := filepath.Join(base, "submariner-20240101000000")
+
+		Expect(os.MkdirAll(filepath.Join(dir, "cluster1"), 0o700)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "cluster1", "pods.yaml"), []byte("pods"), 0o600)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "summary.html"), []byte("summary"), 0o600)).To(Succeed())
+
+		archive := dir + ".tar.gz"
+		Expect(upload.Archive(dir, archive)).To(Succeed())
+
+		Expect(archivedFiles(archive)).To(Equal(map[string]string{
+			"submariner-20240101000000/cluster1/pods.yaml": "pods",
+			"submariner-20240101000000/summary.html":       "summary",
+		}))
+	})
+
+	It("should only archive the given subdirectories", func() {
+		dir := filepath.Join(GinkgoT().TempDir(), "gather")
+
+		for _, subdir := range []string{"cluster1", "cluster2", "unrelated"} {
+			Expect(os.MkdirAll(filepath.Join(dir, subdir), 0o700)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, subdir, "pods.yaml"), []byte(subdir), 0o600)).To(Succeed())
+		}
+
+		archive := filepath.Join(dir, "gather.tar.gz")
+		Expect(upload.Archive(dir, archive, "cluster1", "cluster2")).To(Succeed())
+
+		Expect(archivedFiles(archive)).To(Equal(map[string]string{
+			"gather/cluster1/pods.yaml": "cluster1",
+			"gather/cluster2/pods.yaml": "cluster2",
+		}))
+	})
+
+	It("should name the archived paths after the directory when given a relative path", func() {
+		dir := filepath.Join(GinkgoT().TempDir(), "gather")
+		Expect(os.MkdirAll(filepath.Join(dir, "cluster1"), 0o700)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "cluster1", "pods.yaml"), []byte("pods"), 0o600)).To(Succeed())
+
+		archive := filepath.Join(GinkgoT().TempDir(), "gather.tar.gz")
+
+		wd, err := os.Getwd()
+		Expect(err).ToNot(HaveOccurred())
+
+		DeferCleanup(os.Chdir, wd)
+		Expect(os.Chdir(dir)).To(Succeed())
+
+		Expect(upload.Archive(".", archive, "cluster1")).To(Succeed())
+
+		Expect(archivedFiles(archive)).To(Equal(map[string]string{"gather/cluster1/pods.yaml": "pods"}))
+	})
+})
+
+func archivedFiles(archive string) map[string]string {
+	file, err := os.Open(archive)
+	Expect(err).ToNot(HaveOccurred())
+
+	defer file.Close()
+
+	gzipReader, err := gzip.NewReader(file)
+	Expect(err).ToNot(HaveOccurred())
+
+	files := map[string]string{}
+	tarReader := tar.NewReader(gzipReader)
+
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+
+		Expect(err).ToNot(HaveOccurred())
+
+		if header.Typeflag == tar.TypeReg {
+			content, err := io.ReadAll(tarReader)
+			Expect(err).ToNot(HaveOccurred())
+
+			files[header.Name] = string(content)
+		}
+	}
+
+	return files
+}
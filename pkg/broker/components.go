@@ -0,0 +1,97 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package broker
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/submariner-io/subctl/internal/component"
+	"github.com/submariner-io/subctl/internal/constants"
+	"github.com/submariner-io/subctl/pkg/cluster"
+	"github.com/submariner-io/submariner-operator/api/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/set"
+)
+
+// ComponentsAnnotation is set on the broker secret stored in joined clusters; it records the components which were enabled
+// at the broker when the cluster joined.
+const ComponentsAnnotation = "submariner.io/broker-components"
+
+// ComponentsOf returns the components enabled in the given Broker.
+func ComponentsOf(broker *v1alpha1.Broker) set.Set[string] {
+	components := set.New(broker.Spec.Components...)
+
+	if broker.Spec.GlobalnetEnabled {
+		components.Insert(component.Globalnet)
+	}
+
+	return components
+}
+
+// JoinedComponents returns the components which were enabled at the broker when the cluster joined, as recorded in its
+// broker secret; nil is returned if they weren't recorded, typically because the cluster was joined by an older version
+// of subctl.
+func JoinedComponents(ctx context.Context, clusterInfo *cluster.Info) (set.Set[string], error) {
+	namespace := constants.OperatorNamespace
+	secretName := localSecretName(clusterInfo)
+
+	brokerSecret, err := clusterInfo.ClientProducer.ForKubernetes().CoreV1().Secrets(namespace).Get(ctx, secretName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "error retrieving the broker secret %s/%s", namespace, secretName)
+	}
+
+	recorded, ok := brokerSecret.Annotations[ComponentsAnnotation]
+	if !ok {
+		return nil, nil
+	}
+
+	components := set.New[string]()
+
+	for _, name := range strings.Split(recorded, ",") {
+		if name != "" {
+			components.Insert(name)
+		}
+	}
+
+	return components, nil
+}
+
+func localSecretName(clusterInfo *cluster.Info) string {
+	if clusterInfo.Submariner != nil && clusterInfo.Submariner.Spec.BrokerK8sSecret != "" {
+		return clusterInfo.Submariner.Spec.BrokerK8sSecret
+	}
+
+	if clusterInfo.ServiceDiscovery != nil && clusterInfo.ServiceDiscovery.Spec.BrokerK8sSecret != "" {
+		return clusterInfo.ServiceDiscovery.Spec.BrokerK8sSecret
+	}
+
+	return LocalClientBrokerSecretName
+}
+
+// MissingComponents returns the components in required which aren't in available, sorted.
+func MissingComponents(required, available set.Set[string]) []string {
+	return required.Difference(available).SortedList()
+}
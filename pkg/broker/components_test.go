@@ -0,0 +1,106 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package broker_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/subctl/internal/component"
+	"github.com/submariner-io/subctl/internal/constants"
+	"github.com/submariner-io/subctl/pkg/broker"
+	"github.com/submariner-io/subctl/pkg/client"
+	"github.com/submariner-io/subctl/pkg/cluster"
+	"github.com/submariner-io/submariner-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/set"
+)
+
+var _ = Describe("JoinedComponents", func() {
+	var (
+		objects     []runtime.Object
+		clusterInfo *cluster.Info
+	)
+
+	BeforeEach(func() {
+		objects = nil
+	})
+
+	JustBeforeEach(func() {
+		clusterInfo = &cluster.Info{
+			ClientProducer: &client.DefaultProducer{KubeClient: fakeclientset.NewSimpleClientset(objects...)},
+			Submariner:     &v1alpha1.Submariner{},
+		}
+	})
+
+	brokerSecret := func(annotations map[string]string) *corev1.Secret {
+		return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name:        broker.LocalClientBrokerSecretName,
+			Namespace:   constants.OperatorNamespace,
+			Annotations: annotations,
+		}}
+	}
+
+	When("the broker secret records the components", func() {
+		BeforeEach(func() {
+			objects = append(objects, brokerSecret(map[string]string{
+				broker.ComponentsAnnotation: component.Connectivity + "," + component.ServiceDiscovery,
+			}))
+		})
+
+		It("should return them", func() {
+			Expect(broker.JoinedComponents(context.TODO(), clusterInfo)).To(Equal(
+				set.New(component.Connectivity, component.ServiceDiscovery)))
+		})
+	})
+
+	When("the broker secret doesn't record the components", func() {
+		BeforeEach(func() {
+			objects = append(objects, brokerSecret(nil))
+		})
+
+		It("should return nil", func() {
+			Expect(broker.JoinedComponents(context.TODO(), clusterInfo)).To(BeNil())
+		})
+	})
+
+	When("there is no broker secret", func() {
+		It("should return nil", func() {
+			Expect(broker.JoinedComponents(context.TODO(), clusterInfo)).To(BeNil())
+		})
+	})
+})
+
+var _ = Describe("MissingComponents", func() {
+	It("should return the components which aren't available, sorted", func() {
+		brokerResource := &v1alpha1.Broker{Spec: v1alpha1.BrokerSpec{Components: []string{component.Connectivity}}}
+
+		Expect(broker.MissingComponents(set.New(component.ServiceDiscovery, component.Globalnet, component.Connectivity),
+			broker.ComponentsOf(brokerResource))).To(Equal([]string{component.Globalnet, component.ServiceDiscovery}))
+
+		brokerResource.Spec.GlobalnetEnabled = true
+
+		Expect(broker.MissingComponents(set.New(component.Globalnet, component.Connectivity),
+			broker.ComponentsOf(brokerResource))).To(BeEmpty())
+	})
+})
@@ -20,6 +20,7 @@ package broker
 
 import (
 	"context"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/submariner-io/subctl/internal/component"
@@ -41,7 +42,7 @@ import (
 )
 
 func Ensure(ctx context.Context, crdUpdater crd.Updater, kubeClient kubernetes.Interface, componentArr []string, createCRDs bool,
-	brokerNS string,
+	brokerNS string, tokenWaitTimeout time.Duration,
 ) error {
 	if createCRDs {
 		for i := range componentArr {
@@ -75,7 +76,7 @@ func Ensure(ctx context.Context, crdUpdater crd.Updater, kubeClient kubernetes.I
 	}
 
 	// Create administrator SA, Role, and bind them
-	if err := createBrokerAdministratorRoleAndSA(ctx, kubeClient, brokerNS); err != nil {
+	if err := createBrokerAdministratorRoleAndSA(ctx, kubeClient, brokerNS, tokenWaitTimeout); err != nil {
 		return err
 	}
 
@@ -88,8 +89,11 @@ func Ensure(ctx context.Context, crdUpdater crd.Updater, kubeClient kubernetes.I
 	return nil
 }
 
-// CreateSAForCluster creates a new SA for each new cluster joined and binds it to the submariner cluster role.
-func CreateSAForCluster(ctx context.Context, kubeClient kubernetes.Interface, clusterID, inNamespace string) (*v1.Secret, error) {
+// CreateSAForCluster creates a new SA for each new cluster joined and binds it to the submariner cluster role; the SA's
+// token is waited for up to the given timeout.
+func CreateSAForCluster(ctx context.Context, kubeClient kubernetes.Interface, clusterID, inNamespace string,
+	tokenWaitTimeout time.Duration,
+) (*v1.Secret, error) {
 	saName := names.ForClusterSA(clusterID)
 
 	err := CreateNewBrokerSA(ctx, kubeClient, saName, inNamespace)
@@ -102,7 +106,7 @@ func CreateSAForCluster(ctx context.Context, kubeClient kubernetes.Interface, cl
 		return nil, errors.Wrap(err, "error binding sa to cluster role")
 	}
 
-	clientToken, err := serviceaccount.EnsureTokenSecret(ctx, kubeClient, inNamespace, saName)
+	clientToken, err := serviceaccount.EnsureTokenSecret(ctx, kubeClient, inNamespace, saName, tokenWaitTimeout)
 	if err != nil {
 		return nil, errors.Wrap(err, "error getting cluster sa token")
 	}
@@ -110,9 +114,11 @@ func CreateSAForCluster(ctx context.Context, kubeClient kubernetes.Interface, cl
 	return clientToken, nil
 }
 
-func createBrokerAdministratorRoleAndSA(ctx context.Context, kubeClient kubernetes.Interface, inNamespace string) error {
+func createBrokerAdministratorRoleAndSA(ctx context.Context, kubeClient kubernetes.Interface, inNamespace string,
+	tokenWaitTimeout time.Duration,
+) error {
 	// Create the SA we need for the managing the broker (from subctl, etc..).
-	err := CreateNewBrokerAdminSA(ctx, kubeClient, inNamespace)
+	err := CreateNewBrokerAdminSA(ctx, kubeClient, inNamespace, tokenWaitTimeout)
 	if err != nil && !apierrors.IsAlreadyExists(err) {
 		return errors.Wrap(err, "error creating the broker admin service account")
 	}
@@ -166,13 +172,14 @@ func CreateNewBrokerSA(ctx context.Context, kubeClient kubernetes.Interface, sub
 }
 
 //nolint:wrapcheck // No need to wrap here
-func CreateNewBrokerAdminSA(ctx context.Context, kubeClient kubernetes.Interface, inNamespace string) (err error) {
+func CreateNewBrokerAdminSA(ctx context.Context, kubeClient kubernetes.Interface, inNamespace string, tokenWaitTimeout time.Duration,
+) (err error) {
 	_, err = serviceaccount.EnsureFromYAML(ctx, kubeClient, inNamespace, embeddedyamls.Config_broker_broker_admin_service_account_yaml)
 	if err != nil {
 		return err
 	}
 
-	_, err = serviceaccount.EnsureTokenSecret(ctx, kubeClient, inNamespace, constants.SubmarinerBrokerAdminSA)
+	_, err = serviceaccount.EnsureTokenSecret(ctx, kubeClient, inNamespace, constants.SubmarinerBrokerAdminSA, tokenWaitTimeout)
 
 	return err
 }
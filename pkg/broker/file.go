@@ -22,58 +22,59 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"os"
 	"sort"
 	"strings"
 	"time"
 
+	"github.com/coreos/go-semver/semver"
 	"github.com/pkg/errors"
 	"github.com/submariner-io/admiral/pkg/reporter"
 	"github.com/submariner-io/subctl/internal/component"
 	"github.com/submariner-io/subctl/internal/constants"
 	"github.com/submariner-io/subctl/pkg/serviceaccount"
+	"github.com/submariner-io/subctl/pkg/version"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/utils/set"
 )
 
-const InfoFileName = "broker-info.subm"
+const (
+	InfoFileName = "broker-info.subm"
+	// InfoSchemaVersion is the version of the broker information file format written by this version of subctl
+	InfoSchemaVersion = 1
+)
 
-func WriteInfoToFile(restConfig *rest.Config, brokerNamespace, brokerURL string, ipsecPSK []byte, components set.Set[string],
-	customDomains []string, status reporter.Interface,
+// WriteInfoToFile writes the broker information file. The given CA bundle, if any, replaces the cluster CA in the client
+// token, for brokers whose URL is served with a certificate from another CA.
+func WriteInfoToFile(restConfig *rest.Config, brokerNamespace, brokerURL string, brokerCA, ipsecPSK []byte,
+	components set.Set[string], customDomains []string, status reporter.Interface,
 ) error {
 	status.Start("Saving broker info to file %q", InfoFileName)
 	defer status.End()
 
-	newFilename, err := backupIfExists(InfoFileName)
-	if err != nil {
-		return status.Error(err, "error backing up the broker file")
-	}
-
-	if newFilename != "" {
-		status.Success("Backed up previous file %q to %q", InfoFileName, newFilename)
-	}
-
 	kubeClient, err := kubernetes.NewForConfig(restConfig)
 	if err != nil {
 		return status.Error(err, "error creating Kubernetes client")
 	}
 
-	data := &Info{}
+	data := &Info{
+		SchemaVersion: InfoSchemaVersion,
+		SubctlVersion: version.Version,
+	}
 
 	data.ClientToken, err = serviceaccount.GetTokenSecretFor(context.TODO(), kubeClient, brokerNamespace, constants.SubmarinerBrokerAdminSA)
 	if err != nil {
 		return errors.Wrap(err, "error getting broker client secret")
 	}
 
-	data.IPSecPSK = wrapIPSecPSKSecret(ipsecPSK)
-
-	data.BrokerURL = restConfig.Host + restConfig.APIPath
-
-	if brokerURL != "" {
-		data.BrokerURL = brokerURL
+	if len(brokerCA) > 0 {
+		data.ClientToken.Data["ca.crt"] = brokerCA
 	}
 
+	data.IPSecPSK = wrapIPSecPSKSecret(ipsecPSK)
+	data.BrokerURL = InfoURL(restConfig, brokerURL)
 	data.ServiceDiscovery = components.Has(component.ServiceDiscovery)
 	data.Components = components.UnsortedList()
 	sort.Strings(data.Components)
@@ -82,10 +83,67 @@ func WriteInfoToFile(restConfig *rest.Config, brokerNamespace, brokerURL string,
 		data.CustomDomains = &customDomains
 	}
 
+	dataStr, err := data.encode()
+	if err != nil {
+		return status.Error(err, "error encoding broker info")
+	}
+
+	// Re-running with the same broker and options mustn't pile up identical backups
+	if existing, err := os.ReadFile(InfoFileName); err == nil && strings.TrimSpace(string(existing)) == dataStr {
+		status.Success("The broker info in file %q is already up to date", InfoFileName)
+		return nil
+	}
+
+	newFilename, err := backupIfExists(InfoFileName)
+	if err != nil {
+		return status.Error(err, "error backing up the broker file")
+	}
+
+	if newFilename != "" {
+		status.Success("Backed up previous file %q to %q", InfoFileName, newFilename)
+	}
+
 	return status.Error(data.writeToFile(InfoFileName), "error saving broker info")
 }
 
+// InfoURL returns the broker URL to store in the broker information file: the given URL if any, the context URL otherwise.
+func InfoURL(restConfig *rest.Config, brokerURL string) string {
+	if brokerURL != "" {
+		return brokerURL
+	}
+
+	return restConfig.Host + restConfig.APIPath
+}
+
+// ExistingIPsecPSK returns the IPsec PSK stored in the given broker information file if it describes the broker in the
+// given namespace and at the given URL, nil otherwise. Re-deploying a broker must preserve its PSK, since the clusters
+// already joined with it keep using it.
+func ExistingIPsecPSK(filename, brokerNamespace, brokerURL string) []byte {
+	data, err := DecodeInfoFromFile(filename)
+	if err != nil || data.BrokerURL != brokerURL || data.ClientToken == nil || data.IPSecPSK == nil ||
+		string(data.ClientToken.Data["namespace"]) != brokerNamespace {
+		return nil
+	}
+
+	return data.IPSecPSK.Data["psk"]
+}
+
+// ReadInfoFromFile reads and validates the broker information stored in the given file.
 func ReadInfoFromFile(filename string) (*Info, error) {
+	data, err := DecodeInfoFromFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := data.Validate(); err != nil {
+		return nil, errors.Wrapf(err, "the broker information in file %q can't be used", filename)
+	}
+
+	return data, nil
+}
+
+// DecodeInfoFromFile reads the broker information stored in the given file, without validating it.
+func DecodeInfoFromFile(filename string) (*Info, error) {
 	raw, err := os.ReadFile(filename)
 	if err != nil {
 		return nil, errors.Wrapf(err, "error reading file %q", filename)
@@ -93,7 +151,7 @@ func ReadInfoFromFile(filename string) (*Info, error) {
 
 	data := &Info{}
 
-	bytes, err := base64.URLEncoding.DecodeString(string(raw))
+	bytes, err := base64.URLEncoding.DecodeString(strings.TrimSpace(string(raw)))
 	if err != nil {
 		return nil, errors.Wrapf(err, "error decoding data from file %q", filename)
 	}
@@ -101,6 +159,70 @@ func ReadInfoFromFile(filename string) (*Info, error) {
 	return data, errors.Wrap(json.Unmarshal(bytes, data), "error unmarshalling data")
 }
 
+// Validate checks that the broker information contains everything needed to join a cluster.
+func (d *Info) Validate() error {
+	const regenerate = "; broker information files can be regenerated with \"subctl recover-broker-info\""
+
+	if d.SchemaVersion > InfoSchemaVersion {
+		return fmt.Errorf("it uses format version %d, written by subctl %s, but this version of subctl only supports"+
+			" format version %d or older; please use a more recent version of subctl", d.SchemaVersion, d.SubctlVersion,
+			InfoSchemaVersion)
+	}
+
+	if d.BrokerURL == "" {
+		return errors.New("it doesn't contain the broker URL" + regenerate)
+	}
+
+	if d.ClientToken == nil || len(d.ClientToken.Data["token"]) == 0 {
+		return errors.New("it doesn't contain the broker client token" + regenerate)
+	}
+
+	if d.IPSecPSK == nil || len(d.IPSecPSK.Data["psk"]) == 0 {
+		return errors.New("it doesn't contain the IPsec PSK" + regenerate)
+	}
+
+	return nil
+}
+
+// CheckCompatibility warns if the broker information was written by a different minor version of subctl, and fills in
+// the components if they're missing, as they are in files written by old versions of subctl.
+func (d *Info) CheckCompatibility(status reporter.Interface) {
+	switch {
+	case d.SubctlVersion == "":
+		status.Warning("The broker information file was written by an old version of subctl which didn't record its version;" +
+			" consider regenerating it")
+	case differentMinorVersions(d.SubctlVersion, version.Version):
+		status.Warning("The broker information file was written by subctl %s, but this is subctl %s", d.SubctlVersion,
+			version.Version)
+	}
+
+	if len(d.Components) == 0 {
+		// Old files only recorded whether service discovery was enabled, connectivity was always deployed
+		d.Components = []string{component.Connectivity}
+		if d.ServiceDiscovery {
+			d.Components = append(d.Components, component.ServiceDiscovery)
+		}
+
+		status.Warning("The broker information file doesn't list the components to deploy, defaulting to %s",
+			strings.Join(d.Components, ", "))
+	}
+}
+
+// differentMinorVersions returns true if both versions are release versions with different major or minor versions.
+func differentMinorVersions(v1, v2 string) bool {
+	first, err := semver.NewVersion(strings.TrimPrefix(v1, "v"))
+	if err != nil {
+		return false
+	}
+
+	second, err := semver.NewVersion(strings.TrimPrefix(v2, "v"))
+	if err != nil {
+		return false
+	}
+
+	return first.Major != second.Major || first.Minor != second.Minor
+}
+
 func backupIfExists(fileName string) (string, error) {
 	if _, err := os.Stat(fileName); os.IsNotExist(err) {
 		return "", nil
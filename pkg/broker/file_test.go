@@ -0,0 +1,211 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package broker_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/admiral/pkg/reporter"
+	"github.com/submariner-io/subctl/internal/component"
+	"github.com/submariner-io/subctl/pkg/broker"
+	"github.com/submariner-io/subctl/pkg/version"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("ReadInfoFromFile", func() {
+	var (
+		info     *broker.Info
+		fileName string
+	)
+
+	BeforeEach(func() {
+		info = &broker.Info{
+			SchemaVersion: broker.InfoSchemaVersion,
+			SubctlVersion: "v0.18.0",
+			BrokerURL:     "https://broker:6443",
+			ClientToken:   &corev1.Secret{Data: map[string][]byte{"token": []byte("token"), "namespace": []byte("broker")}},
+			IPSecPSK:      &corev1.Secret{Data: map[string][]byte{"psk": []byte("psk")}},
+			Components:    []string{component.Connectivity},
+		}
+
+		fileName = filepath.Join(GinkgoT().TempDir(), broker.InfoFileName)
+	})
+
+	read := func() (*broker.Info, error) {
+		jsonBytes, err := json.Marshal(info)
+		Expect(err).To(Succeed())
+		Expect(os.WriteFile(fileName, []byte(base64.URLEncoding.EncodeToString(jsonBytes)), 0o600)).To(Succeed())
+
+		return broker.ReadInfoFromFile(fileName)
+	}
+
+	When("the file is complete", func() {
+		It("should return its contents", func() {
+			read, err := read()
+			Expect(err).To(Succeed())
+			Expect(read).To(Equal(info))
+		})
+	})
+
+	When("the file doesn't contain the IPsec PSK", func() {
+		It("should return an error", func() {
+			info.IPSecPSK = nil
+			_, err := read()
+			Expect(err).To(MatchError(ContainSubstring("IPsec PSK")))
+		})
+	})
+
+	When("the file doesn't contain the client token", func() {
+		It("should return an error", func() {
+			info.ClientToken.Data["token"] = nil
+			_, err := read()
+			Expect(err).To(MatchError(ContainSubstring("client token")))
+		})
+	})
+
+	When("the file uses a newer format", func() {
+		It("should return an error", func() {
+			info.SchemaVersion = broker.InfoSchemaVersion + 1
+			_, err := read()
+			Expect(err).To(MatchError(ContainSubstring("more recent version of subctl")))
+		})
+	})
+
+	When("the file isn't base64-encoded", func() {
+		It("should return an error", func() {
+			Expect(os.WriteFile(fileName, []byte("{}"), 0o600)).To(Succeed())
+			_, err := broker.ReadInfoFromFile(fileName)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("CheckCompatibility", func() {
+	var (
+		info           *broker.Info
+		tracker        *reporter.Tracker
+		currentVersion string
+	)
+
+	BeforeEach(func() {
+		currentVersion = version.Version
+		version.Version = "v0.18.1"
+
+		info = &broker.Info{
+			SubctlVersion: "v0.18.0",
+			Components:    []string{component.Connectivity, component.ServiceDiscovery},
+		}
+
+		tracker = reporter.NewTracker(reporter.Silent())
+	})
+
+	AfterEach(func() {
+		version.Version = currentVersion
+	})
+
+	When("the file was written by the same minor version", func() {
+		It("should not warn", func() {
+			info.CheckCompatibility(tracker)
+			Expect(tracker.HasWarnings()).To(BeFalse())
+		})
+	})
+
+	When("the file was written by a different minor version", func() {
+		It("should warn", func() {
+			info.SubctlVersion = "v0.16.3"
+			info.CheckCompatibility(tracker)
+			Expect(tracker.HasWarnings()).To(BeTrue())
+		})
+	})
+
+	When("the file doesn't record the subctl version", func() {
+		It("should warn", func() {
+			info.SubctlVersion = ""
+			info.CheckCompatibility(tracker)
+			Expect(tracker.HasWarnings()).To(BeTrue())
+		})
+	})
+
+	When("the file doesn't list the components", func() {
+		BeforeEach(func() {
+			info.Components = nil
+		})
+
+		It("should default to connectivity and warn", func() {
+			info.CheckCompatibility(tracker)
+			Expect(info.Components).To(Equal([]string{component.Connectivity}))
+			Expect(tracker.HasWarnings()).To(BeTrue())
+		})
+
+		Context("and service discovery was enabled", func() {
+			It("should include service discovery", func() {
+				info.ServiceDiscovery = true
+				info.CheckCompatibility(tracker)
+				Expect(info.Components).To(Equal([]string{component.Connectivity, component.ServiceDiscovery}))
+			})
+		})
+	})
+})
+
+var _ = Describe("ExistingIPsecPSK", func() {
+	var fileName string
+
+	BeforeEach(func() {
+		info := &broker.Info{
+			BrokerURL:   "https://broker:6443",
+			ClientToken: &corev1.Secret{Data: map[string][]byte{"token": []byte("token"), "namespace": []byte("broker")}},
+			IPSecPSK:    &corev1.Secret{Data: map[string][]byte{"psk": []byte("psk")}},
+		}
+
+		jsonBytes, err := json.Marshal(info)
+		Expect(err).To(Succeed())
+
+		fileName = filepath.Join(GinkgoT().TempDir(), broker.InfoFileName)
+		Expect(os.WriteFile(fileName, []byte(base64.URLEncoding.EncodeToString(jsonBytes)), 0o600)).To(Succeed())
+	})
+
+	When("the file describes the same broker", func() {
+		It("should return its PSK", func() {
+			Expect(broker.ExistingIPsecPSK(fileName, "broker", "https://broker:6443")).To(Equal([]byte("psk")))
+		})
+	})
+
+	When("the file describes a broker in another namespace", func() {
+		It("should return nil", func() {
+			Expect(broker.ExistingIPsecPSK(fileName, "other", "https://broker:6443")).To(BeNil())
+		})
+	})
+
+	When("the file describes a broker at another URL", func() {
+		It("should return nil", func() {
+			Expect(broker.ExistingIPsecPSK(fileName, "broker", "https://other:6443")).To(BeNil())
+		})
+	})
+
+	When("the file doesn't exist", func() {
+		It("should return nil", func() {
+			Expect(broker.ExistingIPsecPSK(fileName+".missing", "broker", "https://broker:6443")).To(BeNil())
+		})
+	})
+})
@@ -27,6 +27,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/submariner-io/admiral/pkg/resource"
 	"github.com/submariner-io/subctl/internal/component"
+	submarinerv1 "github.com/submariner-io/submariner/pkg/apis/submariner.io/v1"
 	submarinerClientset "github.com/submariner-io/submariner/pkg/client/clientset/versioned"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -35,6 +36,10 @@ import (
 )
 
 type Info struct {
+	// SchemaVersion identifies the format of the file; files written before it was introduced don't have one
+	SchemaVersion int `json:"schemaVersion,omitempty"`
+	// SubctlVersion is the version of subctl which wrote the file
+	SubctlVersion    string         `json:"subctlVersion,omitempty"`
 	BrokerURL        string         `json:"brokerURL"`
 	ClientToken      *corev1.Secret `omitempty,json:"clientToken"`
 	IPSecPSK         *corev1.Secret `omitempty,json:"ipsecPSK"`
@@ -90,7 +95,7 @@ func (d *Info) getAndCheckBrokerAdministratorConfig(ctx context.Context, private
 	// This attempts to determine whether we can connect, by trying to access a Submariner object
 	// Successful connections result in either the object, or a “not found” error; anything else
 	// likely means we couldn’t connect
-	_, err = submClientset.SubmarinerV1().Clusters(string(d.ClientToken.Data["namespace"])).List(
+	_, err = submClientset.SubmarinerV1().Clusters(d.GetNamespace()).List(
 		ctx, metav1.ListOptions{})
 	if resource.IsNotFoundErr(err) {
 		err = nil
@@ -117,6 +122,35 @@ func (d *Info) getBrokerAdministratorConfig(private, insecure bool) *rest.Config
 	return &restConfig
 }
 
+// GetNamespace returns the broker namespace, as recorded in the client token.
+func (d *Info) GetNamespace() string {
+	if d.ClientToken == nil {
+		return ""
+	}
+
+	return string(d.ClientToken.Data[corev1.ServiceAccountNamespaceKey])
+}
+
+// ListClusters returns the clusters registered with the broker, using the broker information's credentials.
+func (d *Info) ListClusters(ctx context.Context) ([]submarinerv1.Cluster, error) {
+	config, err := d.GetBrokerAdministratorConfig(ctx, false)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error connecting to the broker at %q", d.BrokerURL)
+	}
+
+	submClientset, err := submarinerClientset.NewForConfig(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating client")
+	}
+
+	clusters, err := submClientset.SubmarinerV1().Clusters(d.GetNamespace()).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing the Clusters")
+	}
+
+	return clusters.Items, nil
+}
+
 func (d *Info) IsConnectivityEnabled() bool {
 	return d.GetComponents().Has(component.Connectivity)
 }
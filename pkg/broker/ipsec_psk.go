@@ -19,8 +19,16 @@ limitations under the License.
 package broker
 
 import (
+	"context"
 	"crypto/rand"
+	"encoding/base64"
 
+	"github.com/submariner-io/admiral/pkg/reporter"
+	"github.com/submariner-io/admiral/pkg/resource"
+	"github.com/submariner-io/admiral/pkg/util"
+	"github.com/submariner-io/subctl/pkg/cluster"
+	"github.com/submariner-io/subctl/pkg/secret"
+	operatorv1alpha1 "github.com/submariner-io/submariner-operator/api/v1alpha1"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -51,3 +59,65 @@ func wrapIPSecPSKSecret(psk []byte) *v1.Secret {
 
 	return pskSecret
 }
+
+// RotateIPSecPSK generates a new IPsec PSK and stores it in the given broker information file, backing up the previous
+// file, so that clusters joined from now on use it. It returns the new PSK, which must then be applied to the clusters
+// which are already joined, using UpdateIPSecPSK.
+func RotateIPSecPSK(filename string, status reporter.Interface) ([]byte, error) {
+	status.Start("Generating a new IPsec PSK and saving it to file %q", filename)
+	defer status.End()
+
+	data, err := ReadInfoFromFile(filename)
+	if err != nil {
+		return nil, status.Error(err, "")
+	}
+
+	psk, err := GenerateRandomPSK()
+	if err != nil {
+		return nil, status.Error(err, "error generating the IPsec PSK")
+	}
+
+	data.IPSecPSK = wrapIPSecPSKSecret(psk)
+
+	newFilename, err := backupIfExists(filename)
+	if err != nil {
+		return nil, status.Error(err, "error backing up the broker file")
+	}
+
+	status.Success("Backed up previous file %q to %q", filename, newFilename)
+
+	return psk, status.Error(data.writeToFile(filename), "error saving broker info")
+}
+
+// UpdateIPSecPSK replaces the IPsec PSK used by the given joined cluster, in its PSK secret and in its Submariner
+// resource. The gateways keep using the previous PSK until they're restarted.
+func UpdateIPSecPSK(ctx context.Context, clusterInfo *cluster.Info, psk []byte, status reporter.Interface) error {
+	status.Start("Updating the IPsec PSK in cluster %q", clusterInfo.Name)
+	defer status.End()
+
+	namespace := clusterInfo.Submariner.Namespace
+
+	// Clusters joined by old versions of subctl only have the PSK in the Submariner resource
+	if secretName := clusterInfo.Submariner.Spec.CeIPSecPSKSecret; secretName != "" {
+		pskSecret := wrapIPSecPSKSecret(psk)
+		pskSecret.Name = secretName
+
+		if _, err := secret.Ensure(ctx, clusterInfo.ClientProducer.ForKubernetes(), namespace, pskSecret); err != nil {
+			return status.Error(err, "error updating the PSK secret %q", secretName)
+		}
+	}
+
+	err := util.Update[*operatorv1alpha1.Submariner](ctx,
+		resource.ForControllerClient(clusterInfo.ClientProducer.ForGeneral(), namespace, &operatorv1alpha1.Submariner{}),
+		clusterInfo.Submariner, func(existing *operatorv1alpha1.Submariner) (*operatorv1alpha1.Submariner, error) {
+			existing.Spec.CeIPSecPSK = base64.StdEncoding.EncodeToString(psk)
+			return existing, nil
+		})
+	if err != nil {
+		return status.Error(err, "error updating the Submariner resource")
+	}
+
+	status.Success("Updated the IPsec PSK; the gateways must be restarted to use it")
+
+	return nil
+}
@@ -0,0 +1,73 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package broker_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/admiral/pkg/reporter"
+	"github.com/submariner-io/subctl/pkg/broker"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("RotateIPSecPSK", func() {
+	var fileName string
+
+	BeforeEach(func() {
+		info := &broker.Info{
+			BrokerURL:   "https://broker:6443",
+			ClientToken: &corev1.Secret{Data: map[string][]byte{"token": []byte("token"), "namespace": []byte("broker")}},
+			IPSecPSK:    &corev1.Secret{Data: map[string][]byte{"psk": []byte("psk")}},
+		}
+
+		jsonBytes, err := json.Marshal(info)
+		Expect(err).To(Succeed())
+
+		fileName = filepath.Join(GinkgoT().TempDir(), broker.InfoFileName)
+		Expect(os.WriteFile(fileName, []byte(base64.URLEncoding.EncodeToString(jsonBytes)), 0o600)).To(Succeed())
+	})
+
+	When("the file contains valid broker information", func() {
+		It("should store and return a new PSK and back up the previous file", func() {
+			psk, err := broker.RotateIPSecPSK(fileName, reporter.Silent())
+			Expect(err).To(Succeed())
+			Expect(psk).ToNot(BeEmpty())
+			Expect(psk).ToNot(Equal([]byte("psk")))
+
+			Expect(broker.ExistingIPsecPSK(fileName, "broker", "https://broker:6443")).To(Equal(psk))
+
+			backups, err := filepath.Glob(fileName + ".*")
+			Expect(err).To(Succeed())
+			Expect(backups).To(HaveLen(1))
+			Expect(broker.ExistingIPsecPSK(backups[0], "broker", "https://broker:6443")).To(Equal([]byte("psk")))
+		})
+	})
+
+	When("the file doesn't exist", func() {
+		It("should return an error", func() {
+			_, err := broker.RotateIPSecPSK(fileName+".missing", reporter.Silent())
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
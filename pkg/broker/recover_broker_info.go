@@ -43,7 +43,7 @@ func RecoverData(submCluster *cluster.Info, broker *v1alpha1.Broker, brokerNames
 
 	status.Success("Successfully retrieved the data. Writing it to broker-info.subm")
 
-	err = WriteInfoToFile(brokerRestConfig, brokerNamespace, brokerURL, decodedPSKSecret,
+	err = WriteInfoToFile(brokerRestConfig, brokerNamespace, brokerURL, nil, decodedPSKSecret,
 		set.New(broker.Spec.Components...), broker.Spec.DefaultCustomDomains, status)
 
 	return status.Error(err, "error reconstructing broker-info.subm")
@@ -0,0 +1,173 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package broker
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/submariner-io/admiral/pkg/reporter"
+)
+
+const tlsDialTimeout = 10 * time.Second
+
+// ReadCABundle reads a PEM-encoded CA bundle from the given file, checking that it contains at least one certificate.
+func ReadCABundle(filename string) ([]byte, error) {
+	caData, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error reading the CA bundle file %q", filename)
+	}
+
+	if !x509.NewCertPool().AppendCertsFromPEM(caData) {
+		return nil, fmt.Errorf("the file %q doesn't contain any PEM-encoded certificate", filename)
+	}
+
+	return caData, nil
+}
+
+// CheckTLS verifies that the certificate served at the given broker URL can be trusted by the member clusters and covers
+// the URL's host. Like the member clusters when they join, the system CAs are tried first, then the given CA bundle, which
+// is the one provided to the member clusters. Verification failures are returned as errors since the clusters wouldn't be
+// able to join; a URL which can't be reached is only reported as a warning.
+func CheckTLS(brokerURL string, caData []byte, status reporter.Interface) error {
+	status.Start("Checking the TLS certificate served at the broker URL %q", brokerURL)
+	defer status.End()
+
+	address, host, err := tlsAddress(brokerURL)
+	if err != nil {
+		return status.Error(err, "error parsing the broker URL %q", brokerURL)
+	}
+
+	var (
+		hostnameErr  x509.HostnameError
+		authorityErr x509.UnknownAuthorityError
+	)
+
+	err = dialTLS(address, host, nil)
+	if err == nil {
+		status.Success("The certificate served at %q is trusted by the system CAs", brokerURL)
+
+		return nil
+	}
+
+	if errors.As(err, &authorityErr) {
+		roots := x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(caData) {
+			return status.Error(err, "the certificate served at %q isn't trusted by the system CAs, and there is no broker CA"+
+				" bundle to fall back to; provide the CA bundle with --broker-ca-file", brokerURL)
+		}
+
+		err = dialTLS(address, host, roots)
+		if err == nil {
+			status.Success("The certificate served at %q is trusted by the broker CA bundle", brokerURL)
+
+			return nil
+		}
+	}
+
+	switch {
+	case errors.As(err, &hostnameErr):
+		return status.Error(err, "the certificate served at %q doesn't cover %q, its subject alternative names are %s;"+
+			" please use a broker URL matching one of them", brokerURL, host, certificateSANs(hostnameErr.Certificate))
+	case errors.As(err, &authorityErr):
+		return status.Error(err, "the certificate served at %q, issued by %q, isn't signed by the system CAs or the broker CA;"+
+			" if the API server is behind a re-encrypting load balancer, provide its CA bundle with --broker-ca-file", brokerURL,
+			issuer(authorityErr.Cert))
+	}
+
+	var verificationErr *tls.CertificateVerificationError
+	if errors.As(err, &verificationErr) {
+		return status.Error(err, "the certificate served at %q can't be verified", brokerURL)
+	}
+
+	status.Warning("Unable to check the certificate served at %q: %v", brokerURL, err)
+
+	return nil
+}
+
+// dialTLS opens a TLS connection to the given address, verifying the server certificate against the given roots, or the
+// system CAs if nil, and closes it.
+func dialTLS(address, host string, roots *x509.CertPool) error {
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: tlsDialTimeout}, "tcp", address, &tls.Config{
+		RootCAs:    roots,
+		ServerName: host,
+		MinVersion: tls.VersionTLS12,
+	})
+	if err != nil {
+		return err //nolint:wrapcheck // The caller inspects the error
+	}
+
+	return conn.Close() //nolint:wrapcheck // The caller inspects the error
+}
+
+// tlsAddress returns the address to dial for the given URL, defaulting to the HTTPS port, along with its host name.
+func tlsAddress(brokerURL string) (string, string, error) {
+	// Broker URLs are sometimes given without a scheme
+	if !strings.Contains(brokerURL, "://") {
+		brokerURL = "https://" + brokerURL
+	}
+
+	parsed, err := url.Parse(brokerURL)
+	if err != nil {
+		return "", "", errors.Wrap(err, "error parsing the URL")
+	}
+
+	if parsed.Hostname() == "" {
+		return "", "", fmt.Errorf("the URL %q doesn't contain a host", brokerURL)
+	}
+
+	port := parsed.Port()
+	if port == "" {
+		port = "443"
+	}
+
+	return net.JoinHostPort(parsed.Hostname(), port), parsed.Hostname(), nil
+}
+
+func certificateSANs(cert *x509.Certificate) string {
+	if cert == nil {
+		return "unknown"
+	}
+
+	sans := append([]string{}, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+
+	if len(sans) == 0 {
+		return "empty"
+	}
+
+	return strings.Join(sans, ", ")
+}
+
+func issuer(cert *x509.Certificate) string {
+	if cert == nil {
+		return "unknown"
+	}
+
+	return cert.Issuer.String()
+}
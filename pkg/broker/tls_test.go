@@ -0,0 +1,151 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package broker_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/admiral/pkg/reporter"
+	"github.com/submariner-io/subctl/pkg/broker"
+)
+
+var _ = Describe("CheckTLS", func() {
+	var (
+		server  *httptest.Server
+		caData  []byte
+		tracker *reporter.Tracker
+	)
+
+	BeforeEach(func() {
+		server = httptest.NewTLSServer(http.NotFoundHandler())
+		DeferCleanup(server.Close)
+
+		caData = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+		tracker = reporter.NewTracker(reporter.Silent())
+	})
+
+	When("the certificate is signed by the CA and covers the host", func() {
+		It("should succeed", func() {
+			Expect(broker.CheckTLS(server.URL, caData, tracker)).To(Succeed())
+			Expect(tracker.HasWarnings()).To(BeFalse())
+		})
+	})
+
+	When("the URL has no scheme", func() {
+		It("should succeed", func() {
+			Expect(broker.CheckTLS(server.Listener.Addr().String(), caData, tracker)).To(Succeed())
+		})
+	})
+
+	When("the certificate doesn't cover the host", func() {
+		It("should return an error", func() {
+			serverURL, err := url.Parse(server.URL)
+			Expect(err).To(Succeed())
+
+			Expect(broker.CheckTLS("https://localhost:"+serverURL.Port(), caData, tracker)).ToNot(Succeed())
+		})
+	})
+
+	When("the certificate isn't signed by the CA", func() {
+		It("should return an error", func() {
+			Expect(broker.CheckTLS(server.URL, differentCA(), tracker)).ToNot(Succeed())
+		})
+	})
+
+	When("the certificate isn't trusted by the system CAs and there is no CA bundle", func() {
+		It("should return an error", func() {
+			Expect(broker.CheckTLS(server.URL, nil, tracker)).ToNot(Succeed())
+		})
+	})
+
+	When("the URL isn't served", func() {
+		It("should only warn", func() {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).To(Succeed())
+
+			brokerURL := "https://" + listener.Addr().String()
+			Expect(listener.Close()).To(Succeed())
+
+			Expect(broker.CheckTLS(brokerURL, caData, tracker)).To(Succeed())
+			Expect(tracker.HasWarnings()).To(BeTrue())
+		})
+	})
+})
+
+var _ = Describe("ReadCABundle", func() {
+	var filename string
+
+	BeforeEach(func() {
+		filename = filepath.Join(GinkgoT().TempDir(), "ca.pem")
+	})
+
+	When("the file contains a certificate", func() {
+		It("should return its contents", func() {
+			caData := differentCA()
+			Expect(os.WriteFile(filename, caData, 0o600)).To(Succeed())
+
+			Expect(broker.ReadCABundle(filename)).To(Equal(caData))
+		})
+	})
+
+	When("the file doesn't contain any certificate", func() {
+		It("should return an error", func() {
+			Expect(os.WriteFile(filename, []byte("not a certificate"), 0o600)).To(Succeed())
+
+			_, err := broker.ReadCABundle(filename)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
+
+// differentCA returns a freshly generated self-signed CA certificate.
+func differentCA() []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).To(Succeed())
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "other-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).To(Succeed())
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
@@ -0,0 +1,86 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package broker
+
+import (
+	"context"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/submariner-io/admiral/pkg/reporter"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const urlCheckInterval = 5 * time.Second
+
+// CheckURL verifies that the broker API is served at the given URL, using the credentials from the given configuration.
+// A URL which isn't served yet, typically a load balancer which is still being provisioned, is retried for up to the
+// given duration; with no duration, it's only reported as a warning. Other errors, e.g. certificates which don't cover
+// the URL, are reported as warnings since they don't necessarily affect the member clusters.
+func CheckURL(restConfig *rest.Config, brokerURL string, waitFor time.Duration, status reporter.Interface) error {
+	status.Start("Checking the broker URL %q", brokerURL)
+	defer status.End()
+
+	config := rest.CopyConfig(restConfig)
+	config.Host = brokerURL
+	config.APIPath = ""
+
+	kubeClient, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return status.Error(err, "error creating a Kubernetes client for the broker URL")
+	}
+
+	check := func(_ context.Context) (bool, error) {
+		_, err = kubeClient.Discovery().ServerVersion()
+		return err == nil || !isNotServingError(err), nil
+	}
+
+	if waitFor > 0 {
+		status.Start("Waiting up to %s for the broker URL %q to be served", waitFor, brokerURL)
+
+		_ = wait.PollUntilContextTimeout(context.TODO(), urlCheckInterval, waitFor, true, check)
+	} else {
+		_, _ = check(context.TODO())
+	}
+
+	switch {
+	case err == nil:
+		status.Success("The broker API is served at %q", brokerURL)
+	case !isNotServingError(err):
+		status.Warning("Unable to verify the broker URL %q: %v", brokerURL, err)
+	case waitFor > 0:
+		return status.Error(err, "the broker URL %q still isn't served after %s", brokerURL, waitFor)
+	default:
+		status.Warning("The broker URL %q isn't served yet, clusters won't be able to join until it is;"+
+			" use --wait-for-broker-url to wait for it: %v", brokerURL, err)
+	}
+
+	return nil
+}
+
+// isNotServingError returns true if the error indicates that nothing is serving the URL yet.
+func isNotServingError(err error) bool {
+	var dnsErr *net.DNSError
+
+	return errors.Is(err, syscall.ECONNREFUSED) || (errors.As(err, &dnsErr) && dnsErr.IsNotFound)
+}
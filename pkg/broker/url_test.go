@@ -0,0 +1,61 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package broker_test
+
+import (
+	"net"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/admiral/pkg/reporter"
+	"github.com/submariner-io/subctl/pkg/broker"
+	"k8s.io/client-go/rest"
+)
+
+var _ = Describe("CheckURL", func() {
+	var (
+		brokerURL string
+		tracker   *reporter.Tracker
+	)
+
+	BeforeEach(func() {
+		// Find a free port, then release it so that connecting to it is refused
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).To(Succeed())
+
+		brokerURL = "https://" + listener.Addr().String()
+		Expect(listener.Close()).To(Succeed())
+
+		tracker = reporter.NewTracker(reporter.Silent())
+	})
+
+	When("the URL isn't served and no wait is requested", func() {
+		It("should only warn", func() {
+			Expect(broker.CheckURL(&rest.Config{}, brokerURL, 0, tracker)).To(Succeed())
+			Expect(tracker.HasWarnings()).To(BeTrue())
+		})
+	})
+
+	When("the URL still isn't served after the wait", func() {
+		It("should return an error", func() {
+			Expect(broker.CheckURL(&rest.Config{}, brokerURL, 100*time.Millisecond, tracker)).ToNot(Succeed())
+		})
+	})
+})
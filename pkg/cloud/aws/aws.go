@@ -41,6 +41,7 @@ type Config struct {
 	WorkerSecurityGroup       string
 	VpcName                   string
 	SubnetNames               []string
+	DedicatedGateway          bool
 }
 
 // RunOn runs the given function on AWS, supplying it with a cloud instance connected to AWS and a reporter that writes to CLI.
@@ -39,13 +39,14 @@ import (
 )
 
 type Config struct {
-	Gateways        int
-	InfraID         string
-	Region          string
-	ProjectID       string
-	CredentialsFile string
-	OcpMetadataFile string
-	GWInstanceType  string
+	Gateways         int
+	InfraID          string
+	Region           string
+	ProjectID        string
+	CredentialsFile  string
+	OcpMetadataFile  string
+	GWInstanceType   string
+	DedicatedGateway bool
 }
 
 // RunOn runs the given function on GCP, supplying it with a cloud instance connected to GCP and a reporter that writes to CLI.
@@ -36,14 +36,15 @@ func AWS(clusterInfo *cluster.Info, ports *cloud.Ports, config *aws.Config, useL
 	}
 
 	// For load-balanced gateways we want these ports open internally to facilitate private-ip to pivate-ip gateways communications.
-	if useLoadBalancer {
+	// Without dedicated gateways, the gateway ports are opened on the existing worker nodes instead.
+	if useLoadBalancer || !config.DedicatedGateway {
 		internalPorts = append(internalPorts, gwPorts...)
 	}
 
 	//nolint:wrapcheck // No need to wrap errors here.
 	err = aws.RunOn(clusterInfo, config, status,
 		func(cloud api.Cloud, gwDeployer api.GatewayDeployer, status reporter.Interface) error {
-			if config.Gateways > 0 {
+			if config.DedicatedGateway && config.Gateways > 0 {
 				gwInput := api.GatewayDeployInput{
 					PublicPorts:     gwPorts,
 					Gateways:        config.Gateways,
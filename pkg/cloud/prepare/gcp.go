@@ -35,10 +35,15 @@ func GCP(clusterInfo *cluster.Info, ports *cloud.Ports, config *gcp.Config, useL
 		return status.Error(err, "Failed to prepare the cloud")
 	}
 
+	// Without dedicated gateways, the gateway ports are opened on the existing worker nodes instead.
+	if !config.DedicatedGateway {
+		internalPorts = append(internalPorts, gwPorts...)
+	}
+
 	//nolint:wrapcheck // No need to wrap errors here.
 	err = gcp.RunOn(clusterInfo, config, cli.NewReporter(),
 		func(cloud api.Cloud, gwDeployer api.GatewayDeployer, status reporter.Interface) error {
-			if config.Gateways > 0 {
+			if config.DedicatedGateway && config.Gateways > 0 {
 				gwInput := api.GatewayDeployInput{
 					PublicPorts:     gwPorts,
 					Gateways:        config.Gateways,
@@ -28,21 +28,48 @@ import (
 
 const rfc1123Compliant = "0"
 
+var regDNS1123 = regexp.MustCompile("[^a-z0-9-]+")
+
+// IsValidID checks that the given cluster ID is a valid RFC 1123 label, listing the offending characters if any.
 func IsValidID(clusterID string) error {
-	if errs := validation.IsDNS1123Label(clusterID); len(errs) > 0 {
-		return errors.Errorf("%s is not a valid ClusterID %v", clusterID, errs)
+	errs := validation.IsDNS1123Label(clusterID)
+	if len(errs) == 0 {
+		return nil
+	}
+
+	if invalid := invalidIDCharacters(clusterID); invalid != "" {
+		return errors.Errorf("%s is not a valid ClusterID, the characters %q aren't allowed %v", clusterID, invalid, errs)
 	}
 
-	return nil
+	return errors.Errorf("%s is not a valid ClusterID %v", clusterID, errs)
 }
 
+// invalidIDCharacters returns the distinct characters in the given cluster ID which aren't allowed in RFC 1123 labels.
+func invalidIDCharacters(clusterID string) string {
+	invalid := ""
+
+	for _, match := range regDNS1123.FindAllString(clusterID, -1) {
+		for _, r := range match {
+			if !strings.ContainsRune(invalid, r) {
+				invalid += string(r)
+			}
+		}
+	}
+
+	return invalid
+}
+
+// SanitizeID turns the given cluster ID into a valid RFC 1123 label: it's lowercased, invalid characters are replaced
+// with dashes, and it's trimmed to the maximum label length.
 func SanitizeID(clusterID string) string {
 	if clusterID == "" {
 		return ""
 	}
 
-	regDNS1123 := regexp.MustCompile("[^a-z0-9-]+")
 	result := regDNS1123.ReplaceAllString(strings.ToLower(clusterID), "-")
+	if len(result) > validation.DNS1123LabelMaxLength {
+		result = result[:validation.DNS1123LabelMaxLength]
+	}
 
 	if result[0] == '-' {
 		result = rfc1123Compliant + result[1:]
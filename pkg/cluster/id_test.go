@@ -74,6 +74,10 @@ func testIsValidID() {
 			Expect(cluster.IsValidID("abcdéfg")).To(Not(Succeed()))
 			Expect(cluster.IsValidID("abcde.g")).To(Not(Succeed()))
 		})
+
+		It("should list the invalid characters", func() {
+			Expect(cluster.IsValidID("Cluster_1.east_A")).To(MatchError(ContainSubstring(`"C_.A"`)))
+		})
 	})
 
 	When("the id starts or ends with a dash", func() {
@@ -175,12 +179,26 @@ func testSanitizeID() {
 	})
 
 	When("the id is longer than 63 characters", func() {
-		It("should return same id", func() {
-			testID := "0123456789012345678901234567890123456789012345678901234567890123"
+		It("should trim it to 63 characters", func() {
+			testID := "012345678901234567890123456789012345678901234567890123456789012"
 			expectSanitizeIDNoChange(testID)
+			Expect(cluster.SanitizeID(testID + "3456789")).To(Equal(testID))
+			Expect(cluster.IsValidID(cluster.SanitizeID(testID + "3456789"))).To(Succeed())
+		})
+	})
 
-			testID = "0123456789012345678901234567890123456789012345678901234567890123456789"
-			expectSanitizeIDNoChange(testID)
+	When("the id is trimmed after a dash", func() {
+		It("should replace the trailing dash with 0", func() {
+			Expect(cluster.SanitizeID("01234567890123456789012345678901234567890123456789012345678901.23")).To(
+				Equal("012345678901234567890123456789012345678901234567890123456789010"))
+		})
+	})
+
+	When("the id is an EKS ARN", func() {
+		It("should return a valid id", func() {
+			id := cluster.SanitizeID("arn:aws:eks:us-east-1:123456789012:cluster/My_Cluster")
+			Expect(id).To(Equal("arn-aws-eks-us-east-1-123456789012-cluster-my-cluster"))
+			Expect(cluster.IsValidID(id)).To(Succeed())
 		})
 	})
 
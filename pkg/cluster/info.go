@@ -21,6 +21,7 @@ package cluster
 import (
 	"context"
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -32,6 +33,7 @@ import (
 	"github.com/submariner-io/submariner-operator/api/v1alpha1"
 	opnames "github.com/submariner-io/submariner-operator/pkg/names"
 	submarinerv1 "github.com/submariner-io/submariner/pkg/apis/submariner.io/v1"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -40,65 +42,144 @@ import (
 	controllerClient "sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// InClusterName is the name given to the cluster when using the in-cluster configuration, which doesn't provide one;
+// SetInCluster replaces it with the cluster ID when Submariner is installed.
+const InClusterName = "in-cluster"
+
 type Info struct {
-	Name             string
-	RestConfig       *rest.Config
-	ClientProducer   client.Producer
+	Name           string
+	RestConfig     *rest.Config
+	ClientProducer client.Producer
+	// Submariner and ServiceDiscovery are only set once LoadSubmariner has been called; the restconfig producer does so
+	// unless its lookup is deferred. GetSubmariner and GetServiceDiscovery load them on demand.
 	Submariner       *v1alpha1.Submariner
 	ServiceDiscovery *v1alpha1.ServiceDiscovery
-	nodeCount        int
+	// InCluster is set when the cluster is accessed with the in-cluster configuration, i.e. from a pod running in it
+	InCluster bool
+	nodeCount int
+	loaded    bool
 }
 
+// NewInfo creates the information for the given cluster, without retrieving the Submariner and ServiceDiscovery
+// resources (see LoadSubmariner).
 func NewInfo(clusterName string, config *rest.Config) (*Info, error) {
-	info := &Info{
-		Name:       clusterName,
-		RestConfig: config,
-		nodeCount:  -1,
+	clientProducer, err := client.NewProducerFromRestConfig(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating client producer")
 	}
 
-	var err error
+	return NewInfoFromProducer(clusterName, config, clientProducer)
+}
+
+// NewInfoFromProducer creates the information for the given cluster like NewInfo, accessing the cluster with the given
+// client producer.
+func NewInfoFromProducer(clusterName string, config *rest.Config, clientProducer client.Producer) (*Info, error) {
+	info := &Info{
+		Name:           clusterName,
+		RestConfig:     config,
+		ClientProducer: clientProducer,
+		nodeCount:      -1,
+	}
 
-	info.ClientProducer, err = client.NewProducerFromRestConfig(config)
+	_, err := info.GetGateways()
 	if err != nil {
-		return nil, errors.Wrap(err, "error creating client producer")
+		return nil, errors.Wrap(err, "error retrieving Gateways")
 	}
 
+	return info, nil
+}
+
+// SetInCluster marks the information as being accessed with the in-cluster configuration, and names the cluster after
+// its Submariner (or ServiceDiscovery) cluster ID since that configuration doesn't provide a name.
+func (c *Info) SetInCluster() error {
+	c.InCluster = true
+
+	if err := c.LoadSubmariner(); err != nil {
+		return err
+	}
+
+	if c.Submariner != nil && c.Submariner.Spec.ClusterID != "" {
+		c.Name = c.Submariner.Spec.ClusterID
+	} else if c.ServiceDiscovery != nil && c.ServiceDiscovery.Spec.ClusterID != "" {
+		c.Name = c.ServiceDiscovery.Spec.ClusterID
+	}
+
+	return nil
+}
+
+// LoadSubmariner retrieves the Submariner and ServiceDiscovery resources, unless they have already been retrieved.
+func (c *Info) LoadSubmariner() error {
+	if c.loaded {
+		return nil
+	}
+
+	return c.RefreshSubmariner(context.TODO())
+}
+
+// GetSubmariner returns the Submariner resource, loading it if necessary; nil is returned if it doesn't exist.
+func (c *Info) GetSubmariner() (*v1alpha1.Submariner, error) {
+	if err := c.LoadSubmariner(); err != nil {
+		return nil, err
+	}
+
+	return c.Submariner, nil
+}
+
+// GetServiceDiscovery returns the ServiceDiscovery resource, loading it if necessary; nil is returned if it doesn't exist.
+func (c *Info) GetServiceDiscovery() (*v1alpha1.ServiceDiscovery, error) {
+	if err := c.LoadSubmariner(); err != nil {
+		return nil, err
+	}
+
+	return c.ServiceDiscovery, nil
+}
+
+// RefreshSubmariner re-fetches the Submariner and ServiceDiscovery resources, so that long-running operations don't act on
+// stale data if the operator updated them in the meantime. Resources which no longer exist are set to nil.
+func (c *Info) RefreshSubmariner(ctx context.Context) error {
 	submariner := &v1alpha1.Submariner{}
-	err = info.ClientProducer.ForGeneral().Get(context.TODO(), controllerClient.ObjectKey{
-		Namespace: constants.OperatorNamespace,
-		Name:      opnames.SubmarinerCrName,
-	}, submariner)
+	err := RetryOnTransientError(func() error {
+		return c.ClientProducer.ForGeneral().Get(ctx, controllerClient.ObjectKey{
+			Namespace: constants.OperatorNamespace,
+			Name:      opnames.SubmarinerCrName,
+		}, submariner)
+	})
 
 	if err == nil {
-		info.Submariner = submariner
-	} else if !resource.IsNotFoundErr(err) {
-		return nil, errors.Wrap(err, "error retrieving Submariner")
+		c.Submariner = submariner
+	} else if resource.IsNotFoundErr(err) {
+		c.Submariner = nil
+	} else {
+		return errors.Wrap(err, "error retrieving Submariner")
 	}
 
 	serviceDiscovery := &v1alpha1.ServiceDiscovery{}
-	err = info.ClientProducer.ForGeneral().Get(context.TODO(), controllerClient.ObjectKey{
-		Namespace: constants.OperatorNamespace,
-		Name:      opnames.ServiceDiscoveryCrName,
-	}, serviceDiscovery)
+	err = RetryOnTransientError(func() error {
+		return c.ClientProducer.ForGeneral().Get(ctx, controllerClient.ObjectKey{
+			Namespace: constants.OperatorNamespace,
+			Name:      opnames.ServiceDiscoveryCrName,
+		}, serviceDiscovery)
+	})
 
 	if err == nil {
-		info.ServiceDiscovery = serviceDiscovery
-	} else if !resource.IsNotFoundErr(err) {
-		return nil, errors.Wrap(err, "error retrieving ServiceDiscovery")
+		c.ServiceDiscovery = serviceDiscovery
+	} else if resource.IsNotFoundErr(err) {
+		c.ServiceDiscovery = nil
+	} else {
+		return errors.Wrap(err, "error retrieving ServiceDiscovery")
 	}
 
-	_, err = info.GetGateways()
-	if err != nil {
-		return nil, errors.Wrap(err, "error retrieving Gateways")
-	}
+	c.loaded = true
 
-	return info, nil
+	return nil
 }
 
 func (c *Info) GetGateways() ([]submarinerv1.Gateway, error) {
 	gateways := &submarinerv1.GatewayList{}
 
-	err := c.ClientProducer.ForGeneral().List(context.TODO(), gateways, controllerClient.InNamespace(constants.OperatorNamespace))
+	err := RetryOnTransientError(func() error {
+		return c.ClientProducer.ForGeneral().List(context.TODO(), gateways, controllerClient.InNamespace(constants.OperatorNamespace))
+	})
 	if err != nil {
 		if resource.IsNotFoundErr(err) {
 			return []submarinerv1.Gateway{}, nil
@@ -113,7 +194,9 @@ func (c *Info) GetGateways() ([]submarinerv1.Gateway, error) {
 func (c *Info) GetRouteAgents() ([]submarinerv1.RouteAgent, error) {
 	routeAgents := &submarinerv1.RouteAgentList{}
 
-	err := c.ClientProducer.ForGeneral().List(context.TODO(), routeAgents, controllerClient.InNamespace(constants.OperatorNamespace))
+	err := RetryOnTransientError(func() error {
+		return c.ClientProducer.ForGeneral().List(context.TODO(), routeAgents, controllerClient.InNamespace(constants.OperatorNamespace))
+	})
 	if err != nil {
 		if resource.IsNotFoundErr(err) {
 			return []submarinerv1.RouteAgent{}, nil
@@ -127,7 +210,14 @@ func (c *Info) GetRouteAgents() ([]submarinerv1.RouteAgent, error) {
 
 func (c *Info) HasSingleNode() (bool, error) {
 	if c.nodeCount == -1 {
-		nodes, err := c.ClientProducer.ForKubernetes().CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+		var nodes *corev1.NodeList
+
+		err := RetryOnTransientError(func() error {
+			var err error
+			nodes, err = c.ClientProducer.ForKubernetes().CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+
+			return err //nolint:wrapcheck // Wrapped below.
+		})
 		if err != nil {
 			return false, errors.Wrap(err, "error listing Nodes")
 		}
@@ -141,7 +231,9 @@ func (c *Info) HasSingleNode() (bool, error) {
 func (c *Info) GetLocalEndpoint() (*submarinerv1.Endpoint, error) {
 	endpoints := &submarinerv1.EndpointList{}
 
-	err := c.ClientProducer.ForGeneral().List(context.TODO(), endpoints, controllerClient.InNamespace(constants.OperatorNamespace))
+	err := RetryOnTransientError(func() error {
+		return c.ClientProducer.ForGeneral().List(context.TODO(), endpoints, controllerClient.InNamespace(constants.OperatorNamespace))
+	})
 	if err != nil {
 		return nil, errors.Wrap(err, "error listing Endpoints")
 	}
@@ -161,7 +253,9 @@ func (c *Info) GetLocalEndpoint() (*submarinerv1.Endpoint, error) {
 func (c *Info) GetAnyRemoteEndpoint() (*submarinerv1.Endpoint, error) {
 	endpoints := &submarinerv1.EndpointList{}
 
-	err := c.ClientProducer.ForGeneral().List(context.TODO(), endpoints, controllerClient.InNamespace(constants.OperatorNamespace))
+	err := RetryOnTransientError(func() error {
+		return c.ClientProducer.ForGeneral().List(context.TODO(), endpoints, controllerClient.InNamespace(constants.OperatorNamespace))
+	})
 	if err != nil {
 		return nil, errors.Wrap(err, "error listing Endpoints")
 	}
@@ -179,6 +273,8 @@ func (c *Info) GetAnyRemoteEndpoint() (*submarinerv1.Endpoint, error) {
 }
 
 func (c *Info) GetImageRepositoryInfo(localImageOverrides ...string) (*image.RepositoryInfo, error) {
+	var repositoryInfo *image.RepositoryInfo
+
 	if c.Submariner != nil {
 		spec := c.Submariner.Spec
 
@@ -187,15 +283,47 @@ func (c *Info) GetImageRepositoryInfo(localImageOverrides ...string) (*image.Rep
 			return nil, err
 		}
 
-		return image.NewRepositoryInfo(spec.Repository, spec.Version, imageOverrides), nil
+		repositoryInfo = image.NewRepositoryInfo(spec.Repository, spec.Version, imageOverrides)
+	} else {
+		imageOverrides, err := MergeImageOverrides(make(map[string]string), localImageOverrides)
+		if err != nil {
+			return nil, err
+		}
+
+		repositoryInfo = image.NewRepositoryInfo("", "", imageOverrides)
 	}
 
-	imageOverrides, err := MergeImageOverrides(make(map[string]string), localImageOverrides)
+	pullSecrets, err := c.getImagePullSecrets()
 	if err != nil {
 		return nil, err
 	}
 
-	return image.NewRepositoryInfo("", "", imageOverrides), nil
+	repositoryInfo.PullSecrets = pullSecrets
+	repositoryInfo.PullSecretsNamespace = c.OperatorNamespace()
+
+	return repositoryInfo, nil
+}
+
+// getImagePullSecrets returns the names of the pull secrets configured on the operator deployment; the Submariner
+// images come from the same registry as the operator, so they need the same secrets.
+func (c *Info) getImagePullSecrets() ([]string, error) {
+	operatorDeployment, err := c.ClientProducer.ForKubernetes().AppsV1().Deployments(c.OperatorNamespace()).Get(context.TODO(),
+		names.OperatorComponent, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) || apierrors.IsForbidden(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "error retrieving the operator deployment")
+	}
+
+	pullSecrets := []string{}
+
+	for _, pullSecret := range operatorDeployment.Spec.Template.Spec.ImagePullSecrets {
+		pullSecrets = append(pullSecrets, pullSecret.Name)
+	}
+
+	return pullSecrets, nil
 }
 
 func (c *Info) OperatorNamespace() string {
@@ -213,7 +341,9 @@ func (c *Info) OperatorNamespace() string {
 func (c *Info) GetClusters(namespace string) ([]submarinerv1.Cluster, error) {
 	clusters := &submarinerv1.ClusterList{}
 
-	err := c.ClientProducer.ForGeneral().List(context.TODO(), clusters, controllerClient.InNamespace(namespace))
+	err := RetryOnTransientError(func() error {
+		return c.ClientProducer.ForGeneral().List(context.TODO(), clusters, controllerClient.InNamespace(namespace))
+	})
 	if err != nil {
 		return nil, errors.Wrap(err, "error retrieving Clusters")
 	}
@@ -232,6 +362,12 @@ var validOverrides = []string{
 	names.MetricsProxyComponent,
 }
 
+// repositoryPattern matches an image repository without a tag or digest, optionally prefixed with a registry host and port.
+var repositoryPattern = regexp.MustCompile(`^([a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?)*` +
+	`(:[0-9]+)?/)?[a-z0-9]+(([._]|__|-+)[a-z0-9]+)*(/[a-z0-9]+(([._]|__|-+)[a-z0-9]+)*)*$`)
+
+// MergeImageOverrides adds the given component=url overrides to the existing ones. The image.AllComponentsOverride
+// component takes a repository, used for all the components without a specific override.
 func MergeImageOverrides(imageOverrides map[string]string, localImageOverrides []string) (map[string]string, error) {
 	if imageOverrides == nil {
 		imageOverrides = make(map[string]string, len(localImageOverrides))
@@ -243,8 +379,14 @@ func MergeImageOverrides(imageOverrides map[string]string, localImageOverrides [
 			return nil, fmt.Errorf("invalid override %s provided. Please use `a=b` syntax", s)
 		}
 
-		if !slices.Contains(validOverrides, component) {
-			return nil, fmt.Errorf("invalid image override component %q provided. Valid components are %q", component, validOverrides)
+		if component == image.AllComponentsOverride {
+			if !repositoryPattern.MatchString(imageURL) {
+				return nil, fmt.Errorf("invalid image override %q provided: %q isn't a valid image repository, such as"+
+					" registry.example.com/submariner", s, imageURL)
+			}
+		} else if !slices.Contains(validOverrides, component) {
+			return nil, fmt.Errorf("invalid image override component %q provided. Valid components are %q or %q for all components",
+				component, validOverrides, image.AllComponentsOverride)
 		}
 
 		imageOverrides[component] = imageURL
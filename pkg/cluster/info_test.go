@@ -0,0 +1,277 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/admiral/pkg/names"
+	"github.com/submariner-io/subctl/internal/constants"
+	"github.com/submariner-io/subctl/pkg/client"
+	"github.com/submariner-io/subctl/pkg/cluster"
+	"github.com/submariner-io/submariner-operator/api/v1alpha1"
+	opnames "github.com/submariner-io/submariner-operator/pkg/names"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+	controllerClient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Info RefreshSubmariner", func() {
+	var (
+		generalClient controllerClient.Client
+		info          *cluster.Info
+	)
+
+	BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(v1alpha1.AddToScheme(scheme)).To(Succeed())
+
+		generalClient = fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(&v1alpha1.Submariner{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      opnames.SubmarinerCrName,
+				Namespace: constants.OperatorNamespace,
+			},
+			Spec: v1alpha1.SubmarinerSpec{
+				Version: "0.18.0",
+			},
+		}).Build()
+
+		info = &cluster.Info{
+			Name:           "east",
+			ClientProducer: &client.DefaultProducer{GeneralClient: generalClient},
+		}
+
+		Expect(info.RefreshSubmariner(context.TODO())).To(Succeed())
+		Expect(info.Submariner).ToNot(BeNil())
+		Expect(info.ServiceDiscovery).To(BeNil())
+	})
+
+	When("the Submariner resource is updated", func() {
+		It("should retrieve the updated resource", func() {
+			submariner := info.Submariner.DeepCopy()
+			submariner.Spec.Version = "0.19.0"
+			Expect(generalClient.Update(context.TODO(), submariner)).To(Succeed())
+
+			Expect(info.Submariner.Spec.Version).To(Equal("0.18.0"))
+			Expect(info.RefreshSubmariner(context.TODO())).To(Succeed())
+			Expect(info.Submariner.Spec.Version).To(Equal("0.19.0"))
+		})
+	})
+
+	When("the ServiceDiscovery resource is created", func() {
+		It("should retrieve the new resource", func() {
+			Expect(generalClient.Create(context.TODO(), &v1alpha1.ServiceDiscovery{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      opnames.ServiceDiscoveryCrName,
+					Namespace: constants.OperatorNamespace,
+				},
+			})).To(Succeed())
+
+			Expect(info.RefreshSubmariner(context.TODO())).To(Succeed())
+			Expect(info.ServiceDiscovery).ToNot(BeNil())
+		})
+	})
+
+	When("the Submariner resource is deleted", func() {
+		It("should clear the resource", func() {
+			Expect(generalClient.Delete(context.TODO(), info.Submariner)).To(Succeed())
+
+			Expect(info.RefreshSubmariner(context.TODO())).To(Succeed())
+			Expect(info.Submariner).To(BeNil())
+		})
+	})
+})
+
+var _ = Describe("Info GetSubmariner", func() {
+	var (
+		generalClient controllerClient.Client
+		info          *cluster.Info
+	)
+
+	BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(v1alpha1.AddToScheme(scheme)).To(Succeed())
+
+		generalClient = fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(&v1alpha1.Submariner{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      opnames.SubmarinerCrName,
+				Namespace: constants.OperatorNamespace,
+			},
+		}).Build()
+
+		info = &cluster.Info{
+			Name:           "east",
+			ClientProducer: &client.DefaultProducer{GeneralClient: generalClient},
+		}
+	})
+
+	When("the resources haven't been loaded", func() {
+		It("should retrieve them", func() {
+			Expect(info.Submariner).To(BeNil())
+
+			submariner, err := info.GetSubmariner()
+			Expect(err).To(Succeed())
+			Expect(submariner).ToNot(BeNil())
+			Expect(info.Submariner).To(Equal(submariner))
+
+			serviceDiscovery, err := info.GetServiceDiscovery()
+			Expect(err).To(Succeed())
+			Expect(serviceDiscovery).To(BeNil())
+		})
+	})
+
+	When("the resources have already been loaded", func() {
+		It("should not retrieve them again", func() {
+			Expect(info.LoadSubmariner()).To(Succeed())
+
+			Expect(generalClient.Create(context.TODO(), &v1alpha1.ServiceDiscovery{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      opnames.ServiceDiscoveryCrName,
+					Namespace: constants.OperatorNamespace,
+				},
+			})).To(Succeed())
+
+			serviceDiscovery, err := info.GetServiceDiscovery()
+			Expect(err).To(Succeed())
+			Expect(serviceDiscovery).To(BeNil())
+
+			Expect(info.RefreshSubmariner(context.TODO())).To(Succeed())
+			Expect(info.ServiceDiscovery).ToNot(BeNil())
+		})
+	})
+})
+
+var _ = Describe("MergeImageOverrides", func() {
+	When("a component override is specified", func() {
+		It("should add it to the existing overrides", func() {
+			overrides, err := cluster.MergeImageOverrides(map[string]string{"submariner-gateway": "quay.io/other/gateway:v1"},
+				[]string{"submariner-operator=registry.example.com/operator:devel"})
+			Expect(err).To(Succeed())
+			Expect(overrides).To(Equal(map[string]string{
+				"submariner-gateway":  "quay.io/other/gateway:v1",
+				"submariner-operator": "registry.example.com/operator:devel",
+			}))
+		})
+	})
+
+	When("an all-components override with a valid repository is specified", func() {
+		It("should accept it", func() {
+			for _, repository := range []string{
+				"registry.example.com/submariner", "registry.example.com:5000/mirror/submariner", "submariner",
+			} {
+				_, err := cluster.MergeImageOverrides(nil, []string{"*=" + repository})
+				Expect(err).To(Succeed(), repository)
+			}
+		})
+	})
+
+	When("an all-components override with an invalid repository is specified", func() {
+		It("should return an error", func() {
+			for _, repository := range []string{
+				"", "registry.example.com/submariner:v1", "registry.example.com/Submariner", "https://registry",
+			} {
+				_, err := cluster.MergeImageOverrides(nil, []string{"*=" + repository})
+				Expect(err).To(HaveOccurred(), repository)
+			}
+		})
+	})
+
+	When("an unknown component is specified", func() {
+		It("should return an error", func() {
+			_, err := cluster.MergeImageOverrides(nil, []string{"unknown=registry.example.com/unknown"})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("GetImageRepositoryInfo", func() {
+	var info *cluster.Info
+
+	BeforeEach(func() {
+		info = &cluster.Info{
+			Submariner: &v1alpha1.Submariner{
+				ObjectMeta: metav1.ObjectMeta{Namespace: constants.OperatorNamespace},
+				Spec: v1alpha1.SubmarinerSpec{
+					Repository: "quay.io/submariner",
+					Version:    "0.19.0",
+				},
+			},
+			ClientProducer: &client.DefaultProducer{KubeClient: fakeclientset.NewClientset()},
+		}
+	})
+
+	When("the operator deployment has image pull secrets", func() {
+		BeforeEach(func() {
+			info.ClientProducer = &client.DefaultProducer{KubeClient: fakeclientset.NewClientset(&appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Namespace: constants.OperatorNamespace, Name: names.OperatorComponent},
+				Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+					ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry-credentials"}},
+				}}},
+			})}
+		})
+
+		It("should return them", func() {
+			repositoryInfo, err := info.GetImageRepositoryInfo()
+			Expect(err).To(Succeed())
+			Expect(repositoryInfo.PullSecrets).To(Equal([]string{"registry-credentials"}))
+			Expect(repositoryInfo.PullSecretsNamespace).To(Equal(constants.OperatorNamespace))
+		})
+	})
+
+	When("an all-components override is specified", func() {
+		It("should use its repository for all the components", func() {
+			repositoryInfo, err := info.GetImageRepositoryInfo("*=registry.example.com/submariner")
+			Expect(err).To(Succeed())
+			Expect(repositoryInfo.Overrides).ToNot(HaveKey("*"))
+			Expect(repositoryInfo.GetNettestImage()).To(Equal("registry.example.com/submariner/nettest:0.19.0"))
+			Expect(repositoryInfo.GetOperatorImage()).To(Equal("registry.example.com/submariner/submariner-operator:0.19.0"))
+		})
+	})
+
+	When("both all-components and specific overrides are specified", func() {
+		It("should use the specific overrides for their components", func() {
+			for _, overrides := range [][]string{
+				{"*=registry.example.com/submariner", "submariner-nettest=other.example.com/nettest:devel"},
+				{"submariner-nettest=other.example.com/nettest:devel", "*=registry.example.com/submariner"},
+			} {
+				repositoryInfo, err := info.GetImageRepositoryInfo(overrides...)
+				Expect(err).To(Succeed())
+				Expect(repositoryInfo.GetNettestImage()).To(Equal("other.example.com/nettest:devel"))
+				Expect(repositoryInfo.GetOperatorImage()).To(Equal("registry.example.com/submariner/submariner-operator:0.19.0"))
+			}
+		})
+	})
+
+	When("the Submariner resource has specific overrides", func() {
+		It("should preserve them alongside an all-components override", func() {
+			info.Submariner.Spec.ImageOverrides = map[string]string{"submariner-operator": "other.example.com/operator:devel"}
+
+			repositoryInfo, err := info.GetImageRepositoryInfo("*=registry.example.com/submariner")
+			Expect(err).To(Succeed())
+			Expect(repositoryInfo.GetOperatorImage()).To(Equal("other.example.com/operator:devel"))
+			Expect(repositoryInfo.GetNettestImage()).To(Equal("registry.example.com/submariner/nettest:0.19.0"))
+		})
+	})
+})
@@ -0,0 +1,111 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"errors"
+	"math"
+	"net"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	DefaultReadRetryTimeout = 15 * time.Second
+	UnreachableMessage      = "data unavailable (API server unreachable)"
+)
+
+// ReadRetryTimeout bounds how long read operations are retried when the API server is transiently unavailable,
+// e.g. during a rolling restart. Zero disables retries.
+var ReadRetryTimeout = DefaultReadRetryTimeout
+
+// UnreachableError is returned once the retries on a transient error are exhausted.
+type UnreachableError struct {
+	err error
+}
+
+// NewUnreachableError returns an UnreachableError wrapping the given error.
+func NewUnreachableError(err error) error {
+	return &UnreachableError{err: err}
+}
+
+func (e *UnreachableError) Error() string {
+	return "API server unreachable: " + e.err.Error()
+}
+
+func (e *UnreachableError) Unwrap() error {
+	return e.err
+}
+
+func IsUnreachable(err error) bool {
+	var unreachable *UnreachableError
+	return errors.As(err, &unreachable)
+}
+
+// IsTransientError determines whether an error is likely to go away on its own shortly, typically because the API
+// server is restarting.
+func IsTransientError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if utilnet.IsConnectionRefused(err) || utilnet.IsConnectionReset(err) || utilnet.IsProbableEOF(err) {
+		return true
+	}
+
+	if apierrors.IsServiceUnavailable(err) || apierrors.IsServerTimeout(err) || apierrors.IsTimeout(err) ||
+		apierrors.IsTooManyRequests(err) {
+		return true
+	}
+
+	var netErr net.Error
+
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// RetryOnTransientError runs the given operation, retrying with backoff while it fails with a transient error, for up
+// to ReadRetryTimeout. If the error persists, it's returned wrapped in an UnreachableError.
+func RetryOnTransientError(operation func() error) error {
+	backoff := wait.Backoff{
+		Duration: 500 * time.Millisecond,
+		Factor:   2,
+		Jitter:   0.1,
+		Steps:    math.MaxInt32,
+		Cap:      5 * time.Second,
+	}
+
+	deadline := time.Now().Add(ReadRetryTimeout)
+
+	for {
+		err := operation()
+		if !IsTransientError(err) {
+			return err
+		}
+
+		delay := backoff.Step()
+		if time.Until(deadline) < delay {
+			return &UnreachableError{err: err}
+		}
+
+		time.Sleep(delay)
+	}
+}
@@ -0,0 +1,110 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster_test
+
+import (
+	"errors"
+	"syscall"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/subctl/pkg/cluster"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+var _ = Describe("RetryOnTransientError", func() {
+	var (
+		attempts    int
+		failures    int
+		failWith    error
+		origTimeout time.Duration
+	)
+
+	operation := func() error {
+		attempts++
+		if attempts <= failures {
+			return failWith
+		}
+
+		return nil
+	}
+
+	BeforeEach(func() {
+		attempts = 0
+		failWith = syscall.ECONNREFUSED
+
+		origTimeout = cluster.ReadRetryTimeout
+		cluster.ReadRetryTimeout = 2 * time.Second
+	})
+
+	AfterEach(func() {
+		cluster.ReadRetryTimeout = origTimeout
+	})
+
+	When("the operation succeeds immediately", func() {
+		BeforeEach(func() {
+			failures = 0
+		})
+
+		It("should not retry", func() {
+			Expect(cluster.RetryOnTransientError(operation)).To(Succeed())
+			Expect(attempts).To(Equal(1))
+		})
+	})
+
+	When("the connection is refused transiently", func() {
+		BeforeEach(func() {
+			failures = 1
+		})
+
+		It("should retry and succeed", func() {
+			Expect(cluster.RetryOnTransientError(operation)).To(Succeed())
+			Expect(attempts).To(Equal(2))
+		})
+	})
+
+	When("the API server remains unavailable", func() {
+		BeforeEach(func() {
+			failures = 1000
+			failWith = apierrors.NewServiceUnavailable("restarting")
+		})
+
+		It("should return an unreachable error once the timeout expires", func() {
+			err := cluster.RetryOnTransientError(operation)
+			Expect(cluster.IsUnreachable(err)).To(BeTrue())
+			Expect(apierrors.IsServiceUnavailable(errors.Unwrap(err))).To(BeTrue())
+			Expect(attempts).To(BeNumerically(">", 1))
+		})
+	})
+
+	When("the error isn't transient", func() {
+		BeforeEach(func() {
+			failures = 1000
+			failWith = apierrors.NewBadRequest("invalid")
+		})
+
+		It("should return it without retrying", func() {
+			err := cluster.RetryOnTransientError(operation)
+			Expect(apierrors.IsBadRequest(err)).To(BeTrue())
+			Expect(cluster.IsUnreachable(err)).To(BeFalse())
+			Expect(attempts).To(Equal(1))
+		})
+	})
+})
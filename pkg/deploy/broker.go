@@ -21,6 +21,7 @@ package deploy
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/submariner-io/admiral/pkg/reporter"
 	"github.com/submariner-io/subctl/internal/component"
@@ -47,6 +48,8 @@ type BrokerOptions struct {
 	BrokerURL       string
 	BrokerSpec      operatorv1alpha1.BrokerSpec
 	HTTPProxyConfig httpproxy.Config
+	// TokenWaitTimeout is how long to wait for service account tokens to be generated
+	TokenWaitTimeout time.Duration
 }
 
 var ValidComponents = []string{component.ServiceDiscovery, component.Connectivity}
@@ -107,7 +110,7 @@ func Deploy(ctx context.Context, options *BrokerOptions, status reporter.Interfa
 	defer status.End()
 
 	err := broker.Ensure(ctx, crd.UpdaterFromControllerClient(clientProducer.ForGeneral()), clientProducer.ForKubernetes(),
-		options.BrokerSpec.Components, false, options.BrokerNamespace)
+		options.BrokerSpec.Components, false, options.BrokerNamespace, options.TokenWaitTimeout)
 	if err != nil {
 		return status.Error(err, "error setting up broker RBAC")
 	}
@@ -117,7 +120,7 @@ func Deploy(ctx context.Context, options *BrokerOptions, status reporter.Interfa
 	repositoryInfo := image.NewRepositoryInfo(options.Repository, options.ImageVersion, nil)
 
 	err = operator.Ensure(ctx, status, clientProducer, constants.OperatorNamespace, repositoryInfo.GetOperatorImage(),
-		options.OperatorDebug, &options.HTTPProxyConfig)
+		options.OperatorDebug, &options.HTTPProxyConfig, nil, nil)
 	if err != nil {
 		return status.Error(err, "error deploying Submariner operator")
 	}
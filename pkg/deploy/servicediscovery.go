@@ -74,7 +74,7 @@ func populateServiceDiscoverySpec(options *ServiceDiscoveryOptions, brokerInfo *
 	brokerURL := removeSchemaPrefix(brokerInfo.BrokerURL)
 
 	serviceDiscoverySpec := operatorv1alpha1.ServiceDiscoverySpec{
-		Repository:               options.Repository,
+		Repository:               repositoryInfo.Name,
 		Version:                  options.ImageVersion,
 		BrokerK8sCA:              base64.StdEncoding.EncodeToString(brokerSecret.Data["ca.crt"]),
 		BrokerK8sRemoteNamespace: string(brokerSecret.Data["namespace"]),
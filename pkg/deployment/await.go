@@ -20,6 +20,7 @@ package deployment
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/pkg/errors"
@@ -29,6 +30,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/ptr"
 )
 
 const (
@@ -36,8 +38,14 @@ const (
 	waitTime      = 10 * time.Minute
 )
 
-func AwaitReady(ctx context.Context, kubeClient kubernetes.Interface, namespace, deployment string) error {
+// AwaitReady waits for the deployment to be available. If onProgress isn't nil, it's called on every check while the
+// deployment isn't available yet, with the time elapsed so far and a description of the current phase.
+func AwaitReady(ctx context.Context, kubeClient kubernetes.Interface, namespace, deployment string,
+	onProgress func(elapsed time.Duration, phase string),
+) error {
 	deployments := kubeClient.AppsV1().Deployments(namespace)
+	start := time.Now()
+	checks := 0
 
 	//nolint:wrapcheck // No need to wrap here
 	return wait.PollUntilContextTimeout(ctx, checkInterval, waitTime, true, func(_ context.Context) (bool, error) {
@@ -46,10 +54,24 @@ func AwaitReady(ctx context.Context, kubeClient kubernetes.Interface, namespace,
 			return false, errors.Wrap(err, "error waiting for controller deployment to come up")
 		}
 
-		for _, cond := range dp.Status.Conditions {
-			if cond.Type == appsv1.DeploymentAvailable && cond.Status == v1.ConditionTrue {
-				return true, nil
+		var phase string
+
+		if err != nil {
+			phase = "the deployment doesn't exist yet"
+		} else {
+			for _, cond := range dp.Status.Conditions {
+				if cond.Type == appsv1.DeploymentAvailable && cond.Status == v1.ConditionTrue {
+					return true, nil
+				}
 			}
+
+			phase = fmt.Sprintf("%d/%d replicas available", dp.Status.AvailableReplicas, ptr.Deref(dp.Spec.Replicas, 1))
+		}
+
+		// The first check is immediate, there's no progress to report yet
+		checks++
+		if onProgress != nil && checks > 1 {
+			onProgress(time.Since(start).Round(time.Second), phase)
 		}
 
 		return false, nil
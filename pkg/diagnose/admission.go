@@ -0,0 +1,318 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diagnose
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/submariner-io/admiral/pkg/reporter"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+// The controllers report pods they can't create with FailedCreate events; when an admission webhook is responsible, the
+// message reads e.g. `... admission webhook "deny.example.com" denied the request: image isn't signed`.
+const failedCreateReason = "FailedCreate"
+
+var admissionDenialRegexp = regexp.MustCompile(`admission webhook "([^"]+)" denied the request:?\s*(.*)`)
+
+// podCreationFailure describes why a controller couldn't create one of its pods.
+type podCreationFailure struct {
+	message string
+	// webhook is the name of the admission webhook which denied the creation, if any
+	webhook string
+	// reason is the message returned by the webhook, if any
+	reason string
+}
+
+// podCreationFailures returns the distinct pod creation failures reported in the events of the given object, most recent
+// first.
+func podCreationFailures(k8sClient kubernetes.Interface, namespace, kind, name string) ([]podCreationFailure, error) {
+	events, err := k8sClient.CoreV1().Events(namespace).List(context.TODO(), metav1.ListOptions{
+		FieldSelector: fields.Set{
+			"involvedObject.kind": kind,
+			"involvedObject.name": name,
+			"reason":              failedCreateReason,
+		}.String(),
+	})
+	if err != nil {
+		return nil, err //nolint:wrapcheck // The caller reports the error
+	}
+
+	sort.Slice(events.Items, func(i, j int) bool {
+		return eventTime(&events.Items[i]).After(eventTime(&events.Items[j]).Time)
+	})
+
+	failures := []podCreationFailure{}
+	seen := map[string]bool{}
+
+	for i := range events.Items {
+		message := events.Items[i].Message
+		if seen[message] {
+			continue
+		}
+
+		seen[message] = true
+		failure := podCreationFailure{message: message}
+
+		if match := admissionDenialRegexp.FindStringSubmatch(message); match != nil {
+			failure.webhook = match[1]
+			failure.reason = match[2]
+		}
+
+		failures = append(failures, failure)
+	}
+
+	return failures, nil
+}
+
+func eventTime(event *v1.Event) metav1.Time {
+	if !event.LastTimestamp.IsZero() {
+		return event.LastTimestamp
+	}
+
+	if event.EventTime.Time.IsZero() {
+		return event.CreationTimestamp
+	}
+
+	return metav1.Time{Time: event.EventTime.Time}
+}
+
+// reportPodCreationFailures explains why a controller is missing pods, based on its events.
+func reportPodCreationFailures(k8sClient kubernetes.Interface, namespace, kind, name string, status reporter.Interface) {
+	failures, err := podCreationFailures(k8sClient, namespace, kind, name)
+	if err != nil {
+		status.Warning("Unable to retrieve the events for %s %q: %v", kind, name, err)
+		return
+	}
+
+	for _, failure := range failures {
+		if failure.webhook != "" {
+			status.Failure("The creation of pods for %s %q was denied by admission webhook %q: %s", kind, name, failure.webhook,
+				failure.reason)
+		} else {
+			status.Failure("Pods for %s %q couldn't be created: %s", kind, name, failure.message)
+		}
+	}
+}
+
+// reportDeploymentPodCreationFailures explains why a Deployment is missing pods; the pods are created by its ReplicaSets,
+// so that's where the events are.
+func reportDeploymentPodCreationFailures(k8sClient kubernetes.Interface, deployment *appsv1.Deployment, status reporter.Interface) {
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return
+	}
+
+	replicaSets, err := k8sClient.AppsV1().ReplicaSets(deployment.Namespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: selector.String(),
+	})
+	if err != nil {
+		status.Warning("Unable to retrieve the ReplicaSets for Deployment %q: %v", deployment.Name, err)
+		return
+	}
+
+	for i := range replicaSets.Items {
+		if metav1.IsControlledBy(&replicaSets.Items[i], deployment) {
+			reportPodCreationFailures(k8sClient, deployment.Namespace, "ReplicaSet", replicaSets.Items[i].Name, status)
+		}
+	}
+}
+
+// webhookEntry is the part of a validating or mutating webhook relevant to whether it can block pod creation.
+type webhookEntry struct {
+	configuration     string
+	name              string
+	failurePolicy     *admissionregistrationv1.FailurePolicyType
+	rules             []admissionregistrationv1.RuleWithOperations
+	namespaceSelector *metav1.LabelSelector
+	service           *admissionregistrationv1.ServiceReference
+}
+
+// checkAdmissionWebhooks warns about webhooks which would reject the creation of pods in the given namespace because their
+// service is unreachable; with a Fail policy, such webhooks block every matching request.
+func checkAdmissionWebhooks(k8sClient kubernetes.Interface, namespace string, status reporter.Interface) {
+	status.Start("Checking for admission webhooks which could block the creation of Submariner pods")
+	defer status.End()
+
+	ns, err := k8sClient.CoreV1().Namespaces().Get(context.TODO(), namespace, metav1.GetOptions{})
+	if err != nil {
+		status.Warning("Unable to retrieve namespace %q: %v", namespace, err)
+		return
+	}
+
+	webhooks, err := listWebhooks(k8sClient)
+	if err != nil {
+		status.Warning("Unable to list the admission webhook configurations: %v", err)
+		return
+	}
+
+	tracker := reporter.NewTracker(status)
+
+	for i := range webhooks {
+		webhook := &webhooks[i]
+
+		if !webhook.blocksOnFailure() || !webhook.matchesPodCreation() || !webhook.matchesNamespace(ns) {
+			continue
+		}
+
+		if reason := webhookServiceUnavailable(k8sClient, webhook.service); reason != "" {
+			tracker.Warning("Admission webhook %q (in %s) applies to pods in namespace %q with a Fail policy, but %s;"+
+				" pod creation will be denied", webhook.name, webhook.configuration, namespace, reason)
+		}
+	}
+
+	if !tracker.HasWarnings() {
+		status.Success("No admission webhooks are blocking the creation of Submariner pods")
+	}
+}
+
+func listWebhooks(k8sClient kubernetes.Interface) ([]webhookEntry, error) {
+	webhooks := []webhookEntry{}
+
+	validating, err := k8sClient.AdmissionregistrationV1().ValidatingWebhookConfigurations().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err //nolint:wrapcheck // The caller reports the error
+	}
+
+	for i := range validating.Items {
+		for j := range validating.Items[i].Webhooks {
+			webhook := &validating.Items[i].Webhooks[j]
+			webhooks = append(webhooks, webhookEntry{
+				configuration:     "ValidatingWebhookConfiguration " + validating.Items[i].Name,
+				name:              webhook.Name,
+				failurePolicy:     webhook.FailurePolicy,
+				rules:             webhook.Rules,
+				namespaceSelector: webhook.NamespaceSelector,
+				service:           webhook.ClientConfig.Service,
+			})
+		}
+	}
+
+	mutating, err := k8sClient.AdmissionregistrationV1().MutatingWebhookConfigurations().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err //nolint:wrapcheck // The caller reports the error
+	}
+
+	for i := range mutating.Items {
+		for j := range mutating.Items[i].Webhooks {
+			webhook := &mutating.Items[i].Webhooks[j]
+			webhooks = append(webhooks, webhookEntry{
+				configuration:     "MutatingWebhookConfiguration " + mutating.Items[i].Name,
+				name:              webhook.Name,
+				failurePolicy:     webhook.FailurePolicy,
+				rules:             webhook.Rules,
+				namespaceSelector: webhook.NamespaceSelector,
+				service:           webhook.ClientConfig.Service,
+			})
+		}
+	}
+
+	return webhooks, nil
+}
+
+// blocksOnFailure returns true if the webhook rejects requests when it can't be called; Fail is the default policy.
+func (w *webhookEntry) blocksOnFailure() bool {
+	return w.failurePolicy == nil || *w.failurePolicy == admissionregistrationv1.Fail
+}
+
+func (w *webhookEntry) matchesPodCreation() bool {
+	for i := range w.rules {
+		rule := &w.rules[i]
+
+		if matchesAny(rule.APIGroups, "") && matchesAny(rule.Resources, "pods") &&
+			matchesAny(operationStrings(rule.Operations), string(admissionregistrationv1.Create)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (w *webhookEntry) matchesNamespace(ns *v1.Namespace) bool {
+	if w.namespaceSelector == nil {
+		return true
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(w.namespaceSelector)
+	if err != nil {
+		return false
+	}
+
+	return selector.Matches(labels.Set(ns.Labels))
+}
+
+func operationStrings(operations []admissionregistrationv1.OperationType) []string {
+	result := make([]string, len(operations))
+	for i := range operations {
+		result[i] = string(operations[i])
+	}
+
+	return result
+}
+
+func matchesAny(values []string, value string) bool {
+	for _, v := range values {
+		if v == value || v == "*" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// webhookServiceUnavailable returns why the given webhook service can't be reached, or an empty string if it has ready
+// endpoints. Webhooks configured with a URL are assumed to be reachable.
+func webhookServiceUnavailable(k8sClient kubernetes.Interface, service *admissionregistrationv1.ServiceReference) string {
+	if service == nil {
+		return ""
+	}
+
+	_, err := k8sClient.CoreV1().Services(service.Namespace).Get(context.TODO(), service.Name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Sprintf("its service %s/%s can't be retrieved (%v)", service.Namespace, service.Name, err)
+	}
+
+	slices, err := k8sClient.DiscoveryV1().EndpointSlices(service.Namespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: labels.Set{discoveryv1.LabelServiceName: service.Name}.String(),
+	})
+	if err != nil {
+		return ""
+	}
+
+	for i := range slices.Items {
+		for j := range slices.Items[i].Endpoints {
+			ready := slices.Items[i].Endpoints[j].Conditions.Ready
+			if ready == nil || *ready {
+				return ""
+			}
+		}
+	}
+
+	return fmt.Sprintf("its service %s/%s has no ready endpoints", service.Namespace, service.Name)
+}
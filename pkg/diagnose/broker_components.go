@@ -0,0 +1,160 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diagnose
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/submariner-io/admiral/pkg/reporter"
+	"github.com/submariner-io/admiral/pkg/resource"
+	"github.com/submariner-io/subctl/internal/component"
+	"github.com/submariner-io/subctl/internal/restconfig"
+	"github.com/submariner-io/subctl/pkg/broker"
+	"github.com/submariner-io/subctl/pkg/client"
+	"github.com/submariner-io/subctl/pkg/cluster"
+	"github.com/submariner-io/submariner-operator/api/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/utils/set"
+	controllerClient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// BrokerComponents checks that the components enabled in the cluster were also enabled at the broker; for example, a
+// cluster using service discovery joined to a broker deployed with only connectivity doesn't sync its services. The
+// broker's components are read from the Broker resource if the broker credentials allow it, and otherwise from those
+// recorded when the cluster joined.
+func BrokerComponents(clusterInfo *cluster.Info, _ string, status reporter.Interface) error {
+	required := enabledComponents(clusterInfo)
+	if required.Len() == 0 {
+		return nil
+	}
+
+	status.Start("Checking that the components enabled in the cluster are enabled at the broker")
+	defer status.End()
+
+	tracker := reporter.NewTracker(status)
+
+	brokerResource, err := brokerResourceFor(clusterInfo)
+	if err != nil {
+		tracker.Warning("Unable to retrieve the Broker resource with the broker credentials, using the components recorded"+
+			" when the cluster joined instead: %v", err)
+	}
+
+	var available set.Set[string]
+
+	if brokerResource != nil {
+		available = broker.ComponentsOf(brokerResource)
+	} else {
+		available, err = broker.JoinedComponents(context.TODO(), clusterInfo)
+		if err != nil {
+			tracker.Failure("Error retrieving the components recorded when the cluster joined: %v", err)
+			return nil
+		}
+	}
+
+	if available == nil {
+		tracker.Warning("Unable to determine the components enabled at the broker; the cluster was likely joined by an older" +
+			" version of subctl")
+		return nil
+	}
+
+	missing := broker.MissingComponents(required, available)
+	if len(missing) == 0 {
+		tracker.Success("The broker has all the components enabled in the cluster (%s)", strings.Join(required.SortedList(), ", "))
+		return nil
+	}
+
+	tracker.Warning("The component(s) %s are enabled in the cluster but weren't enabled at the broker, so they won't work"+
+		" across clusters; %s", strings.Join(missing, ", "), componentsRemediation(brokerResource, available.Union(required)))
+
+	return nil
+}
+
+func enabledComponents(clusterInfo *cluster.Info) set.Set[string] {
+	components := set.New[string]()
+
+	if clusterInfo.Submariner != nil {
+		components.Insert(component.Connectivity)
+
+		if clusterInfo.Submariner.Spec.GlobalCIDR != "" {
+			components.Insert(component.Globalnet)
+		}
+
+		if clusterInfo.Submariner.Spec.ServiceDiscoveryEnabled {
+			components.Insert(component.ServiceDiscovery)
+		}
+	}
+
+	if clusterInfo.ServiceDiscovery != nil {
+		components.Insert(component.ServiceDiscovery)
+	}
+
+	return components
+}
+
+// brokerResourceFor retrieves the Broker resource using the cluster's broker credentials; nil is returned if there is none.
+func brokerResourceFor(clusterInfo *cluster.Info) (*v1alpha1.Broker, error) {
+	brokerRestConfig, brokerNamespace, err := restconfig.ForBroker(clusterInfo.Submariner, clusterInfo.ServiceDiscovery)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // No need to wrap errors here.
+	}
+
+	brokerProducer, err := client.NewProducerFromRestConfig(brokerRestConfig)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // No need to wrap errors here.
+	}
+
+	brokerList := &v1alpha1.BrokerList{}
+
+	// Member clusters' credentials usually aren't allowed to read the Broker resource
+	err = brokerProducer.ForGeneral().List(context.TODO(), brokerList, controllerClient.InNamespace(brokerNamespace))
+	if apierrors.IsForbidden(err) || resource.IsNotFoundErr(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err //nolint:wrapcheck // No need to wrap errors here.
+	}
+
+	if len(brokerList.Items) == 0 {
+		return nil, nil
+	}
+
+	return &brokerList.Items[0], nil
+}
+
+func componentsRemediation(brokerResource *v1alpha1.Broker, components set.Set[string]) string {
+	// Globalnet is enabled with its own flag
+	redeploy := "redeploy the broker with \"subctl deploy-broker --components " +
+		strings.Join(components.Clone().Delete(component.Globalnet).SortedList(), ",")
+
+	if components.Has(component.Globalnet) {
+		redeploy += " --globalnet"
+	}
+
+	redeploy += "\""
+
+	if brokerResource == nil {
+		return redeploy
+	}
+
+	return fmt.Sprintf("%s, or update the Broker resource %s/%s accordingly", redeploy, brokerResource.Namespace,
+		brokerResource.Name)
+}
@@ -0,0 +1,122 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diagnose
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/submariner-io/admiral/pkg/reporter"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/yaml"
+)
+
+var ipPoolNameReplacer = strings.NewReplacer(".", "-", "/", "-", ":", "-")
+
+// calicoIPPoolFixer creates or patches the Calico IPPools for the remote clusters' CIDRs. A nil fixer only reports
+// the problems.
+type calicoIPPoolFixer struct {
+	client dynamic.ResourceInterface
+	dryRun bool
+}
+
+// fix reports the given problem with the IPPool for a remote CIDR, and fixes it if fixing is enabled: the IPPool is
+// created if there is no existing pool, otherwise the existing pool is patched.
+func (f *calicoIPPoolFixer) fix(ippools map[string]unstructured.Unstructured, existing *unstructured.Unstructured, cidr, problem string,
+	status reporter.Interface,
+) {
+	if f == nil {
+		status.Failure(problem)
+		return
+	}
+
+	name := "submariner-remote-" + strings.ToLower(ipPoolNameReplacer.Replace(cidr))
+	if existing != nil {
+		name = existing.GetName()
+	}
+
+	// The traffic to the remote CIDRs is carried by Submariner: Calico mustn't allocate addresses from these pools,
+	// masquerade traffic to them, or encapsulate it. Existing pools only have their allocation and masquerading fixed.
+	spec := map[string]interface{}{
+		"disabled":    true,
+		"natOutgoing": false,
+	}
+
+	if existing == nil {
+		spec["cidr"] = cidr
+		spec["ipipMode"] = "Never"
+		spec["vxlanMode"] = "Never"
+	}
+
+	manifest := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	manifest.SetAPIVersion(calicoGVR.GroupVersion().String())
+	manifest.SetKind("IPPool")
+	manifest.SetName(name)
+
+	if f.dryRun {
+		status.Failure(problem)
+
+		out, err := yaml.Marshal(manifest.Object)
+		if err != nil {
+			status.Failure("Error marshalling the IPPool %q: %v", name, err)
+			return
+		}
+
+		if existing == nil {
+			status.Warning("--fix would create IPPool %q:\n%s", name, out)
+		} else {
+			status.Warning("--fix would patch IPPool %q with:\n%s", name, out)
+		}
+
+		return
+	}
+
+	var (
+		fixed *unstructured.Unstructured
+		err   error
+	)
+
+	if existing == nil {
+		fixed, err = f.client.Create(context.TODO(), manifest, metav1.CreateOptions{})
+	} else {
+		var patch []byte
+
+		patch, err = json.Marshal(map[string]interface{}{"spec": spec})
+		if err == nil {
+			fixed, err = f.client.Patch(context.TODO(), name, types.MergePatchType, patch, metav1.PatchOptions{})
+		}
+	}
+
+	if err != nil {
+		status.Failure("%s, and fixing IPPool %q failed: %v", problem, name, err)
+		return
+	}
+
+	ippools[cidr] = *fixed
+
+	if existing == nil {
+		status.Success("%s: created IPPool %q", problem, name)
+	} else {
+		status.Success("%s: patched IPPool %q", problem, name)
+	}
+}
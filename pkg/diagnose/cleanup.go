@@ -0,0 +1,91 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diagnose
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/submariner-io/admiral/pkg/reporter"
+	"github.com/submariner-io/subctl/internal/constants"
+	"github.com/submariner-io/subctl/pkg/cluster"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TransientPods deletes the transient pods left over by interrupted diagnose and verify runs, in all namespaces, along with
+// the transient image pull secret copies created for them.
+func TransientPods(clusterInfo *cluster.Info, _ string, status reporter.Interface) error {
+	status.Start("Deleting leftover transient pods and secrets")
+	defer status.End()
+
+	kubeClient := clusterInfo.ClientProducer.ForKubernetes()
+	listOptions := metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", constants.TransientLabel, constants.TrueLabel),
+	}
+
+	podList, err := kubeClient.CoreV1().Pods(corev1.NamespaceAll).List(context.TODO(), listOptions)
+	if err != nil {
+		return status.Error(err, "Error listing the transient pods")
+	}
+
+	secretList, err := kubeClient.CoreV1().Secrets(corev1.NamespaceAll).List(context.TODO(), listOptions)
+	if err != nil {
+		return status.Error(err, "Error listing the transient secrets")
+	}
+
+	tracker := reporter.NewTracker(status)
+
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+
+		err := kubeClient.CoreV1().Pods(pod.Namespace).Delete(context.TODO(), pod.Name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			tracker.Failure("Error deleting pod %s/%s: %v", pod.Namespace, pod.Name, err)
+			continue
+		}
+
+		tracker.Success("Deleted pod %s/%s", pod.Namespace, pod.Name)
+	}
+
+	// The secrets are deleted after the pods using them
+	for i := range secretList.Items {
+		secret := &secretList.Items[i]
+
+		err := kubeClient.CoreV1().Secrets(secret.Namespace).Delete(context.TODO(), secret.Name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			tracker.Failure("Error deleting secret %s/%s: %v", secret.Namespace, secret.Name, err)
+			continue
+		}
+
+		tracker.Success("Deleted secret %s/%s", secret.Namespace, secret.Name)
+	}
+
+	if tracker.HasFailures() {
+		return errors.New("failures while deleting the transient pods and secrets")
+	}
+
+	if len(podList.Items) == 0 && len(secretList.Items) == 0 {
+		status.Success("There are no leftover transient pods or secrets")
+	}
+
+	return nil
+}
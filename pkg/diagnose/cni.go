@@ -22,13 +22,16 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/coreos/go-semver/semver"
 	"github.com/pkg/errors"
 	"github.com/submariner-io/admiral/pkg/reporter"
 	"github.com/submariner-io/subctl/pkg/cluster"
+	"github.com/submariner-io/submariner-operator/api/v1alpha1"
 	submv1 "github.com/submariner-io/submariner/pkg/apis/submariner.io/v1"
+	submcidr "github.com/submariner-io/submariner/pkg/cidr"
 	"github.com/submariner-io/submariner/pkg/cni"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -38,6 +41,7 @@ import (
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/utils/set"
 )
 
 const ovnKubeDBPodLabel = "ovn-db-pod=true"
@@ -56,7 +60,15 @@ var calicoGVR = schema.GroupVersionResource{
 	Resource: "ippools",
 }
 
-func CNIConfig(clusterInfo *cluster.Info, _ string, status reporter.Interface) error {
+// CNIOptions configures the CNI checks.
+type CNIOptions struct {
+	// Fix creates or patches the Calico IPPools required for the remote clusters' CIDRs
+	Fix bool
+	// FixDryRun shows the Calico IPPool changes Fix would make, without making them
+	FixDryRun bool
+}
+
+func CNIConfig(clusterInfo *cluster.Info, _ string, options CNIOptions, status reporter.Interface) error {
 	mustHaveSubmariner(clusterInfo)
 
 	status.Start("Checking Submariner support for the CNI network plugin")
@@ -93,10 +105,27 @@ func CNIConfig(clusterInfo *cluster.Info, _ string, status reporter.Interface) e
 		return checkOVNVersion(context.TODO(), clusterInfo, status)
 	}
 
-	return checkCalicoIPPoolsIfCalicoCNI(clusterInfo, status)
+	return checkCalicoIPPoolsIfCalicoCNI(clusterInfo, options, status)
+}
+
+// CalicoIPPools checks the Calico IPPools used with Submariner: the IPPools for the remote clusters' CIDRs, the
+// encapsulation used by the local pools, and overlaps with the service CIDR.
+func CalicoIPPools(clusterInfo *cluster.Info, _ string, status reporter.Interface) error {
+	mustHaveSubmariner(clusterInfo)
+
+	if !strings.EqualFold(clusterInfo.Submariner.Status.NetworkPlugin, cni.Calico) {
+		status.Start("Checking the Calico IPPools")
+		status.Success("The detected CNI network plugin (%q) isn't Calico, skipping this check",
+			clusterInfo.Submariner.Status.NetworkPlugin)
+		status.End()
+
+		return nil
+	}
+
+	return checkCalicoIPPoolsIfCalicoCNI(clusterInfo, CNIOptions{}, status)
 }
 
-func checkCalicoIPPoolsIfCalicoCNI(info *cluster.Info, status reporter.Interface) error {
+func checkCalicoIPPoolsIfCalicoCNI(info *cluster.Info, options CNIOptions, status reporter.Interface) error {
 	if !strings.EqualFold(info.Submariner.Status.NetworkPlugin, cni.Calico) {
 		return nil
 	}
@@ -142,7 +171,14 @@ func checkCalicoIPPoolsIfCalicoCNI(info *cluster.Info, status reporter.Interface
 		ippools[cidr] = pool
 	}
 
-	checkCalicoIPPools(gateways, ippools, tracker)
+	var fixer *calicoIPPoolFixer
+	if options.Fix || options.FixDryRun {
+		fixer = &calicoIPPoolFixer{client: client, dryRun: options.FixDryRun}
+	}
+
+	checkCalicoIPPools(gateways, ippools, fixer, tracker)
+	checkCalicoEncapsulationMode(gateways, ippools, tracker)
+	checkCalicoServiceCIDROverlap(info.Submariner, ippools, tracker)
 
 	if tracker.HasFailures() {
 		return errors.New("failures while diagnosing CNI")
@@ -151,19 +187,23 @@ func checkCalicoIPPoolsIfCalicoCNI(info *cluster.Info, status reporter.Interface
 	return nil
 }
 
-func checkCalicoIPPools(gateways []submv1.Gateway, ippools map[string]unstructured.Unstructured, status reporter.Interface) {
+func checkCalicoIPPools(gateways []submv1.Gateway, ippools map[string]unstructured.Unstructured, fixer *calicoIPPoolFixer,
+	status reporter.Interface,
+) {
 	for i := range gateways {
 		gateway := &gateways[i]
 		if gateway.Status.HAStatus != submv1.HAStatusActive {
 			continue
 		}
 
-		checkCalicoSubmConfig(gateway, ippools, status)
+		checkCalicoSubmConfig(gateway, ippools, fixer, status)
 		checkCalicoEncapsulation(gateway, ippools, status)
 	}
 }
 
-func checkCalicoSubmConfig(gateway *submv1.Gateway, ippools map[string]unstructured.Unstructured, status reporter.Interface) {
+func checkCalicoSubmConfig(gateway *submv1.Gateway, ippools map[string]unstructured.Unstructured, fixer *calicoIPPoolFixer,
+	status reporter.Interface,
+) {
 	for j := range gateway.Status.Connections {
 		connection := &gateway.Status.Connections[j]
 		for _, subnet := range connection.Endpoint.Subnets {
@@ -178,8 +218,8 @@ func checkCalicoSubmConfig(gateway *submv1.Gateway, ippools map[string]unstructu
 				// When spec.disabled is set to true, Calico IPAM will not assign addresses from this Pool.
 				// The IPPools configured for Submariner remote CIDRs should have disabled as true.
 				if !isDisabled {
-					status.Failure("The IPPool %q with CIDR %q for remote endpoint"+
-						" %q has disabled set to false", ipPool.GetName(), subnet, connection.Endpoint.CableName)
+					fixer.fix(ippools, &ipPool, subnet, fmt.Sprintf("The IPPool %q with CIDR %q for remote endpoint"+
+						" %q has disabled set to false", ipPool.GetName(), subnet, connection.Endpoint.CableName), status)
 					continue
 				}
 
@@ -193,13 +233,13 @@ func checkCalicoSubmConfig(gateway *submv1.Gateway, ippools map[string]unstructu
 				// any Calico IP pools will be masqueraded.
 				// The IPPools configured for Submariner remote CIDRs should have natOutgoing as false.
 				if natOutgoing {
-					status.Failure("The IPPool %q with CIDR %q for remote endpoint"+
-						" %q has natOutgoing set to true", ipPool.GetName(), subnet, connection.Endpoint.CableName)
+					fixer.fix(ippools, &ipPool, subnet, fmt.Sprintf("The IPPool %q with CIDR %q for remote endpoint"+
+						" %q has natOutgoing set to true", ipPool.GetName(), subnet, connection.Endpoint.CableName), status)
 					continue
 				}
 			} else {
-				status.Failure("Could not find any IPPool with CIDR %q for remote"+
-					" endpoint %q", subnet, connection.Endpoint.CableName)
+				fixer.fix(ippools, nil, subnet, fmt.Sprintf("Could not find any IPPool with CIDR %q for remote"+
+					" endpoint %q", subnet, connection.Endpoint.CableName), status)
 				continue
 			}
 		}
@@ -210,22 +250,107 @@ func checkCalicoEncapsulation(gateway *submv1.Gateway, ippools map[string]unstru
 	for _, subnet := range gateway.Status.LocalEndpoint.Subnets {
 		ipPool, found := ippools[subnet]
 		if found {
-			vxlanMode, err := getSpecString(ipPool, "vxlanMode")
+			// Calico defaults both encapsulation modes to Never
+			vxlanMode, _, err := unstructured.NestedString(ipPool.Object, "spec", "vxlanMode")
 			if err != nil {
-				status.Failure(err.Error())
+				status.Failure("Error extracting field vxlanMode from IPPool %q: %v", ipPool.GetName(), err)
+				continue
+			}
+
+			ipipMode, _, err := unstructured.NestedString(ipPool.Object, "spec", "ipipMode")
+			if err != nil {
+				status.Failure("Error extracting field ipipMode from IPPool %q: %v", ipPool.GetName(), err)
 				continue
 			}
 
 			// Calico supports different types of overlay networking. Currently, Submariner is validated only
 			// when Calico is deployed with VXLAN encapsulation.
 			if vxlanMode != "Always" {
-				status.Failure("Calico IPPool %q does not seem to be configured with VXLAN overlay encapsulation.", ipPool.GetName())
+				if ipipMode != "" && ipipMode != "Never" {
+					status.Failure("Calico IPPool %q uses IPIP encapsulation (ipipMode: %s) instead of VXLAN; Submariner only"+
+						" supports VXLAN overlay encapsulation with Calico", ipPool.GetName(), ipipMode)
+				} else {
+					status.Failure("Calico IPPool %q does not seem to be configured with VXLAN overlay encapsulation.", ipPool.GetName())
+				}
+
 				continue
 			}
 		}
 	}
 }
 
+func checkCalicoEncapsulationMode(gateways []submv1.Gateway, ippools map[string]unstructured.Unstructured, status reporter.Interface) {
+	// The local pools' encapsulation is checked by checkCalicoEncapsulation
+	localSubnets := set.New[string]()
+
+	for i := range gateways {
+		if gateways[i].Status.HAStatus == submv1.HAStatusActive {
+			localSubnets.Insert(gateways[i].Status.LocalEndpoint.Subnets...)
+		}
+	}
+
+	for _, cidr := range sortedPoolCIDRs(ippools) {
+		ipPool := ippools[cidr]
+
+		if localSubnets.Has(cidr) {
+			continue
+		}
+
+		ipipMode, _, err := unstructured.NestedString(ipPool.Object, "spec", "ipipMode")
+		if err != nil {
+			status.Failure("Error extracting field ipipMode from IPPool %q: %v", ipPool.GetName(), err)
+			continue
+		}
+
+		// Submariner's inter-cluster traffic is carried over VXLAN within the cluster; Calico's IPIP encapsulation
+		// on top of that can lead to double encapsulation, with MTU and performance issues.
+		if ipipMode == "Always" {
+			status.Warning("Calico IPPool %q uses IPIP encapsulation (ipipMode: Always), which can cause double"+
+				" encapsulation issues with Submariner's VXLAN tunnels; VXLAN encapsulation is recommended instead", ipPool.GetName())
+		}
+	}
+}
+
+func checkCalicoServiceCIDROverlap(submariner *v1alpha1.Submariner, ippools map[string]unstructured.Unstructured,
+	status reporter.Interface,
+) {
+	serviceCIDR := submariner.Spec.ServiceCIDR
+	if serviceCIDR == "" {
+		serviceCIDR = submariner.Status.ServiceCIDR
+	}
+
+	if serviceCIDR == "" {
+		return
+	}
+
+	for _, poolCIDR := range sortedPoolCIDRs(ippools) {
+		ipPool := ippools[poolCIDR]
+
+		overlap, err := submcidr.IsOverlapping([]string{serviceCIDR}, poolCIDR)
+		if err != nil {
+			status.Failure("Error checking the CIDR %q of IPPool %q: %v", poolCIDR, ipPool.GetName(), err)
+			continue
+		}
+
+		if overlap {
+			status.Failure("The CIDR %q of Calico IPPool %q overlaps with the service CIDR %q", poolCIDR, ipPool.GetName(),
+				serviceCIDR)
+		}
+	}
+}
+
+func sortedPoolCIDRs(ippools map[string]unstructured.Unstructured) []string {
+	cidrs := make([]string, 0, len(ippools))
+
+	for cidr := range ippools {
+		cidrs = append(cidrs, cidr)
+	}
+
+	sort.Strings(cidrs)
+
+	return cidrs
+}
+
 func getSpecBool(pool unstructured.Unstructured, key string) (bool, error) {
 	// value defaults to false when not found; not finding a bool isn't an error
 	value, _, err := unstructured.NestedBool(pool.Object, "spec", key)
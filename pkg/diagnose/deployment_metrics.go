@@ -19,32 +19,51 @@ limitations under the License.
 package diagnose
 
 import (
+	"context"
+	"fmt"
 	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/submariner-io/admiral/pkg/reporter"
 	"github.com/submariner-io/subctl/pkg/cluster"
+	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	apierrors "k8s.io/apimachinery/pkg/util/errors"
 )
 
-const (
-	curlCmd              = "curl -I -m 10 --retry 6"
-	gwCurlMetricsCommand = curlCmd + " submariner-gateway-metrics.submariner-operator.svc.cluster.local:8080/metrics"
-	gnCurlMetricsCommand = curlCmd + " submariner-globalnet-metrics.submariner-operator.svc.cluster.local:8081/metrics"
+// DefaultMetricsTimeout is the default time in seconds allowed to scrape each metrics endpoint.
+const DefaultMetricsTimeout = 10
+
+// The pod output is read from its termination log which is size-limited, so only the HTTP status and the first
+// Submariner metric are kept.
+const metricsScrapeCommand = "echo \"HTTP $(curl -s -m %[1]d --retry 6 --retry-max-time %[1]d -o /tmp/metrics" +
+	" -w '%%{http_code}' %[2]s)\"; grep -m 1 '^submariner_' /tmp/metrics || true"
+
+type metricsEndpoint struct {
+	component string
+	service   string
+	port      int
+}
+
+var (
+	gatewayMetricsEndpoint   = metricsEndpoint{component: "gateway", service: "submariner-gateway-metrics", port: 8080}
+	globalnetMetricsEndpoint = metricsEndpoint{component: "globalnet", service: "submariner-globalnet-metrics", port: 8081}
 )
 
-func checkMetricsConfig(clusterInfo *cluster.Info, imageOverrides []string, status reporter.Interface) error {
+func checkMetricsConfig(clusterInfo *cluster.Info, options DeploymentOptions, status reporter.Interface) error {
 	if clusterInfo.Submariner == nil {
 		return nil
 	}
 
 	metricsErrors := []error{}
-	if err := checkComponentMetrics(clusterInfo, imageOverrides, "gateway", gwCurlMetricsCommand, status); err != nil {
+	if err := checkComponentMetrics(clusterInfo, options, gatewayMetricsEndpoint, status); err != nil {
 		metricsErrors = append(metricsErrors, err)
 	}
 
 	if clusterInfo.Submariner.Spec.GlobalCIDR != "" {
-		if err := checkComponentMetrics(clusterInfo, imageOverrides, "globalnet", gnCurlMetricsCommand, status); err != nil {
+		if err := checkComponentMetrics(clusterInfo, options, globalnetMetricsEndpoint, status); err != nil {
 			metricsErrors = append(metricsErrors, err)
 		}
 	}
@@ -52,8 +71,10 @@ func checkMetricsConfig(clusterInfo *cluster.Info, imageOverrides []string, stat
 	return apierrors.NewAggregate(metricsErrors)
 }
 
-func checkComponentMetrics(clusterInfo *cluster.Info, imageOverrides []string, component, command string, status reporter.Interface) error {
-	status.Start("Checking that %s metrics are accessible from non-gateway nodes", component)
+func checkComponentMetrics(clusterInfo *cluster.Info, options DeploymentOptions, endpoint metricsEndpoint,
+	status reporter.Interface,
+) error {
+	status.Start("Checking that %s metrics are accessible from non-gateway nodes", endpoint.component)
 	defer status.End()
 
 	singleNode, err := clusterInfo.HasSingleNode()
@@ -66,13 +87,21 @@ func checkComponentMetrics(clusterInfo *cluster.Info, imageOverrides []string, c
 		return nil
 	}
 
-	repositoryInfo, err := clusterInfo.GetImageRepositoryInfo(imageOverrides...)
+	repositoryInfo, err := clusterInfo.GetImageRepositoryInfo(options.ImageOverrides...)
 	if err != nil {
 		return status.Error(err, "Error determining repository information")
 	}
 
+	timeout := options.MetricsTimeout
+	if timeout == 0 {
+		timeout = DefaultMetricsTimeout
+	}
+
+	namespace := clusterInfo.Submariner.Namespace
+	url := fmt.Sprintf("%s.%s.svc.cluster.local:%d/metrics", endpoint.service, namespace, endpoint.port)
+
 	cPod, err := spawnClientPodOnNonGatewayNode(clusterInfo.ClientProducer.ForKubernetes(),
-		clusterInfo.Submariner.Namespace, command, repositoryInfo)
+		namespace, fmt.Sprintf(metricsScrapeCommand, timeout, url), repositoryInfo)
 	if err != nil {
 		return status.Error(err, "Error spawning the client pod on non-Gateway node")
 	}
@@ -83,11 +112,107 @@ func checkComponentMetrics(clusterInfo *cluster.Info, imageOverrides []string, c
 		return status.Error(err, "Error waiting for the client pod to finish its execution")
 	}
 
-	// Expected response: "HTTP/1.1 200 OK"
-	if !strings.Contains(cPod.PodOutput, "200 OK") {
-		return status.Error(errors.Errorf("Unexpected output %v", cPod.PodOutput),
-			"Unable to access %s metrics service in submariner-operator namespace", component)
+	if err = checkMetricsOutput(cPod.PodOutput); err != nil {
+		reportMetricsNetworkPolicies(clusterInfo, endpoint, status)
+
+		return status.Error(err, "Unable to scrape the %s metrics from %s", endpoint.component, url)
 	}
 
+	status.Success("The %s metrics are served by %s", endpoint.component, url)
+
 	return nil
 }
+
+// checkMetricsOutput checks the output of metricsScrapeCommand: the HTTP status must be 200, and the response must
+// include at least one Submariner metric.
+func checkMetricsOutput(output string) error {
+	statusLine, metric, _ := strings.Cut(strings.TrimSpace(output), "\n")
+
+	switch code := strings.TrimSpace(strings.TrimPrefix(statusLine, "HTTP")); code {
+	case "200":
+	case "", "000":
+		return errors.New("the metrics endpoint couldn't be reached")
+	default:
+		return errors.Errorf("the metrics endpoint returned HTTP status %s", code)
+	}
+
+	if !strings.HasPrefix(metric, "submariner_") {
+		return errors.New("the metrics endpoint didn't return any Submariner metrics")
+	}
+
+	return nil
+}
+
+// reportMetricsNetworkPolicies warns about the NetworkPolicies in the namespace which select the pods serving the metrics,
+// since they may block access to the metrics.
+func reportMetricsNetworkPolicies(clusterInfo *cluster.Info, endpoint metricsEndpoint, status reporter.Interface) {
+	kubeClient := clusterInfo.ClientProducer.ForKubernetes()
+	namespace := clusterInfo.Submariner.Namespace
+
+	service, err := kubeClient.CoreV1().Services(namespace).Get(context.TODO(), endpoint.service, metav1.GetOptions{})
+	if err != nil {
+		status.Warning("Unable to retrieve the %q Service: %v", endpoint.service, err)
+		return
+	}
+
+	podList, err := kubeClient.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(service.Spec.Selector).String(),
+	})
+	if err != nil {
+		status.Warning("Unable to list the pods selected by the %q Service: %v", endpoint.service, err)
+		return
+	}
+
+	if len(podList.Items) == 0 {
+		status.Failure("The %q Service doesn't select any pods", endpoint.service)
+		return
+	}
+
+	policies, err := kubeClient.NetworkingV1().NetworkPolicies(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		status.Warning("Unable to list the NetworkPolicies in namespace %q: %v", namespace, err)
+		return
+	}
+
+	selecting := []string{}
+
+	for i := range policies.Items {
+		if selectsIngressOf(&policies.Items[i], podList.Items) {
+			selecting = append(selecting, policies.Items[i].Name)
+		}
+	}
+
+	if len(selecting) > 0 {
+		status.Warning("The NetworkPolicies %q in namespace %q select the %s metrics pods and may be blocking access to the metrics",
+			selecting, namespace, endpoint.component)
+	}
+}
+
+// selectsIngressOf determines whether the given NetworkPolicy restricts the ingress traffic of any of the given pods.
+func selectsIngressOf(policy *networkingv1.NetworkPolicy, pods []v1.Pod) bool {
+	// Policies without types restrict ingress
+	restrictsIngress := len(policy.Spec.PolicyTypes) == 0
+
+	for _, policyType := range policy.Spec.PolicyTypes {
+		if policyType == networkingv1.PolicyTypeIngress {
+			restrictsIngress = true
+		}
+	}
+
+	if !restrictsIngress {
+		return false
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(&policy.Spec.PodSelector)
+	if err != nil {
+		return false
+	}
+
+	for i := range pods {
+		if selector.Matches(labels.Set(pods[i].Labels)) {
+			return true
+		}
+	}
+
+	return false
+}
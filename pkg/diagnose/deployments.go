@@ -37,18 +37,33 @@ import (
 	controllerClient "sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-func Deployments(clusterInfo *cluster.Info, _ string, imageOverrides []string, status reporter.Interface) error {
+// DefaultRestartThreshold is the default number of restarts from which a Submariner container is reported.
+const DefaultRestartThreshold = 5
+
+type DeploymentOptions struct {
+	ImageOverrides []string
+	// RestartThreshold is the number of restarts from which a container is reported; 0 disables the check
+	RestartThreshold uint
+	// MetricsTimeout is the time in seconds allowed to scrape each metrics endpoint, DefaultMetricsTimeout if zero
+	MetricsTimeout uint
+}
+
+func Deployments(clusterInfo *cluster.Info, _ string, options DeploymentOptions, status reporter.Interface) error {
 	if clusterInfo.Submariner != nil {
 		if err := checkOverlappingCIDRs(clusterInfo, status); err != nil {
 			return err
 		}
 	}
 
-	if err := checkPods(clusterInfo, status); err != nil {
+	if err := checkOperatorNamespaces(clusterInfo, status); err != nil {
+		return err
+	}
+
+	if err := checkPods(clusterInfo, options.RestartThreshold, status); err != nil {
 		return err
 	}
 
-	return checkMetricsConfig(clusterInfo, imageOverrides, status)
+	return checkMetricsConfig(clusterInfo, options, status)
 }
 
 func checkOverlappingCIDRs(clusterInfo *cluster.Info, status reporter.Interface) error {
@@ -118,7 +133,7 @@ func checkOverlappingCIDRs(clusterInfo *cluster.Info, status reporter.Interface)
 	return nil
 }
 
-func checkPods(clusterInfo *cluster.Info, status reporter.Interface) error {
+func checkPods(clusterInfo *cluster.Info, restartThreshold uint, status reporter.Interface) error {
 	tracker := reporter.NewTracker(status)
 
 	if clusterInfo.Submariner != nil {
@@ -140,7 +155,9 @@ func checkPods(clusterInfo *cluster.Info, status reporter.Interface) error {
 	}
 
 	if clusterInfo.Submariner != nil || clusterInfo.ServiceDiscovery != nil {
-		checkPodsStatus(clusterInfo.ClientProducer.ForKubernetes(), constants.OperatorNamespace, tracker)
+		checkPodsStatus(clusterInfo.ClientProducer.ForKubernetes(), constants.OperatorNamespace, restartThreshold, tracker)
+		checkAdmissionWebhooks(clusterInfo.ClientProducer.ForKubernetes(), constants.OperatorNamespace, tracker)
+		checkSCCs(clusterInfo, constants.OperatorNamespace, tracker)
 	}
 
 	if tracker.HasFailures() {
@@ -150,6 +167,24 @@ func checkPods(clusterInfo *cluster.Info, status reporter.Interface) error {
 	return nil
 }
 
+func reportContainerRestarts(pod *v1.Pod, c *v1.ContainerStatus, status reporter.Interface) {
+	reason := ""
+	if c.LastTerminationState.Terminated != nil {
+		reason = c.LastTerminationState.Terminated.Reason
+	}
+
+	switch reason {
+	case "OOMKilled":
+		status.Failure("Container %q of pod %q has restarted %d times, the last time because it ran out of memory."+
+			" Consider increasing its memory limit", c.Name, pod.Name, c.RestartCount)
+	case "Error":
+		status.Failure("Container %q of pod %q has restarted %d times, the last time because it failed."+
+			" Inspect its logs, for example using \"subctl gather\"", c.Name, pod.Name, c.RestartCount)
+	default:
+		status.Warning("Pod %q has restarted %d times", pod.Name, c.RestartCount)
+	}
+}
+
 func checkDeployment(k8sClient kubernetes.Interface, namespace, deploymentName string, status reporter.Interface) {
 	status.Start("Checking Deployment %q", deploymentName)
 	defer status.End()
@@ -169,6 +204,7 @@ func checkDeployment(k8sClient kubernetes.Interface, namespace, deploymentName s
 		status.Failure("The desired number of replicas for Deployment %q (%d)"+
 			" does not match the actual number running (%d)", deploymentName, replicas,
 			deployment.Status.AvailableReplicas)
+		reportDeploymentPodCreationFailures(k8sClient, deployment, status)
 	}
 }
 
@@ -186,10 +222,11 @@ func checkDaemonset(k8sClient kubernetes.Interface, namespace, daemonSetName str
 		status.Failure("The desired number of running pods for DaemonSet %q (%d)"+
 			" does not match the actual number (%d)", daemonSetName, daemonSet.Status.DesiredNumberScheduled,
 			daemonSet.Status.CurrentNumberScheduled)
+		reportPodCreationFailures(k8sClient, namespace, "DaemonSet", daemonSetName, status)
 	}
 }
 
-func checkPodsStatus(k8sClient kubernetes.Interface, namespace string, status reporter.Interface) {
+func checkPodsStatus(k8sClient kubernetes.Interface, namespace string, restartThreshold uint, status reporter.Interface) {
 	status.Start("Checking the status of all Submariner pods")
 	defer status.End()
 
@@ -217,8 +254,8 @@ func checkPodsStatus(k8sClient kubernetes.Interface, namespace string, status re
 
 		for j := range pod.Status.ContainerStatuses {
 			c := &pod.Status.ContainerStatuses[j]
-			if c.RestartCount >= 5 {
-				status.Warning("Pod %q has restarted %d times", pod.Name, c.RestartCount)
+			if restartThreshold > 0 && c.RestartCount >= 0 && uint(c.RestartCount) >= restartThreshold {
+				reportContainerRestarts(pod, c, status)
 			}
 		}
 	}
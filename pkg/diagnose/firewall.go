@@ -21,6 +21,8 @@ package diagnose
 import (
 	"context"
 	"fmt"
+	"math"
+	"slices"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -33,6 +35,7 @@ import (
 	"github.com/submariner-io/submariner-operator/api/v1alpha1"
 	subv1 "github.com/submariner-io/submariner/pkg/apis/submariner.io/v1"
 	"github.com/submariner-io/submariner/pkg/port"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/uuid"
 	"k8s.io/client-go/kubernetes"
@@ -51,8 +54,13 @@ const (
 	VxLAN     = "vxlan"
 )
 
+// The default number of datagrams sent by the inter-cluster firewall probes, and the default source port they're sent from.
+const (
+	DefaultProbePacketCount = 1000
+	DefaultProbePort        = 9898
+)
+
 const (
-	clientSourcePort = "9898"
 	loadBalancerName = "submariner-gateway"
 	encapsPortName   = "cable-encaps"
 	nattPortName     = "natt-discovery"
@@ -66,6 +74,12 @@ type FirewallOptions struct {
 	ImageOverrides    []string
 	ValidationTimeout uint
 	VerboseOutput     bool
+	// TCP additionally checks that TCP connections can be established to the gateway node
+	TCP bool
+	// ProbePacketCount is the number of datagrams sent by the inter-cluster probes, DefaultProbePacketCount if zero
+	ProbePacketCount uint
+	// ProbePort is the source port of the inter-cluster probes, DefaultProbePort if zero
+	ProbePort uint
 }
 
 func spawnClientPodOnNonGatewayNode(client kubernetes.Interface, namespace, podCommand string,
@@ -174,6 +188,20 @@ func verifyConnectivity(localClusterInfo, remoteClusterInfo *cluster.Info, names
 	status.Start(message)
 	defer status.End()
 
+	packetCount := options.ProbePacketCount
+	if packetCount == 0 {
+		packetCount = DefaultProbePacketCount
+	}
+
+	probePort := options.ProbePort
+	if probePort == 0 {
+		probePort = DefaultProbePort
+	}
+
+	if probePort > math.MaxUint16 {
+		return status.Error(fmt.Errorf("invalid probe port %d", probePort), "")
+	}
+
 	singleNode, err := remoteClusterInfo.HasSingleNode()
 	if err != nil {
 		return status.Error(err, "")
@@ -216,20 +244,36 @@ func verifyConnectivity(localClusterInfo, remoteClusterInfo *cluster.Info, names
 		return status.Error(err, "Error determining repository information")
 	}
 
-	sPod, err := spawnSnifferPodOnNode(localClusterInfo.ClientProducer.ForKubernetes(), gwNodeName, namespace, podCommand, repositoryInfo)
+	snifferNodes, err := getSnifferNodes(localClusterInfo, localEndpoint, gwNodeName)
 	if err != nil {
-		return status.Error(err, "Error spawning the sniffer pod on the Gateway node %q", gwNodeName)
+		return status.Error(err, "Could not determine the nodes which may receive the traffic")
 	}
 
-	defer sPod.Delete()
+	// The first sniffer is always on the gateway node
+	sPods := make([]*pods.Scheduled, 0, len(snifferNodes))
+
+	defer func() {
+		for _, sPod := range sPods {
+			sPod.Delete()
+		}
+	}()
+
+	for _, nodeName := range snifferNodes {
+		sPod, err := spawnSnifferPodOnNode(localClusterInfo.ClientProducer.ForKubernetes(), nodeName, namespace, podCommand, repositoryInfo)
+		if err != nil {
+			return status.Error(err, "Error spawning the sniffer pod on node %q", nodeName)
+		}
+
+		sPods = append(sPods, sPod)
+	}
 
 	gatewayPodIP, err := getGatewayIP(remoteClusterInfo, localClusterInfo.Submariner.Status.ClusterID, status)
 	if err != nil {
 		return status.Error(err, "Error retrieving the gateway IP of cluster %q", localClusterInfo.Name)
 	}
 
-	podCommand = fmt.Sprintf("for x in $(seq 1000); do echo %s; done | for i in $(seq 5);"+
-		" do timeout 2 nc -n -p %s -u %s %d; done", clientMessage, clientSourcePort, gatewayPodIP, destPort)
+	podCommand = fmt.Sprintf("for x in $(seq %d); do echo %s; done | for i in $(seq 5);"+
+		" do timeout 2 nc -n -p %d -u %s %d; done", packetCount, clientMessage, probePort, gatewayPodIP, destPort)
 
 	// Spawn the pod on the nonGateway node. If we spawn the pod on Gateway node, the tunnel process can
 	// sometimes drop the udp traffic from client pod until the tunnels are properly setup.
@@ -241,13 +285,26 @@ func verifyConnectivity(localClusterInfo, remoteClusterInfo *cluster.Info, names
 
 	defer cPod.Delete()
 
-	err = awaitPodCompletion(cPod, sPod, status)
-	if err != nil {
-		return err
+	for _, sPod := range sPods {
+		if err := awaitPodCompletion(cPod, sPod, status); err != nil {
+			return err
+		}
 	}
 
-	if options.VerboseOutput {
-		status.Success("tcpdump output from sniffer pod on Gateway node:\n%s", sPod.PodOutput)
+	receivedOn := []string{}
+
+	for i, sPod := range sPods {
+		if options.VerboseOutput {
+			status.Success("tcpdump output from sniffer pod on node %q:\n%s", snifferNodes[i], sPod.PodOutput)
+		}
+
+		if strings.Contains(sPod.PodOutput, clientMessage) {
+			receivedOn = append(receivedOn, snifferNodes[i])
+		}
+	}
+
+	if len(sPods) > 1 {
+		return reportLoadBalancedTraffic(receivedOn, snifferNodes, gwNodeName, destPort, status)
 	}
 
 	var espNeeded bool
@@ -255,7 +312,59 @@ func verifyConnectivity(localClusterInfo, remoteClusterInfo *cluster.Info, names
 		espNeeded = true
 	}
 
-	return validateOutput(sPod, clientMessage, localEndpoint.Spec.Hostname, destPort, espNeeded, status)
+	return validateOutput(sPods[0], clientMessage, localEndpoint.Spec.Hostname, destPort, espNeeded, status)
+}
+
+// getSnifferNodes returns the nodes on which the client traffic may arrive, starting with the gateway node. With a
+// load balancer whose external traffic policy is Cluster, the traffic may be delivered to any node's node port, and is
+// then forwarded to the gateway node by kube-proxy.
+func getSnifferNodes(clusterInfo *cluster.Info, endpoint *subv1.Endpoint, gwNodeName string) ([]string, error) {
+	if !isUsingLoadBalancer(endpoint) {
+		return []string{gwNodeName}, nil
+	}
+
+	svc, err := clusterInfo.ClientProducer.ForKubernetes().CoreV1().Services(endpoint.GetNamespace()).Get(
+		context.TODO(), loadBalancerName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error reading the details of LB service %s: %w", loadBalancerName, err)
+	}
+
+	if svc.Spec.ExternalTrafficPolicy == corev1.ServiceExternalTrafficPolicyLocal {
+		return []string{gwNodeName}, nil
+	}
+
+	nodes, err := clusterInfo.ClientProducer.ForKubernetes().CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing the nodes")
+	}
+
+	snifferNodes := []string{gwNodeName}
+
+	for i := range nodes.Items {
+		if nodes.Items[i].Name != gwNodeName {
+			snifferNodes = append(snifferNodes, nodes.Items[i].Name)
+		}
+	}
+
+	return snifferNodes, nil
+}
+
+// reportLoadBalancedTraffic checks that the client traffic was received on at least one of the nodes behind the load
+// balancer, and explains where it was received.
+func reportLoadBalancedTraffic(receivedOn, snifferNodes []string, gwNodeName string, destPort int32, status reporter.Interface) error {
+	switch {
+	case len(receivedOn) == 0:
+		return status.Error(fmt.Errorf("the tcpdump output from the sniffer pods on the nodes behind the %q load balancer (%s)"+
+			" does not include the message sent from the client pod. Please check that your firewall configuration and the"+
+			" load balancer allow UDP/%d traffic to the nodes", loadBalancerName, strings.Join(snifferNodes, ", "), destPort), "")
+	case slices.Contains(receivedOn, gwNodeName):
+		status.Success("The client traffic was received on the gateway node %q", gwNodeName)
+	default:
+		status.Success("The client traffic was received on node(s) %s behind the %q load balancer instead of the gateway"+
+			" node %q; kube-proxy forwards it to the gateway node", strings.Join(receivedOn, ", "), loadBalancerName, gwNodeName)
+	}
+
+	return nil
 }
 
 func awaitPodCompletion(cPod, sPod *pods.Scheduled, status reporter.Interface) error {
@@ -343,9 +452,13 @@ func getTargetPort(submariner *v1alpha1.Submariner, endpoint *subv1.Endpoint, tg
 	}
 }
 
-func getLbNodePort(clusterInfo *cluster.Info, endpoint *subv1.Endpoint, tgtport TargetPort) (int32, error) {
+func isUsingLoadBalancer(endpoint *subv1.Endpoint) bool {
 	usingLoadBalancer, _ := endpoint.Spec.GetBackendBool(subv1.UsingLoadBalancer, nil)
-	if usingLoadBalancer == nil || !*usingLoadBalancer {
+	return usingLoadBalancer != nil && *usingLoadBalancer
+}
+
+func getLbNodePort(clusterInfo *cluster.Info, endpoint *subv1.Endpoint, tgtport TargetPort) (int32, error) {
+	if !isUsingLoadBalancer(endpoint) {
 		return 0, nil
 	}
 
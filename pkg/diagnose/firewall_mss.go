@@ -0,0 +1,324 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diagnose
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/submariner-io/admiral/pkg/reporter"
+	"github.com/submariner-io/subctl/internal/pods"
+	"github.com/submariner-io/subctl/pkg/cluster"
+	"github.com/submariner-io/subctl/pkg/image"
+)
+
+const (
+	mssListenerPort = 9899
+	// mssCheckTimeout bounds the time the sniffers run, in seconds, so that the whole check completes within about a minute
+	mssCheckTimeout = 40
+	// Size of the IPv4 and TCP headers, without options; the MSS advertised without clamping is the MTU minus this
+	ipv4TCPHeadersSize = 40
+	// The smallest encapsulation overhead of the cable drivers, an outer IPv4 header and a UDP (or ESP) header; an MSS above the
+	// gateway MTU minus this and the headers can't fit the tunnel, whatever the cable driver
+	minTunnelOverhead = 28
+	fragNeededMessage = "need to frag"
+)
+
+var (
+	mssRegexp      = regexp.MustCompile(`mss (\d+)`)
+	receivedRegexp = regexp.MustCompile(`received (\d+)`)
+)
+
+// The sniffers print the MTU of the Gateway node's default route interface, the first matching SYN (or SYN-ACK) received
+// from the tunnel, and any ICMP fragmentation-needed messages, which are needed for path MTU discovery to work if the MSS
+// isn't clamped.
+const mssSnifferCommand = "cat /sys/class/net/$(ip route show default | awk '{print $5; exit}')/mtu; " +
+	"timeout %d tcpdump -ln -Q in -c 1 -i any '%s' & " +
+	"timeout %d tcpdump -ln -c 5 -i any 'icmp[icmptype] == icmp-unreach and icmp[icmpcode] == 4'; wait"
+
+// MSSClampingAcrossClusters checks that TCP connections between pods in the two clusters have their MSS clamped to fit
+// the tunnel, in both directions. A client pod in the remote cluster downloads a payload larger than the tunnel MTU from
+// a listener pod in the local cluster, while sniffers on both gateway nodes capture the MSS in the SYN and SYN-ACK
+// packets coming out of the tunnel, along with any ICMP fragmentation-needed messages. Only IPv4 is checked.
+func MSSClampingAcrossClusters(localClusterInfo, remoteClusterInfo *cluster.Info, namespace string, options FirewallOptions,
+	status reporter.Interface,
+) error {
+	mustHaveSubmariner(localClusterInfo)
+	mustHaveSubmariner(remoteClusterInfo)
+
+	status.Start("Checking TCP MSS clamping between clusters %q and %q", remoteClusterInfo.Name, localClusterInfo.Name)
+	defer status.End()
+
+	if localClusterInfo.Submariner.Spec.GlobalCIDR != "" || remoteClusterInfo.Submariner.Spec.GlobalCIDR != "" {
+		status.Success("Skipping this check as pods can't be reached directly across clusters when Globalnet is enabled")
+		return nil
+	}
+
+	check := &mssCheck{
+		local:   localClusterInfo,
+		remote:  remoteClusterInfo,
+		timeout: min(options.ValidationTimeout, mssCheckTimeout),
+		status:  status,
+	}
+
+	err := check.run(namespace, options)
+	if err != nil {
+		return err
+	}
+
+	if options.VerboseOutput {
+		status.Success("tcpdump output from the sniffer pod on the Gateway node of cluster %q:\n%s", localClusterInfo.Name,
+			check.localSniffer.PodOutput)
+		status.Success("tcpdump output from the sniffer pod on the Gateway node of cluster %q:\n%s", remoteClusterInfo.Name,
+			check.remoteSniffer.PodOutput)
+	}
+
+	tracker := reporter.NewTracker(status)
+	check.status = tracker
+
+	check.analyze()
+
+	if tracker.HasFailures() {
+		return errors.New("failures while diagnosing TCP MSS clamping")
+	}
+
+	return nil
+}
+
+type mssCheck struct {
+	local          *cluster.Info
+	remote         *cluster.Info
+	timeout        uint
+	status         reporter.Interface
+	localGateway   string
+	remoteGateway  string
+	payloadSize    int
+	localSniffer   *pods.Scheduled
+	remoteSniffer  *pods.Scheduled
+	listenerOutput string
+	clientOutput   string
+}
+
+func (c *mssCheck) run(namespace string, options FirewallOptions) error {
+	var err error
+
+	c.localGateway, err = getActiveGatewayNodeName(c.local, c.status)
+	if err != nil {
+		return err
+	}
+
+	c.remoteGateway, err = getActiveGatewayNodeName(c.remote, c.status)
+	if err != nil {
+		return err
+	}
+
+	localRepositoryInfo, err := c.local.GetImageRepositoryInfo(options.ImageOverrides...)
+	if err != nil {
+		return c.status.Error(err, "Error determining repository information")
+	}
+
+	remoteRepositoryInfo, err := c.remote.GetImageRepositoryInfo(options.ImageOverrides...)
+	if err != nil {
+		return c.status.Error(err, "Error determining repository information")
+	}
+
+	// SYNs sent by the client to the listener, and SYN-ACKs sent back by the listener
+	c.localSniffer, err = spawnSnifferPodOnNode(c.local.ClientProducer.ForKubernetes(), c.localGateway, namespace,
+		fmt.Sprintf(mssSnifferCommand, c.timeout,
+			fmt.Sprintf("tcp dst port %d and tcp[tcpflags] & (tcp-syn|tcp-ack) == tcp-syn", mssListenerPort), c.timeout),
+		localRepositoryInfo)
+	if err != nil {
+		return c.status.Error(err, "Error spawning the sniffer pod on the Gateway node %q", c.localGateway)
+	}
+
+	defer c.localSniffer.Delete()
+
+	c.remoteSniffer, err = spawnSnifferPodOnNode(c.remote.ClientProducer.ForKubernetes(), c.remoteGateway, namespace,
+		fmt.Sprintf(mssSnifferCommand, c.timeout,
+			fmt.Sprintf("tcp src port %d and tcp[tcpflags] & (tcp-syn|tcp-ack) == (tcp-syn|tcp-ack)", mssListenerPort), c.timeout),
+		remoteRepositoryInfo)
+	if err != nil {
+		return c.status.Error(err, "Error spawning the sniffer pod on the Gateway node %q", c.remoteGateway)
+	}
+
+	defer c.remoteSniffer.Delete()
+
+	listener, err := spawnWorkloadPod(c.local, namespace, "validate-listener",
+		fmt.Sprintf("cat /sys/class/net/eth0/mtu; head -c $(( $(cat /sys/class/net/eth0/mtu) * 4 )) /dev/zero |"+
+			" timeout %d nc -l -p %d >/dev/null", c.timeout, mssListenerPort), localRepositoryInfo)
+	if err != nil {
+		return c.status.Error(err, "Error spawning the listener pod in cluster %q", c.local.Name)
+	}
+
+	defer listener.Delete()
+
+	client, err := spawnWorkloadPod(c.remote, namespace, "validate-client",
+		fmt.Sprintf("cat /sys/class/net/eth0/mtu; for i in $(seq 3); do n=$(timeout 8 nc -n %s %d </dev/null | wc -c);"+
+			" [ \"$n\" -gt 0 ] && break; sleep 1; done; echo received $n", listener.Pod.Status.PodIP, mssListenerPort),
+		remoteRepositoryInfo)
+	if err != nil {
+		return c.status.Error(err, "Error spawning the client pod in cluster %q", c.remote.Name)
+	}
+
+	defer client.Delete()
+
+	if err := client.AwaitCompletion(); err != nil {
+		return c.status.Error(err, "Error waiting for the client pod to finish its execution")
+	}
+
+	for _, pod := range []*pods.Scheduled{listener, c.localSniffer, c.remoteSniffer} {
+		if err := pod.AwaitCompletion(); err != nil {
+			return c.status.Error(err, "Error waiting for pod %q to finish its execution", pod.Pod.Name)
+		}
+	}
+
+	c.listenerOutput = listener.PodOutput
+	c.clientOutput = client.PodOutput
+
+	return nil
+}
+
+// spawnWorkloadPod spawns a pod on the pod network, on a non-gateway node if there is one, so that its traffic goes through
+// the gateway in the same way as the workloads'.
+func spawnWorkloadPod(clusterInfo *cluster.Info, namespace, name, podCommand string, repositoryInfo *image.RepositoryInfo,
+) (*pods.Scheduled, error) {
+	singleNode, err := clusterInfo.HasSingleNode()
+	if err != nil {
+		return nil, errors.Wrap(err, "error determining whether the cluster has a single node")
+	}
+
+	scheduling := pods.Scheduling{ScheduleOn: pods.NonGatewayNode, Networking: pods.PodNetworking}
+	if singleNode {
+		scheduling.ScheduleOn = pods.GatewayNode
+	}
+
+	return spawnPod(clusterInfo.ClientProducer.ForKubernetes(), scheduling, name, namespace, podCommand, repositoryInfo)
+}
+
+func (c *mssCheck) analyze() {
+	c.payloadSize = firstLineValue(c.listenerOutput) * 4
+	tunnelMTU := minNonZero(firstLineValue(c.localSniffer.PodOutput), firstLineValue(c.remoteSniffer.PodOutput))
+
+	synMSS, synSeen := capturedMSS(c.localSniffer.PodOutput)
+	if !synSeen {
+		c.status.Failure("The sniffer pod on the Gateway node %q of cluster %q didn't see any connection attempt from cluster %q;"+
+			" please check the connectivity between the clusters first. Actual pod output: \n%s", c.localGateway, c.local.Name,
+			c.remote.Name, truncate(c.localSniffer.PodOutput))
+
+		return
+	}
+
+	clamped := c.reportDirection(c.remote, c.remoteGateway, c.local, "SYN", synMSS, tunnelMTU)
+
+	synAckMSS, synAckSeen := capturedMSS(c.remoteSniffer.PodOutput)
+	if synAckSeen {
+		clamped = c.reportDirection(c.local, c.localGateway, c.remote, "SYN-ACK", synAckMSS, tunnelMTU) && clamped
+	} else {
+		c.status.Warning("The sniffer pod on the Gateway node %q of cluster %q didn't see any reply from cluster %q,"+
+			" so MSS clamping couldn't be checked in that direction", c.remoteGateway, c.remote.Name, c.local.Name)
+	}
+
+	c.reportTransfer(clamped)
+}
+
+// reportDirection reports whether the MSS advertised by a pod in the "from" cluster was clamped to fit the tunnel on its
+// way to the "to" cluster, and returns true if it was. If the gateway MTU is unknown, the clamping can't be judged, and
+// is assumed to be in place so that the transfer result is reported on its own.
+func (c *mssCheck) reportDirection(from *cluster.Info, fromGateway string, to *cluster.Info, packet string, mss, gatewayMTU int,
+) bool {
+	if gatewayMTU == 0 {
+		c.status.Warning("The MSS of TCP %s packets from cluster %q to cluster %q is %d, but the MTU of the Gateway nodes"+
+			" couldn't be determined, so whether it is clamped is unknown", packet, from.Name, to.Name, mss)
+		return true
+	}
+
+	maxMSS := gatewayMTU - minTunnelOverhead - ipv4TCPHeadersSize
+	if mss <= maxMSS {
+		c.status.Success("The MSS of TCP %s packets from cluster %q to cluster %q is clamped to %d", packet, from.Name, to.Name,
+			mss)
+		return true
+	}
+
+	c.status.Failure("The MSS of TCP %s packets from cluster %q to cluster %q isn't clamped: it's %d, which doesn't fit the"+
+		" tunnel over a Gateway MTU of %d (at most %d). The route agent's MSS clamping rule seems to be missing on the Gateway"+
+		" node %q", packet, from.Name, to.Name, mss, gatewayMTU, maxMSS, fromGateway)
+
+	return false
+}
+
+func (c *mssCheck) reportTransfer(clamped bool) {
+	received := 0
+	if match := receivedRegexp.FindStringSubmatch(c.clientOutput); match != nil {
+		received, _ = strconv.Atoi(match[1])
+	}
+
+	if c.payloadSize > 0 && received >= c.payloadSize {
+		c.status.Success("A %d byte payload, larger than the tunnel MTU, was transferred from cluster %q to cluster %q",
+			c.payloadSize, c.local.Name, c.remote.Name)
+		return
+	}
+
+	fragNeeded := strings.Contains(c.localSniffer.PodOutput, fragNeededMessage) ||
+		strings.Contains(c.remoteSniffer.PodOutput, fragNeededMessage)
+
+	var cause string
+
+	switch {
+	case clamped:
+		cause = "please check the tunnel MTU and the firewalls between the gateways"
+	case fragNeeded:
+		cause = "ICMP fragmentation-needed messages were seen on the Gateway nodes, but path MTU discovery didn't recover;" +
+			" please check that the firewalls let these messages reach the pods"
+	default:
+		cause = "no ICMP fragmentation-needed messages were seen on the Gateway nodes, so path MTU discovery can't compensate" +
+			" for the missing MSS clamping; a firewall is likely dropping ICMP"
+	}
+
+	c.status.Failure("Only %d bytes of a %d byte payload, larger than the tunnel MTU, were transferred from cluster %q to"+
+		" cluster %q: %s", received, c.payloadSize, c.local.Name, c.remote.Name, cause)
+}
+
+func capturedMSS(output string) (int, bool) {
+	match := mssRegexp.FindStringSubmatch(output)
+	if match == nil {
+		return 0, false
+	}
+
+	mss, err := strconv.Atoi(match[1])
+
+	return mss, err == nil
+}
+
+func minNonZero(a, b int) int {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+
+	return a
+}
+
+func firstLineValue(output string) int {
+	line, _, _ := strings.Cut(strings.TrimSpace(output), "\n")
+	value, _ := strconv.Atoi(strings.TrimSpace(line))
+
+	return value
+}
@@ -19,10 +19,23 @@ limitations under the License.
 package diagnose
 
 import (
+	"context"
 	"fmt"
+	"strconv"
+	"strings"
 
+	"github.com/submariner-io/admiral/pkg/names"
 	"github.com/submariner-io/admiral/pkg/reporter"
+	"github.com/submariner-io/subctl/internal/constants"
 	"github.com/submariner-io/subctl/pkg/cluster"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/uuid"
+)
+
+const (
+	defaultGatewayMetricsPort = 32780
+	gatewayMetricsPortEnv     = "SUBMARINER_METRICSPORT"
 )
 
 func TunnelConfigAcrossClusters(localClusterInfo, remoteClusterInfo *cluster.Info, namespace string, options FirewallOptions,
@@ -37,5 +50,132 @@ func TunnelConfigAcrossClusters(localClusterInfo, remoteClusterInfo *cluster.Inf
 		status.Success("Tunnels can be established on the gateway node of cluster %q", localClusterInfo.Name)
 	}
 
-	return err
+	if !options.TCP {
+		return err
+	}
+
+	tcpErr := verifyTCPConnectivity(localClusterInfo, remoteClusterInfo, namespace, options, status)
+	if tcpErr != nil {
+		status.Failure("TCP connections can't be established to the gateway node of cluster %q", localClusterInfo.Name)
+	} else {
+		status.Success("TCP connections can be established to the gateway node of cluster %q", localClusterInfo.Name)
+	}
+
+	return utilerrors.NewAggregate([]error{err, tcpErr})
+}
+
+// verifyTCPConnectivity sends a message over TCP from a non-gateway node in the remote cluster to the gateway metrics
+// port, which is always listening, on the local gateway node, and checks that a sniffer on that node sees it. The
+// message is only sent once the connection is established, so this fails with firewalls which let the SYN packets in
+// but block the rest of the TCP traffic, even when UDP works.
+func verifyTCPConnectivity(localClusterInfo, remoteClusterInfo *cluster.Info, namespace string, options FirewallOptions,
+	status reporter.Interface,
+) error {
+	status.Start("Checking if TCP connections can be established to the gateway node of cluster %q", localClusterInfo.Name)
+	defer status.End()
+
+	singleNode, err := remoteClusterInfo.HasSingleNode()
+	if err != nil {
+		return status.Error(err, "")
+	}
+
+	if singleNode {
+		status.Success(singleNodeMessage)
+		return nil
+	}
+
+	gwNodeName, err := getActiveGatewayNodeName(localClusterInfo, status)
+	if err != nil {
+		return err
+	}
+
+	metricsPort, err := getGatewayMetricsPort(localClusterInfo)
+	if err != nil {
+		return status.Error(err, "Could not determine the gateway metrics port")
+	}
+
+	repositoryInfo, err := localClusterInfo.GetImageRepositoryInfo(options.ImageOverrides...)
+	if err != nil {
+		return status.Error(err, "Error determining repository information")
+	}
+
+	clientMessage := string(uuid.NewUUID())[0:8]
+	podCommand := fmt.Sprintf(
+		"(tcpdump --immediate-mode -ln -Q in -A -s 200 -i any tcp and dst port %d & pid=\"$!\"; (sleep %d; kill \"$pid\") &) | sed '/%s/q'",
+		metricsPort, options.ValidationTimeout, clientMessage)
+
+	sPod, err := spawnSnifferPodOnNode(localClusterInfo.ClientProducer.ForKubernetes(), gwNodeName, namespace, podCommand, repositoryInfo)
+	if err != nil {
+		return status.Error(err, "Error spawning the sniffer pod on the Gateway node %q", gwNodeName)
+	}
+
+	defer sPod.Delete()
+
+	gatewayIP, err := getGatewayIP(remoteClusterInfo, localClusterInfo.Submariner.Status.ClusterID, status)
+	if err != nil {
+		return status.Error(err, "Error retrieving the gateway IP of cluster %q", localClusterInfo.Name)
+	}
+
+	podCommand = fmt.Sprintf("for i in $(seq 5); do echo %s | nc -n -w 5 %s %d && break; sleep 1; done", clientMessage, gatewayIP,
+		metricsPort)
+
+	cPod, err := spawnClientPodOnNonGatewayNodeWithHostNet(remoteClusterInfo.ClientProducer.ForKubernetes(), namespace,
+		podCommand, repositoryInfo)
+	if err != nil {
+		return status.Error(err, "Error spawning the client pod on non-Gateway node of cluster %q", remoteClusterInfo.Name)
+	}
+
+	defer cPod.Delete()
+
+	err = awaitPodCompletion(cPod, sPod, status)
+	if err != nil {
+		return err
+	}
+
+	if options.VerboseOutput {
+		status.Success("tcpdump output from sniffer pod on Gateway node:\n%s", sPod.PodOutput)
+	}
+
+	if strings.Contains(sPod.PodOutput, clientMessage) {
+		return nil
+	}
+
+	if strings.Contains(sPod.PodOutput, "Flags [S]") {
+		return status.Error(fmt.Errorf("TCP/%d connection attempts reached the %q node but the connection couldn't be"+
+			" established; please check that your firewall allows the return traffic and established TCP connections."+
+			" Actual pod output: \n%s", metricsPort, gwNodeName, truncate(sPod.PodOutput)), "")
+	}
+
+	return status.Error(fmt.Errorf("the tcpdump output from the sniffer pod does not include the message sent from"+
+		" the client pod. Please check that your firewall configuration allows TCP/%d traffic on the %q node."+
+		" Actual pod output: \n%s", metricsPort, gwNodeName, truncate(sPod.PodOutput)), "")
+}
+
+func getGatewayMetricsPort(clusterInfo *cluster.Info) (int32, error) {
+	gwPods, err := clusterInfo.ClientProducer.ForKubernetes().CoreV1().Pods(constants.OperatorNamespace).List(context.TODO(),
+		metav1.ListOptions{
+			LabelSelector: fmt.Sprintf("app=%s,gateway.submariner.io/status=active", names.GatewayComponent),
+		})
+	if err != nil {
+		return 0, err //nolint:wrapcheck // The caller wraps the error
+	}
+
+	if len(gwPods.Items) == 0 {
+		return defaultGatewayMetricsPort, nil
+	}
+
+	for i := range gwPods.Items[0].Spec.Containers {
+		for _, env := range gwPods.Items[0].Spec.Containers[i].Env {
+			if env.Name == gatewayMetricsPortEnv && env.Value != "" {
+				port, err := strconv.ParseInt(env.Value, 10, 32)
+				if err != nil {
+					return 0, fmt.Errorf("invalid %s value %q: %w", gatewayMetricsPortEnv, env.Value, err)
+				}
+
+				return int32(port), nil
+			}
+		}
+	}
+
+	return defaultGatewayMetricsPort, nil
 }
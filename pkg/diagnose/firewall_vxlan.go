@@ -25,16 +25,27 @@ import (
 	"github.com/pkg/errors"
 	"github.com/submariner-io/admiral/pkg/reporter"
 	"github.com/submariner-io/subctl/pkg/cluster"
+	"github.com/submariner-io/submariner/pkg/cni"
 )
 
 const (
-	tcpSniffVxLANCommand = "tcpdump -ln -c 3 -i vx-submariner tcp and port 8080 and 'tcp[tcpflags] == tcp-syn'"
+	tcpSniffCommand = "tcpdump -ln -c 3 -i %s tcp and port 8080 and 'tcp[tcpflags] == tcp-syn'"
+	vxlanInterface  = "vx-submariner"
+	// With OVN-Kubernetes, the traffic to the remote clusters reaches the gateway node through its management port
+	ovnManagementInterface = "ovn-k8s-mp0"
 )
 
 func FirewallIntraVxLANConfig(clusterInfo *cluster.Info, namespace string, options FirewallOptions, status reporter.Interface) error {
 	mustHaveSubmariner(clusterInfo)
 
-	status.Start("Checking that firewall configuration allows intra-cluster VXLAN traffic")
+	isOVN := strings.EqualFold(clusterInfo.Submariner.Status.NetworkPlugin, cni.OVNKubernetes)
+
+	if isOVN {
+		status.Start("Checking that firewall configuration allows intra-cluster traffic over the OVN overlay")
+	} else {
+		status.Start("Checking that firewall configuration allows intra-cluster VXLAN traffic")
+	}
+
 	defer status.End()
 
 	singleNode, err := clusterInfo.HasSingleNode()
@@ -49,20 +60,26 @@ func FirewallIntraVxLANConfig(clusterInfo *cluster.Info, namespace string, optio
 
 	tracker := reporter.NewTracker(status)
 
-	checkFWConfig(clusterInfo, namespace, options, tracker)
+	if isOVN {
+		checkFWConfig(clusterInfo, namespace, options, ovnManagementInterface,
+			"allows Geneve (UDP/6081) traffic between the nodes", tracker)
+		checkOVNRemoteSubnetRouting(clusterInfo, tracker)
+	} else {
+		checkFWConfig(clusterInfo, namespace, options, vxlanInterface, "allows UDP/4800 traffic", tracker)
+	}
 
 	if tracker.HasFailures() {
-		return errors.New("failures while diagnosing the intra-VXLAN firewall configuration")
+		return errors.New("failures while diagnosing the intra-cluster firewall configuration")
 	}
 
 	return nil
 }
 
-func checkFWConfig(clusterInfo *cluster.Info, namespace string, options FirewallOptions, status reporter.Interface) {
-	if clusterInfo.Submariner.Status.NetworkPlugin == "OVNKubernetes" {
-		return
-	}
-
+// checkFWConfig checks that traffic from a non-gateway node to a remote cluster reaches the gateway node on the given
+// interface; firewallHint describes what the firewall must allow for that to work.
+func checkFWConfig(clusterInfo *cluster.Info, namespace string, options FirewallOptions, iface, firewallHint string,
+	status reporter.Interface,
+) {
 	remoteEndpoint, err := clusterInfo.GetAnyRemoteEndpoint()
 	if err != nil {
 		status.Failure("Unable to obtain a remote endpoint: %v", err)
@@ -75,7 +92,7 @@ func checkFWConfig(clusterInfo *cluster.Info, namespace string, options Firewall
 		return
 	}
 
-	podCommand := fmt.Sprintf("timeout %d %s", options.ValidationTimeout, tcpSniffVxLANCommand)
+	podCommand := fmt.Sprintf("timeout %d "+tcpSniffCommand, options.ValidationTimeout, iface)
 
 	repositoryInfo, err := clusterInfo.GetImageRepositoryInfo(options.ImageOverrides...)
 	if err != nil {
@@ -118,9 +135,9 @@ func checkFWConfig(clusterInfo *cluster.Info, namespace string, options Firewall
 
 	// Verify that tcpdump output (i.e, from snifferPod) contains the remoteClusterIP
 	if !strings.Contains(sPod.PodOutput, remoteClusterIP) {
-		status.Failure("The tcpdump output from the sniffer pod does not contain the expected remote"+
-			" endpoint IP %s. Please check that your firewall configuration allows UDP/4800 traffic. Actual pod output: \n%s",
-			remoteClusterIP, truncate(sPod.PodOutput))
+		status.Failure("The tcpdump output from the sniffer pod on interface %s does not contain the expected remote"+
+			" endpoint IP %s. Please check that your firewall configuration %s. Actual pod output: \n%s",
+			iface, remoteClusterIP, firewallHint, truncate(sPod.PodOutput))
 
 		return
 	}
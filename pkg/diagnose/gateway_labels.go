@@ -0,0 +1,92 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diagnose
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/submariner-io/admiral/pkg/reporter"
+	"github.com/submariner-io/subctl/internal/constants"
+	"github.com/submariner-io/subctl/pkg/cluster"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/utils/set"
+)
+
+var gatewayLabel = fmt.Sprintf("%s=%s", constants.SubmarinerGatewayLabel, constants.TrueLabel)
+
+// GatewayNodeLabels checks that the nodes labeled as gateways match the Gateway resources. Each gateway creates a
+// Gateway resource named after its node, so labeled nodes without a Gateway indicate gateways which aren't running, and
+// Gateways without a labeled node indicate stale resources or nodes whose label was removed.
+func GatewayNodeLabels(clusterInfo *cluster.Info, _ string, status reporter.Interface) error {
+	mustHaveSubmariner(clusterInfo)
+
+	status.Start("Checking that the gateway node labels match the Gateway resources")
+	defer status.End()
+
+	nodes, err := clusterInfo.ClientProducer.ForKubernetes().CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(map[string]string{constants.SubmarinerGatewayLabel: constants.TrueLabel}).String(),
+	})
+	if err != nil {
+		return status.Error(err, "Error listing the gateway nodes")
+	}
+
+	if len(nodes.Items) == 0 {
+		return status.Error(fmt.Errorf("there are no nodes labeled %q, so no gateway can run", gatewayLabel), "")
+	}
+
+	gateways, err := clusterInfo.GetGateways()
+	if err != nil {
+		return status.Error(err, "Error retrieving the Gateways")
+	}
+
+	gatewayNames := set.New[string]()
+	for i := range gateways {
+		gatewayNames.Insert(gateways[i].Name)
+	}
+
+	nodeNames := set.New[string]()
+	for i := range nodes.Items {
+		nodeNames.Insert(nodes.Items[i].Name)
+	}
+
+	tracker := reporter.NewTracker(status)
+
+	for _, name := range nodeNames.Difference(gatewayNames).SortedList() {
+		tracker.Warning("Node %q is labeled %q but there is no corresponding Gateway; check that its gateway pod is running",
+			name, gatewayLabel)
+	}
+
+	for _, name := range gatewayNames.Difference(nodeNames).SortedList() {
+		tracker.Warning("Gateway %q doesn't correspond to a node labeled %q; the node may have been deleted or unlabeled",
+			name, gatewayLabel)
+	}
+
+	if tracker.HasFailures() {
+		return errors.New("failures while diagnosing the gateway node labels")
+	}
+
+	if !tracker.HasWarnings() {
+		status.Success("All the gateway nodes have a corresponding Gateway")
+	}
+
+	return nil
+}
@@ -0,0 +1,266 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diagnose
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/submariner-io/admiral/pkg/reporter"
+	"github.com/submariner-io/subctl/internal/pods"
+	"github.com/submariner-io/subctl/pkg/cluster"
+	"github.com/submariner-io/subctl/pkg/image"
+	submarinerv1 "github.com/submariner-io/submariner/pkg/apis/submariner.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Matches the source address of the first packet captured by tcpdump, e.g.
+// "IP 242.0.255.254.39512 > 242.1.255.253.9900: Flags [S], ...".
+var capturedSourceRegexp = regexp.MustCompile(`IP (\d+\.\d+\.\d+\.\d+)\.\d+ > `)
+
+// GlobalnetDatapathAcrossClusters checks that a pod in the local cluster can connect to an exported service in the remote
+// cluster through the service's global ingress IP. It uses an existing exported ClusterIP service with a global IP, or
+// exports a transient listener pod if there isn't any. A sniffer pod on the remote cluster's active Gateway node reports
+// the source address the connection arrives with, which should be one of the local cluster's global egress IPs.
+func GlobalnetDatapathAcrossClusters(localClusterInfo, remoteClusterInfo *cluster.Info, namespace string, options FirewallOptions,
+	status reporter.Interface,
+) error {
+	mustHaveSubmariner(localClusterInfo)
+	mustHaveSubmariner(remoteClusterInfo)
+
+	status.Start("Checking the Globalnet datapath from cluster %q to the global IP of a service in cluster %q",
+		localClusterInfo.Name, remoteClusterInfo.Name)
+	defer status.End()
+
+	for _, clusterInfo := range []*cluster.Info{localClusterInfo, remoteClusterInfo} {
+		if clusterInfo.Submariner.Spec.GlobalCIDR == "" {
+			status.Warning("Skipping this check as Globalnet isn't enabled on cluster %q", clusterInfo.Name)
+			return nil
+		}
+	}
+
+	check := &globalnetDatapathCheck{
+		local:     localClusterInfo,
+		remote:    remoteClusterInfo,
+		namespace: namespace,
+		timeout:   options.ValidationTimeout,
+		status:    status,
+	}
+
+	err := check.run(options)
+	if err != nil {
+		return err
+	}
+
+	if options.VerboseOutput {
+		status.Success("tcpdump output from the sniffer pod on the Gateway node of cluster %q:\n%s", remoteClusterInfo.Name,
+			check.snifferOutput)
+		status.Success("Output from the client pod in cluster %q:\n%s", localClusterInfo.Name, check.clientOutput)
+	}
+
+	tracker := reporter.NewTracker(status)
+	check.status = tracker
+
+	check.analyze()
+
+	if tracker.HasFailures() {
+		return errors.New("failures while diagnosing the Globalnet datapath")
+	}
+
+	return nil
+}
+
+type globalnetDatapathCheck struct {
+	local         *cluster.Info
+	remote        *cluster.Info
+	status        reporter.Interface
+	namespace     string
+	service       string
+	globalIP      string
+	snifferOutput string
+	clientOutput  string
+	timeout       uint
+	port          int32
+}
+
+func (c *globalnetDatapathCheck) run(options FirewallOptions) error {
+	localRepositoryInfo, err := c.local.GetImageRepositoryInfo(options.ImageOverrides...)
+	if err != nil {
+		return c.status.Error(err, "Error determining repository information")
+	}
+
+	remoteRepositoryInfo, err := c.remote.GetImageRepositoryInfo(options.ImageOverrides...)
+	if err != nil {
+		return c.status.Error(err, "Error determining repository information")
+	}
+
+	found, err := c.findExportedService()
+	if err != nil {
+		return c.status.Error(err, "Error looking for an exported service with a global IP in cluster %q", c.remote.Name)
+	}
+
+	if found {
+		c.status.Success("Using the global IP %s of the exported service %q in cluster %q", c.globalIP, c.service, c.remote.Name)
+	} else {
+		listener, err := c.exportListener(remoteRepositoryInfo)
+		if err != nil {
+			return err
+		}
+
+		defer listener.Delete()
+	}
+
+	gwNodeName, err := getActiveGatewayNodeName(c.remote, c.status)
+	if err != nil {
+		return err
+	}
+
+	sniffer, err := spawnSnifferPodOnNode(c.remote.ClientProducer.ForKubernetes(), gwNodeName, c.namespace,
+		fmt.Sprintf("timeout %d tcpdump --immediate-mode -ln -c 1 -i any tcp and dst host %s and dst port %d", c.timeout,
+			c.globalIP, c.port), remoteRepositoryInfo)
+	if err != nil {
+		return c.status.Error(err, "Error spawning the sniffer pod on the Gateway node %q of cluster %q", gwNodeName, c.remote.Name)
+	}
+
+	defer sniffer.Delete()
+
+	client, err := spawnWorkloadPod(c.local, c.namespace, "validate-client",
+		fmt.Sprintf("for i in $(seq 3); do timeout 8 nc -zvn -w 5 %s %d </dev/null && echo connected && break; sleep 1; done",
+			c.globalIP, c.port), localRepositoryInfo)
+	if err != nil {
+		return c.status.Error(err, "Error spawning the client pod in cluster %q", c.local.Name)
+	}
+
+	defer client.Delete()
+
+	for _, pod := range []*pods.Scheduled{client, sniffer} {
+		if err := pod.AwaitCompletion(); err != nil {
+			return c.status.Error(err, "Error waiting for pod %q to finish its execution", pod.Pod.Name)
+		}
+	}
+
+	c.snifferOutput = sniffer.PodOutput
+	c.clientOutput = client.PodOutput
+
+	return nil
+}
+
+// exportListener exports a transient listener pod in the remote cluster, for use when there isn't any exported service
+// with a global IP there.
+func (c *globalnetDatapathCheck) exportListener(repositoryInfo *image.RepositoryInfo) (*pods.Scheduled, error) {
+	listener, err := spawnWorkloadPod(c.remote, c.namespace, "validate-listener",
+		fmt.Sprintf("timeout %d nc -lvn -p %d", c.timeout, globalnetSourceListenerPort), repositoryInfo)
+	if err != nil {
+		return nil, c.status.Error(err, "Error spawning the listener pod in cluster %q", c.remote.Name)
+	}
+
+	// The Service and ServiceExport are owned by the listener pod, so that they're garbage collected along with it even
+	// if subctl is interrupted
+	err = exportListenerPod(c.remote, listener.Pod)
+	if err == nil {
+		c.globalIP, err = awaitGlobalIngressIP(c.remote, listener.Pod.Namespace, listener.Pod.Name)
+	}
+
+	if err != nil {
+		listener.Delete()
+		return nil, c.status.Error(err, "Error exporting the listener pod in cluster %q", c.remote.Name)
+	}
+
+	c.service = listener.Pod.Namespace + "/" + listener.Pod.Name
+	c.port = globalnetSourceListenerPort
+
+	c.status.Success("Using the global IP %s of the transient exported service %q in cluster %q", c.globalIP, c.service,
+		c.remote.Name)
+
+	return listener, nil
+}
+
+// findExportedService looks for an exported ClusterIP service with a TCP port and a global IP in the remote cluster.
+func (c *globalnetDatapathCheck) findExportedService() (bool, error) {
+	globalIngressIPs := &submarinerv1.GlobalIngressIPList{}
+
+	err := c.remote.ClientProducer.ForGeneral().List(context.TODO(), globalIngressIPs)
+	if err != nil {
+		return false, errors.Wrap(err, "error listing the GlobalIngressIPs")
+	}
+
+	for i := range globalIngressIPs.Items {
+		globalIngress := &globalIngressIPs.Items[i]
+		if globalIngress.Spec.Target != submarinerv1.ClusterIPService || globalIngress.Spec.ServiceRef == nil ||
+			globalIngress.Status.AllocatedIP == "" {
+			continue
+		}
+
+		service, err := c.remote.ClientProducer.ForKubernetes().CoreV1().Services(globalIngress.Namespace).Get(context.TODO(),
+			globalIngress.Spec.ServiceRef.Name, metav1.GetOptions{})
+		if err != nil {
+			continue
+		}
+
+		for j := range service.Spec.Ports {
+			if service.Spec.Ports[j].Protocol == corev1.ProtocolTCP {
+				c.service = service.Namespace + "/" + service.Name
+				c.globalIP = globalIngress.Status.AllocatedIP
+				c.port = service.Spec.Ports[j].Port
+
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+func (c *globalnetDatapathCheck) analyze() {
+	connected := strings.Contains(c.clientOutput, "connected")
+
+	source := ""
+	if match := capturedSourceRegexp.FindStringSubmatch(c.snifferOutput); match != nil {
+		source = match[1]
+	}
+
+	switch {
+	case source == "" && !connected:
+		c.status.Failure("The connection from cluster %q to the global IP %s of service %q didn't reach the Gateway node of"+
+			" cluster %q; please check the connectivity between the clusters and the Globalnet egress rules in cluster %q."+
+			" Actual client pod output: \n%s", c.local.Name, c.globalIP, c.service, c.remote.Name, c.local.Name,
+			truncate(c.clientOutput))
+	case source == "":
+		c.status.Warning("The connection from cluster %q to the global IP %s of service %q succeeded, but the sniffer pod"+
+			" on the Gateway node of cluster %q didn't see it", c.local.Name, c.globalIP, c.service, c.remote.Name)
+	case !connected:
+		c.status.Failure("The connection from cluster %q reached the Gateway node of cluster %q with source address %s, but"+
+			" it couldn't be established to the global IP %s of service %q; please check the Globalnet ingress rules in"+
+			" cluster %q and that the service has ready endpoints. Actual client pod output: \n%s", c.local.Name, c.remote.Name,
+			source, c.globalIP, c.service, c.remote.Name, truncate(c.clientOutput))
+	default:
+		c.status.Success("The connection from cluster %q to the global IP %s of service %q in cluster %q succeeded, with the"+
+			" source address %s", c.local.Name, c.globalIP, c.service, c.remote.Name, source)
+	}
+
+	if source != "" && !cidrContains(c.local.Submariner.Spec.GlobalCIDR, source) {
+		c.status.Failure("The connection from cluster %q arrived in cluster %q with source address %s, which isn't in the"+
+			" global CIDR %s of cluster %q, so its traffic wasn't translated to a global egress IP", c.local.Name,
+			c.remote.Name, source, c.local.Submariner.Spec.GlobalCIDR, c.local.Name)
+	}
+}
@@ -0,0 +1,348 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diagnose
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/submariner-io/admiral/pkg/reporter"
+	"github.com/submariner-io/admiral/pkg/resource"
+	subctlconstants "github.com/submariner-io/subctl/internal/constants"
+	"github.com/submariner-io/subctl/internal/gvr"
+	"github.com/submariner-io/subctl/internal/pods"
+	"github.com/submariner-io/subctl/pkg/cluster"
+	submarinerv1 "github.com/submariner-io/submariner/pkg/apis/submariner.io/v1"
+	"github.com/submariner-io/submariner/pkg/globalnet/constants"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/wait"
+	controllerClient "sigs.k8s.io/controller-runtime/pkg/client"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+const (
+	globalnetSourceListenerPort  = 9900
+	globalIngressIPTimeout       = 30 * time.Second
+	globalIngressIPInterval      = 2 * time.Second
+	globalnetSourceListenerLabel = "submariner.io/globalnet-source-listener"
+)
+
+// Matches the peer address in the connection messages printed by the various nc implementations, e.g.
+// "Ncat: Connection from 242.0.255.254:39512." or "connect to [10.1.0.5]:9900 from [242.0.255.254]:39512".
+var peerAddressRegexp = regexp.MustCompile(`(?i)from\D*?(\d+\.\d+\.\d+\.\d+)`)
+
+// GlobalnetSourceIPAcrossClusters checks that connections from the remote cluster to an exported service in the local
+// cluster arrive with a source address from the remote cluster's global CIDR, and that the replies make it back. A listener
+// pod in the local cluster, exported through a transient Service, prints the address it sees and replies to a client pod
+// in the remote cluster. A source address from outside the global CIDR means that something between the client and the
+// listener rewrote it, so the return traffic can't be routed back through globalnet.
+func GlobalnetSourceIPAcrossClusters(localClusterInfo, remoteClusterInfo *cluster.Info, namespace string, options FirewallOptions,
+	status reporter.Interface,
+) error {
+	mustHaveSubmariner(localClusterInfo)
+	mustHaveSubmariner(remoteClusterInfo)
+
+	status.Start("Checking the source address of Globalnet traffic from cluster %q to cluster %q", remoteClusterInfo.Name,
+		localClusterInfo.Name)
+	defer status.End()
+
+	for _, clusterInfo := range []*cluster.Info{localClusterInfo, remoteClusterInfo} {
+		if clusterInfo.Submariner.Spec.GlobalCIDR == "" {
+			status.Warning("Skipping this check as Globalnet isn't enabled on cluster %q", clusterInfo.Name)
+			return nil
+		}
+	}
+
+	check := &globalnetSourceCheck{
+		local:     localClusterInfo,
+		remote:    remoteClusterInfo,
+		namespace: namespace,
+		timeout:   options.ValidationTimeout,
+		status:    status,
+	}
+
+	err := check.run(options)
+	if err != nil {
+		return err
+	}
+
+	if options.VerboseOutput {
+		status.Success("Output from the listener pod in cluster %q:\n%s", localClusterInfo.Name, check.listenerOutput)
+		status.Success("Output from the client pod in cluster %q:\n%s", remoteClusterInfo.Name, check.clientOutput)
+	}
+
+	tracker := reporter.NewTracker(status)
+	check.status = tracker
+
+	check.analyze()
+
+	if tracker.HasFailures() {
+		return errors.New("failures while diagnosing the Globalnet source address")
+	}
+
+	return nil
+}
+
+type globalnetSourceCheck struct {
+	local          *cluster.Info
+	remote         *cluster.Info
+	namespace      string
+	timeout        uint
+	status         reporter.Interface
+	globalIP       string
+	listenerOutput string
+	clientOutput   string
+}
+
+func (c *globalnetSourceCheck) run(options FirewallOptions) error {
+	localRepositoryInfo, err := c.local.GetImageRepositoryInfo(options.ImageOverrides...)
+	if err != nil {
+		return c.status.Error(err, "Error determining repository information")
+	}
+
+	remoteRepositoryInfo, err := c.remote.GetImageRepositoryInfo(options.ImageOverrides...)
+	if err != nil {
+		return c.status.Error(err, "Error determining repository information")
+	}
+
+	listener, err := spawnWorkloadPod(c.local, c.namespace, "validate-listener",
+		fmt.Sprintf("echo ok | timeout %d nc -lvn -p %d", c.timeout, globalnetSourceListenerPort), localRepositoryInfo)
+	if err != nil {
+		return c.status.Error(err, "Error spawning the listener pod in cluster %q", c.local.Name)
+	}
+
+	defer listener.Delete()
+
+	// The Service and ServiceExport are owned by the listener pod, so that they're garbage collected along with it even
+	// if subctl is interrupted
+	err = exportListenerPod(c.local, listener.Pod)
+	if err != nil {
+		return c.status.Error(err, "Error exporting the listener pod in cluster %q", c.local.Name)
+	}
+
+	c.globalIP, err = awaitGlobalIngressIP(c.local, listener.Pod.Namespace, listener.Pod.Name)
+	if err != nil {
+		return c.status.Error(err, "Error waiting for a global IP to be allocated to the listener Service in cluster %q",
+			c.local.Name)
+	}
+
+	client, err := spawnWorkloadPod(c.remote, c.namespace, "validate-client",
+		fmt.Sprintf("for i in $(seq 3); do r=$(timeout 8 nc -n %s %d </dev/null); [ -n \"$r\" ] && break; sleep 1; done;"+
+			" echo received $r", c.globalIP, globalnetSourceListenerPort), remoteRepositoryInfo)
+	if err != nil {
+		return c.status.Error(err, "Error spawning the client pod in cluster %q", c.remote.Name)
+	}
+
+	defer client.Delete()
+
+	for _, pod := range []*pods.Scheduled{client, listener} {
+		if err := pod.AwaitCompletion(); err != nil {
+			return c.status.Error(err, "Error waiting for pod %q to finish its execution", pod.Pod.Name)
+		}
+	}
+
+	c.listenerOutput = listener.PodOutput
+	c.clientOutput = client.PodOutput
+
+	return nil
+}
+
+func exportListenerPod(clusterInfo *cluster.Info, pod *corev1.Pod) error {
+	kubeClient := clusterInfo.ClientProducer.ForKubernetes()
+
+	// The pod's app label is shared with the other listener pods, so it's given a label of its own for the Service to select
+	_, err := kubeClient.CoreV1().Pods(pod.Namespace).Patch(context.TODO(), pod.Name, types.MergePatchType,
+		[]byte(fmt.Sprintf(`{"metadata":{"labels":{%q:%q}}}`, globalnetSourceListenerLabel, pod.Name)), metav1.PatchOptions{})
+	if err != nil {
+		return errors.Wrap(err, "error labeling the listener pod")
+	}
+
+	ownerReferences := []metav1.OwnerReference{
+		{APIVersion: "v1", Kind: "Pod", Name: pod.Name, UID: pod.UID},
+	}
+	labels := map[string]string{subctlconstants.TransientLabel: subctlconstants.TrueLabel}
+
+	_, err = kubeClient.CoreV1().Services(pod.Namespace).Create(context.TODO(), &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            pod.Name,
+			Labels:          labels,
+			OwnerReferences: ownerReferences,
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeClusterIP,
+			Selector: map[string]string{globalnetSourceListenerLabel: pod.Name},
+			Ports: []corev1.ServicePort{{
+				Protocol:   corev1.ProtocolTCP,
+				Port:       globalnetSourceListenerPort,
+				TargetPort: intstr.FromInt32(globalnetSourceListenerPort),
+			}},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return errors.Wrap(err, "error creating the listener Service")
+	}
+
+	serviceExport, err := resource.ToUnstructured(&mcsv1a1.ServiceExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            pod.Name,
+			Namespace:       pod.Namespace,
+			Labels:          labels,
+			OwnerReferences: ownerReferences,
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "error converting the listener ServiceExport")
+	}
+
+	_, err = clusterInfo.ClientProducer.ForDynamic().Resource(gvr.FromMetaGroupVersion(mcsv1a1.GroupVersion, "serviceexports")).
+		Namespace(pod.Namespace).Create(context.TODO(), serviceExport, metav1.CreateOptions{})
+
+	return errors.Wrap(err, "error creating the listener ServiceExport")
+}
+
+func awaitGlobalIngressIP(clusterInfo *cluster.Info, namespace, name string) (string, error) {
+	globalIngress := &submarinerv1.GlobalIngressIP{}
+
+	err := wait.PollUntilContextTimeout(context.TODO(), globalIngressIPInterval, globalIngressIPTimeout, true,
+		func(ctx context.Context) (bool, error) {
+			err := clusterInfo.ClientProducer.ForGeneral().Get(ctx, controllerClient.ObjectKey{Namespace: namespace, Name: name},
+				globalIngress)
+			if apierrors.IsNotFound(err) {
+				return false, nil
+			}
+
+			if err != nil {
+				return false, errors.Wrapf(err, "error retrieving GlobalIngressIP %s/%s", namespace, name)
+			}
+
+			return globalIngress.Status.AllocatedIP != "", nil
+		})
+
+	return globalIngress.Status.AllocatedIP, errors.Wrapf(err, "no global IP was allocated to GlobalIngressIP %s/%s", namespace, name)
+}
+
+func (c *globalnetSourceCheck) analyze() {
+	received := strings.Contains(c.clientOutput, "received ok")
+
+	match := peerAddressRegexp.FindStringSubmatch(c.listenerOutput)
+	if match == nil {
+		c.status.Failure("The listener pod in cluster %q didn't see any connection from cluster %q to its global IP %s;"+
+			" please check the connectivity between the clusters first. Actual pod output: \n%s", c.local.Name, c.remote.Name,
+			c.globalIP, truncate(c.listenerOutput))
+
+		return
+	}
+
+	source := match[1]
+
+	if !cidrContains(c.remote.Submariner.Spec.GlobalCIDR, source) {
+		c.status.Failure("The connection from cluster %q arrived in cluster %q with source address %s, which isn't in the"+
+			" global CIDR %s of cluster %q: %s", c.remote.Name, c.local.Name, source, c.remote.Submariner.Spec.GlobalCIDR,
+			c.remote.Name, c.sourceCause(source))
+
+		return
+	}
+
+	egressIPs := c.clusterGlobalEgressIPs()
+	if len(egressIPs) > 0 && !slices.Contains(egressIPs, source) {
+		c.status.Warning("The connection from cluster %q arrived with source address %s, which is in its global CIDR %s but"+
+			" isn't one of its cluster global egress IPs %v; this is expected if a GlobalEgressIP applies to namespace %q",
+			c.remote.Name, source, c.remote.Submariner.Spec.GlobalCIDR, egressIPs, c.namespace)
+	}
+
+	if !received {
+		c.status.Failure("The connection from cluster %q arrived in cluster %q with the expected global source address %s,"+
+			" but the reply didn't make it back; the return traffic is likely dropped or reset by an external SNAT device or"+
+			" firewall between the clusters. Actual client pod output: \n%s", c.remote.Name, c.local.Name, source,
+			truncate(c.clientOutput))
+
+		return
+	}
+
+	c.status.Success("The connection from cluster %q arrived in cluster %q with the global source address %s, and the reply"+
+		" made it back", c.remote.Name, c.local.Name, source)
+}
+
+// sourceCause suggests which component rewrote the source address of the connection, based on where the address is from.
+func (c *globalnetSourceCheck) sourceCause(source string) string {
+	switch {
+	case cidrContains(c.remote.Submariner.Status.ClusterCIDR, source):
+		return fmt.Sprintf("it's the client pod's own address, so its traffic wasn't translated; please check the Globalnet"+
+			" egress rules on the active Gateway node of cluster %q", c.remote.Name)
+	case isNodeAddress(c.remote, source):
+		return fmt.Sprintf("it's a node address of cluster %q, so its traffic was masqueraded instead of being translated to a"+
+			" global IP; please check the Globalnet egress rules and any masquerading rules on the nodes of cluster %q",
+			c.remote.Name, c.remote.Name)
+	case isNodeAddress(c.local, source) || cidrContains(c.local.Submariner.Status.ClusterCIDR, source):
+		return fmt.Sprintf("it's an address of cluster %q, so the incoming traffic was SNATed there; please check the Globalnet"+
+			" ingress rules and any masquerading rules (e.g. kube-proxy's) on the nodes of cluster %q", c.local.Name, c.local.Name)
+	default:
+		return "it isn't an address of either cluster, so it was most likely rewritten by an external SNAT device or cluster" +
+			" egress appliance between the clusters"
+	}
+}
+
+func (c *globalnetSourceCheck) clusterGlobalEgressIPs() []string {
+	clusterGlobalEgress := &submarinerv1.ClusterGlobalEgressIP{}
+
+	err := c.remote.ClientProducer.ForGeneral().Get(context.TODO(), controllerClient.ObjectKey{
+		Name: constants.ClusterGlobalEgressIPName,
+	}, clusterGlobalEgress)
+	if err != nil {
+		return nil
+	}
+
+	return clusterGlobalEgress.Status.AllocatedIPs
+}
+
+func isNodeAddress(clusterInfo *cluster.Info, address string) bool {
+	nodes, err := clusterInfo.ClientProducer.ForKubernetes().CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return false
+	}
+
+	for i := range nodes.Items {
+		for _, nodeAddress := range nodes.Items[i].Status.Addresses {
+			if nodeAddress.Address == address {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func cidrContains(cidr, address string) bool {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return false
+	}
+
+	return ipNet.Contains(net.ParseIP(address))
+}
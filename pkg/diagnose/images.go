@@ -0,0 +1,156 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diagnose
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/submariner-io/admiral/pkg/names"
+	"github.com/submariner-io/admiral/pkg/reporter"
+	"github.com/submariner-io/subctl/internal/pods"
+	"github.com/submariner-io/subctl/pkg/cluster"
+	"github.com/submariner-io/subctl/pkg/image"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const imagePullTimeout = 2 * time.Minute
+
+// The components whose images are always needed, along with those of the deployed components.
+var (
+	baseImageComponents             = []string{names.OperatorComponent, names.NettestComponent}
+	connectivityImageComponents     = []string{names.GatewayComponent, names.RouteAgentComponent, names.MetricsProxyComponent}
+	serviceDiscoveryImageComponents = []string{names.ServiceDiscoveryComponent, names.LighthouseCoreDNSComponent}
+)
+
+type ImagesOptions struct {
+	ImageOverrides []string
+	// Repository and Version override the deployed image repository and version, if specified
+	Repository string
+	Version    string
+}
+
+// Images checks that the images of the deployed components can be pulled in the cluster; if Submariner isn't deployed,
+// the images of all the components are checked.
+func Images(clusterInfo *cluster.Info, namespace string, options ImagesOptions, status reporter.Interface) error {
+	repositoryInfo, err := clusterInfo.GetImageRepositoryInfo(options.ImageOverrides...)
+	if err != nil {
+		return status.Error(err, "Error determining repository information")
+	}
+
+	if options.Repository != "" {
+		repositoryInfo.Name = options.Repository
+	}
+
+	if options.Version != "" {
+		repositoryInfo.Version = options.Version
+	}
+
+	notDeployed := clusterInfo.Submariner == nil && clusterInfo.ServiceDiscovery == nil
+
+	return CheckImagesPullable(clusterInfo.ClientProducer.ForKubernetes(), namespace, repositoryInfo,
+		ImageComponents(clusterInfo.Submariner != nil || notDeployed,
+			notDeployed || (clusterInfo.Submariner != nil && clusterInfo.Submariner.Spec.GlobalCIDR != ""),
+			clusterInfo.ServiceDiscovery != nil || notDeployed), status)
+}
+
+// ImageComponents returns the components whose images are needed to deploy the given Submariner components.
+func ImageComponents(connectivity, globalnet, serviceDiscovery bool) []string {
+	components := slices.Clone(baseImageComponents)
+
+	if connectivity {
+		components = append(components, connectivityImageComponents...)
+
+		if globalnet {
+			components = append(components, names.GlobalnetComponent)
+		}
+	}
+
+	if serviceDiscovery {
+		components = append(components, serviceDiscoveryImageComponents...)
+	}
+
+	return components
+}
+
+// CheckImagesPullable checks that the images of the given components can be pulled in the cluster, by running a
+// short-lived pod for each distinct image, and reports those which can't be pulled. The pods run in the given namespace
+// if it exists, otherwise in the default namespace.
+func CheckImagesPullable(kubeClient kubernetes.Interface, namespace string, repositoryInfo *image.RepositoryInfo,
+	components []string, status reporter.Interface,
+) error {
+	status.Start("Checking that the Submariner images can be pulled")
+	defer status.End()
+
+	_, err := kubeClient.CoreV1().Namespaces().Get(context.TODO(), namespace, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		namespace = metav1.NamespaceDefault
+	} else if err != nil {
+		return status.Error(err, "Error retrieving namespace %q", namespace)
+	}
+
+	// Some components share their image
+	imageComponents := map[string][]string{}
+	componentImages := []string{}
+
+	for component, componentImage := range repositoryInfo.GetComponentImages(components...) {
+		if _, ok := imageComponents[componentImage]; !ok {
+			componentImages = append(componentImages, componentImage)
+		}
+
+		imageComponents[componentImage] = append(imageComponents[componentImage], component)
+	}
+
+	slices.Sort(componentImages)
+
+	tracker := reporter.NewTracker(status)
+
+	for _, componentImage := range componentImages {
+		users := imageComponents[componentImage]
+		slices.Sort(users)
+
+		failure, err := pods.CheckImagePull(&pods.Config{
+			Name:                "validate-image-pull",
+			ClientSet:           kubeClient,
+			Namespace:           namespace,
+			ImageRepositoryInfo: *repositoryInfo,
+		}, componentImage, imagePullTimeout)
+
+		switch {
+		case err != nil:
+			tracker.Failure("Error checking whether image %q (used by %s) can be pulled: %v", componentImage,
+				strings.Join(users, ", "), err)
+		case failure != "":
+			tracker.Failure("Image %q (used by %s) can't be pulled: %s", componentImage, strings.Join(users, ", "), failure)
+		default:
+			tracker.Success("Image %q (used by %s) can be pulled", componentImage, strings.Join(users, ", "))
+		}
+	}
+
+	if tracker.HasFailures() {
+		return errors.New("some of the Submariner images can't be pulled")
+	}
+
+	return nil
+}
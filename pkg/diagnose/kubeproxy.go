@@ -19,6 +19,8 @@ limitations under the License.
 package diagnose
 
 import (
+	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/submariner-io/admiral/pkg/reporter"
@@ -31,8 +33,16 @@ const (
 	kubeProxyIPVSIfaceCommand = "ip a s kube-ipvs0"
 	missingInterface          = "ip: can't find device"
 	notEnabled                = "Device \"kube-ipvs0\" does not exist"
+	// The pod output is read from its termination log which is size-limited, so only the masquerading rules which
+	// mention a remote CIDR are kept
+	kubeProxyMasqueradeRulesCommand = "iptables-save -t nat | grep -E -- '-j (KUBE-MARK-MASQ|MASQUERADE)' | grep -F%s | head -n 20"
 )
 
+// The network plugins which rely on kube-proxy's iptables rules.
+var iptablesNetworkPlugins = []string{
+	cni.Generic, cni.Calico, cni.CanalFlannel, cni.Flannel, cni.KindNet, cni.OpenShiftSDN, cni.WeaveNet,
+}
+
 func KubeProxyMode(clusterInfo *cluster.Info, namespace string, imageOverrides []string, status reporter.Interface) error {
 	status.Start("Checking Submariner support for the kube-proxy mode")
 	defer status.End()
@@ -70,3 +80,104 @@ func KubeProxyMode(clusterInfo *cluster.Info, namespace string, imageOverrides [
 
 	return nil
 }
+
+// KubeProxyConflicts checks the active Gateway node for masquerading rules, typically set up by kube-proxy or the
+// network plugin, which match the CIDRs of the remote clusters; masquerading the tunnel traffic leads to asymmetric
+// routing.
+func KubeProxyConflicts(clusterInfo *cluster.Info, namespace string, imageOverrides []string, status reporter.Interface) error {
+	mustHaveSubmariner(clusterInfo)
+
+	status.Start("Checking for masquerading rules conflicting with the remote cluster CIDRs")
+	defer status.End()
+
+	if !slices.ContainsFunc(iptablesNetworkPlugins, func(plugin string) bool {
+		return strings.EqualFold(clusterInfo.Submariner.Status.NetworkPlugin, plugin)
+	}) {
+		status.Success("The %q network plugin doesn't rely on kube-proxy's iptables rules", clusterInfo.Submariner.Status.NetworkPlugin)
+		return nil
+	}
+
+	clusterSubnets, err := getRemoteSubnets(clusterInfo)
+	if err != nil {
+		return status.Error(err, "Error determining the remote cluster CIDRs")
+	}
+
+	remoteSubnets := map[string]string{}
+
+	for clusterID, subnets := range clusterSubnets {
+		for _, subnet := range subnets {
+			remoteSubnets[subnet] = clusterID
+		}
+	}
+
+	if len(remoteSubnets) == 0 {
+		status.Success("There are no remote clusters")
+		return nil
+	}
+
+	gwNodeName, err := getActiveGatewayNodeName(clusterInfo, status)
+	if err != nil {
+		return err
+	}
+
+	repositoryInfo, err := clusterInfo.GetImageRepositoryInfo(imageOverrides...)
+	if err != nil {
+		return status.Error(err, "Error determining repository information")
+	}
+
+	patterns := ""
+	for subnet := range remoteSubnets {
+		patterns += fmt.Sprintf(" -e ' %s'", subnet)
+	}
+
+	sPod, err := spawnSnifferPodOnNode(clusterInfo.ClientProducer.ForKubernetes(), gwNodeName, namespace,
+		fmt.Sprintf(kubeProxyMasqueradeRulesCommand, patterns), repositoryInfo)
+	if err != nil {
+		return status.Error(err, "Error spawning the network pod on the Gateway node %q", gwNodeName)
+	}
+
+	defer sPod.Delete()
+
+	if err = sPod.AwaitCompletion(); err != nil {
+		return status.Error(err, "Error waiting for the network pod to finish its execution")
+	}
+
+	tracker := reporter.NewTracker(status)
+
+	for _, rule := range strings.Split(sPod.PodOutput, "\n") {
+		checkMasqueradeRule(strings.TrimSpace(rule), remoteSubnets, gwNodeName, tracker)
+	}
+
+	if !tracker.HasWarnings() {
+		status.Success("No masquerading rules on the Gateway node %q match the remote cluster CIDRs", gwNodeName)
+	}
+
+	return nil
+}
+
+// checkMasqueradeRule warns if the given iptables-save rule has a non-negated source or destination match on one of the
+// remote subnets, which are mapped to their cluster IDs.
+func checkMasqueradeRule(rule string, remoteSubnets map[string]string, gwNodeName string, status reporter.Interface) {
+	fields := strings.Fields(rule)
+
+	for i := 1; i < len(fields)-1; i++ {
+		direction := ""
+
+		switch fields[i] {
+		case "-s", "--source":
+			direction = "from"
+		case "-d", "--destination":
+			direction = "to"
+		default:
+			continue
+		}
+
+		clusterID, remote := remoteSubnets[fields[i+1]]
+		if !remote || fields[i-1] == "!" {
+			continue
+		}
+
+		status.Warning("The rule %q on the Gateway node %q masquerades the traffic %s the CIDR %s of cluster %q;"+
+			" this can cause asymmetric routing of the Submariner traffic", rule, gwNodeName, direction, fields[i+1], clusterID)
+	}
+}
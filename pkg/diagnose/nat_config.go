@@ -0,0 +1,185 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diagnose
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/submariner-io/admiral/pkg/reporter"
+	"github.com/submariner-io/subctl/internal/pods"
+	"github.com/submariner-io/subctl/pkg/cluster"
+	submv1 "github.com/submariner-io/submariner/pkg/apis/submariner.io/v1"
+	utilerrs "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// The resolvers the gateway uses when the Endpoint doesn't configure any.
+var defaultPublicIPResolvers = []string{
+	"api:ip4.seeip.org", "api:ipecho.net/plain", "api:ifconfig.me", "api:ipinfo.io/ip",
+	"api:4.ident.me", "api:checkip.amazonaws.com", "api:4.icanhazip.com", "api:api.ipify.org",
+}
+
+func NATConfig(clusterInfo *cluster.Info, namespace string, imageOverrides []string, status reporter.Interface) error {
+	mustHaveSubmariner(clusterInfo)
+
+	status.Start("Checking the NAT configuration advertised by the local Endpoint")
+	defer status.End()
+
+	localEndpoint, err := clusterInfo.GetLocalEndpoint()
+	if err != nil {
+		return status.Error(err, "Unable to obtain the local endpoint")
+	}
+
+	gwNodeName, err := getActiveGatewayNodeName(clusterInfo, status)
+	if err != nil {
+		return err
+	}
+
+	repositoryInfo, err := clusterInfo.GetImageRepositoryInfo(imageOverrides...)
+	if err != nil {
+		return status.Error(err, "Error determining repository information")
+	}
+
+	var probe probeFn = func(name, command string) (string, error) {
+		//nolint:wrapcheck // No need to wrap errors here.
+		return pods.ScheduleAndAwaitCompletion(&pods.Config{
+			Name:      name,
+			ClientSet: clusterInfo.ClientProducer.ForKubernetes(),
+			Scheduling: pods.Scheduling{
+				ScheduleOn: pods.CustomNode, NodeName: gwNodeName,
+				Networking: pods.HostNetworking,
+			},
+			Namespace:           namespace,
+			Command:             command,
+			ImageRepositoryInfo: *repositoryInfo,
+		})
+	}
+
+	tracker := reporter.NewTracker(status)
+
+	err = utilerrs.NewAggregate([]error{
+		checkAdvertisedPublicIP(&localEndpoint.Spec, gwNodeName, probe, tracker),
+		checkAdvertisedPrivateIP(&localEndpoint.Spec, gwNodeName, probe, tracker),
+	})
+
+	if err == nil && !tracker.HasFailures() && !tracker.HasWarnings() {
+		status.Success("The local Endpoint advertises the IPs of the Gateway node %q", gwNodeName)
+	}
+
+	return err
+}
+
+type probeFn func(name, command string) (string, error)
+
+func checkAdvertisedPublicIP(endpoint *submv1.EndpointSpec, gwNodeName string, probe probeFn, status reporter.Interface) error {
+	if endpoint.PublicIP == "" {
+		status.Warning("The local Endpoint doesn't advertise a public IP")
+		return nil
+	}
+
+	resolvers := defaultPublicIPResolvers
+	if config := endpoint.BackendConfig[submv1.PublicIP]; config != "" {
+		resolvers = strings.Split(config, ",")
+	}
+
+	apiHosts := []string{}
+
+	for _, resolver := range resolvers {
+		method, value, _ := strings.Cut(strings.TrimSpace(resolver), ":")
+
+		switch method {
+		case "ipv4":
+			// A statically configured IP takes precedence, there's nothing to resolve
+			comparePublicIP(endpoint.PublicIP, value, "the static configuration", status)
+			return nil
+		case "api":
+			apiHosts = append(apiHosts, value)
+		}
+	}
+
+	if len(apiHosts) == 0 {
+		status.Warning("The public IP is resolved using %q which can't be checked from the Gateway node",
+			endpoint.BackendConfig[submv1.PublicIP])
+		return nil
+	}
+
+	// Print the first IPv4 address returned by a resolver, along with the resolver
+	command := fmt.Sprintf("for r in %s; do ip=$(curl -s -m 5 https://$r | grep -Eo '([0-9]{1,3}\\.){3}[0-9]{1,3}' | head -n 1); "+
+		"if [ -n \"$ip\" ]; then echo \"$r $ip\"; exit 0; fi; done", strings.Join(apiHosts, " "))
+
+	podOutput, err := probe("resolve-public-ip", command)
+	if err != nil {
+		return status.Error(err, "Error spawning the network pod on the Gateway node %q", gwNodeName)
+	}
+
+	resolver, resolvedIP, found := strings.Cut(strings.TrimSpace(podOutput), " ")
+	if !found {
+		status.Warning("None of the public IP resolvers %q could be reached from the Gateway node %q", apiHosts, gwNodeName)
+		return nil
+	}
+
+	comparePublicIP(endpoint.PublicIP, resolvedIP, fmt.Sprintf("resolver %q", resolver), status)
+
+	return nil
+}
+
+func comparePublicIP(advertised, resolved, source string, status reporter.Interface) {
+	if advertised != resolved {
+		status.Failure("The local Endpoint advertises the public IP %q but %s returns %q; NAT-traversal peers won't be able to"+
+			" connect until the Gateway pod is restarted to refresh it", advertised, source, resolved)
+	}
+}
+
+func checkAdvertisedPrivateIP(endpoint *submv1.EndpointSpec, gwNodeName string, probe probeFn, status reporter.Interface) error {
+	if endpoint.NATEnabled {
+		return nil
+	}
+
+	podOutput, err := probe("query-addresses", "ip -o addr show")
+	if err != nil {
+		return status.Error(err, "Error spawning the network pod on the Gateway node %q", gwNodeName)
+	}
+
+	if !hasInterfaceAddress(podOutput, endpoint.PrivateIP) {
+		status.Failure("NAT is disabled but the private IP %q advertised by the local Endpoint isn't assigned to any interface"+
+			" on the Gateway node %q", endpoint.PrivateIP, gwNodeName)
+	}
+
+	return nil
+}
+
+// hasInterfaceAddress parses the output of "ip -o addr show" and determines whether the given IP is assigned to an interface.
+func hasInterfaceAddress(ipAddrOutput, ip string) bool {
+	for _, line := range strings.Split(ipAddrOutput, "\n") {
+		// index: name family address/prefix ...
+		fields := strings.Fields(line)
+		for i := 2; i < len(fields)-1; i++ {
+			if fields[i] != "inet" && fields[i] != "inet6" {
+				continue
+			}
+
+			if address, _, err := net.ParseCIDR(fields[i+1]); err == nil && address.String() == ip {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,159 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diagnose
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/submariner-io/admiral/pkg/reporter"
+	"github.com/submariner-io/subctl/internal/pods"
+	"github.com/submariner-io/subctl/pkg/cluster"
+	submv1 "github.com/submariner-io/submariner/pkg/apis/submariner.io/v1"
+	utilerrs "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// UDP sockets have no LISTEN state; a bound, unconnected socket is reported as TCP_CLOSE in /proc/net/udp.
+const udpBoundState = "07"
+
+func NATTraversalHealth(clusterInfo *cluster.Info, namespace string, imageOverrides []string, status reporter.Interface) error {
+	mustHaveSubmariner(clusterInfo)
+
+	return utilerrs.NewAggregate([]error{
+		checkNATDiscoveryPortBound(clusterInfo, namespace, imageOverrides, status),
+		checkNATTraversalIPs(clusterInfo, status),
+	})
+}
+
+func checkNATDiscoveryPortBound(clusterInfo *cluster.Info, namespace string, imageOverrides []string, status reporter.Interface) error {
+	status.Start("Checking that the nat-discovery port is bound on the active Gateway node")
+	defer status.End()
+
+	localEndpoint, err := clusterInfo.GetLocalEndpoint()
+	if err != nil {
+		return status.Error(err, "Unable to obtain the local endpoint")
+	}
+
+	nattPort, err := getTargetPort(clusterInfo.Submariner, localEndpoint, NatDiscoveryPort)
+	if err != nil {
+		return status.Error(err, "Could not determine the nat-discovery port")
+	}
+
+	gwNodeName, err := getActiveGatewayNodeName(clusterInfo, status)
+	if err != nil {
+		return err
+	}
+
+	repositoryInfo, err := clusterInfo.GetImageRepositoryInfo(imageOverrides...)
+	if err != nil {
+		return status.Error(err, "Error determining repository information")
+	}
+
+	// Only keep the sockets on the port, the pod output is read from its termination log which is size-limited
+	podOutput, err := pods.ScheduleAndAwaitCompletion(&pods.Config{
+		Name:      "query-udp-sockets",
+		ClientSet: clusterInfo.ClientProducer.ForKubernetes(),
+		Scheduling: pods.Scheduling{
+			ScheduleOn: pods.CustomNode, NodeName: gwNodeName,
+			Networking: pods.HostNetworking,
+		},
+		Namespace:           namespace,
+		Command:             fmt.Sprintf("grep -hi ':%04X ' /proc/net/udp /proc/net/udp6", nattPort),
+		ImageRepositoryInfo: *repositoryInfo,
+	})
+	if err != nil {
+		return status.Error(err, "Error spawning the network pod on the Gateway node %q", gwNodeName)
+	}
+
+	if !isUDPPortBound(podOutput, nattPort) {
+		return status.Error(fmt.Errorf("no process is bound to the nat-discovery port UDP/%d on the Gateway node %q",
+			nattPort, gwNodeName), "")
+	}
+
+	status.Success("The nat-discovery port UDP/%d is bound on the Gateway node %q", nattPort, gwNodeName)
+
+	return nil
+}
+
+// isUDPPortBound parses lines in the /proc/net/udp format and determines whether a socket is bound to the given local port.
+func isUDPPortBound(procNetUDP string, port int32) bool {
+	portSuffix := fmt.Sprintf(":%04X", port)
+
+	for _, line := range strings.Split(procNetUDP, "\n") {
+		// sl local_address rem_address st ...
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+
+		if strings.HasSuffix(strings.ToUpper(fields[1]), portSuffix) && fields[3] == udpBoundState {
+			return true
+		}
+	}
+
+	return false
+}
+
+func checkNATTraversalIPs(clusterInfo *cluster.Info, status reporter.Interface) error {
+	status.Start("Checking the IPs used by the gateway connections")
+	defer status.End()
+
+	gateways, err := clusterInfo.GetGateways()
+	if err != nil {
+		return status.Error(err, "Error retrieving gateways")
+	}
+
+	tracker := reporter.NewTracker(status)
+
+	for i := range gateways {
+		gateway := &gateways[i]
+		if gateway.Status.HAStatus != submv1.HAStatusActive {
+			continue
+		}
+
+		for j := range gateway.Status.Connections {
+			checkConnectionUsingIP(&gateway.Status.Connections[j], tracker)
+		}
+	}
+
+	if !tracker.HasFailures() && !tracker.HasWarnings() {
+		status.Success("The gateway connections use the expected IPs")
+	}
+
+	return nil
+}
+
+func checkConnectionUsingIP(connection *submv1.Connection, status reporter.Interface) {
+	remote := &connection.Endpoint
+
+	switch {
+	case connection.UsingIP == "":
+		status.Warning("The connection to cluster %q doesn't report the IP it uses", remote.ClusterID)
+	case connection.UsingIP == remote.PublicIP:
+		return
+	case connection.UsingIP == remote.PrivateIP:
+		if remote.NATEnabled && remote.PublicIP != "" && remote.PublicIP != remote.PrivateIP {
+			status.Warning("The connection to cluster %q uses the private IP %q although NAT is enabled (public IP %q);"+
+				" check that nat-discovery is reachable on both gateways", remote.ClusterID, remote.PrivateIP, remote.PublicIP)
+		}
+	default:
+		status.Warning("The connection to cluster %q uses IP %q which is neither its public IP %q nor its private IP %q",
+			remote.ClusterID, connection.UsingIP, remote.PublicIP, remote.PrivateIP)
+	}
+}
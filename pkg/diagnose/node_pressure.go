@@ -0,0 +1,149 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diagnose
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/submariner-io/admiral/pkg/names"
+	"github.com/submariner-io/admiral/pkg/reporter"
+	"github.com/submariner-io/subctl/internal/constants"
+	"github.com/submariner-io/subctl/internal/pods"
+	"github.com/submariner-io/subctl/pkg/cluster"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const maxGatewayCPUUsage = 90.0
+
+// The first iteration of top reports the usage since boot, so the second one is used. The idle percentage is extracted
+// from both the procps ("98.1 id,") and busybox ("98% idle") summary line formats.
+const cpuIdleCmd = `top -bn2 -d1 | awk '/^%?C[Pp][Uu]/ { if (match($0, /[0-9.]+%? *id/)) idle = substr($0, RSTART, RLENGTH) }` +
+	` END { sub(/%? *id.*/, "", idle); print idle }'`
+
+var nodePressureConditions = []corev1.NodeConditionType{
+	corev1.NodeMemoryPressure,
+	corev1.NodeDiskPressure,
+	corev1.NodePIDPressure,
+}
+
+func GatewayNodePressure(clusterInfo *cluster.Info, _ string, status reporter.Interface) error {
+	mustHaveSubmariner(clusterInfo)
+
+	status.Start("Checking the resource pressure on the gateway nodes")
+	defer status.End()
+
+	kubeClient := clusterInfo.ClientProducer.ForKubernetes()
+
+	nodes, err := kubeClient.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(map[string]string{constants.SubmarinerGatewayLabel: constants.TrueLabel}).String(),
+	})
+	if err != nil {
+		return status.Error(err, "Error listing the gateway nodes")
+	}
+
+	if len(nodes.Items) == 0 {
+		status.Warning("There are no gateway nodes")
+		return nil
+	}
+
+	gatewayPods, err := kubeClient.CoreV1().Pods(constants.OperatorNamespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: "app=" + names.GatewayComponent,
+	})
+	if err != nil {
+		return status.Error(err, "Error listing the gateway pods")
+	}
+
+	tracker := reporter.NewTracker(status)
+
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+
+		checkNodePressureConditions(node, tracker)
+
+		gatewayPod := findPodOnNode(gatewayPods.Items, node.Name)
+		if gatewayPod == nil {
+			tracker.Warning("There is no gateway pod on node %q to check its CPU usage", node.Name)
+			continue
+		}
+
+		checkNodeCPUUsage(clusterInfo, node.Name, gatewayPod, tracker)
+	}
+
+	if tracker.HasFailures() {
+		return errors.New("failures while diagnosing the gateway node resource pressure")
+	}
+
+	if !tracker.HasWarnings() {
+		status.Success("The gateway nodes aren't under resource pressure")
+	}
+
+	return nil
+}
+
+func checkNodePressureConditions(node *corev1.Node, status reporter.Interface) {
+	for _, conditionType := range nodePressureConditions {
+		for i := range node.Status.Conditions {
+			condition := &node.Status.Conditions[i]
+
+			if condition.Type == conditionType && condition.Status == corev1.ConditionTrue {
+				status.Failure("Gateway node %q is under %s since %s: %s", node.Name, conditionType,
+					condition.LastTransitionTime.Format("2006-01-02 15:04:05"), condition.Message)
+			}
+		}
+	}
+}
+
+func checkNodeCPUUsage(clusterInfo *cluster.Info, nodeName string, gatewayPod *corev1.Pod, status reporter.Interface) {
+	execOptions := pods.ExecOptionsFromPod(gatewayPod)
+	execOptions.Command = []string{"sh", "-c", cpuIdleCmd}
+
+	stdout, stderr, err := pods.ExecWithOptions(context.TODO(), pods.ExecConfig{
+		RestConfig: clusterInfo.RestConfig,
+		ClientSet:  clusterInfo.ClientProducer.ForKubernetes(),
+	}, &execOptions)
+	if err != nil {
+		status.Warning("Unable to determine the CPU usage on gateway node %q: %v %s", nodeName, err, stderr)
+		return
+	}
+
+	idle, err := strconv.ParseFloat(strings.TrimSpace(stdout), 64)
+	if err != nil {
+		status.Warning("Unable to determine the CPU usage on gateway node %q from the output of top %q", nodeName, stdout)
+		return
+	}
+
+	if usage := 100 - idle; usage > maxGatewayCPUUsage {
+		status.Warning("The CPU usage on gateway node %q is %.1f%%, which may cause intermittent tunnel failures", nodeName, usage)
+	}
+}
+
+func findPodOnNode(podList []corev1.Pod, nodeName string) *corev1.Pod {
+	for i := range podList {
+		if podList[i].Spec.NodeName == nodeName {
+			return &podList[i]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,263 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diagnose
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/submariner-io/admiral/pkg/names"
+	"github.com/submariner-io/admiral/pkg/reporter"
+	"github.com/submariner-io/subctl/internal/constants"
+	"github.com/submariner-io/subctl/pkg/cluster"
+	operatorv1alpha1 "github.com/submariner-io/submariner-operator/api/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/utils/set"
+)
+
+const watchNamespaceEnvVar = "WATCH_NAMESPACE"
+
+// Matches the downward API reference to an annotation, as used by OLM to pass the operator group's target namespaces.
+var annotationFieldPath = regexp.MustCompile(`^metadata\.annotations\['(.+)'\]$`)
+
+// watchedNamespaces describes the namespaces watched by the operator; with all set, every namespace is watched.
+type watchedNamespaces struct {
+	all        bool
+	namespaces set.Set[string]
+}
+
+func (w *watchedNamespaces) has(namespace string) bool {
+	return w.all || w.namespaces.Has(namespace)
+}
+
+func (w *watchedNamespaces) String() string {
+	if w.all {
+		return "all namespaces"
+	}
+
+	return fmt.Sprintf("%v", w.namespaces.SortedList())
+}
+
+type operatorResource struct {
+	kind       string
+	namespace  string
+	name       string
+	reconciled bool
+}
+
+func (r *operatorResource) String() string {
+	return r.namespace + "/" + r.name
+}
+
+// checkOperatorNamespaces verifies that the operator watches the namespaces containing the Submariner and
+// ServiceDiscovery resources; otherwise the resources are never reconciled.
+func checkOperatorNamespaces(clusterInfo *cluster.Info, status reporter.Interface) error {
+	status.Start("Checking that the operator watches the namespaces containing the Submariner resources")
+	defer status.End()
+
+	resources, err := listOperatorResources(clusterInfo)
+	if err != nil {
+		return status.Error(err, "Error listing the Submariner resources")
+	}
+
+	if len(resources) == 0 {
+		status.Success("There are no Submariner or ServiceDiscovery resources to check")
+		return nil
+	}
+
+	operatorDeployment, err := findOperatorDeployment(clusterInfo)
+	if err != nil {
+		return status.Error(err, "Error retrieving the operator Deployment")
+	}
+
+	if operatorDeployment == nil {
+		status.Failure("The operator Deployment %q wasn't found in any namespace, the Submariner resources won't be reconciled",
+			names.OperatorComponent)
+		return errors.New("missing operator Deployment")
+	}
+
+	watched, err := operatorWatchedNamespaces(operatorDeployment)
+	if err != nil {
+		status.Failure("Unable to determine the namespaces watched by the operator Deployment %s/%s: %v",
+			operatorDeployment.Namespace, operatorDeployment.Name, err)
+		return errors.New("unknown operator watch namespaces")
+	}
+
+	tracker := reporter.NewTracker(status)
+
+	for _, resource := range resources {
+		if watched.has(resource.namespace) {
+			continue
+		}
+
+		message := fmt.Sprintf("The %s resource %q is in namespace %q, but the operator Deployment %s/%s only watches %s so it"+
+			" will never be reconciled", resource.kind, resource.name, resource.namespace, operatorDeployment.Namespace,
+			operatorDeployment.Name, watched)
+		if !resource.reconciled {
+			message += " (its status is empty, it hasn't been reconciled)"
+		}
+
+		tracker.Failure(message)
+	}
+
+	warnOnMultipleResources(resources, "Submariner", tracker)
+	warnOnMultipleResources(resources, "ServiceDiscovery", tracker)
+
+	if tracker.HasFailures() {
+		return errors.New("failures while diagnosing the operator namespaces")
+	}
+
+	if !tracker.HasWarnings() {
+		status.Success("The operator Deployment %s/%s watches %s, which contain all the Submariner resources",
+			operatorDeployment.Namespace, operatorDeployment.Name, watched)
+	}
+
+	return nil
+}
+
+func listOperatorResources(clusterInfo *cluster.Info) ([]operatorResource, error) {
+	resources := []operatorResource{}
+
+	submariners := &operatorv1alpha1.SubmarinerList{}
+
+	err := clusterInfo.ClientProducer.ForGeneral().List(context.TODO(), submariners)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, errors.Wrap(err, "error listing Submariner resources")
+	}
+
+	for i := range submariners.Items {
+		resources = append(resources, operatorResource{
+			kind:       "Submariner",
+			namespace:  submariners.Items[i].Namespace,
+			name:       submariners.Items[i].Name,
+			reconciled: submariners.Items[i].Status.ClusterID != "",
+		})
+	}
+
+	serviceDiscoveries := &operatorv1alpha1.ServiceDiscoveryList{}
+
+	err = clusterInfo.ClientProducer.ForGeneral().List(context.TODO(), serviceDiscoveries)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, errors.Wrap(err, "error listing ServiceDiscovery resources")
+	}
+
+	for i := range serviceDiscoveries.Items {
+		resources = append(resources, operatorResource{
+			kind:       "ServiceDiscovery",
+			namespace:  serviceDiscoveries.Items[i].Namespace,
+			name:       serviceDiscoveries.Items[i].Name,
+			reconciled: serviceDiscoveries.Items[i].Status.DeploymentInfo.KubernetesVersion != "",
+		})
+	}
+
+	return resources, nil
+}
+
+// findOperatorDeployment returns the operator Deployment, looking in all namespaces and preferring the default operator
+// namespace if there are several; nil is returned if it isn't found.
+func findOperatorDeployment(clusterInfo *cluster.Info) (*appsv1.Deployment, error) {
+	deployments, err := clusterInfo.ClientProducer.ForKubernetes().AppsV1().Deployments(metav1.NamespaceAll).List(context.TODO(),
+		metav1.ListOptions{FieldSelector: fields.OneTermEqualSelector("metadata.name", names.OperatorComponent).String()})
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing Deployments")
+	}
+
+	var found *appsv1.Deployment
+
+	for i := range deployments.Items {
+		if deployments.Items[i].Name != names.OperatorComponent {
+			continue
+		}
+
+		if found == nil || deployments.Items[i].Namespace == constants.OperatorNamespace {
+			found = &deployments.Items[i]
+		}
+	}
+
+	return found, nil
+}
+
+// operatorWatchedNamespaces determines the namespaces watched by the operator from its WATCH_NAMESPACE environment
+// variable, which contains a comma-separated list of namespaces, or nothing to watch all namespaces. The value may come
+// from the pod's namespace or one of its annotations.
+func operatorWatchedNamespaces(deployment *appsv1.Deployment) (*watchedNamespaces, error) {
+	for i := range deployment.Spec.Template.Spec.Containers {
+		for _, env := range deployment.Spec.Template.Spec.Containers[i].Env {
+			if env.Name != watchNamespaceEnvVar {
+				continue
+			}
+
+			value := env.Value
+
+			if env.ValueFrom != nil {
+				if env.ValueFrom.FieldRef == nil {
+					return nil, fmt.Errorf("%s is set from a source other than the pod's fields", watchNamespaceEnvVar)
+				}
+
+				fieldPath := env.ValueFrom.FieldRef.FieldPath
+
+				if fieldPath == "metadata.namespace" {
+					value = deployment.Namespace
+				} else if matches := annotationFieldPath.FindStringSubmatch(fieldPath); matches != nil {
+					value = deployment.Spec.Template.Annotations[matches[1]]
+				} else {
+					return nil, fmt.Errorf("%s is set from the unsupported field %q", watchNamespaceEnvVar, fieldPath)
+				}
+			}
+
+			return parseWatchedNamespaces(value), nil
+		}
+	}
+
+	return nil, fmt.Errorf("%s isn't set", watchNamespaceEnvVar)
+}
+
+func parseWatchedNamespaces(value string) *watchedNamespaces {
+	watched := &watchedNamespaces{namespaces: set.New[string]()}
+
+	for _, namespace := range strings.Split(value, ",") {
+		if namespace = strings.TrimSpace(namespace); namespace != "" {
+			watched.namespaces.Insert(namespace)
+		}
+	}
+
+	watched.all = watched.namespaces.Len() == 0
+
+	return watched
+}
+
+func warnOnMultipleResources(resources []operatorResource, kind string, status reporter.Interface) {
+	found := []string{}
+
+	for i := range resources {
+		if resources[i].kind == kind {
+			found = append(found, resources[i].String())
+		}
+	}
+
+	if len(found) > 1 {
+		status.Warning("Found %d %s resources (%s), but only one is supported", len(found), kind, strings.Join(found, ", "))
+	}
+}
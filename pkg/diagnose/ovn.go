@@ -0,0 +1,267 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diagnose
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/submariner-io/admiral/pkg/names"
+	"github.com/submariner-io/admiral/pkg/reporter"
+	"github.com/submariner-io/subctl/internal/constants"
+	"github.com/submariner-io/subctl/internal/pods"
+	"github.com/submariner-io/subctl/pkg/cluster"
+	submarinerv1 "github.com/submariner-io/submariner/pkg/apis/submariner.io/v1"
+	"github.com/submariner-io/submariner/pkg/cni"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	controllerClient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	ovnMasterPodLabelOCP     = "app=ovnkube-master"
+	ovnMasterPodLabelGeneric = "name=ovnkube-master"
+	// With OVN interconnect, there is no master pod and each node pod has its own zone database
+	ovnKubeNodePodLabel = "app=ovnkube-node"
+)
+
+var (
+	ovnRouteListCmd  = []string{"ovn-nbctl", "--no-leader-only", "lr-route-list", "ovn_cluster_router"}
+	ovnPolicyListCmd = []string{"ovn-nbctl", "--no-leader-only", "lr-policy-list", "ovn_cluster_router"}
+)
+
+// ovnPolicyDestination extracts the destination prefix from a logical router policy's match.
+var ovnPolicyDestination = regexp.MustCompile(`ip[46]\.dst\s*==\s*([0-9a-fA-F:.]+/[0-9]+)`)
+
+func OVNConfig(clusterInfo *cluster.Info, _ string, status reporter.Interface) error {
+	mustHaveSubmariner(clusterInfo)
+
+	if !strings.EqualFold(clusterInfo.Submariner.Status.NetworkPlugin, cni.OVNKubernetes) {
+		status.Start("Checking the OVN configuration")
+		status.Success("The detected CNI network plugin (%q) isn't OVN-Kubernetes, skipping this check",
+			clusterInfo.Submariner.Status.NetworkPlugin)
+		status.End()
+
+		return nil
+	}
+
+	routesOK := checkOVNRoutes(clusterInfo, status)
+
+	if !checkNetworkPluginSyncer(clusterInfo, status) || !routesOK {
+		return errors.New("failures while diagnosing OVN")
+	}
+
+	return nil
+}
+
+func checkOVNRoutes(clusterInfo *cluster.Info, status reporter.Interface) bool {
+	status.Start("Checking the OVN routing for the remote cluster CIDRs")
+	defer status.End()
+
+	tracker := reporter.NewTracker(status)
+
+	checkOVNRemoteSubnetRouting(clusterInfo, tracker)
+
+	return !tracker.HasFailures()
+}
+
+// getRemoteSubnets returns the subnets advertised by each remote cluster, keyed by cluster ID.
+func getRemoteSubnets(clusterInfo *cluster.Info) (map[string][]string, error) {
+	endpoints := &submarinerv1.EndpointList{}
+
+	err := cluster.RetryOnTransientError(func() error {
+		return clusterInfo.ClientProducer.ForGeneral().List(context.TODO(), endpoints,
+			controllerClient.InNamespace(constants.OperatorNamespace))
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing Endpoints")
+	}
+
+	remoteSubnets := map[string][]string{}
+
+	for i := range endpoints.Items {
+		if endpoints.Items[i].Spec.ClusterID != clusterInfo.Submariner.Spec.ClusterID {
+			remoteSubnets[endpoints.Items[i].Spec.ClusterID] = append(remoteSubnets[endpoints.Items[i].Spec.ClusterID],
+				endpoints.Items[i].Spec.Subnets...)
+		}
+	}
+
+	return remoteSubnets, nil
+}
+
+// getOVNCmdsPods returns the pods on which to run the OVN commands: the master pod if there is one, otherwise all the
+// node pods since each one has its own zone database.
+func getOVNCmdsPods(clusterInfo *cluster.Info) ([]corev1.Pod, error) {
+	for _, label := range []string{ovnMasterPodLabelOCP, ovnMasterPodLabelGeneric, ovnKubeNodePodLabel} {
+		podList, err := clusterInfo.ClientProducer.ForKubernetes().CoreV1().Pods("").List(context.TODO(), metav1.ListOptions{
+			LabelSelector: label,
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "error listing Pods by label selector %q", label)
+		}
+
+		if len(podList.Items) == 0 {
+			continue
+		}
+
+		if label == ovnKubeNodePodLabel {
+			return podList.Items, nil
+		}
+
+		return podList.Items[:1], nil
+	}
+
+	return nil, errors.New("no OVN master or node pods were found")
+}
+
+// checkOVNRemoteSubnetRouting checks that the OVN cluster router sends the traffic for each remote cluster CIDR towards
+// Submariner, with either a static route or a reroute policy, and reports the CIDRs which aren't.
+func checkOVNRemoteSubnetRouting(clusterInfo *cluster.Info, status reporter.Interface) {
+	remoteSubnets, err := getRemoteSubnets(clusterInfo)
+	if err != nil {
+		status.Failure("Error retrieving the remote cluster CIDRs: %v", err)
+		return
+	}
+
+	if len(remoteSubnets) == 0 {
+		status.Warning("There are no remote clusters to check the OVN routing for")
+		return
+	}
+
+	ovnPods, err := getOVNCmdsPods(clusterInfo)
+	if err != nil {
+		status.Failure("Error finding the OVN pods: %v", err)
+		return
+	}
+
+	for i := range ovnPods {
+		pod := &ovnPods[i]
+
+		routes, err := listOVNRoutes(clusterInfo, pod)
+		if err != nil {
+			status.Failure("Error listing the OVN routes on pod %q: %v", pod.Name, err)
+			continue
+		}
+
+		policies, err := listOVNPolicyDestinations(clusterInfo, pod)
+		if err != nil {
+			status.Failure("Error listing the OVN router policies on pod %q: %v", pod.Name, err)
+			continue
+		}
+
+		missing := false
+
+		for clusterID, subnets := range remoteSubnets {
+			for _, subnet := range subnets {
+				if !routes[subnet] && !policies[subnet] {
+					missing = true
+
+					status.Failure("Neither a static route nor a reroute policy for CIDR %q of remote cluster %q exists in the"+
+						" OVN cluster router on pod %q", subnet, clusterID, pod.Name)
+				}
+			}
+		}
+
+		if !missing {
+			status.Success("The OVN cluster router on pod %q has static routes or reroute policies for all the remote cluster"+
+				" CIDRs", pod.Name)
+		}
+	}
+}
+
+func runOVNCommand(clusterInfo *cluster.Info, pod *corev1.Pod, command []string) (string, error) {
+	execOptions := pods.ExecOptionsFromPod(pod)
+	execOptions.Command = command
+
+	stdout, stderr, err := pods.ExecWithOptions(context.TODO(), pods.ExecConfig{
+		RestConfig: clusterInfo.RestConfig,
+		ClientSet:  clusterInfo.ClientProducer.ForKubernetes(),
+	}, &execOptions)
+	if err != nil {
+		return "", errors.Wrapf(err, "error running %q: %s", strings.Join(command, " "), stderr)
+	}
+
+	return stdout, nil
+}
+
+// listOVNPolicyDestinations returns the destination prefixes of the OVN cluster router's reroute policies.
+func listOVNPolicyDestinations(clusterInfo *cluster.Info, pod *corev1.Pod) (map[string]bool, error) {
+	stdout, err := runOVNCommand(clusterInfo, pod, ovnPolicyListCmd)
+	if err != nil {
+		return nil, err
+	}
+
+	// Policies are listed as "<priority> <match> <action> [<next hops>]", under a header
+	destinations := map[string]bool{}
+
+	for _, line := range strings.Split(stdout, "\n") {
+		if !strings.Contains(line, "reroute") {
+			continue
+		}
+
+		if match := ovnPolicyDestination.FindStringSubmatch(line); match != nil {
+			destinations[match[1]] = true
+		}
+	}
+
+	return destinations, nil
+}
+
+// listOVNRoutes returns the prefixes of the OVN cluster router's static routes.
+func listOVNRoutes(clusterInfo *cluster.Info, pod *corev1.Pod) (map[string]bool, error) {
+	stdout, err := runOVNCommand(clusterInfo, pod, ovnRouteListCmd)
+	if err != nil {
+		return nil, err
+	}
+
+	// Routes are listed as "<prefix> <next hop> <policy> [<output port>]", under table headers
+	routes := map[string]bool{}
+
+	for _, line := range strings.Split(stdout, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 1 && strings.Contains(fields[0], "/") {
+			routes[fields[0]] = true
+		}
+	}
+
+	return routes, nil
+}
+
+func checkNetworkPluginSyncer(clusterInfo *cluster.Info, status reporter.Interface) bool {
+	kubeClient := clusterInfo.ClientProducer.ForKubernetes()
+
+	_, err := kubeClient.AppsV1().Deployments(constants.OperatorNamespace).Get(context.TODO(),
+		names.NetworkPluginSyncerComponent, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		status.Start("Checking Deployment %q", names.NetworkPluginSyncerComponent)
+		status.Success("The Deployment %q isn't present; the route agent handles the OVN configuration in this version",
+			names.NetworkPluginSyncerComponent)
+		status.End()
+
+		return true
+	}
+
+	tracker := reporter.NewTracker(status)
+	checkDeployment(kubeClient, constants.OperatorNamespace, names.NetworkPluginSyncerComponent, tracker)
+
+	return !tracker.HasFailures()
+}
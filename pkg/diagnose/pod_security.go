@@ -0,0 +1,135 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diagnose
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/submariner-io/admiral/pkg/names"
+	"github.com/submariner-io/admiral/pkg/reporter"
+	"github.com/submariner-io/subctl/internal/pods"
+	"github.com/submariner-io/subctl/pkg/cluster"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// podSecurityRequirements describes the privileges requested by the pods running with a given service account. This
+// is the single source of truth for the pod security checks (SCCs on OpenShift).
+type podSecurityRequirements struct {
+	component      string
+	serviceAccount string
+	hostNetwork    bool
+	privileged     bool
+	capabilities   []v1.Capability
+	// appliesTo determines whether the component is expected to be deployed in the cluster
+	appliesTo func(clusterInfo *cluster.Info) bool
+}
+
+var componentPodSecurityRequirements = []podSecurityRequirements{
+	{
+		component:      names.OperatorComponent,
+		serviceAccount: "submariner-operator",
+		appliesTo:      func(_ *cluster.Info) bool { return true },
+	},
+	{
+		component:      names.GatewayComponent,
+		serviceAccount: "submariner-gateway",
+		hostNetwork:    true,
+		privileged:     true,
+		capabilities:   []v1.Capability{"NET_ADMIN"},
+		appliesTo:      connectivityInstalled,
+	},
+	{
+		component:      names.RouteAgentComponent,
+		serviceAccount: "submariner-routeagent",
+		hostNetwork:    true,
+		privileged:     true,
+		capabilities:   []v1.Capability{"ALL"},
+		appliesTo:      connectivityInstalled,
+	},
+	{
+		component:      names.GlobalnetComponent,
+		serviceAccount: "submariner-globalnet",
+		hostNetwork:    true,
+		privileged:     true,
+		capabilities:   []v1.Capability{"ALL"},
+		appliesTo: func(clusterInfo *cluster.Info) bool {
+			return clusterInfo.Submariner != nil && clusterInfo.Submariner.Spec.GlobalCIDR != ""
+		},
+	},
+	{
+		component:      "submariner-diagnose",
+		serviceAccount: "submariner-diagnose",
+		hostNetwork:    true,
+		privileged:     true,
+		capabilities:   pods.HostNetworkingCapabilities,
+		appliesTo:      connectivityInstalled,
+	},
+}
+
+func connectivityInstalled(clusterInfo *cluster.Info) bool {
+	return clusterInfo.Submariner != nil
+}
+
+// PodSecurityAdmission checks the Pod Security admission labels on the operator namespace: the gateway, route agent and
+// Globalnet pods are privileged, so they're rejected unless the namespace enforces the privileged level, and the audit
+// and warn modes report them unless they're also privileged.
+func PodSecurityAdmission(clusterInfo *cluster.Info, _ string, status reporter.Interface) error {
+	namespace := clusterInfo.OperatorNamespace()
+
+	status.Start("Checking the Pod Security admission labels on namespace %q", namespace)
+	defer status.End()
+
+	ns, err := clusterInfo.ClientProducer.ForKubernetes().CoreV1().Namespaces().Get(context.TODO(), namespace, metav1.GetOptions{})
+	if err != nil {
+		return status.Error(err, "Error retrieving namespace %q", namespace)
+	}
+
+	tracker := reporter.NewTracker(status)
+
+	for _, mode := range pods.PodSecurityModes {
+		label := pods.PodSecurityLabel(mode)
+		level, found := ns.Labels[label]
+
+		switch {
+		case level == pods.PrivilegedPodSecurityLevel:
+		case !found && mode == "enforce":
+			tracker.Warning("Namespace %q doesn't have the %q label, so the cluster's default Pod Security level applies; unless"+
+				" it's %q, the Submariner gateway pods will be rejected", namespace, label, pods.PrivilegedPodSecurityLevel)
+		case !found:
+		case mode == "enforce":
+			tracker.Failure("Namespace %q enforces the %q Pod Security level, but the Submariner gateway pods require privileged"+
+				" mode and will be rejected; label the namespace with %s=%s", namespace, level, label, pods.PrivilegedPodSecurityLevel)
+		default:
+			tracker.Warning("Namespace %q has the %q Pod Security level in %s mode, so the privileged Submariner pods will be"+
+				" reported; label the namespace with %s=%s to avoid this", namespace, level, mode, label, pods.PrivilegedPodSecurityLevel)
+		}
+	}
+
+	if tracker.HasFailures() {
+		return errors.New("failures while diagnosing the Pod Security admission labels")
+	}
+
+	if !tracker.HasWarnings() {
+		status.Success("The Pod Security admission labels on namespace %q allow privileged pods", namespace)
+	}
+
+	return nil
+}
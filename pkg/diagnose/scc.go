@@ -0,0 +1,233 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diagnose
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	securityv1 "github.com/openshift/api/security/v1"
+	"github.com/submariner-io/admiral/pkg/reporter"
+	"github.com/submariner-io/subctl/pkg/cluster"
+	"github.com/submariner-io/subctl/pkg/operator/ocp"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	privilegedSCC = "privileged"
+	restrictedSCC = "restricted-v2"
+)
+
+// sccAccess determines which SCCs a service account may use, either because the SCC lists the account (or one of its
+// groups) directly, or because RBAC grants it the "use" verb on the SCC.
+type sccAccess struct {
+	kubeClient kubernetes.Interface
+	// rbacUnavailable is set once RBAC can't be evaluated, after which only the SCCs' users and groups are considered
+	rbacUnavailable bool
+}
+
+// checkSCCs verifies on OpenShift that each Submariner service account can use an SCC granting the privileges its pods
+// request. It does nothing on other platforms.
+func checkSCCs(clusterInfo *cluster.Info, namespace string, status reporter.Interface) {
+	if !ocp.IsOcpPlatform(context.TODO(), clusterInfo.ClientProducer.ForDynamic()) {
+		return
+	}
+
+	status.Start("Checking the OpenShift SecurityContextConstraints available to the Submariner service accounts")
+	defer status.End()
+
+	sccs, err := listSCCs(clusterInfo)
+	if err != nil {
+		status.Failure("Error listing the SecurityContextConstraints: %v", err)
+		return
+	}
+
+	access := &sccAccess{kubeClient: clusterInfo.ClientProducer.ForKubernetes()}
+	tracker := reporter.NewTracker(status)
+
+	for i := range componentPodSecurityRequirements {
+		requirements := &componentPodSecurityRequirements[i]
+		if requirements.appliesTo(clusterInfo) {
+			checkServiceAccountSCCs(requirements, namespace, sccs, access, tracker)
+		}
+	}
+
+	if !tracker.HasFailures() && !tracker.HasWarnings() {
+		status.Success("All the Submariner service accounts can use the SecurityContextConstraints they require")
+	}
+}
+
+func listSCCs(clusterInfo *cluster.Info) ([]securityv1.SecurityContextConstraints, error) {
+	list, err := clusterInfo.ClientProducer.ForDynamic().Resource(securityv1.GroupVersion.WithResource("securitycontextconstraints")).
+		List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err //nolint:wrapcheck // The caller reports the error
+	}
+
+	sccs := make([]securityv1.SecurityContextConstraints, len(list.Items))
+
+	for i := range list.Items {
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(list.Items[i].Object, &sccs[i]); err != nil {
+			return nil, err //nolint:wrapcheck // The caller reports the error
+		}
+	}
+
+	return sccs, nil
+}
+
+func checkServiceAccountSCCs(requirements *podSecurityRequirements, namespace string, sccs []securityv1.SecurityContextConstraints,
+	access *sccAccess, status reporter.Interface,
+) {
+	usable := []*securityv1.SecurityContextConstraints{}
+
+	for i := range sccs {
+		allowed, err := access.canUse(&sccs[i], requirements.serviceAccount, namespace)
+		if err != nil {
+			status.Warning("Unable to evaluate the RBAC granting the use of SCCs, only the SCCs' users and groups are considered: %v", err)
+		}
+
+		if allowed {
+			if len(missingSCCPrivileges(&sccs[i], requirements)) == 0 {
+				return
+			}
+
+			usable = append(usable, &sccs[i])
+		}
+	}
+
+	// Determine the privileges which none of the usable SCCs grant
+	missing := missingSCCPrivileges(nil, requirements)
+
+	for _, scc := range usable {
+		missing = intersectPrivileges(missing, missingSCCPrivileges(scc, requirements))
+	}
+
+	fixSCC := restrictedSCC
+	if requirements.privileged || requirements.hostNetwork || len(requirements.capabilities) > 0 {
+		fixSCC = privilegedSCC
+	}
+
+	fix := fmt.Sprintf("oc adm policy add-scc-to-user %s -z %s -n %s", fixSCC, requirements.serviceAccount, namespace)
+
+	switch {
+	case len(usable) == 0:
+		status.Failure("The %s service account %q can't use any SCC, its pods will be rejected; to fix this, run %q",
+			requirements.component, requirements.serviceAccount, fix)
+	case len(missing) > 0:
+		status.Failure("The %s service account %q can't use an SCC allowing %s, its pods will be rejected; to fix this, run %q",
+			requirements.component, requirements.serviceAccount, strings.Join(missing, ", "), fix)
+	default:
+		status.Failure("None of the SCCs available to the %s service account %q allows all of %s together,"+
+			" its pods will be rejected; to fix this, run %q", requirements.component, requirements.serviceAccount,
+			strings.Join(missingSCCPrivileges(nil, requirements), ", "), fix)
+	}
+}
+
+// missingSCCPrivileges returns the privileges in the requirements which the SCC doesn't allow; with a nil SCC, it
+// returns all the required privileges.
+func missingSCCPrivileges(scc *securityv1.SecurityContextConstraints, requirements *podSecurityRequirements) []string {
+	missing := []string{}
+
+	if requirements.hostNetwork && (scc == nil || !scc.AllowHostNetwork) {
+		missing = append(missing, "hostNetwork")
+	}
+
+	if requirements.privileged && (scc == nil || !scc.AllowPrivilegedContainer) {
+		missing = append(missing, "privileged containers")
+	}
+
+	for _, capability := range requirements.capabilities {
+		if scc == nil || !sccAllowsCapability(scc, capability) {
+			missing = append(missing, "the "+string(capability)+" capability")
+		}
+	}
+
+	return missing
+}
+
+func sccAllowsCapability(scc *securityv1.SecurityContextConstraints, capability v1.Capability) bool {
+	for _, allowed := range append(scc.AllowedCapabilities, scc.DefaultAddCapabilities...) {
+		if allowed == securityv1.AllowAllCapabilities || strings.EqualFold(string(allowed), string(capability)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func intersectPrivileges(privileges, others []string) []string {
+	result := []string{}
+
+	for _, privilege := range privileges {
+		if slices.Contains(others, privilege) {
+			result = append(result, privilege)
+		}
+	}
+
+	return result
+}
+
+func (a *sccAccess) canUse(scc *securityv1.SecurityContextConstraints, serviceAccount, namespace string) (bool, error) {
+	user := fmt.Sprintf("system:serviceaccount:%s:%s", namespace, serviceAccount)
+	groups := []string{"system:serviceaccounts", "system:serviceaccounts:" + namespace, "system:authenticated"}
+
+	for _, sccUser := range scc.Users {
+		if sccUser == user {
+			return true, nil
+		}
+	}
+
+	for _, sccGroup := range scc.Groups {
+		for _, group := range groups {
+			if sccGroup == group {
+				return true, nil
+			}
+		}
+	}
+
+	if a.rbacUnavailable {
+		return false, nil
+	}
+
+	review, err := a.kubeClient.AuthorizationV1().SubjectAccessReviews().Create(context.TODO(), &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   user,
+			Groups: groups,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: namespace,
+				Verb:      "use",
+				Group:     securityv1.GroupName,
+				Resource:  "securitycontextconstraints",
+				Name:      scc.Name,
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		a.rbacUnavailable = true
+		return false, err //nolint:wrapcheck // The caller reports the error
+	}
+
+	return review.Status.Allowed, nil
+}
@@ -21,6 +21,7 @@ package diagnose
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/submariner-io/admiral/pkg/reporter"
@@ -33,20 +34,39 @@ import (
 	discovery "k8s.io/api/discovery/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
 )
 
-func ServiceDiscovery(clusterInfo *cluster.Info, _ string, status reporter.Interface) error {
-	status.Start("Checking that services have been exported properly")
-	defer status.End()
-
+// ServiceDiscovery diagnoses the service discovery components; with verbose set, it also shows the details of each service
+// exported successfully.
+func ServiceDiscovery(clusterInfo *cluster.Info, namespace string, imageOverrides []string, verbose bool, status reporter.Interface,
+) error {
 	tracker := reporter.NewTracker(status)
 
-	checkServiceExport(clusterInfo, tracker)
+	tracker.Start("Checking that services have been exported properly")
+	checkServiceExport(clusterInfo, verbose, tracker)
+	tracker.End()
+
+	failed := tracker.HasFailures()
+
+	tracker.Start("Checking that the cluster DNS forwards the service discovery domains")
+	checkDNSForwarding(clusterInfo, tracker)
+	tracker.End()
+
+	failed = failed || tracker.HasFailures()
+
+	tracker.Start("Checking that exported services can be resolved")
+	checkDNSResolution(clusterInfo, namespace, imageOverrides, tracker)
+	tracker.End()
 
-	if tracker.HasFailures() {
+	tracker.Start("Checking the pods' DNS search path against the service discovery domains")
+	checkDNSSearchPath(clusterInfo, namespace, imageOverrides, tracker)
+	tracker.End()
+
+	if failed || tracker.HasFailures() {
 		return errors.New("failures while diagnosing service discovery")
 	}
 
@@ -54,7 +74,7 @@ func ServiceDiscovery(clusterInfo *cluster.Info, _ string, status reporter.Inter
 }
 
 // This function checks if all ServiceExports have a matching ServiceImport and if an EndpointSlice has been created for the service.
-func checkServiceExport(clusterInfo *cluster.Info, status reporter.Interface) {
+func checkServiceExport(clusterInfo *cluster.Info, verbose bool, parentStatus reporter.Interface) {
 	ctx := context.TODO()
 
 	serviceExportGVR := gvr.FromMetaGroupVersion(mcsv1a1.GroupVersion, "serviceexports")
@@ -62,13 +82,15 @@ func checkServiceExport(clusterInfo *cluster.Info, status reporter.Interface) {
 	serviceExports, err := clusterInfo.ClientProducer.ForDynamic().Resource(serviceExportGVR).Namespace(corev1.NamespaceAll).
 		List(ctx, metav1.ListOptions{})
 	if err != nil {
-		status.Failure("Error listing ServiceExport resources: %v", err)
+		parentStatus.Failure("Error listing ServiceExport resources: %v", err)
 		return
 	}
 
 	serviceImportsGVR := gvr.FromMetaGroupVersion(mcsv1a1.GroupVersion, "serviceimports")
 
 	for i := range serviceExports.Items {
+		// Track each service separately, to only show the details of those exported successfully
+		status := reporter.NewTracker(parentStatus)
 		se := &mcsv1a1.ServiceExport{}
 
 		err = runtime.DefaultUnstructuredConverter.FromUnstructured(serviceExports.Items[i].Object, se)
@@ -115,11 +137,10 @@ func checkServiceExport(clusterInfo *cluster.Info, status reporter.Interface) {
 		checkForAggregateSI := false
 
 		serviceImportClient := clusterInfo.ClientProducer.ForDynamic().Resource(serviceImportsGVR)
-
-		localSI, err := serviceImportClient.Namespace(constants.OperatorNamespace).Get(ctx,
+		serviceImport, err := serviceImportClient.Namespace(constants.OperatorNamespace).Get(ctx,
 			fmt.Sprintf("%s-%s-%s", se.Name, se.Namespace, clusterInfo.Submariner.Spec.ClusterID), metav1.GetOptions{})
 		if err == nil {
-			_, checkForAggregateSI = localSI.GetLabels()[mcsv1a1.LabelServiceName]
+			_, checkForAggregateSI = serviceImport.GetLabels()[mcsv1a1.LabelServiceName]
 		} else if apierrors.IsNotFound(err) {
 			status.Failure("No local ServiceImport in %q found for exported service %s/%s", constants.OperatorNamespace,
 				se.Namespace, se.Name)
@@ -128,16 +149,43 @@ func checkServiceExport(clusterInfo *cluster.Info, status reporter.Interface) {
 		}
 
 		if checkForAggregateSI {
-			_, err = serviceImportClient.Namespace(se.Namespace).Get(ctx, se.Name, metav1.GetOptions{})
-			if err != nil {
-				if apierrors.IsNotFound(err) {
-					status.Failure("No ServiceImport found for exported service %s/%s", se.Namespace, se.Name)
-				} else {
-					status.Failure("Error retrieving ServiceImport for exported service %s/%s: %v", se.Namespace, se.Name, err)
-				}
+			aggregateSI, err := serviceImportClient.Namespace(se.Namespace).Get(ctx, se.Name, metav1.GetOptions{})
+			if err == nil {
+				serviceImport = aggregateSI
+			} else if apierrors.IsNotFound(err) {
+				status.Failure("No ServiceImport found for exported service %s/%s", se.Namespace, se.Name)
+			} else {
+				status.Failure("Error retrieving ServiceImport for exported service %s/%s: %v", se.Namespace, se.Name, err)
 			}
 		}
+
+		if verbose && !status.HasFailures() {
+			showServiceExportDetails(se, epsList.Items, serviceImport, status)
+		}
+	}
+}
+
+func showServiceExportDetails(se *mcsv1a1.ServiceExport, endpointSlices []discovery.EndpointSlice, serviceImport *unstructured.Unstructured,
+	status reporter.Interface,
+) {
+	var addresses []string
+
+	for i := range endpointSlices {
+		for j := range endpointSlices[i].Endpoints {
+			addresses = append(addresses, endpointSlices[i].Endpoints[j].Addresses...)
+		}
 	}
+
+	importType, _, _ := unstructured.NestedString(serviceImport.Object, "spec", "type")
+
+	status.Success("Service %s/%s is exported", se.Namespace, se.Name)
+	status.Success("  EndpointSlices: %d", len(endpointSlices))
+	if len(addresses) == 0 {
+		addresses = []string{"none"}
+	}
+
+	status.Success("  Endpoint addresses: %s", strings.Join(addresses, ", "))
+	status.Success("  ServiceImport type: %s", importType)
 }
 
 func verifyStatusCondition(se *mcsv1a1.ServiceExport, condType mcsv1a1.ServiceExportConditionType, condStatus corev1.ConditionStatus,
@@ -0,0 +1,366 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diagnose
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/submariner-io/admiral/pkg/names"
+	"github.com/submariner-io/admiral/pkg/reporter"
+	"github.com/submariner-io/subctl/internal/dns"
+	"github.com/submariner-io/subctl/internal/gvr"
+	"github.com/submariner-io/subctl/internal/pods"
+	"github.com/submariner-io/subctl/pkg/cluster"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/strings/slices"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+// These match the DNS configurations managed by the Submariner operator.
+const (
+	clusterSetDomain            = dns.ClusterSetDomain
+	coreDNSNamespace            = "kube-system"
+	coreDNSConfigMap            = "coredns"
+	coreDNSCorefileKey          = "Corefile"
+	customCoreDNSKey            = "lighthouse.server"
+	microshiftDNSNamespace      = "openshift-dns"
+	microshiftDNSConfigMap      = "dns-default"
+	openShiftDNSName            = "default"
+	lighthouseForwardServerName = "lighthouse"
+)
+
+var openShiftDNSGVR = schema.GroupVersionResource{Group: "operator.openshift.io", Version: "v1", Resource: "dnses"}
+
+func checkDNSForwarding(clusterInfo *cluster.Info, status reporter.Interface) {
+	ctx := context.TODO()
+	serviceDiscovery := clusterInfo.ServiceDiscovery
+	kubeClient := clusterInfo.ClientProducer.ForKubernetes()
+
+	lighthouseDNS, err := kubeClient.CoreV1().Services(serviceDiscovery.Namespace).Get(ctx, names.LighthouseCoreDNSComponent,
+		metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		status.Failure("The %q Service was not found in namespace %q; the cluster DNS can't forward queries to the service discovery"+
+			" DNS server. Check the Submariner operator logs", names.LighthouseCoreDNSComponent, serviceDiscovery.Namespace)
+
+		return
+	}
+
+	if err != nil {
+		status.Failure("Error retrieving the %q Service: %v", names.LighthouseCoreDNSComponent, err)
+		return
+	}
+
+	clusterIP := lighthouseDNS.Spec.ClusterIP
+	if clusterIP == "" || clusterIP == corev1.ClusterIPNone {
+		status.Failure("The %q Service has no cluster IP, so the cluster DNS can't forward queries to it",
+			names.LighthouseCoreDNSComponent)
+		return
+	}
+
+	domains := dns.ServiceDiscoveryDomains(serviceDiscovery)
+
+	if customConfig := serviceDiscovery.Spec.CoreDNSCustomConfig; customConfig != nil && customConfig.ConfigMapName != "" {
+		namespace := customConfig.Namespace
+		if namespace == "" {
+			namespace = coreDNSNamespace
+		}
+
+		checkCoreDNSConfigMap(clusterInfo, namespace, customConfig.ConfigMapName, customCoreDNSKey, domains, clusterIP, status)
+
+		return
+	}
+
+	if checkCoreDNSConfigMap(clusterInfo, coreDNSNamespace, coreDNSConfigMap, coreDNSCorefileKey, domains, clusterIP, status) {
+		return
+	}
+
+	if checkOpenShiftDNS(clusterInfo, domains, clusterIP, status) {
+		return
+	}
+
+	if checkCoreDNSConfigMap(clusterInfo, microshiftDNSNamespace, microshiftDNSConfigMap, coreDNSCorefileKey, domains, clusterIP,
+		status) {
+		return
+	}
+
+	status.Warning("Unable to find the cluster DNS configuration (ConfigMap %s/%s or the OpenShift DNS operator); please verify"+
+		" manually that the %v zones are forwarded to the %q Service IP %s", coreDNSNamespace, coreDNSConfigMap, domains,
+		names.LighthouseCoreDNSComponent, clusterIP)
+}
+
+// checkCoreDNSConfigMap verifies the forwarding configuration in the given CoreDNS ConfigMap; it returns false if the
+// ConfigMap doesn't exist.
+func checkCoreDNSConfigMap(clusterInfo *cluster.Info, namespace, name, key string, domains []string, clusterIP string,
+	status reporter.Interface,
+) bool {
+	configMap, err := clusterInfo.ClientProducer.ForKubernetes().CoreV1().ConfigMaps(namespace).Get(context.TODO(), name,
+		metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return false
+	}
+
+	if err != nil {
+		status.Failure("Error retrieving the CoreDNS ConfigMap %s/%s: %v", namespace, name, err)
+		return true
+	}
+
+	corefile, ok := configMap.Data[key]
+	if !ok {
+		status.Failure("The CoreDNS ConfigMap %s/%s has no %q entry; the Submariner operator normally adds the service discovery"+
+			" configuration there, check its logs", namespace, name, key)
+		return true
+	}
+
+	for _, domain := range domains {
+		forwarders, found := corefileForwarders(corefile, domain)
+
+		switch {
+		case !found:
+			status.Failure("The CoreDNS configuration in ConfigMap %s/%s has no server block for %q, so lookups of exported"+
+				" services will fail; the Submariner operator normally adds it, check its logs", namespace, name, domain)
+		case !slices.Contains(forwarders, clusterIP):
+			status.Failure("The CoreDNS configuration in ConfigMap %s/%s forwards %q to %v instead of the %q Service IP %s;"+
+				" the Service may have been recreated, restart the Submariner operator so that it updates the configuration",
+				namespace, name, domain, forwarders, names.LighthouseCoreDNSComponent, clusterIP)
+		default:
+			status.Success("CoreDNS forwards %q to the %q Service", domain, names.LighthouseCoreDNSComponent)
+		}
+	}
+
+	return true
+}
+
+// corefileForwarders returns the forward destinations in the Corefile server block for the given domain, and whether
+// there is such a block.
+func corefileForwarders(corefile, domain string) ([]string, bool) {
+	var forwarders []string
+
+	found := false
+	inBlock := false
+	depth := 0
+
+	for _, line := range strings.Split(corefile, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		opensBlock := fields[len(fields)-1] == "{"
+
+		if depth == 0 && opensBlock {
+			// Server blocks start with "zone[:port] [zone[:port]...] {"
+			for _, zone := range fields[:len(fields)-1] {
+				zone, _, _ = strings.Cut(strings.TrimPrefix(zone, "dns://"), ":")
+				if strings.TrimSuffix(zone, ".") == domain {
+					inBlock = true
+					found = true
+				}
+			}
+		}
+
+		if opensBlock {
+			depth++
+		} else if fields[0] == "}" {
+			depth--
+			inBlock = inBlock && depth > 0
+		}
+
+		if !inBlock {
+			continue
+		}
+
+		// forward FROM TO... [{ options }]
+		if fields[0] == "forward" && len(fields) > 2 {
+			for _, to := range fields[2:] {
+				if to == "{" {
+					break
+				}
+
+				to, _, _ = strings.Cut(strings.TrimPrefix(to, "dns://"), ":")
+				forwarders = append(forwarders, to)
+			}
+		}
+	}
+
+	return forwarders, found
+}
+
+// checkOpenShiftDNS verifies the forwarding configuration in the OpenShift DNS operator; it returns false if there isn't one.
+func checkOpenShiftDNS(clusterInfo *cluster.Info, domains []string, clusterIP string, status reporter.Interface) bool {
+	dns, err := clusterInfo.ClientProducer.ForDynamic().Resource(openShiftDNSGVR).Get(context.TODO(), openShiftDNSName,
+		metav1.GetOptions{})
+	if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+		return false
+	}
+
+	if err != nil {
+		status.Failure("Error retrieving the OpenShift DNS operator configuration: %v", err)
+		return true
+	}
+
+	servers, _, _ := unstructured.NestedSlice(dns.Object, "spec", "servers")
+
+	for _, domain := range domains {
+		var upstreams []string
+
+		found := false
+
+		for _, server := range servers {
+			serverMap, ok := server.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			zones, _, _ := unstructured.NestedStringSlice(serverMap, "zones")
+			if !slices.Contains(zones, domain) {
+				continue
+			}
+
+			found = true
+			serverUpstreams, _, _ := unstructured.NestedStringSlice(serverMap, "forwardPlugin", "upstreams")
+
+			for _, upstream := range serverUpstreams {
+				upstream, _, _ = strings.Cut(upstream, ":")
+				upstreams = append(upstreams, upstream)
+			}
+		}
+
+		switch {
+		case !found:
+			status.Failure("The OpenShift DNS operator configuration %q has no %q server for %q, so lookups of exported services"+
+				" will fail; the Submariner operator normally adds it, check its logs", openShiftDNSName, lighthouseForwardServerName,
+				domain)
+		case !slices.Contains(upstreams, clusterIP):
+			status.Failure("The OpenShift DNS operator configuration %q forwards %q to %v instead of the %q Service IP %s;"+
+				" restart the Submariner operator so that it updates the configuration", openShiftDNSName, domain, upstreams,
+				names.LighthouseCoreDNSComponent, clusterIP)
+		default:
+			status.Success("The OpenShift DNS operator forwards %q to the %q Service", domain, names.LighthouseCoreDNSComponent)
+		}
+	}
+
+	return true
+}
+
+func checkDNSResolution(clusterInfo *cluster.Info, namespace string, imageOverrides []string, status reporter.Interface) {
+	serviceExports, err := clusterInfo.ClientProducer.ForDynamic().Resource(gvr.FromMetaGroupVersion(mcsv1a1.GroupVersion,
+		"serviceexports")).Namespace(corev1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		status.Failure("Error listing ServiceExport resources: %v", err)
+		return
+	}
+
+	if len(serviceExports.Items) == 0 {
+		status.Success("There are no exported services, skipping the DNS lookup")
+		return
+	}
+
+	exported := &serviceExports.Items[0]
+	hostname := fmt.Sprintf("%s.%s.svc.%s", exported.GetName(), exported.GetNamespace(), clusterSetDomain)
+
+	repositoryInfo, err := clusterInfo.GetImageRepositoryInfo(imageOverrides...)
+	if err != nil {
+		status.Failure("Error determining repository information: %v", err)
+		return
+	}
+
+	podOutput, err := pods.ScheduleAndAwaitCompletion(&pods.Config{
+		Name:                "query-dns",
+		ClientSet:           clusterInfo.ClientProducer.ForKubernetes(),
+		Scheduling:          pods.Scheduling{ScheduleOn: pods.GatewayNode, Networking: pods.PodNetworking},
+		Namespace:           namespace,
+		Command:             "nslookup " + hostname,
+		ImageRepositoryInfo: *repositoryInfo,
+	})
+	if err != nil {
+		status.Failure("Error spawning the DNS lookup pod: %v", err)
+		return
+	}
+
+	reportDNSLookup(hostname, podOutput, status)
+}
+
+// reportDNSLookup reports the result of looking up the given exported service's hostname, from the nslookup output.
+func reportDNSLookup(hostname, podOutput string, status reporter.Interface) {
+	switch {
+	case strings.Contains(podOutput, "NXDOMAIN") || strings.Contains(podOutput, "can't find"):
+		status.Failure("The exported service %q doesn't resolve: the query reached a DNS server which doesn't know it. Check"+
+			" that the cluster DNS forwards %q to the %q Service and that the service has a ServiceImport. Lookup output:\n%s",
+			hostname, clusterSetDomain, names.LighthouseCoreDNSComponent, truncate(podOutput))
+	case strings.Contains(podOutput, "timed out") || strings.Contains(podOutput, "no servers could be reached"):
+		status.Failure("The DNS lookup of the exported service %q timed out. Check that the %q pods are running and that"+
+			" the cluster DNS can reach them. Lookup output:\n%s", hostname, names.LighthouseCoreDNSComponent, truncate(podOutput))
+	case !strings.Contains(podOutput, "Name:"):
+		status.Failure("The DNS lookup of the exported service %q failed. Lookup output:\n%s", hostname, truncate(podOutput))
+	default:
+		status.Success("The exported service %q resolves", hostname)
+	}
+}
+
+// checkDNSSearchPath warns if appending the service discovery domains to the pods' search path, so that exported services
+// can be resolved by their short names, would exceed the glibc resolver limits; domains beyond them are silently ignored,
+// which breaks short-name resolution for all the pods.
+func checkDNSSearchPath(clusterInfo *cluster.Info, namespace string, imageOverrides []string, status reporter.Interface) {
+	repositoryInfo, err := clusterInfo.GetImageRepositoryInfo(imageOverrides...)
+	if err != nil {
+		status.Failure("Error determining repository information: %v", err)
+		return
+	}
+
+	podOutput, err := pods.ScheduleAndAwaitCompletion(&pods.Config{
+		Name:                "query-dns-search",
+		ClientSet:           clusterInfo.ClientProducer.ForKubernetes(),
+		Scheduling:          pods.Scheduling{ScheduleOn: pods.GatewayNode, Networking: pods.PodNetworking},
+		Namespace:           namespace,
+		Command:             "cat /etc/resolv.conf",
+		ImageRepositoryInfo: *repositoryInfo,
+	})
+	if err != nil {
+		status.Failure("Error spawning the pod retrieving the DNS configuration: %v", err)
+		return
+	}
+
+	podSearchPath := dns.SearchDomains(podOutput)
+	domains := dns.ServiceDiscoveryDomains(clusterInfo.ServiceDiscovery)
+	projected := dns.ProjectedSearchPath(podSearchPath, domains)
+
+	if problems := dns.SearchPathProblems(podSearchPath); len(problems) > 0 {
+		status.Warning("The pods' DNS search path %v already exceeds the glibc resolver limits (%s), independently of the service"+
+			" discovery domains; the last domains are ignored", podSearchPath, strings.Join(problems, ", "))
+		return
+	}
+
+	problems := dns.SearchPathProblems(projected)
+	if len(problems) == 0 {
+		status.Success("Adding the service discovery domains %v to the pods' DNS search path stays within the glibc resolver limits",
+			domains)
+		return
+	}
+
+	status.Warning("Adding the service discovery domains %v to the pods' DNS search path %v would exceed the glibc resolver limits"+
+		" (%s); short names wouldn't resolve in the domains beyond the limits. Avoid adding the service discovery domains to the"+
+		" search path, or reduce the number of custom domains", domains, podSearchPath, strings.Join(problems, ", "))
+}
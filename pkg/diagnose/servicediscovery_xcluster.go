@@ -0,0 +1,157 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diagnose
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/submariner-io/admiral/pkg/reporter"
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	"github.com/submariner-io/subctl/internal/gvr"
+	"github.com/submariner-io/subctl/internal/pods"
+	"github.com/submariner-io/subctl/pkg/cluster"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+// ServiceDiscoveryCrossCluster checks that the services exported from the local cluster are imported in the remote
+// cluster, and that they resolve there.
+func ServiceDiscoveryCrossCluster(localClusterInfo, remoteClusterInfo *cluster.Info, namespace string, imageOverrides []string,
+	status reporter.Interface,
+) error {
+	for _, clusterInfo := range []*cluster.Info{localClusterInfo, remoteClusterInfo} {
+		if clusterInfo.ServiceDiscovery == nil {
+			return status.Error(fmt.Errorf("service discovery isn't installed in cluster %q", clusterInfo.Name), "")
+		}
+	}
+
+	tracker := reporter.NewTracker(status)
+
+	tracker.Start("Checking that the services exported from cluster %q are imported in cluster %q", localClusterInfo.Name,
+		remoteClusterInfo.Name)
+	defer tracker.End()
+
+	serviceExports, err := localClusterInfo.ClientProducer.ForDynamic().Resource(
+		gvr.FromMetaGroupVersion(mcsv1a1.GroupVersion, "serviceexports")).Namespace(corev1.NamespaceAll).List(
+		context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return tracker.Error(err, "Error listing the ServiceExports in cluster %q", localClusterInfo.Name)
+	}
+
+	if len(serviceExports.Items) == 0 {
+		tracker.Success("There are no exported services in cluster %q", localClusterInfo.Name)
+		return nil
+	}
+
+	repositoryInfo, err := remoteClusterInfo.GetImageRepositoryInfo(imageOverrides...)
+	if err != nil {
+		return tracker.Error(err, "Error determining repository information")
+	}
+
+	for i := range serviceExports.Items {
+		se := &mcsv1a1.ServiceExport{}
+
+		err = runtime.DefaultUnstructuredConverter.FromUnstructured(serviceExports.Items[i].Object, se)
+		if err != nil {
+			tracker.Failure("Error converting ServiceExport: %v", err)
+			continue
+		}
+
+		// The DNS lookup is checked even if the ServiceImport is missing or incomplete, to show how it affects resolution
+		checkRemoteServiceImport(se, localClusterInfo, remoteClusterInfo, tracker)
+
+		hostname := fmt.Sprintf("%s.%s.svc.%s", se.Name, se.Namespace, clusterSetDomain)
+
+		podOutput, err := pods.ScheduleAndAwaitCompletion(&pods.Config{
+			Name:                "query-dns-xcluster",
+			ClientSet:           remoteClusterInfo.ClientProducer.ForKubernetes(),
+			Scheduling:          pods.Scheduling{ScheduleOn: pods.GatewayNode, Networking: pods.PodNetworking},
+			Namespace:           namespace,
+			Command:             "nslookup " + hostname,
+			ImageRepositoryInfo: *repositoryInfo,
+		})
+		if err != nil {
+			tracker.Failure("Error spawning the DNS lookup pod in cluster %q: %v", remoteClusterInfo.Name, err)
+			continue
+		}
+
+		reportDNSLookup(hostname, podOutput, tracker)
+	}
+
+	if tracker.HasFailures() {
+		return errors.New("failures while diagnosing cross-cluster service discovery")
+	}
+
+	return nil
+}
+
+// checkRemoteServiceImport checks that the given ServiceExport has a matching ServiceImport in the remote cluster, with
+// cluster set IPs if they're enabled for the service and it isn't headless.
+func checkRemoteServiceImport(se *mcsv1a1.ServiceExport, localClusterInfo, remoteClusterInfo *cluster.Info,
+	status reporter.Interface,
+) {
+	obj, err := remoteClusterInfo.ClientProducer.ForDynamic().Resource(gvr.FromMetaGroupVersion(mcsv1a1.GroupVersion,
+		"serviceimports")).Namespace(se.Namespace).Get(context.TODO(), se.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		status.Failure("No ServiceImport found in cluster %q for exported service %s/%s", remoteClusterInfo.Name,
+			se.Namespace, se.Name)
+
+		return
+	}
+
+	if err != nil {
+		status.Failure("Error retrieving the ServiceImport in cluster %q for exported service %s/%s: %v", remoteClusterInfo.Name,
+			se.Namespace, se.Name, err)
+
+		return
+	}
+
+	si := &mcsv1a1.ServiceImport{}
+
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, si)
+	if err != nil {
+		status.Failure("Error converting ServiceImport: %v", err)
+		return
+	}
+
+	if si.Spec.Type != mcsv1a1.Headless && clustersetIPEnabled(se, localClusterInfo) && len(si.Spec.IPs) == 0 {
+		status.Failure("The ServiceImport in cluster %q for exported service %s/%s has no cluster set IPs, although they're"+
+			" enabled for the service", remoteClusterInfo.Name, se.Namespace, se.Name)
+
+		return
+	}
+
+	status.Success("Exported service %s/%s is imported in cluster %q", se.Namespace, se.Name, remoteClusterInfo.Name)
+}
+
+// clustersetIPEnabled determines whether the given exported service uses a cluster set IP, like Lighthouse does: the
+// service's annotation takes precedence over the setting of the cluster exporting it, and both default to false.
+func clustersetIPEnabled(se *mcsv1a1.ServiceExport, localClusterInfo *cluster.Info) bool {
+	if value, found := se.Annotations[lhconstants.UseClustersetIP]; found {
+		return value == strconv.FormatBool(true)
+	}
+
+	return localClusterInfo.ServiceDiscovery.Spec.ClustersetIPEnabled
+}
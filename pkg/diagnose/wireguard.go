@@ -0,0 +1,131 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diagnose
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/submariner-io/admiral/pkg/reporter"
+	"github.com/submariner-io/subctl/internal/constants"
+	"github.com/submariner-io/subctl/internal/pods"
+	"github.com/submariner-io/subctl/pkg/cluster"
+	"github.com/submariner-io/subctl/pkg/image"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// The module is either loaded, available to be loaded, or built into the kernel.
+const wireGuardNodeCmd = "if lsmod | grep -q wireguard || modinfo wireguard >/dev/null 2>&1 || [ -d /sys/module/wireguard ];" +
+	" then echo wireguard=available; else echo wireguard=missing; fi; echo ip_forward=$(cat /proc/sys/net/ipv4/ip_forward)"
+
+// WireGuardKernelModule checks that the gateway nodes support WireGuard and forward IPv4 packets, when WireGuard is the
+// cable driver.
+func WireGuardKernelModule(clusterInfo *cluster.Info, namespace string, imageOverrides []string, status reporter.Interface) error {
+	mustHaveSubmariner(clusterInfo)
+
+	status.Start("Checking WireGuard support on the gateway nodes")
+	defer status.End()
+
+	if clusterInfo.Submariner.Spec.CableDriver != Wireguard {
+		status.Success("Skipping this check as the cable driver is %q", clusterInfo.Submariner.Spec.CableDriver)
+		return nil
+	}
+
+	nodes, err := clusterInfo.ClientProducer.ForKubernetes().CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(map[string]string{constants.SubmarinerGatewayLabel: constants.TrueLabel}).String(),
+	})
+	if err != nil {
+		return status.Error(err, "Error listing the gateway nodes")
+	}
+
+	if len(nodes.Items) == 0 {
+		status.Warning("There are no gateway nodes")
+		return nil
+	}
+
+	repositoryInfo, err := clusterInfo.GetImageRepositoryInfo(imageOverrides...)
+	if err != nil {
+		return status.Error(err, "Error determining repository information")
+	}
+
+	tracker := reporter.NewTracker(status)
+
+	for i := range nodes.Items {
+		checkWireGuardOnNode(clusterInfo, namespace, nodes.Items[i].Name, repositoryInfo, tracker)
+	}
+
+	if tracker.HasFailures() {
+		return errors.New("failures while diagnosing WireGuard support on the gateway nodes")
+	}
+
+	if !tracker.HasWarnings() {
+		status.Success("The gateway nodes support WireGuard")
+	}
+
+	return nil
+}
+
+func checkWireGuardOnNode(clusterInfo *cluster.Info, namespace, nodeName string, repositoryInfo *image.RepositoryInfo,
+	status reporter.Interface,
+) {
+	podOutput, err := pods.ScheduleAndAwaitCompletion(&pods.Config{
+		Name:      "query-wireguard",
+		ClientSet: clusterInfo.ClientProducer.ForKubernetes(),
+		Scheduling: pods.Scheduling{
+			ScheduleOn: pods.CustomNode, NodeName: nodeName,
+			Networking: pods.HostNetworking,
+		},
+		Namespace:           namespace,
+		Command:             wireGuardNodeCmd,
+		ImageRepositoryInfo: *repositoryInfo,
+	})
+	if err != nil {
+		status.Failure("Error spawning the network pod on the gateway node %q: %v", nodeName, err)
+		return
+	}
+
+	values := map[string]string{}
+
+	for _, line := range strings.Split(podOutput, "\n") {
+		if key, value, found := strings.Cut(strings.TrimSpace(line), "="); found {
+			values[key] = value
+		}
+	}
+
+	switch values["wireguard"] {
+	case "available":
+	case "missing":
+		status.Failure("The kernel of gateway node %q doesn't support WireGuard; it must be built with WireGuard support, or"+
+			" the WireGuard module must be installed, for example with the wireguard-tools package", nodeName)
+	default:
+		status.Warning("Unable to determine whether gateway node %q supports WireGuard from the pod output %q", nodeName,
+			truncate(podOutput))
+	}
+
+	switch values["ip_forward"] {
+	case "1":
+	case "0":
+		status.Failure("IPv4 forwarding is disabled on gateway node %q; net.ipv4.ip_forward must be set to 1", nodeName)
+	default:
+		status.Warning("Unable to determine whether IPv4 forwarding is enabled on gateway node %q from the pod output %q",
+			nodeName, truncate(podOutput))
+	}
+}
@@ -19,16 +19,25 @@ limitations under the License.
 package image
 
 import (
+	"maps"
+
 	"github.com/submariner-io/admiral/pkg/names"
 	submariner "github.com/submariner-io/submariner-operator/api/v1alpha1"
 	"github.com/submariner-io/submariner-operator/pkg/images"
 	imagenames "github.com/submariner-io/submariner-operator/pkg/names"
 )
 
+// AllComponentsOverride is the image override key which replaces the repository for all the components; overrides for
+// specific components take precedence over it.
+const AllComponentsOverride = "*"
+
 type RepositoryInfo struct {
 	Name      string
 	Version   string
 	Overrides map[string]string
+	// PullSecrets are the names of the secrets needed to pull the images, in the PullSecretsNamespace namespace
+	PullSecrets          []string
+	PullSecretsNamespace string
 }
 
 func NewRepositoryInfo(name, verion string, overrides map[string]string) *RepositoryInfo {
@@ -40,6 +49,12 @@ func NewRepositoryInfo(name, verion string, overrides map[string]string) *Reposi
 		verion = submariner.DefaultSubmarinerOperatorVersion
 	}
 
+	if repository, ok := overrides[AllComponentsOverride]; ok {
+		name = repository
+		overrides = maps.Clone(overrides)
+		delete(overrides, AllComponentsOverride)
+	}
+
 	repositoryInfo := &RepositoryInfo{
 		Name:      name,
 		Version:   verion,
@@ -56,3 +71,29 @@ func (i *RepositoryInfo) GetNettestImage() string {
 func (i *RepositoryInfo) GetOperatorImage() string {
 	return images.GetImagePath(i.Name, i.Version, imagenames.OperatorImage, names.OperatorComponent, i.Overrides)
 }
+
+// componentImages are the names of the images used by the components.
+var componentImages = map[string]string{
+	names.OperatorComponent:          imagenames.OperatorImage,
+	names.GatewayComponent:           imagenames.GatewayImage,
+	names.RouteAgentComponent:        imagenames.RouteAgentImage,
+	names.GlobalnetComponent:         imagenames.GlobalnetImage,
+	names.ServiceDiscoveryComponent:  imagenames.ServiceDiscoveryImage,
+	names.LighthouseCoreDNSComponent: imagenames.LighthouseCoreDNSImage,
+	names.MetricsProxyComponent:      imagenames.MetricsProxyImage,
+	names.NettestComponent:           imagenames.NettestImage,
+}
+
+// GetComponentImages returns the images used by the given components, indexed by component; components without a known
+// image are ignored.
+func (i *RepositoryInfo) GetComponentImages(components ...string) map[string]string {
+	componentImagePaths := map[string]string{}
+
+	for _, component := range components {
+		if imageName, ok := componentImages[component]; ok {
+			componentImagePaths[component] = images.GetImagePath(i.Name, i.Version, imageName, component, i.Overrides)
+		}
+	}
+
+	return componentImagePaths
+}
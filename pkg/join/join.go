@@ -27,11 +27,13 @@ import (
 	"github.com/pkg/errors"
 	"github.com/submariner-io/admiral/pkg/reporter"
 	"github.com/submariner-io/admiral/pkg/resource"
+	"github.com/submariner-io/subctl/internal/component"
 	"github.com/submariner-io/subctl/internal/constants"
 	"github.com/submariner-io/subctl/pkg/broker"
 	"github.com/submariner-io/subctl/pkg/client"
 	"github.com/submariner-io/subctl/pkg/cluster"
 	"github.com/submariner-io/subctl/pkg/deploy"
+	"github.com/submariner-io/subctl/pkg/diagnose"
 	"github.com/submariner-io/subctl/pkg/image"
 	"github.com/submariner-io/subctl/pkg/operator"
 	"github.com/submariner-io/subctl/pkg/secret"
@@ -41,6 +43,7 @@ import (
 	submarinerv1 "github.com/submariner-io/submariner/pkg/apis/submariner.io/v1"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	controllerClient "sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -49,6 +52,10 @@ import (
 func ClusterToBroker(ctx context.Context, brokerInfo *broker.Info, options *Options,
 	clientProducer client.Producer, status reporter.Interface,
 ) error {
+	if err := options.Validate(); err != nil {
+		return status.Error(err, "Error validating the join options")
+	}
+
 	err := checkRequirements(clientProducer.ForKubernetes(), options.IgnoreRequirements, brokerInfo, status)
 	if err != nil {
 		return err
@@ -59,11 +66,25 @@ func ClusterToBroker(ctx context.Context, brokerInfo *broker.Info, options *Opti
 		return status.Error(err, "error validating custom CoreDNS config")
 	}
 
+	imagePullSecret, err := imagePullSecretFrom(options.ImagePullSecretName)
+	if err != nil {
+		return status.Error(err, "error validating the image pull secret")
+	}
+
 	imageOverrides, err := cluster.MergeImageOverrides(nil, options.ImageOverrideArr)
 	if err != nil {
 		return status.Error(err, "Error calculating image overrides")
 	}
 
+	repositoryInfo := image.NewRepositoryInfo(options.Repository, options.ImageVersion, imageOverrides)
+
+	if options.CheckImages || options.AirGappedDeployment {
+		err = checkImages(clientProducer.ForKubernetes(), brokerInfo, options, *repositoryInfo, imagePullSecret, status)
+		if err != nil {
+			return err
+		}
+	}
+
 	status.Start("Gathering relevant information from Broker")
 	defer status.End()
 
@@ -116,23 +137,40 @@ func ClusterToBroker(ctx context.Context, brokerInfo *broker.Info, options *Opti
 		}
 	}
 
-	status.Start("Deploying the Submariner operator")
+	if options.SkipOperatorDeploy {
+		status.Start("Checking the existing Submariner operator")
 
-	repositoryInfo := image.NewRepositoryInfo(options.Repository, options.ImageVersion, imageOverrides)
+		err = operator.CheckReady(ctx, status, clientProducer.ForKubernetes(), operatorNamespace)
+		if err != nil {
+			return status.Error(err, "Error checking the operator")
+		}
 
-	err = operator.Ensure(ctx, status, clientProducer, operatorNamespace, repositoryInfo.GetOperatorImage(), options.OperatorDebug,
-		&options.HTTPProxyConfig)
-	if err != nil {
-		return status.Error(err, "Error deploying the operator")
+		status.Success("The operator is ready")
+	} else {
+		status.Start("Deploying the Submariner operator")
+
+		err = operator.Ensure(ctx, status, clientProducer, operatorNamespace, repositoryInfo.GetOperatorImage(), options.OperatorDebug,
+			&options.HTTPProxyConfig, options.OperatorNodeSelector, imagePullSecret)
+		if err != nil {
+			return status.Error(err, "Error deploying the operator")
+		}
 	}
 
 	status.Start("Creating SA for cluster")
 
-	brokerInfo.ClientToken, err = broker.CreateSAForCluster(ctx, brokerClientProducer.ForKubernetes(), options.ClusterID, brokerNamespace)
+	brokerInfo.ClientToken, err = broker.CreateSAForCluster(ctx, brokerClientProducer.ForKubernetes(), options.ClusterID, brokerNamespace,
+		options.TokenWaitTimeout)
 	if err != nil {
 		return status.Error(err, "Error creating SA for cluster")
 	}
 
+	if brokerInfo.IsConnectivityEnabled() {
+		err = checkClustersetCompatibility(ctx, brokerClientProducer, brokerNamespace, options, status)
+		if err != nil {
+			return err
+		}
+	}
+
 	status.Start("Connecting to Broker")
 
 	// We need to connect to the broker in all cases
@@ -166,6 +204,23 @@ func ClusterToBroker(ctx context.Context, brokerInfo *broker.Info, options *Opti
 	return nil
 }
 
+// checkImages checks that the images of the components which will be deployed can be pulled, using the operator's
+// image pull secret if any.
+func checkImages(kubeClient kubernetes.Interface, brokerInfo *broker.Info, options *Options, repositoryInfo image.RepositoryInfo,
+	imagePullSecret *types.NamespacedName, status reporter.Interface,
+) error {
+	if imagePullSecret != nil {
+		repositoryInfo.PullSecrets = []string{imagePullSecret.Name}
+		repositoryInfo.PullSecretsNamespace = imagePullSecret.Namespace
+	}
+
+	components := diagnose.ImageComponents(brokerInfo.IsConnectivityEnabled(),
+		options.GlobalnetEnabled && brokerInfo.GetComponents().Has(component.Globalnet), brokerInfo.IsServiceDiscoveryEnabled())
+
+	//nolint:wrapcheck // No need to wrap errors here.
+	return diagnose.CheckImagesPullable(kubeClient, constants.OperatorNamespace, &repositoryInfo, components, status)
+}
+
 func submarinerOptionsFrom(joinOptions *Options) *deploy.SubmarinerOptions {
 	return &deploy.SubmarinerOptions{
 		PreferredServer:               joinOptions.PreferredServer,
@@ -226,11 +281,77 @@ func checkRequirements(kubeClient kubernetes.Interface, ignoreRequirements bool,
 	return status.Error(err, "unable to check version requirements")
 }
 
+// checkClustersetCompatibility verifies that the cable driver and NATT port planned for the joining cluster match those
+// advertised by the other clusters' Endpoints on the broker; mismatched clusters would never establish connections. The
+// cable driver is only checked if one is specified.
+func checkClustersetCompatibility(ctx context.Context, brokerProducer client.Producer, brokerNamespace string, options *Options,
+	status reporter.Interface,
+) error {
+	status.Start("Checking the cable driver and NATT port against the other clusters on the broker")
+
+	endpoints := &submarinerv1.EndpointList{}
+
+	err := brokerProducer.ForGeneral().List(ctx, endpoints, controllerClient.InNamespace(brokerNamespace))
+	if err != nil {
+		return status.Error(err, "error listing the Endpoints on the broker")
+	}
+
+	conflicts := []string{}
+	checked := map[string]bool{}
+
+	for i := range endpoints.Items {
+		spec := &endpoints.Items[i].Spec
+
+		if spec.ClusterID == options.ClusterID || checked[spec.ClusterID] {
+			continue
+		}
+
+		checked[spec.ClusterID] = true
+
+		// An empty cable driver leaves the choice to the operator, so there's nothing to compare
+		if options.CableDriver != "" && spec.Backend != options.CableDriver {
+			conflicts = append(conflicts, fmt.Sprintf("* cluster %q uses the %q cable driver, this cluster is configured to use %q",
+				spec.ClusterID, spec.Backend, options.CableDriver))
+		}
+
+		//nolint:gosec // Need to ignore integer overflow conversion int -> int32
+		nattPort, err := spec.GetBackendPort(submarinerv1.UDPPortConfig, int32(options.NATTPort))
+		if err != nil {
+			status.Warning("Unable to determine the NATT port of cluster %q: %s", spec.ClusterID, err)
+			continue
+		}
+
+		if int(nattPort) != options.NATTPort {
+			conflicts = append(conflicts, fmt.Sprintf("* cluster %q uses NATT port %d, this cluster is configured to use %d",
+				spec.ClusterID, nattPort, options.NATTPort))
+		}
+	}
+
+	if len(conflicts) == 0 {
+		status.Success("The cable driver and NATT port are consistent with the other clusters")
+		return nil
+	}
+
+	msg := "The configuration of this cluster conflicts with other clusters joined to the broker:\n" + strings.Join(conflicts, "\n")
+
+	if !options.IgnoreRequirements {
+		status.Failure(msg)
+
+		return goerrors.New("cable driver or NATT port conflicts with other clusters")
+	}
+
+	status.Warning(msg)
+
+	return nil
+}
+
 func populateBrokerSecret(brokerInfo *broker.Info) *v1.Secret {
 	// We need to copy the broker token secret as an opaque secret to store it in the connecting cluster
+	// The broker's components are recorded so that mismatches with the cluster's components can be diagnosed later
 	return &v1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: broker.LocalClientBrokerSecretName,
+			Name:        broker.LocalClientBrokerSecretName,
+			Annotations: map[string]string{broker.ComponentsAnnotation: strings.Join(brokerInfo.GetComponents().SortedList(), ",")},
 		},
 		Type: v1.SecretTypeOpaque,
 		Data: brokerInfo.ClientToken.Data,
@@ -245,6 +366,24 @@ func isValidCustomCoreDNSConfig(corednsCustomConfigMap string) error {
 	return nil
 }
 
+// imagePullSecretFrom parses an image pull secret in [<namespace>/]<name> format; the namespace defaults to "default".
+func imagePullSecretFrom(imagePullSecretName string) (*types.NamespacedName, error) {
+	if imagePullSecretName == "" {
+		return nil, nil
+	}
+
+	namespace, name, found := strings.Cut(imagePullSecretName, "/")
+	if !found {
+		namespace, name = metav1.NamespaceDefault, imagePullSecretName
+	}
+
+	if namespace == "" || name == "" || strings.Contains(name, "/") {
+		return nil, fmt.Errorf("image pull secret %q should be in [<namespace>/]<name> format", imagePullSecretName)
+	}
+
+	return &types.NamespacedName{Namespace: namespace, Name: name}, nil
+}
+
 func ensureUniqueCluster(ctx context.Context, clusterID string, brokerProducer client.Producer, brokerNamespace string,
 	localProducer client.Producer, operatorNamespace string, status reporter.Interface,
 ) error {
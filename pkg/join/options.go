@@ -18,7 +18,27 @@ limitations under the License.
 
 package join
 
-import "golang.org/x/net/http/httpproxy"
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/submariner-io/subctl/pkg/cluster"
+	"golang.org/x/net/http/httpproxy"
+)
+
+// ValidCableDrivers are the cable drivers which can be used to connect the clusters.
+var ValidCableDrivers = []string{"libreswan", "wireguard", "vxlan"}
+
+// The default health check settings, matching the operator's.
+const (
+	// DefaultHealthCheckInterval is the interval between health check packets, in seconds
+	DefaultHealthCheckInterval = 1
+	// DefaultHealthCheckMaxPacketLossCount is the number of packets lost before a connection is marked as down
+	DefaultHealthCheckMaxPacketLossCount = 5
+)
 
 type Options struct {
 	PreferredServer               bool
@@ -48,7 +68,45 @@ type Options struct {
 	CoreDNSCustomConfigMap        string
 	BrokerURL                     string
 	ClustersetIPCIDR              string
+	GatewayNodeSelector           string
 	CustomDomains                 []string
 	ImageOverrideArr              []string
 	HTTPProxyConfig               httpproxy.Config
+	// OperatorNodeSelector restricts the nodes the operator can run on; nil preserves the existing placement
+	OperatorNodeSelector map[string]string
+	// ImagePullSecretName is the secret used to pull the operator image from a private registry, in [<namespace>/]<name> format
+	ImagePullSecretName string
+	// CheckImages checks that all the images can be pulled before deploying anything; it's always done for air-gapped
+	// deployments
+	CheckImages bool
+	// SkipOperatorDeploy uses the operator already deployed in the cluster, e.g. by a GitOps tool, instead of deploying it;
+	// the operator must be ready
+	SkipOperatorDeploy bool
+	// TokenWaitTimeout is how long to wait for the cluster's broker service account token to be generated
+	TokenWaitTimeout time.Duration
+}
+
+// Validate checks the options which can be validated without accessing the cluster or the broker.
+func (o *Options) Validate() error {
+	if err := cluster.IsValidID(o.ClusterID); err != nil {
+		return err //nolint:wrapcheck // The error describes the cluster ID
+	}
+
+	// An empty cable driver leaves the choice to the operator
+	if o.CableDriver != "" && !slices.Contains(ValidCableDrivers, o.CableDriver) {
+		return fmt.Errorf("unsupported cable driver %q, the supported cable drivers are %s", o.CableDriver,
+			strings.Join(ValidCableDrivers, ", "))
+	}
+
+	if o.HealthCheckEnabled {
+		if o.HealthCheckInterval < 1 {
+			return errors.New("the health check interval must be at least 1 second")
+		}
+
+		if o.HealthCheckMaxPacketLossCount < 1 {
+			return errors.New("the health check maximum packet loss count must be at least 1")
+		}
+	}
+
+	return nil
 }
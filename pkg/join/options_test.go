@@ -0,0 +1,75 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package join_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/subctl/pkg/join"
+)
+
+var _ = Describe("Options validation", func() {
+	var options *join.Options
+
+	BeforeEach(func() {
+		options = &join.Options{
+			ClusterID:                     "east",
+			CableDriver:                   "libreswan",
+			HealthCheckEnabled:            true,
+			HealthCheckInterval:           join.DefaultHealthCheckInterval,
+			HealthCheckMaxPacketLossCount: join.DefaultHealthCheckMaxPacketLossCount,
+		}
+	})
+
+	It("should accept the default settings", func() {
+		Expect(options.Validate()).To(Succeed())
+	})
+
+	It("should leave the cable driver to the operator if it isn't specified", func() {
+		options.CableDriver = ""
+		Expect(options.Validate()).To(Succeed())
+	})
+
+	It("should reject an invalid cluster ID", func() {
+		options.ClusterID = "East_Cluster"
+		Expect(options.Validate()).ToNot(Succeed())
+	})
+
+	It("should reject an unsupported cable driver", func() {
+		options.CableDriver = "openvpn"
+		Expect(options.Validate()).To(MatchError(ContainSubstring(`unsupported cable driver "openvpn"`)))
+	})
+
+	It("should reject a zero health check interval", func() {
+		options.HealthCheckInterval = 0
+		Expect(options.Validate()).To(MatchError(ContainSubstring("interval")))
+	})
+
+	It("should reject a zero health check maximum packet loss count", func() {
+		options.HealthCheckMaxPacketLossCount = 0
+		Expect(options.Validate()).To(MatchError(ContainSubstring("packet loss count")))
+	})
+
+	It("should ignore the health check settings if the health check is disabled", func() {
+		options.HealthCheckEnabled = false
+		options.HealthCheckInterval = 0
+		options.HealthCheckMaxPacketLossCount = 0
+		Expect(options.Validate()).To(Succeed())
+	})
+})
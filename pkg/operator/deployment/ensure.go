@@ -21,6 +21,7 @@ package deployment
 import (
 	"context"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/submariner-io/admiral/pkg/names"
@@ -35,8 +36,14 @@ import (
 	"k8s.io/utils/ptr"
 )
 
-// Ensure the operator is deployed, and running.
+// NodeSelectorAnnotation records the operator node selector configured by subctl, so that it can be preserved when the
+// operator Deployment is updated without one being specified.
+const NodeSelectorAnnotation = "submariner.io/operator-node-selector"
+
+// Ensure the operator is deployed, and running. onProgress, if not nil, is called periodically while waiting for the
+// operator to be ready (see deployment.AwaitReady).
 func Ensure(ctx context.Context, kubeClient kubernetes.Interface, namespace, image string, debug bool, proxyConfig *httpproxy.Config,
+	nodeSelector map[string]string, imagePullSecrets []v1.LocalObjectReference, onProgress func(elapsed time.Duration, phase string),
 ) (bool, error) {
 	operatorName := names.OperatorComponent
 	replicas := int32(1)
@@ -54,10 +61,16 @@ func Ensure(ctx context.Context, kubeClient kubernetes.Interface, namespace, ima
 		command = append(command, "-v=1")
 	}
 
+	var annotations map[string]string
+	if len(nodeSelector) > 0 {
+		annotations = map[string]string{NodeSelectorAnnotation: labels.Set(nodeSelector).String()}
+	}
+
 	opDeployment := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
-			Namespace: namespace,
-			Name:      operatorName,
+			Namespace:   namespace,
+			Name:        operatorName,
+			Annotations: annotations,
 		},
 		Spec: appsv1.DeploymentSpec{
 			Replicas: &replicas,
@@ -68,6 +81,8 @@ func Ensure(ctx context.Context, kubeClient kubernetes.Interface, namespace, ima
 				},
 				Spec: v1.PodSpec{
 					ServiceAccountName: operatorName,
+					NodeSelector:       nodeSelector,
+					ImagePullSecrets:   imagePullSecrets,
 					Containers: []v1.Container{
 						{
 							Name:            operatorName,
@@ -107,11 +122,57 @@ func Ensure(ctx context.Context, kubeClient kubernetes.Interface, namespace, ima
 		return false, errors.Wrap(err, "error creating/updating Deployment")
 	}
 
-	err = deployment.AwaitReady(ctx, kubeClient, namespace, opDeployment.Name)
+	err = deployment.AwaitReady(ctx, kubeClient, namespace, opDeployment.Name, onProgress)
 
 	return created, errors.Wrap(err, "error awaiting Deployment ready")
 }
 
+// ResolveNodeSelector returns the node selector to use for the operator: the requested one if any (an empty selector
+// clears the placement), otherwise the one recorded on the existing operator Deployment, falling back to the Deployment's
+// current node selector to preserve placements configured outside of subctl.
+func ResolveNodeSelector(ctx context.Context, kubeClient kubernetes.Interface, namespace string, requested map[string]string,
+) (map[string]string, error) {
+	if requested != nil {
+		return requested, nil
+	}
+
+	dep, err := kubeClient.AppsV1().Deployments(namespace).Get(ctx, names.OperatorComponent, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "error retrieving operator deployment")
+	}
+
+	if recorded, ok := dep.Annotations[NodeSelectorAnnotation]; ok {
+		nodeSelector, err := labels.ConvertSelectorToLabelsMap(recorded)
+		return nodeSelector, errors.Wrapf(err, "invalid operator node selector %q recorded on the deployment", recorded)
+	}
+
+	return dep.Spec.Template.Spec.NodeSelector, nil
+}
+
+// ResolveImagePullSecrets returns the image pull secrets to use for the operator: the requested ones if any, otherwise the
+// ones set on the existing operator Deployment, so that upgrades keep pulling from the same private registry.
+func ResolveImagePullSecrets(ctx context.Context, kubeClient kubernetes.Interface, namespace string, requested []v1.LocalObjectReference,
+) ([]v1.LocalObjectReference, error) {
+	if requested != nil {
+		return requested, nil
+	}
+
+	dep, err := kubeClient.AppsV1().Deployments(namespace).Get(ctx, names.OperatorComponent, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "error retrieving operator deployment")
+	}
+
+	return dep.Spec.Template.Spec.ImagePullSecrets, nil
+}
+
 func GetPodLabelSelector(kubeClient kubernetes.Interface, namespace string) (string, error) {
 	dep, err := kubeClient.AppsV1().Deployments(namespace).Get(context.TODO(), names.OperatorComponent, metav1.GetOptions{})
 	if apierrors.IsNotFound(err) {
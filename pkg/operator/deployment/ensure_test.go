@@ -0,0 +1,186 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployment_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/admiral/pkg/names"
+	"github.com/submariner-io/subctl/pkg/operator/deployment"
+	"golang.org/x/net/http/httpproxy"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/testing"
+)
+
+const namespace = "submariner-operator"
+
+// newFakeClient returns a fake client which reports the operator Deployment as available, since it doesn't run a
+// deployment controller.
+func newFakeClient() *fakeclientset.Clientset {
+	client := fakeclientset.NewClientset()
+
+	client.PrependReactor("get", "deployments", func(action testing.Action) (bool, runtime.Object, error) {
+		obj, err := client.Tracker().Get(action.GetResource(), action.GetNamespace(), action.(testing.GetAction).GetName())
+		if err != nil {
+			return true, nil, err
+		}
+
+		dep := obj.(*appsv1.Deployment)
+		dep.Status.Conditions = []appsv1.DeploymentCondition{{Type: appsv1.DeploymentAvailable, Status: corev1.ConditionTrue}}
+
+		return true, dep, nil
+	})
+
+	return client
+}
+
+var _ = Describe("Operator placement", func() {
+	infraSelector := map[string]string{"node-role.kubernetes.io/infra": ""}
+
+	var client *fakeclientset.Clientset
+
+	BeforeEach(func() {
+		client = newFakeClient()
+	})
+
+	ensure := func(requested map[string]string) {
+		nodeSelector, err := deployment.ResolveNodeSelector(context.TODO(), client, namespace, requested)
+		Expect(err).To(Succeed())
+
+		_, err = deployment.Ensure(context.TODO(), client, namespace, "operator:devel", false, &httpproxy.Config{}, nodeSelector, nil, nil)
+		Expect(err).To(Succeed())
+	}
+
+	getDeployment := func() *appsv1.Deployment {
+		dep, err := client.AppsV1().Deployments(namespace).Get(context.TODO(), names.OperatorComponent, metav1.GetOptions{})
+		Expect(err).To(Succeed())
+
+		return dep
+	}
+
+	When("a node selector is specified on install", func() {
+		BeforeEach(func() {
+			ensure(infraSelector)
+		})
+
+		It("should set and record it", func() {
+			dep := getDeployment()
+			Expect(dep.Spec.Template.Spec.NodeSelector).To(Equal(infraSelector))
+			Expect(dep.Annotations).To(HaveKeyWithValue(deployment.NodeSelectorAnnotation, "node-role.kubernetes.io/infra="))
+		})
+
+		Context("and a subsequent upgrade doesn't specify one", func() {
+			It("should preserve it", func() {
+				ensure(nil)
+				Expect(getDeployment().Spec.Template.Spec.NodeSelector).To(Equal(infraSelector))
+			})
+		})
+
+		Context("and a subsequent upgrade specifies a different one", func() {
+			It("should replace it", func() {
+				ensure(map[string]string{"pool": "control"})
+				Expect(getDeployment().Spec.Template.Spec.NodeSelector).To(Equal(map[string]string{"pool": "control"}))
+			})
+		})
+
+		Context("and a subsequent upgrade specifies an empty one", func() {
+			It("should remove it", func() {
+				ensure(map[string]string{})
+
+				dep := getDeployment()
+				Expect(dep.Spec.Template.Spec.NodeSelector).To(BeEmpty())
+				Expect(dep.Annotations).ToNot(HaveKey(deployment.NodeSelectorAnnotation))
+
+				ensure(nil)
+				Expect(getDeployment().Spec.Template.Spec.NodeSelector).To(BeEmpty())
+			})
+		})
+	})
+
+	When("the node selector was set on the Deployment outside of subctl", func() {
+		It("should preserve it", func() {
+			ensure(nil)
+
+			dep := getDeployment()
+			dep.Status = appsv1.DeploymentStatus{}
+			dep.Spec.Template.Spec.NodeSelector = infraSelector
+			_, err := client.AppsV1().Deployments(namespace).Update(context.TODO(), dep, metav1.UpdateOptions{})
+			Expect(err).To(Succeed())
+
+			ensure(nil)
+			Expect(getDeployment().Spec.Template.Spec.NodeSelector).To(Equal(infraSelector))
+		})
+	})
+
+	When("the operator isn't deployed and no node selector is specified", func() {
+		It("should not set one", func() {
+			nodeSelector, err := deployment.ResolveNodeSelector(context.TODO(), client, namespace, nil)
+			Expect(err).To(Succeed())
+			Expect(nodeSelector).To(BeNil())
+		})
+	})
+})
+
+var _ = Describe("Operator image pull secrets", func() {
+	pullSecrets := []corev1.LocalObjectReference{{Name: "registry-secret"}}
+
+	var client *fakeclientset.Clientset
+
+	BeforeEach(func() {
+		client = newFakeClient()
+	})
+
+	ensure := func(requested []corev1.LocalObjectReference) []corev1.LocalObjectReference {
+		imagePullSecrets, err := deployment.ResolveImagePullSecrets(context.TODO(), client, namespace, requested)
+		Expect(err).To(Succeed())
+
+		_, err = deployment.Ensure(context.TODO(), client, namespace, "operator:devel", false, &httpproxy.Config{}, nil, imagePullSecrets, nil)
+		Expect(err).To(Succeed())
+
+		dep, err := client.AppsV1().Deployments(namespace).Get(context.TODO(), names.OperatorComponent, metav1.GetOptions{})
+		Expect(err).To(Succeed())
+
+		return dep.Spec.Template.Spec.ImagePullSecrets
+	}
+
+	When("image pull secrets are specified on install", func() {
+		It("should set them", func() {
+			Expect(ensure(pullSecrets)).To(Equal(pullSecrets))
+		})
+
+		Context("and a subsequent upgrade doesn't specify any", func() {
+			It("should preserve them", func() {
+				ensure(pullSecrets)
+				Expect(ensure(nil)).To(Equal(pullSecrets))
+			})
+		})
+	})
+
+	When("no image pull secrets are specified on install", func() {
+		It("should not set any", func() {
+			Expect(ensure(nil)).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,120 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployment
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/submariner-io/admiral/pkg/names"
+	"golang.org/x/net/http/httpproxy"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+// The OpenShift cluster-wide proxy configuration.
+const openShiftProxyName = "cluster"
+
+var openShiftProxyGVR = schema.GroupVersionResource{
+	Group:    "config.openshift.io",
+	Version:  "v1",
+	Resource: "proxies",
+}
+
+// DetectHTTPProxy returns the cluster-wide proxy configuration, along with a description of where it was found: the
+// OpenShift Proxy resource if it configures a proxy, otherwise the proxy environment variables of the existing operator
+// Deployment. nil is returned if no proxy is configured.
+func DetectHTTPProxy(ctx context.Context, dynClient dynamic.Interface, kubeClient kubernetes.Interface, namespace string,
+) (*httpproxy.Config, string, error) {
+	proxyConfig, err := openShiftHTTPProxy(ctx, dynClient)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if proxyConfig != nil {
+		return proxyConfig, fmt.Sprintf("the OpenShift Proxy %q", openShiftProxyName), nil
+	}
+
+	dep, err := kubeClient.AppsV1().Deployments(namespace).Get(ctx, names.OperatorComponent, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, "", nil
+	}
+
+	if err != nil {
+		return nil, "", errors.Wrap(err, "error retrieving operator deployment")
+	}
+
+	proxyConfig = &httpproxy.Config{}
+
+	for i := range dep.Spec.Template.Spec.Containers {
+		for _, env := range dep.Spec.Template.Spec.Containers[i].Env {
+			switch env.Name {
+			case "HTTP_PROXY":
+				proxyConfig.HTTPProxy = env.Value
+			case "HTTPS_PROXY":
+				proxyConfig.HTTPSProxy = env.Value
+			case "NO_PROXY":
+				proxyConfig.NoProxy = env.Value
+			}
+		}
+	}
+
+	if !isProxyConfigured(proxyConfig) {
+		return nil, "", nil
+	}
+
+	return proxyConfig, fmt.Sprintf("the operator Deployment %s/%s", namespace, dep.Name), nil
+}
+
+// openShiftHTTPProxy returns the proxy configuration from the OpenShift Proxy resource, preferring its status which
+// contains the effective settings (including the cluster's own networks in the no-proxy list).
+func openShiftHTTPProxy(ctx context.Context, dynClient dynamic.Interface) (*httpproxy.Config, error) {
+	proxy, err := dynClient.Resource(openShiftProxyGVR).Get(ctx, openShiftProxyName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "error retrieving the OpenShift Proxy %q", openShiftProxyName)
+	}
+
+	for _, field := range []string{"status", "spec"} {
+		proxyConfig := &httpproxy.Config{}
+		proxyConfig.HTTPProxy, _, _ = unstructured.NestedString(proxy.Object, field, "httpProxy")
+		proxyConfig.HTTPSProxy, _, _ = unstructured.NestedString(proxy.Object, field, "httpsProxy")
+		proxyConfig.NoProxy, _, _ = unstructured.NestedString(proxy.Object, field, "noProxy")
+
+		if isProxyConfigured(proxyConfig) {
+			return proxyConfig, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// isProxyConfigured returns true if the configuration specifies a proxy; a no-proxy list on its own has no effect.
+func isProxyConfigured(proxyConfig *httpproxy.Config) bool {
+	return proxyConfig.HTTPProxy != "" || proxyConfig.HTTPSProxy != ""
+}
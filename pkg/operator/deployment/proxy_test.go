@@ -0,0 +1,130 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployment_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/subctl/pkg/operator/deployment"
+	"golang.org/x/net/http/httpproxy"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("DetectHTTPProxy", func() {
+	operatorProxy := httpproxy.Config{
+		HTTPProxy:  "http://operator-proxy:3128",
+		HTTPSProxy: "http://operator-proxy:3128",
+		NoProxy:    "10.0.0.0/8",
+	}
+
+	var (
+		client     *fakeclientset.Clientset
+		dynClient  *fakedynamic.FakeDynamicClient
+		proxyState map[string]interface{}
+	)
+
+	BeforeEach(func() {
+		client = newFakeClient()
+		proxyState = nil
+	})
+
+	JustBeforeEach(func() {
+		objects := []runtime.Object{}
+
+		if proxyState != nil {
+			objects = append(objects, &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "config.openshift.io/v1",
+				"kind":       "Proxy",
+				"metadata":   map[string]interface{}{"name": "cluster"},
+				"status":     proxyState,
+			}})
+		}
+
+		dynClient = fakedynamic.NewSimpleDynamicClient(runtime.NewScheme(), objects...)
+	})
+
+	detect := func() *httpproxy.Config {
+		proxyConfig, _, err := deployment.DetectHTTPProxy(context.TODO(), dynClient, client, namespace)
+		Expect(err).To(Succeed())
+
+		return proxyConfig
+	}
+
+	deployOperator := func(proxyConfig *httpproxy.Config) {
+		_, err := deployment.Ensure(context.TODO(), client, namespace, "operator:devel", false, proxyConfig, nil, nil, nil)
+		Expect(err).To(Succeed())
+	}
+
+	When("the OpenShift Proxy configures a proxy", func() {
+		BeforeEach(func() {
+			proxyState = map[string]interface{}{
+				"httpProxy":  "http://cluster-proxy:3128",
+				"httpsProxy": "http://cluster-proxy:3129",
+				"noProxy":    ".cluster.local,172.30.0.0/16",
+			}
+		})
+
+		It("should return its settings", func() {
+			deployOperator(&operatorProxy)
+			Expect(detect()).To(Equal(&httpproxy.Config{
+				HTTPProxy:  "http://cluster-proxy:3128",
+				HTTPSProxy: "http://cluster-proxy:3129",
+				NoProxy:    ".cluster.local,172.30.0.0/16",
+			}))
+		})
+	})
+
+	When("the OpenShift Proxy doesn't configure a proxy", func() {
+		BeforeEach(func() {
+			proxyState = map[string]interface{}{}
+		})
+
+		It("should return the operator Deployment's settings", func() {
+			deployOperator(&operatorProxy)
+			Expect(detect()).To(Equal(&operatorProxy))
+		})
+	})
+
+	When("there is no OpenShift Proxy", func() {
+		Context("and the operator Deployment has proxy settings", func() {
+			It("should return them", func() {
+				deployOperator(&operatorProxy)
+				Expect(detect()).To(Equal(&operatorProxy))
+			})
+		})
+
+		Context("and the operator Deployment has no proxy settings", func() {
+			It("should return nil", func() {
+				deployOperator(&httpproxy.Config{})
+				Expect(detect()).To(BeNil())
+			})
+		})
+
+		Context("and the operator isn't deployed", func() {
+			It("should return nil", func() {
+				Expect(detect()).To(BeNil())
+			})
+		})
+	})
+})
@@ -19,25 +19,39 @@ limitations under the License.
 package operator
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
 	"github.com/submariner-io/admiral/pkg/names"
 	"github.com/submariner-io/admiral/pkg/reporter"
+	"github.com/submariner-io/subctl/internal/constants"
 	"github.com/submariner-io/subctl/pkg/client"
+	pkgdeployment "github.com/submariner-io/subctl/pkg/deployment"
 	"github.com/submariner-io/subctl/pkg/lighthouse"
 	"github.com/submariner-io/subctl/pkg/namespace"
 	opcrds "github.com/submariner-io/subctl/pkg/operator/crds"
 	"github.com/submariner-io/subctl/pkg/operator/deployment"
 	"github.com/submariner-io/subctl/pkg/operator/ocp"
 	"github.com/submariner-io/subctl/pkg/operator/serviceaccount"
+	"github.com/submariner-io/subctl/pkg/secret"
 	"github.com/submariner-io/subctl/pkg/submariner"
 	"github.com/submariner-io/submariner-operator/pkg/crd"
 	"github.com/submariner-io/submariner-operator/pkg/embeddedyamls"
 	"golang.org/x/net/context"
 	"golang.org/x/net/http/httpproxy"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
 )
 
 //nolint:wrapcheck // No need to wrap errors here.
 func Ensure(ctx context.Context, status reporter.Interface, clientProducer client.Producer, operatorNamespace, operatorImage string,
-	debug bool, proxyConfig *httpproxy.Config,
+	debug bool, proxyConfig *httpproxy.Config, nodeSelector map[string]string, imagePullSecret *types.NamespacedName,
 ) error {
 	if created, err := opcrds.Ensure(ctx, crd.UpdaterFromControllerClient(clientProducer.ForGeneral())); err != nil {
 		return err
@@ -55,6 +69,18 @@ func Ensure(ctx context.Context, status reporter.Interface, clientProducer clien
 		status.Success("Created operator namespace: %s", operatorNamespace)
 	}
 
+	var requestedPullSecrets []v1.LocalObjectReference
+
+	if imagePullSecret != nil {
+		if created, err := ensureImagePullSecret(ctx, clientProducer.ForKubernetes(), operatorNamespace, imagePullSecret); err != nil {
+			return err
+		} else if created {
+			status.Success("Copied the image pull secret %q to the operator namespace", imagePullSecret)
+		}
+
+		requestedPullSecrets = []v1.LocalObjectReference{{Name: imagePullSecret.Name}}
+	}
+
 	if created, err := serviceaccount.Ensure(ctx, clientProducer.ForKubernetes(), operatorNamespace); err != nil {
 		return err
 	} else if created {
@@ -84,8 +110,23 @@ func Ensure(ctx context.Context, status reporter.Interface, clientProducer clien
 		return err
 	}
 
+	nodeSelector, err := deployment.ResolveNodeSelector(ctx, clientProducer.ForKubernetes(), operatorNamespace, nodeSelector)
+	if err != nil {
+		return err
+	}
+
+	warnIfGatewaysMatch(ctx, status, clientProducer.ForKubernetes(), nodeSelector)
+
+	imagePullSecrets, err := deployment.ResolveImagePullSecrets(ctx, clientProducer.ForKubernetes(), operatorNamespace,
+		requestedPullSecrets)
+	if err != nil {
+		return err
+	}
+
 	if created, err := deployment.Ensure(ctx, clientProducer.ForKubernetes(), operatorNamespace, operatorImage, debug,
-		proxyConfig); err != nil {
+		proxyConfig, nodeSelector, imagePullSecrets, func(elapsed time.Duration, phase string) {
+			status.Success("Waiting for operator: %s elapsed (%s)", elapsed, phase)
+		}); err != nil {
 		return err
 	} else if created {
 		status.Success("Deployed the operator successfully")
@@ -93,3 +134,101 @@ func Ensure(ctx context.Context, status reporter.Interface, clientProducer clien
 
 	return nil
 }
+
+// CheckReady checks that an operator deployed by other means, e.g. by a GitOps tool, is present and ready, waiting for it
+// to become available if necessary; if it isn't, the states of its pods are reported.
+func CheckReady(ctx context.Context, status reporter.Interface, kubeClient kubernetes.Interface, operatorNamespace string) error {
+	operatorDeployment, err := kubeClient.AppsV1().Deployments(operatorNamespace).Get(ctx, names.OperatorComponent, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return fmt.Errorf("the operator deployment %q doesn't exist in namespace %q; the operator must be deployed before joining"+
+			" when its deployment is skipped", names.OperatorComponent, operatorNamespace)
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "error retrieving the operator deployment")
+	}
+
+	err = pkgdeployment.AwaitReady(ctx, kubeClient, operatorNamespace, operatorDeployment.Name, func(elapsed time.Duration, phase string) {
+		status.Success("Waiting for operator: %s elapsed (%s)", elapsed, phase)
+	})
+	if err == nil {
+		return nil
+	}
+
+	selector, selectorErr := metav1.LabelSelectorAsSelector(operatorDeployment.Spec.Selector)
+	if selectorErr != nil {
+		return errors.Wrap(err, "the operator isn't ready")
+	}
+
+	pods, listErr := kubeClient.CoreV1().Pods(operatorNamespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if listErr != nil || len(pods.Items) == 0 {
+		return errors.Wrap(err, "the operator isn't ready and has no pods")
+	}
+
+	podStates := make([]string, len(pods.Items))
+	for i := range pods.Items {
+		podStates[i] = fmt.Sprintf("%s (%s)", pods.Items[i].Name, pods.Items[i].Status.Phase)
+	}
+
+	return errors.Wrapf(err, "the operator isn't ready, its pods are %s", strings.Join(podStates, ", "))
+}
+
+// ensureImagePullSecret makes the given image pull secret available in the operator namespace, copying it from its own
+// namespace unless a secret with the same name is already present there.
+func ensureImagePullSecret(ctx context.Context, kubeClient kubernetes.Interface, operatorNamespace string,
+	imagePullSecret *types.NamespacedName,
+) (bool, error) {
+	_, err := kubeClient.CoreV1().Secrets(operatorNamespace).Get(ctx, imagePullSecret.Name, metav1.GetOptions{})
+	if err == nil {
+		return false, nil
+	}
+
+	if !apierrors.IsNotFound(err) {
+		return false, errors.Wrapf(err, "error retrieving the image pull secret %q in namespace %q", imagePullSecret.Name,
+			operatorNamespace)
+	}
+
+	source, err := kubeClient.CoreV1().Secrets(imagePullSecret.Namespace).Get(ctx, imagePullSecret.Name, metav1.GetOptions{})
+	if err != nil {
+		return false, errors.Wrapf(err, "error retrieving the image pull secret %q", imagePullSecret)
+	}
+
+	_, err = secret.Ensure(ctx, kubeClient, operatorNamespace, &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: source.Name,
+		},
+		Type: source.Type,
+		Data: source.Data,
+	})
+
+	return err == nil, errors.Wrapf(err, "error copying the image pull secret %q", imagePullSecret)
+}
+
+// warnIfGatewaysMatch warns if the operator's node selector matches gateway nodes, since the operator would then compete
+// with the dataplane for the nodes' resources.
+func warnIfGatewaysMatch(ctx context.Context, status reporter.Interface, kubeClient kubernetes.Interface, nodeSelector map[string]string) {
+	if len(nodeSelector) == 0 {
+		return
+	}
+
+	selector := labels.SelectorFromSet(nodeSelector).String()
+
+	nodes, err := kubeClient.CoreV1().Nodes().List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		status.Warning("Unable to check whether the operator node selector %q matches gateway nodes: %s", selector, err)
+		return
+	}
+
+	gateways := []string{}
+
+	for i := range nodes.Items {
+		if nodes.Items[i].Labels[constants.SubmarinerGatewayLabel] == constants.TrueLabel {
+			gateways = append(gateways, nodes.Items[i].Name)
+		}
+	}
+
+	if len(gateways) > 0 {
+		status.Warning("The operator node selector %q also matches the gateway node(s) %s; the operator and the dataplane"+
+			" will compete for their resources", selector, strings.Join(gateways, ", "))
+	}
+}
@@ -0,0 +1,332 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postupgrade
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/submariner-io/admiral/pkg/reporter"
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	"github.com/submariner-io/subctl/internal/constants"
+	"github.com/submariner-io/subctl/internal/gvr"
+	"github.com/submariner-io/subctl/pkg/cluster"
+	submarinerv1 "github.com/submariner-io/submariner/pkg/apis/submariner.io/v1"
+	discovery "k8s.io/api/discovery/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+const (
+	DefaultTimeout = 5 * time.Minute
+	checkInterval  = 5 * time.Second
+)
+
+// State is what the post-upgrade checks compare against, captured before the upgrade.
+type State struct {
+	// EstablishedConnections is the number of gateway connections in the connected state
+	EstablishedConnections int
+	// ServiceImport is an aggregated ServiceImport which should still be available after the upgrade, if any
+	ServiceImport *types.NamespacedName
+}
+
+// CaptureState records the state of the Submariner components installed in the cluster.
+func CaptureState(ctx context.Context, clusterInfo *cluster.Info) (*State, error) {
+	state := &State{}
+
+	if clusterInfo.Submariner != nil {
+		connections, err := countEstablishedConnections(clusterInfo)
+		if err != nil {
+			return nil, err
+		}
+
+		state.EstablishedConnections = connections
+	}
+
+	if clusterInfo.ServiceDiscovery != nil {
+		serviceImport, err := findServiceImport(ctx, clusterInfo)
+		if err != nil {
+			return nil, err
+		}
+
+		state.ServiceImport = serviceImport
+	}
+
+	return state, nil
+}
+
+// Verify checks that the cluster still works after the upgrade: the components' rollouts must complete, there must be at
+// least as many established connections as before, and the ServiceImport found before must still have endpoints.
+// Each check waits up to the given timeout. An error is returned if any of the checks fail.
+func Verify(ctx context.Context, clusterInfo *cluster.Info, before *State, timeout time.Duration, status reporter.Interface) error {
+	passed := verifyRollouts(ctx, clusterInfo.ClientProducer.ForKubernetes(), timeout, status)
+
+	if clusterInfo.Submariner != nil {
+		passed = verifyConnections(ctx, clusterInfo, before, timeout, status) && passed
+	}
+
+	if clusterInfo.ServiceDiscovery != nil && before.ServiceImport != nil {
+		passed = verifyServiceImport(ctx, clusterInfo, *before.ServiceImport, timeout, status) && passed
+	}
+
+	if !passed {
+		return fmt.Errorf("the post-upgrade checks failed on cluster %q", clusterInfo.Name)
+	}
+
+	return nil
+}
+
+func verifyRollouts(ctx context.Context, kubeClient kubernetes.Interface, timeout time.Duration, status reporter.Interface) bool {
+	status.Start("Waiting for the Submariner components to be ready at the new version")
+	defer status.End()
+
+	var notReady []string
+
+	err := poll(ctx, timeout, func() (bool, error) {
+		var err error
+
+		notReady, err = incompleteRollouts(ctx, kubeClient, constants.OperatorNamespace)
+
+		return len(notReady) == 0, err
+	})
+
+	switch {
+	case len(notReady) > 0:
+		status.Failure("The following components are not ready at the new version after %s: %s", timeout, strings.Join(notReady, ", "))
+		return false
+	case err != nil:
+		status.Failure("Error checking the Submariner components: %v", err)
+		return false
+	default:
+		status.Success("All the Submariner components are ready")
+	}
+
+	return true
+}
+
+// incompleteRollouts returns the Deployments and DaemonSets in the namespace whose pods aren't all updated and available.
+func incompleteRollouts(ctx context.Context, kubeClient kubernetes.Interface, namespace string) ([]string, error) {
+	notReady := []string{}
+
+	deployments, err := kubeClient.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing Deployments")
+	}
+
+	for i := range deployments.Items {
+		deployment := &deployments.Items[i]
+
+		var replicas int32 = 1
+		if deployment.Spec.Replicas != nil {
+			replicas = *deployment.Spec.Replicas
+		}
+
+		if deployment.Status.ObservedGeneration < deployment.Generation || deployment.Status.UpdatedReplicas != replicas ||
+			deployment.Status.AvailableReplicas != replicas {
+			notReady = append(notReady, "Deployment "+deployment.Name)
+		}
+	}
+
+	daemonSets, err := kubeClient.AppsV1().DaemonSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing DaemonSets")
+	}
+
+	for i := range daemonSets.Items {
+		daemonSet := &daemonSets.Items[i]
+
+		if daemonSet.Status.ObservedGeneration < daemonSet.Generation ||
+			daemonSet.Status.UpdatedNumberScheduled != daemonSet.Status.DesiredNumberScheduled ||
+			daemonSet.Status.NumberAvailable != daemonSet.Status.DesiredNumberScheduled {
+			notReady = append(notReady, "DaemonSet "+daemonSet.Name)
+		}
+	}
+
+	return notReady, nil
+}
+
+func verifyConnections(ctx context.Context, clusterInfo *cluster.Info, before *State, timeout time.Duration,
+	status reporter.Interface,
+) bool {
+	status.Start("Checking that the connections established before the upgrade are established again")
+	defer status.End()
+
+	after := 0
+
+	err := poll(ctx, timeout, func() (bool, error) {
+		var err error
+
+		after, err = countEstablishedConnections(clusterInfo)
+
+		return after >= before.EstablishedConnections, err
+	})
+
+	switch {
+	case after < before.EstablishedConnections:
+		status.Failure("CONNECTIONS LOST: %d connections were established before the upgrade, only %d are after %s",
+			before.EstablishedConnections, after, timeout)
+
+		return false
+	case err != nil:
+		status.Failure("Error retrieving the gateway connections: %v", err)
+		return false
+	default:
+		status.Success("%d connections were established before the upgrade, %d are now", before.EstablishedConnections, after)
+	}
+
+	return true
+}
+
+func countEstablishedConnections(clusterInfo *cluster.Info) (int, error) {
+	gateways, err := clusterInfo.GetGateways()
+	if err != nil {
+		return 0, errors.Wrap(err, "error retrieving the gateways")
+	}
+
+	established := 0
+
+	for i := range gateways {
+		for j := range gateways[i].Status.Connections {
+			if gateways[i].Status.Connections[j].Status == submarinerv1.Connected {
+				established++
+			}
+		}
+	}
+
+	return established, nil
+}
+
+// findServiceImport returns the first aggregated ServiceImport, i.e. one in a service's namespace rather than in the
+// operator namespace, or nil if there aren't any.
+func findServiceImport(ctx context.Context, clusterInfo *cluster.Info) (*types.NamespacedName, error) {
+	serviceImports, err := clusterInfo.ClientProducer.ForDynamic().Resource(gvr.FromMetaGroupVersion(mcsv1a1.GroupVersion,
+		"serviceimports")).Namespace(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, errors.Wrap(err, "error listing the ServiceImports")
+	}
+
+	if err != nil {
+		return nil, nil
+	}
+
+	found := []types.NamespacedName{}
+
+	for i := range serviceImports.Items {
+		if serviceImports.Items[i].GetNamespace() != constants.OperatorNamespace {
+			found = append(found, types.NamespacedName{
+				Namespace: serviceImports.Items[i].GetNamespace(),
+				Name:      serviceImports.Items[i].GetName(),
+			})
+		}
+	}
+
+	if len(found) == 0 {
+		return nil, nil
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].String() < found[j].String()
+	})
+
+	return &found[0], nil
+}
+
+func verifyServiceImport(ctx context.Context, clusterInfo *cluster.Info, name types.NamespacedName, timeout time.Duration,
+	status reporter.Interface,
+) bool {
+	status.Start("Checking that the ServiceImport %q still resolves", name)
+	defer status.End()
+
+	var problem string
+
+	err := poll(ctx, timeout, func() (bool, error) {
+		var err error
+
+		problem, err = serviceImportProblem(ctx, clusterInfo, name)
+
+		return problem == "", err
+	})
+
+	switch {
+	case problem != "":
+		status.Failure("SERVICE DISCOVERY BROKEN: the ServiceImport %q existed before the upgrade, after %s %s", name, timeout, problem)
+		return false
+	case err != nil:
+		status.Failure("Error checking the ServiceImport %q: %v", name, err)
+		return false
+	default:
+		status.Success("The ServiceImport %q still has endpoints", name)
+	}
+
+	return true
+}
+
+// serviceImportProblem returns a description of why the ServiceImport wouldn't resolve, or an empty string if it would.
+func serviceImportProblem(ctx context.Context, clusterInfo *cluster.Info, name types.NamespacedName) (string, error) {
+	_, err := clusterInfo.ClientProducer.ForDynamic().Resource(gvr.FromMetaGroupVersion(mcsv1a1.GroupVersion, "serviceimports")).
+		Namespace(name.Namespace).Get(ctx, name.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return "it no longer exists", nil
+	}
+
+	if err != nil {
+		return "", errors.Wrap(err, "error retrieving the ServiceImport")
+	}
+
+	endpointSlices, err := clusterInfo.ClientProducer.ForKubernetes().DiscoveryV1().EndpointSlices(name.Namespace).List(ctx,
+		metav1.ListOptions{
+			LabelSelector: labels.SelectorFromSet(map[string]string{
+				discovery.LabelManagedBy: lhconstants.LabelValueManagedBy,
+				mcsv1a1.LabelServiceName: name.Name,
+			}).String(),
+		})
+	if err != nil {
+		return "", errors.Wrap(err, "error listing the EndpointSlices")
+	}
+
+	if len(endpointSlices.Items) == 0 {
+		return "it has no EndpointSlices", nil
+	}
+
+	return "", nil
+}
+
+// poll runs the check until it succeeds or the timeout expires; errors are retried, and the last one is returned if the
+// check doesn't succeed.
+func poll(ctx context.Context, timeout time.Duration, check func() (bool, error)) error {
+	var lastErr error
+
+	_ = wait.PollUntilContextTimeout(ctx, checkInterval, timeout, true, func(_ context.Context) (bool, error) {
+		done, err := check()
+		lastErr = err
+
+		return done && err == nil, nil
+	})
+
+	return lastErr
+}
@@ -0,0 +1,202 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postupgrade_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/admiral/pkg/reporter"
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	"github.com/submariner-io/subctl/internal/constants"
+	"github.com/submariner-io/subctl/pkg/client"
+	"github.com/submariner-io/subctl/pkg/cluster"
+	"github.com/submariner-io/subctl/pkg/postupgrade"
+	"github.com/submariner-io/submariner-operator/api/v1alpha1"
+	submarinerv1 "github.com/submariner-io/submariner/pkg/apis/submariner.io/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	discovery "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+	fakecontroller "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+const timeout = 100 * time.Millisecond
+
+var _ = Describe("Post-upgrade checks", func() {
+	serviceImportsGVR := schema.GroupVersionResource{Group: mcsv1a1.GroupName, Version: "v1alpha1", Resource: "serviceimports"}
+
+	var (
+		kubeClient    *fakeclientset.Clientset
+		dynamicClient *dynamicfake.FakeDynamicClient
+		gateway       *submarinerv1.Gateway
+		clusterInfo   *cluster.Info
+	)
+
+	BeforeEach(func() {
+		kubeClient = fakeclientset.NewClientset(
+			&appsv1.DaemonSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "submariner-gateway", Namespace: constants.OperatorNamespace},
+				Status:     appsv1.DaemonSetStatus{DesiredNumberScheduled: 2, UpdatedNumberScheduled: 2, NumberAvailable: 2},
+			},
+			&discovery.EndpointSlice{ObjectMeta: metav1.ObjectMeta{
+				Name:      "nginx-east",
+				Namespace: "default",
+				Labels: map[string]string{
+					discovery.LabelManagedBy: lhconstants.LabelValueManagedBy,
+					mcsv1a1.LabelServiceName: "nginx",
+				},
+			}},
+		)
+
+		dynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+			map[schema.GroupVersionResource]string{serviceImportsGVR: "ServiceImportList"},
+			newServiceImport(constants.OperatorNamespace, "nginx-default-east"), newServiceImport("default", "nginx"))
+
+		gateway = &submarinerv1.Gateway{
+			ObjectMeta: metav1.ObjectMeta{Name: "gateway", Namespace: constants.OperatorNamespace},
+			Status: submarinerv1.GatewayStatus{Connections: []submarinerv1.Connection{
+				{Status: submarinerv1.Connected},
+				{Status: submarinerv1.Connected},
+				{Status: submarinerv1.Connecting},
+			}},
+		}
+
+		clusterInfo = &cluster.Info{
+			Name:             "east",
+			Submariner:       &v1alpha1.Submariner{},
+			ServiceDiscovery: &v1alpha1.ServiceDiscovery{},
+		}
+	})
+
+	JustBeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(submarinerv1.AddToScheme(scheme)).To(Succeed())
+
+		clusterInfo.ClientProducer = &client.DefaultProducer{
+			KubeClient:    kubeClient,
+			DynamicClient: dynamicClient,
+			GeneralClient: fakecontroller.NewClientBuilder().WithScheme(scheme).WithObjects(gateway).Build(),
+		}
+	})
+
+	Describe("CaptureState", func() {
+		It("should count the established connections and pick an aggregated ServiceImport", func() {
+			state, err := postupgrade.CaptureState(context.TODO(), clusterInfo)
+			Expect(err).To(Succeed())
+			Expect(state.EstablishedConnections).To(Equal(2))
+			Expect(state.ServiceImport).To(Equal(&types.NamespacedName{Namespace: "default", Name: "nginx"}))
+		})
+
+		When("service discovery isn't installed", func() {
+			BeforeEach(func() {
+				clusterInfo.ServiceDiscovery = nil
+			})
+
+			It("should not pick a ServiceImport", func() {
+				state, err := postupgrade.CaptureState(context.TODO(), clusterInfo)
+				Expect(err).To(Succeed())
+				Expect(state.ServiceImport).To(BeNil())
+			})
+		})
+	})
+
+	Describe("Verify", func() {
+		var before *postupgrade.State
+
+		BeforeEach(func() {
+			before = &postupgrade.State{
+				EstablishedConnections: 2,
+				ServiceImport:          &types.NamespacedName{Namespace: "default", Name: "nginx"},
+			}
+		})
+
+		verify := func() error {
+			return postupgrade.Verify(context.TODO(), clusterInfo, before, timeout, reporter.Silent())
+		}
+
+		It("should succeed if the cluster is back to its previous state", func() {
+			Expect(verify()).To(Succeed())
+		})
+
+		When("a rollout isn't complete", func() {
+			BeforeEach(func() {
+				kubeClient = fakeclientset.NewClientset(&appsv1.DaemonSet{
+					ObjectMeta: metav1.ObjectMeta{Name: "submariner-gateway", Namespace: constants.OperatorNamespace},
+					Status:     appsv1.DaemonSetStatus{DesiredNumberScheduled: 2, UpdatedNumberScheduled: 1, NumberAvailable: 2},
+				})
+				before.ServiceImport = nil
+			})
+
+			It("should fail", func() {
+				Expect(verify()).ToNot(Succeed())
+			})
+		})
+
+		When("fewer connections are established than before", func() {
+			BeforeEach(func() {
+				before.EstablishedConnections = 3
+			})
+
+			It("should fail", func() {
+				Expect(verify()).ToNot(Succeed())
+			})
+		})
+
+		When("the ServiceImport no longer exists", func() {
+			BeforeEach(func() {
+				before.ServiceImport = &types.NamespacedName{Namespace: "default", Name: "httpd"}
+			})
+
+			It("should fail", func() {
+				Expect(verify()).ToNot(Succeed())
+			})
+		})
+
+		When("the ServiceImport has no EndpointSlices", func() {
+			BeforeEach(func() {
+				dynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+					map[schema.GroupVersionResource]string{serviceImportsGVR: "ServiceImportList"}, newServiceImport("other", "nginx"))
+				before.ServiceImport = &types.NamespacedName{Namespace: "other", Name: "nginx"}
+			})
+
+			It("should fail", func() {
+				Expect(verify()).ToNot(Succeed())
+			})
+		})
+	})
+})
+
+func newServiceImport(namespace, name string) *unstructured.Unstructured {
+	serviceImport := &unstructured.Unstructured{}
+	serviceImport.SetAPIVersion(mcsv1a1.GroupVersion.String())
+	serviceImport.SetKind("ServiceImport")
+	serviceImport.SetNamespace(namespace)
+	serviceImport.SetName(name)
+
+	return serviceImport
+}
@@ -21,6 +21,7 @@ package serviceaccount
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -40,8 +41,13 @@ import (
 const (
 	createdByAnnotation = "kubernetes.io/created-by"
 	creatorName         = "subctl"
+	tokenPollInterval   = 200 * time.Millisecond
 )
 
+// DefaultTokenWaitTimeout is the default time to wait for the token controller to populate a token Secret; it can take
+// more than 30 seconds on busy clusters.
+const DefaultTokenWaitTimeout = 60 * time.Second
+
 func ensure(ctx context.Context, kubeClient kubernetes.Interface, namespace string, sa *corev1.ServiceAccount) (bool, error) {
 	result, err := util.CreateOrUpdate(ctx, resource.ForServiceAccount(kubeClient, namespace), sa, util.Replace(sa))
 
@@ -71,7 +77,10 @@ func EnsureFromYAML(ctx context.Context, kubeClient kubernetes.Interface, namesp
 	return ensure(ctx, kubeClient, namespace, sa)
 }
 
-func EnsureTokenSecret(ctx context.Context, client kubernetes.Interface, namespace, saName string) (*corev1.Secret, error) {
+// EnsureTokenSecret ensures that a token Secret exists for the given service account, and waits up to the given timeout
+// (DefaultTokenWaitTimeout if zero) for the token controller to populate it.
+func EnsureTokenSecret(ctx context.Context, client kubernetes.Interface, namespace, saName string, timeout time.Duration,
+) (*corev1.Secret, error) {
 	saSecret, err := GetTokenSecretFor(ctx, client, namespace, saName)
 	if apierrors.IsNotFound(err) {
 		newSecret := &corev1.Secret{
@@ -97,30 +106,96 @@ func EnsureTokenSecret(ctx context.Context, client kubernetes.Interface, namespa
 		return saSecret, nil
 	}
 
-	// Ensure the token has been generated for the secret.
-	backoff := wait.Backoff{
-		Steps:    15,
-		Duration: 30 * time.Millisecond,
-		Factor:   1.3,
-		Jitter:   1,
+	if timeout <= 0 {
+		timeout = DefaultTokenWaitTimeout
 	}
 
-	err = wait.ExponentialBackoff(backoff, func() (bool, error) {
-		saSecret, err = client.CoreV1().Secrets(namespace).Get(ctx, saSecret.Name, metav1.GetOptions{})
+	// Ensure the token has been generated for the secret.
+	secretName := saSecret.Name
+
+	err = wait.PollUntilContextTimeout(ctx, tokenPollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+		saSecret, err = client.CoreV1().Secrets(namespace).Get(ctx, secretName, metav1.GetOptions{})
 		if err != nil {
-			return false, errors.Wrapf(err, "error getting secret %q", saSecret.Name)
+			return false, errors.Wrapf(err, "error getting secret %q", secretName)
 		}
 
 		return len(saSecret.Data["token"]) > 0, nil
 	})
 
 	if wait.Interrupted(err) {
-		return nil, fmt.Errorf("the token was not generated for secret %q", saSecret.Name)
+		return nil, fmt.Errorf("the token was not generated for secret %q within %v: %s", secretName, timeout,
+			tokenDiagnostics(ctx, client, namespace, saName, saSecret))
 	}
 
 	return saSecret, err //nolint:wrapcheck // No need to wrap here
 }
 
+// kubeControllerManagers lists the namespaces and labels of the kube-controller-manager pods, which run the token
+// controller, on Kubernetes and OpenShift.
+var kubeControllerManagers = []struct {
+	namespace string
+	selector  string
+}{
+	{namespace: metav1.NamespaceSystem, selector: "component=kube-controller-manager"},
+	{namespace: "openshift-kube-controller-manager", selector: "app=kube-controller-manager"},
+}
+
+// tokenDiagnostics describes the likely causes of a token not being generated for the given Secret.
+func tokenDiagnostics(ctx context.Context, client kubernetes.Interface, namespace, saName string, saSecret *corev1.Secret) string {
+	details := []string{}
+
+	_, err := client.CoreV1().ServiceAccounts(namespace).Get(ctx, saName, metav1.GetOptions{})
+
+	switch {
+	case apierrors.IsNotFound(err):
+		details = append(details, fmt.Sprintf("the service account %q doesn't exist", saName))
+	case err != nil:
+		details = append(details, fmt.Sprintf("the service account %q couldn't be retrieved (%v)", saName, err))
+	default:
+		details = append(details, fmt.Sprintf("the service account %q exists", saName))
+	}
+
+	if saSecret != nil && saSecret.Annotations[corev1.ServiceAccountNameKey] != saName {
+		details = append(details, fmt.Sprintf("the secret's %q annotation is %q instead of %q", corev1.ServiceAccountNameKey,
+			saSecret.Annotations[corev1.ServiceAccountNameKey], saName))
+	}
+
+	return strings.Join(append(details, tokenControllerHealth(ctx, client)), "; ")
+}
+
+func tokenControllerHealth(ctx context.Context, client kubernetes.Interface) string {
+	for _, manager := range kubeControllerManagers {
+		pods, err := client.CoreV1().Pods(manager.namespace).List(ctx, metav1.ListOptions{LabelSelector: manager.selector})
+		if err != nil || len(pods.Items) == 0 {
+			continue
+		}
+
+		ready := 0
+
+		for i := range pods.Items {
+			if isPodReady(&pods.Items[i]) {
+				ready++
+			}
+		}
+
+		return fmt.Sprintf("%d of the %d kube-controller-manager pods in namespace %q, which run the token controller, are ready",
+			ready, len(pods.Items), manager.namespace)
+	}
+
+	return "the kube-controller-manager pods, which run the token controller, couldn't be found (they aren't visible on some" +
+		" managed clusters)"
+}
+
+func isPodReady(pod *corev1.Pod) bool {
+	for i := range pod.Status.Conditions {
+		if pod.Status.Conditions[i].Type == corev1.PodReady {
+			return pod.Status.Conditions[i].Status == corev1.ConditionTrue
+		}
+	}
+
+	return false
+}
+
 func GetTokenSecretFor(ctx context.Context, kubeClient kubernetes.Interface, namespace, serviceAccountName string,
 ) (*corev1.Secret, error) {
 	saSecrets, err := kubeClient.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{
@@ -20,6 +20,8 @@ package serviceaccount_test
 
 import (
 	"context"
+	"sync/atomic"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -121,7 +123,7 @@ var _ = Describe("EnsureTokenSecret", func() {
 
 	When("the Secret doesn't exist", func() {
 		It("should create it", func() {
-			secret, err := serviceaccount.EnsureTokenSecret(context.Background(), t.client, namespace, saName)
+			secret, err := serviceaccount.EnsureTokenSecret(context.Background(), t.client, namespace, saName, time.Second)
 			Expect(err).To(Succeed())
 			Expect(secret.Type).To(Equal(corev1.SecretTypeServiceAccountToken))
 			Expect(secret.Annotations).To(HaveKeyWithValue(corev1.ServiceAccountNameKey, saName))
@@ -142,15 +144,75 @@ var _ = Describe("EnsureTokenSecret", func() {
 		})
 
 		It("should succeed", func() {
-			secret, err := serviceaccount.EnsureTokenSecret(context.Background(), t.client, namespace, saName)
+			secret, err := serviceaccount.EnsureTokenSecret(context.Background(), t.client, namespace, saName, time.Second)
 			Expect(err).To(Succeed())
 			t.assertSecret(secret)
 		})
 	})
+
+	When("the token controller is slow to populate the token", func() {
+		BeforeEach(func() {
+			t.tokenDelay.Store(int64(500 * time.Millisecond))
+		})
+
+		It("should wait for it", func() {
+			secret, err := serviceaccount.EnsureTokenSecret(context.Background(), t.client, namespace, saName, 5*time.Second)
+			Expect(err).To(Succeed())
+			Expect(secret.Data).To(HaveKey("token"))
+			t.assertSecret(secret)
+		})
+	})
+
+	When("the token isn't populated within the timeout", func() {
+		BeforeEach(func() {
+			t.tokenDelay.Store(int64(time.Hour))
+
+			_, err := t.client.CoreV1().ServiceAccounts(namespace).Create(context.Background(), &corev1.ServiceAccount{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: saName,
+				},
+			}, metav1.CreateOptions{})
+			Expect(err).To(Succeed())
+
+			_, err = t.client.CoreV1().Pods(metav1.NamespaceSystem).Create(context.Background(), &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "kube-controller-manager",
+					Labels: map[string]string{"component": "kube-controller-manager"},
+				},
+				Status: corev1.PodStatus{
+					Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionFalse}},
+				},
+			}, metav1.CreateOptions{})
+			Expect(err).To(Succeed())
+		})
+
+		It("should return an error with diagnostic details", func() {
+			_, err := serviceaccount.EnsureTokenSecret(context.Background(), t.client, namespace, saName, 500*time.Millisecond)
+			Expect(err).To(MatchError(And(
+				ContainSubstring("the token was not generated"),
+				ContainSubstring("the service account %q exists", saName),
+				ContainSubstring("0 of the 1 kube-controller-manager pods"))))
+		})
+	})
+
+	When("the token isn't populated and the service account doesn't exist", func() {
+		BeforeEach(func() {
+			t.tokenDelay.Store(int64(time.Hour))
+		})
+
+		It("should report it", func() {
+			_, err := serviceaccount.EnsureTokenSecret(context.Background(), t.client, namespace, saName, 500*time.Millisecond)
+			Expect(err).To(MatchError(And(
+				ContainSubstring("the service account %q doesn't exist", saName),
+				ContainSubstring("kube-controller-manager pods, which run the token controller, couldn't be found"))))
+		})
+	})
 })
 
 type testDriver struct {
 	client *fakeclientset.Clientset
+	// tokenDelay is how long the fake token controller takes to populate tokens
+	tokenDelay *atomic.Int64
 }
 
 func newTestDriver() *testDriver {
@@ -158,6 +220,8 @@ func newTestDriver() *testDriver {
 
 	BeforeEach(func() {
 		t.client = fakeclientset.NewClientset()
+		tokenDelay := &atomic.Int64{}
+		t.tokenDelay = tokenDelay
 		stopCh := make(chan struct{})
 
 		_, informer := cache.NewInformerWithOptions(cache.InformerOptions{
@@ -172,11 +236,26 @@ func newTestDriver() *testDriver {
 			ObjectType: &corev1.Secret{},
 			Handler: cache.ResourceEventHandlerFuncs{
 				AddFunc: func(obj interface{}) {
-					s := obj.(*corev1.Secret)
+					s := obj.(*corev1.Secret).DeepCopy()
 					s.Data = map[string][]byte{"token": {1, 2, 3}}
 
-					_, err := t.client.CoreV1().Secrets(namespace).Update(context.Background(), s, metav1.UpdateOptions{})
-					Expect(err).To(Succeed())
+					delay := time.Duration(tokenDelay.Load())
+					if delay == 0 {
+						_, err := t.client.CoreV1().Secrets(namespace).Update(context.Background(), s, metav1.UpdateOptions{})
+						Expect(err).To(Succeed())
+
+						return
+					}
+
+					go func() {
+						defer GinkgoRecover()
+
+						select {
+						case <-time.After(delay):
+							_, _ = t.client.CoreV1().Secrets(namespace).Update(context.Background(), s, metav1.UpdateOptions{})
+						case <-stopCh:
+						}
+					}()
 				},
 			},
 		})
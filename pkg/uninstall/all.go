@@ -42,14 +42,41 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/utils/set"
 	controller "sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 const componentReadyTimeout = time.Minute * 2
 
-func All(clients client.Producer, clusterName, submarinerNamespace string,
+// How long the operator is given to delete the Submariner resources before their finalizers are checked, and how often
+// the deletion is checked; these are variables so that the tests don't have to wait.
+var (
+	deletionTimeout       = componentReadyTimeout + time.Second*30
+	deletionCheckInterval = 2 * time.Second
+)
+
+// All uninstalls Submariner from the cluster. With removeDNSConfig, the forwarding to the Lighthouse DNS server which the
+// operator added to the cluster DNS configuration is also removed, in case the operator didn't clean it up.
+func All(clients client.Producer, clusterName, submarinerNamespace string, removeDNSConfig bool,
 	status reporter.Interface,
 ) error {
+	// The member components must be identified before they're deleted, so that if the broker is also deployed on this
+	// cluster, their own registration with it isn't mistaken for another cluster using it
+	localClusterID, err := findLocalClusterID(clients.ForGeneral(), clusterName, submarinerNamespace)
+	if err != nil {
+		return status.Error(err, "Error determining the local cluster ID")
+	}
+
+	// The Lighthouse DNS server's address must also be determined before it's deleted
+	dns := &lighthouseDNS{}
+
+	if removeDNSConfig {
+		dns, err = findLighthouseDNS(clients, submarinerNamespace)
+		if err != nil {
+			return status.Error(err, "Error determining the Lighthouse DNS server")
+		}
+	}
+
 	found, err := ensureSubmarinerDeleted(clients, clusterName, submarinerNamespace, status)
 	if err != nil {
 		return err
@@ -62,12 +89,19 @@ func All(clients client.Producer, clusterName, submarinerNamespace string,
 		}
 	}
 
+	if dns.installed {
+		err = removeLighthouseDNSConfig(clients, clusterName, dns, status)
+		if err != nil {
+			return err
+		}
+	}
+
 	brokerNS, err := findBrokerNamespace(clients.ForGeneral(), clusterName, status)
 	if err != nil {
 		return err
 	}
 
-	deleted, err := deleteBrokerIfUnused(clients, brokerNS, clusterName, status)
+	deleted, err := deleteBrokerIfUnused(clients, brokerNS, localClusterID, status)
 	if err != nil {
 		return err
 	}
@@ -246,19 +280,17 @@ func ensureServiceDiscoveryDeleted(clients client.Producer, clusterName, namespa
 }
 
 func ensureDeleted(clients client.Producer, obj controller.Object, status reporter.Interface) error {
-	const maxWait = componentReadyTimeout + time.Second*30
-	const checkInterval = 2 * time.Second
-
 	awaitDeleted := func() error {
 		//nolint:wrapcheck // No need to wrap
-		return wait.PollUntilContextTimeout(context.Background(), checkInterval, maxWait, true, func(ctx context.Context) (bool, error) {
-			err := clients.ForGeneral().Delete(ctx, obj)
-			if apierrors.IsNotFound(err) {
-				return true, nil
-			}
+		return wait.PollUntilContextTimeout(context.Background(), deletionCheckInterval, deletionTimeout, true,
+			func(ctx context.Context) (bool, error) {
+				err := clients.ForGeneral().Delete(ctx, obj)
+				if apierrors.IsNotFound(err) {
+					return true, nil
+				}
 
-			return false, err
-		})
+				return false, err
+			})
 	}
 
 	err := awaitDeleted()
@@ -308,7 +340,7 @@ func ensureDeleted(clients client.Producer, obj controller.Object, status report
 	return err
 }
 
-func deleteBrokerIfUnused(clients client.Producer, namespace, clusterName string, status reporter.Interface) (bool, error) {
+func deleteBrokerIfUnused(clients client.Producer, namespace, localClusterID string, status reporter.Interface) (bool, error) {
 	if namespace == "" {
 		return true, nil
 	}
@@ -322,7 +354,7 @@ func deleteBrokerIfUnused(clients client.Producer, namespace, clusterName string
 		return false, status.Error(err, "Error retrieving broker namespace %q", namespace)
 	}
 
-	inUse, err := brokerInUse(clients.ForGeneral(), namespace, clusterName, status)
+	inUse, err := brokerInUse(clients.ForGeneral(), namespace, localClusterID, status)
 	if err != nil {
 		return false, err
 	}
@@ -342,29 +374,41 @@ func deleteBrokerIfUnused(clients client.Producer, namespace, clusterName string
 	return true, nil
 }
 
-func brokerInUse(controllerClient controller.Client, namespace, clusterName string, status reporter.Interface) (bool, error) {
+// brokerInUse determines whether any cluster other than the local one is registered with the broker. Clusters with
+// only service discovery don't have Endpoints, so both Endpoints and Clusters are checked.
+func brokerInUse(controllerClient controller.Client, namespace, localClusterID string, status reporter.Interface) (bool, error) {
 	status.Start("Verifying broker namespace %q is not in use", namespace)
 	defer status.End()
 
+	remoteClusterIDs := set.New[string]()
+
 	endpoints := &submarinerv1.EndpointList{}
 
 	err := controllerClient.List(context.TODO(), endpoints, controller.InNamespace(namespace))
-	if err != nil {
+	if err != nil && !resource.IsNotFoundErr(err) {
 		return false, status.Error(err, "error retrieving Endpoints")
 	}
 
-	var remoteClusterNames []string
-
 	for i := range endpoints.Items {
-		remoteClusterName := endpoints.Items[i].Spec.ClusterID
-		if remoteClusterName != clusterName {
-			remoteClusterNames = append(remoteClusterNames, remoteClusterName)
-		}
+		remoteClusterIDs.Insert(endpoints.Items[i].Spec.ClusterID)
 	}
 
-	if len(remoteClusterNames) > 0 {
+	clusters := &submarinerv1.ClusterList{}
+
+	err = controllerClient.List(context.TODO(), clusters, controller.InNamespace(namespace))
+	if err != nil && !resource.IsNotFoundErr(err) {
+		return false, status.Error(err, "error retrieving Clusters")
+	}
+
+	for i := range clusters.Items {
+		remoteClusterIDs.Insert(clusters.Items[i].Spec.ClusterID)
+	}
+
+	remoteClusterIDs.Delete(localClusterID)
+
+	if remoteClusterIDs.Len() > 0 {
 		status.Warning("Broker namespace %q appears to be in use by other clusters (%v) - keeping the broker components.",
-			namespace, remoteClusterNames)
+			namespace, remoteClusterIDs.SortedList())
 
 		return true, nil
 	}
@@ -372,6 +416,43 @@ func brokerInUse(controllerClient controller.Client, namespace, clusterName stri
 	return false, nil
 }
 
+// findLocalClusterID returns the cluster ID used by the member components on this cluster, defaulting to the cluster name
+// if they aren't installed.
+func findLocalClusterID(controllerClient controller.Client, clusterName, namespace string) (string, error) {
+	submariner := &operatorv1alpha1.Submariner{}
+
+	err := controllerClient.Get(context.TODO(), controller.ObjectKey{Namespace: namespace, Name: opnames.SubmarinerCrName}, submariner)
+	if err == nil {
+		return submariner.Spec.ClusterID, nil
+	}
+
+	if !resource.IsNotFoundErr(err) {
+		return "", errors.Wrap(err, "error retrieving the Submariner resource")
+	}
+
+	serviceDiscovery := &operatorv1alpha1.ServiceDiscovery{}
+
+	err = controllerClient.Get(context.TODO(), controller.ObjectKey{Namespace: namespace, Name: opnames.ServiceDiscoveryCrName},
+		serviceDiscovery)
+	if err == nil {
+		return serviceDiscovery.Spec.ClusterID, nil
+	}
+
+	if !resource.IsNotFoundErr(err) {
+		return "", errors.Wrap(err, "error retrieving the ServiceDiscovery resource")
+	}
+
+	return clusterName, nil
+}
+
+// HostsBroker determines whether the broker component is installed on the cluster. When uninstalling several clusters,
+// those hosting the broker must be processed last, so that the others can still remove their registration from it.
+func HostsBroker(clients client.Producer, clusterName string, status reporter.Interface) (bool, error) {
+	brokerNS, err := findBrokerNamespace(clients.ForGeneral(), clusterName, status)
+
+	return brokerNS != "", err
+}
+
 func findBrokerNamespace(controllerClient controller.Client, clusterName string, status reporter.Interface) (string, error) {
 	status.Start("Checking if the broker component is installed on cluster %q", clusterName)
 	defer status.End()
@@ -0,0 +1,564 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package uninstall_test
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/admiral/pkg/names"
+	"github.com/submariner-io/admiral/pkg/reporter"
+	"github.com/submariner-io/subctl/internal/constants"
+	"github.com/submariner-io/subctl/pkg/client"
+	"github.com/submariner-io/subctl/pkg/uninstall"
+	operatorv1alpha1 "github.com/submariner-io/submariner-operator/api/v1alpha1"
+	opnames "github.com/submariner-io/submariner-operator/pkg/names"
+	submarinerv1 "github.com/submariner-io/submariner/pkg/apis/submariner.io/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	controller "sigs.k8s.io/controller-runtime/pkg/client"
+	fakecontroller "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const (
+	clusterName    = "cluster-1"
+	localClusterID = "east"
+	otherBrokerNS  = "other-broker"
+)
+
+var _ = Describe("All", func() {
+	var (
+		kubeClient      *fakeclientset.Clientset
+		dynamicClient   *fakedynamic.FakeDynamicClient
+		generalClient   controller.Client
+		objects         []controller.Object
+		removeDNSConfig bool
+	)
+
+	BeforeEach(func() {
+		DeferCleanup(uninstall.SetDeletionTimeouts(200*time.Millisecond, 10*time.Millisecond))
+
+		kubeClient = fakeclientset.NewClientset(
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: constants.OperatorNamespace}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: constants.DefaultBrokerNamespace}},
+			&corev1.Node{ObjectMeta: metav1.ObjectMeta{
+				Name:   "gateway",
+				Labels: map[string]string{constants.SubmarinerGatewayLabel: constants.TrueLabel, "other": "label"},
+			}},
+			newClusterRoleBinding("submariner-operator"),
+			newClusterRoleBinding("submariner-gateway"),
+			newClusterRoleBinding("other"),
+		)
+
+		dynamicClient = fakedynamic.NewSimpleDynamicClient(runtime.NewScheme())
+		// The DNS configuration is removed by default, see the uninstall command's --keep-dns-config
+		removeDNSConfig = true
+
+		objects = []controller.Object{
+			&operatorv1alpha1.Broker{
+				ObjectMeta: metav1.ObjectMeta{Namespace: constants.DefaultBrokerNamespace, Name: "submariner-broker"},
+			},
+		}
+	})
+
+	uninstallAll := func() error {
+		testScheme := runtime.NewScheme()
+		Expect(scheme.AddToScheme(testScheme)).To(Succeed())
+		Expect(apiextensionsv1.AddToScheme(testScheme)).To(Succeed())
+		Expect(operatorv1alpha1.AddToScheme(testScheme)).To(Succeed())
+		Expect(submarinerv1.AddToScheme(testScheme)).To(Succeed())
+
+		generalClient = fakecontroller.NewClientBuilder().WithScheme(testScheme).WithObjects(objects...).Build()
+
+		return uninstall.All(&client.DefaultProducer{KubeClient: kubeClient, DynamicClient: dynamicClient, GeneralClient: generalClient},
+			clusterName, constants.OperatorNamespace, removeDNSConfig, reporter.Silent())
+	}
+
+	runUninstall := func() {
+		Expect(uninstallAll()).To(Succeed())
+	}
+
+	namespaceExists := func(name string) bool {
+		_, err := kubeClient.CoreV1().Namespaces().Get(context.TODO(), name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return false
+		}
+
+		Expect(err).To(Succeed())
+
+		return true
+	}
+
+	objectExists := func(obj controller.Object) bool {
+		err := generalClient.Get(context.TODO(), controller.ObjectKeyFromObject(obj), obj)
+		if apierrors.IsNotFound(err) {
+			return false
+		}
+
+		Expect(err).To(Succeed())
+
+		return true
+	}
+
+	clusterRoleBindingExists := func(name string) bool {
+		_, err := kubeClient.RbacV1().ClusterRoleBindings().Get(context.TODO(), name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return false
+		}
+
+		Expect(err).To(Succeed())
+
+		return true
+	}
+
+	brokerNamespaceExists := func() bool {
+		return namespaceExists(constants.DefaultBrokerNamespace)
+	}
+
+	joinLocalCluster := func(brokerNamespace string) {
+		objects = append(objects, &operatorv1alpha1.Submariner{
+			ObjectMeta: metav1.ObjectMeta{Namespace: constants.OperatorNamespace, Name: opnames.SubmarinerCrName},
+			Spec: operatorv1alpha1.SubmarinerSpec{
+				ClusterID:                localClusterID,
+				BrokerK8sRemoteNamespace: brokerNamespace,
+			},
+		})
+	}
+
+	registerWithBroker := func(clusterID string, withEndpoint bool) {
+		objects = append(objects, &submarinerv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Namespace: constants.DefaultBrokerNamespace, Name: clusterID},
+			Spec:       submarinerv1.ClusterSpec{ClusterID: clusterID},
+		})
+
+		if withEndpoint {
+			objects = append(objects, &submarinerv1.Endpoint{
+				ObjectMeta: metav1.ObjectMeta{Namespace: constants.DefaultBrokerNamespace, Name: clusterID + "-endpoint"},
+				Spec:       submarinerv1.EndpointSpec{ClusterID: clusterID},
+			})
+		}
+	}
+
+	When("the broker was deployed and then the same cluster was joined to it", func() {
+		BeforeEach(func() {
+			joinLocalCluster(constants.DefaultBrokerNamespace)
+			registerWithBroker(localClusterID, true)
+		})
+
+		Context("and no other cluster is registered", func() {
+			It("should delete the broker", func() {
+				runUninstall()
+				Expect(brokerNamespaceExists()).To(BeFalse())
+			})
+		})
+
+		Context("and another cluster with connectivity is registered", func() {
+			BeforeEach(func() {
+				registerWithBroker("west", true)
+			})
+
+			It("should keep the broker", func() {
+				runUninstall()
+				Expect(brokerNamespaceExists()).To(BeTrue())
+			})
+		})
+
+		Context("and another cluster with only service discovery is registered", func() {
+			BeforeEach(func() {
+				registerWithBroker("west", false)
+			})
+
+			It("should keep the broker", func() {
+				runUninstall()
+				Expect(brokerNamespaceExists()).To(BeTrue())
+			})
+		})
+	})
+
+	When("the cluster was joined to another broker and then the broker was deployed", func() {
+		BeforeEach(func() {
+			joinLocalCluster(otherBrokerNS)
+		})
+
+		Context("and no cluster is registered with the local broker", func() {
+			It("should delete the broker", func() {
+				runUninstall()
+				Expect(brokerNamespaceExists()).To(BeFalse())
+			})
+		})
+
+		Context("and another cluster is registered with the local broker", func() {
+			BeforeEach(func() {
+				registerWithBroker("west", true)
+			})
+
+			It("should keep the broker", func() {
+				runUninstall()
+				Expect(brokerNamespaceExists()).To(BeTrue())
+			})
+		})
+	})
+
+	When("only the broker is deployed", func() {
+		Context("and another cluster is registered with it", func() {
+			BeforeEach(func() {
+				registerWithBroker("west", false)
+			})
+
+			It("should keep the broker", func() {
+				runUninstall()
+				Expect(brokerNamespaceExists()).To(BeTrue())
+			})
+		})
+
+		Context("and a stale registration from an earlier join of the local cluster remains", func() {
+			BeforeEach(func() {
+				registerWithBroker(clusterName, true)
+			})
+
+			It("should delete the broker", func() {
+				runUninstall()
+				Expect(brokerNamespaceExists()).To(BeFalse())
+			})
+		})
+	})
+
+	When("the broker is deleted", func() {
+		BeforeEach(func() {
+			joinLocalCluster(constants.DefaultBrokerNamespace)
+
+			objects = append(objects, newCRD("clusters.submariner.io"), newCRD("gateways.submariner.io"),
+				newCRD("serviceexports.multicluster.x-k8s.io"), newCRD("widgets.notsubmariner.io"))
+		})
+
+		It("should delete the Submariner resources, namespace, CRDs, cluster roles and gateway labels", func() {
+			runUninstall()
+
+			Expect(objectExists(&operatorv1alpha1.Submariner{ObjectMeta: metav1.ObjectMeta{
+				Namespace: constants.OperatorNamespace, Name: opnames.SubmarinerCrName,
+			}})).To(BeFalse())
+			Expect(namespaceExists(constants.OperatorNamespace)).To(BeFalse())
+
+			Expect(objectExists(newCRD("clusters.submariner.io"))).To(BeFalse())
+			Expect(objectExists(newCRD("gateways.submariner.io"))).To(BeFalse())
+			Expect(objectExists(newCRD("serviceexports.multicluster.x-k8s.io"))).To(BeTrue())
+			Expect(objectExists(newCRD("widgets.notsubmariner.io"))).To(BeTrue())
+
+			Expect(clusterRoleBindingExists("submariner-operator")).To(BeFalse())
+			Expect(clusterRoleBindingExists("submariner-gateway")).To(BeFalse())
+			Expect(clusterRoleBindingExists("other")).To(BeTrue())
+
+			node, err := kubeClient.CoreV1().Nodes().Get(context.TODO(), "gateway", metav1.GetOptions{})
+			Expect(err).To(Succeed())
+			Expect(node.Labels).To(Equal(map[string]string{"other": "label"}))
+		})
+	})
+
+	When("the broker is kept", func() {
+		BeforeEach(func() {
+			joinLocalCluster(constants.DefaultBrokerNamespace)
+			registerWithBroker("west", true)
+
+			objects = append(objects, newCRD("clusters.submariner.io"))
+		})
+
+		It("should keep the operator, the Submariner namespace and the CRDs", func() {
+			runUninstall()
+
+			Expect(namespaceExists(constants.OperatorNamespace)).To(BeTrue())
+			Expect(objectExists(newCRD("clusters.submariner.io"))).To(BeTrue())
+			Expect(clusterRoleBindingExists("submariner-operator")).To(BeTrue())
+			Expect(clusterRoleBindingExists("submariner-gateway")).To(BeFalse())
+		})
+	})
+
+	When("only the service discovery component is installed", func() {
+		serviceDiscovery := func() *operatorv1alpha1.ServiceDiscovery {
+			return &operatorv1alpha1.ServiceDiscovery{
+				ObjectMeta: metav1.ObjectMeta{Namespace: constants.OperatorNamespace, Name: opnames.ServiceDiscoveryCrName},
+				Spec:       operatorv1alpha1.ServiceDiscoverySpec{ClusterID: localClusterID},
+			}
+		}
+
+		BeforeEach(func() {
+			objects = append(objects, serviceDiscovery())
+		})
+
+		It("should delete the ServiceDiscovery resource", func() {
+			runUninstall()
+			Expect(objectExists(serviceDiscovery())).To(BeFalse())
+		})
+
+		Context("and its registration with the local broker remains", func() {
+			BeforeEach(func() {
+				registerWithBroker(localClusterID, false)
+			})
+
+			It("should delete the broker", func() {
+				runUninstall()
+				Expect(brokerNamespaceExists()).To(BeFalse())
+			})
+		})
+	})
+
+	When("service discovery is installed and forwarded to by the cluster DNS", func() {
+		const (
+			lighthouseIP = "10.96.0.100"
+			baseCorefile = ".:53 {\n    errors\n    forward . /etc/resolv.conf\n}\n"
+		)
+
+		lighthouseSection := func(forwardTo string) string {
+			return "#lighthouse-start AUTO-GENERATED SECTION. DO NOT EDIT\nclusterset.local:53 {\n    forward . " + forwardTo +
+				"\n}\n#lighthouse-end\n"
+		}
+
+		corefile := func() string {
+			configMap, err := kubeClient.CoreV1().ConfigMaps("kube-system").Get(context.TODO(), "coredns", metav1.GetOptions{})
+			Expect(err).To(Succeed())
+
+			return configMap.Data["Corefile"]
+		}
+
+		setCorefile := func(data string) {
+			Expect(kubeClient.Tracker().Add(&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "coredns"},
+				Data:       map[string]string{"Corefile": data},
+			})).To(Succeed())
+		}
+
+		BeforeEach(func() {
+			objects = append(objects, &operatorv1alpha1.ServiceDiscovery{
+				ObjectMeta: metav1.ObjectMeta{Namespace: constants.OperatorNamespace, Name: opnames.ServiceDiscoveryCrName},
+			})
+
+			Expect(kubeClient.Tracker().Add(&corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Namespace: constants.OperatorNamespace, Name: names.LighthouseCoreDNSComponent},
+				Spec:       corev1.ServiceSpec{ClusterIP: lighthouseIP},
+			})).To(Succeed())
+		})
+
+		Context("through the CoreDNS ConfigMap", func() {
+			BeforeEach(func() {
+				setCorefile(lighthouseSection(lighthouseIP) + baseCorefile)
+			})
+
+			It("should remove the Lighthouse section", func() {
+				runUninstall()
+				Expect(corefile()).To(Equal(baseCorefile))
+			})
+
+			Context("and the DNS configuration isn't to be removed", func() {
+				BeforeEach(func() {
+					removeDNSConfig = false
+				})
+
+				It("should leave the ConfigMap as is", func() {
+					runUninstall()
+					Expect(corefile()).To(Equal(lighthouseSection(lighthouseIP) + baseCorefile))
+				})
+			})
+		})
+
+		Context("through a CoreDNS ConfigMap whose Lighthouse section forwards elsewhere", func() {
+			BeforeEach(func() {
+				setCorefile(lighthouseSection("10.96.0.200") + baseCorefile)
+			})
+
+			It("should leave the ConfigMap as is", func() {
+				runUninstall()
+				Expect(corefile()).To(Equal(lighthouseSection("10.96.0.200") + baseCorefile))
+			})
+		})
+
+		Context("through a CoreDNS ConfigMap whose Lighthouse section was edited", func() {
+			edited := strings.Replace(lighthouseSection(lighthouseIP), "}", "    cache 30\n}", 1) + baseCorefile
+
+			BeforeEach(func() {
+				setCorefile(edited)
+			})
+
+			It("should leave the ConfigMap as is", func() {
+				runUninstall()
+				Expect(corefile()).To(Equal(edited))
+			})
+		})
+
+		Context("through the OpenShift DNS operator", func() {
+			server := func(name, upstream string) map[string]interface{} {
+				return map[string]interface{}{
+					"name":          name,
+					"zones":         []interface{}{"clusterset.local"},
+					"forwardPlugin": map[string]interface{}{"upstreams": []interface{}{upstream}},
+				}
+			}
+
+			BeforeEach(func() {
+				dnsOperator := &unstructured.Unstructured{Object: map[string]interface{}{
+					"spec": map[string]interface{}{
+						"servers": []interface{}{server("lighthouse", lighthouseIP), server("other", "10.96.0.200")},
+					},
+				}}
+				dnsOperator.SetAPIVersion("operator.openshift.io/v1")
+				dnsOperator.SetKind("DNS")
+				dnsOperator.SetName("default")
+
+				dynamicClient = fakedynamic.NewSimpleDynamicClient(runtime.NewScheme(), dnsOperator)
+			})
+
+			It("should remove the Lighthouse server", func() {
+				runUninstall()
+
+				dnsOperator, err := dynamicClient.Resource(schema.GroupVersionResource{
+					Group:    "operator.openshift.io",
+					Version:  "v1",
+					Resource: "dnses",
+				}).Get(context.TODO(), "default", metav1.GetOptions{})
+				Expect(err).To(Succeed())
+
+				servers, _, err := unstructured.NestedSlice(dnsOperator.Object, "spec", "servers")
+				Expect(err).To(Succeed())
+				Expect(servers).To(Equal([]interface{}{server("other", "10.96.0.200")}))
+			})
+		})
+	})
+
+	When("the Submariner resource has a finalizer which isn't removed", func() {
+		submariner := func() *operatorv1alpha1.Submariner {
+			return &operatorv1alpha1.Submariner{ObjectMeta: metav1.ObjectMeta{
+				Namespace:  constants.OperatorNamespace,
+				Name:       opnames.SubmarinerCrName,
+				Finalizers: []string{opnames.CleanupFinalizer},
+			}}
+		}
+
+		BeforeEach(func() {
+			objects = append(objects, submariner())
+		})
+
+		Context("and the operator deployment is missing", func() {
+			It("should force-delete the resource", func() {
+				runUninstall()
+				Expect(objectExists(submariner())).To(BeFalse())
+			})
+		})
+
+		Context("and the operator pod is missing", func() {
+			BeforeEach(func() {
+				Expect(kubeClient.Tracker().Add(newOperatorDeployment())).To(Succeed())
+			})
+
+			It("should force-delete the resource", func() {
+				runUninstall()
+				Expect(objectExists(submariner())).To(BeFalse())
+			})
+		})
+
+		Context("and the operator pod is running", func() {
+			BeforeEach(func() {
+				Expect(kubeClient.Tracker().Add(newOperatorDeployment())).To(Succeed())
+				Expect(kubeClient.Tracker().Add(&corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: constants.OperatorNamespace,
+						Name:      "submariner-operator-pod",
+						Labels:    newOperatorDeployment().Spec.Template.Labels,
+					},
+					Status: corev1.PodStatus{Phase: corev1.PodRunning},
+				})).To(Succeed())
+			})
+
+			It("should fail and keep the resource", func() {
+				Expect(uninstallAll()).ToNot(Succeed())
+				Expect(objectExists(submariner())).To(BeTrue())
+			})
+		})
+	})
+})
+
+var _ = Describe("HostsBroker", func() {
+	var objects []controller.Object
+
+	BeforeEach(func() {
+		objects = nil
+	})
+
+	hostsBroker := func() bool {
+		testScheme := runtime.NewScheme()
+		Expect(operatorv1alpha1.AddToScheme(testScheme)).To(Succeed())
+
+		generalClient := fakecontroller.NewClientBuilder().WithScheme(testScheme).WithObjects(objects...).Build()
+
+		found, err := uninstall.HostsBroker(&client.DefaultProducer{GeneralClient: generalClient}, clusterName, reporter.Silent())
+		Expect(err).To(Succeed())
+
+		return found
+	}
+
+	When("the broker component is installed", func() {
+		BeforeEach(func() {
+			objects = append(objects, &operatorv1alpha1.Broker{
+				ObjectMeta: metav1.ObjectMeta{Namespace: constants.DefaultBrokerNamespace, Name: "submariner-broker"},
+			})
+		})
+
+		It("should return true", func() {
+			Expect(hostsBroker()).To(BeTrue())
+		})
+	})
+
+	When("the broker component isn't installed", func() {
+		It("should return false", func() {
+			Expect(hostsBroker()).To(BeFalse())
+		})
+	})
+})
+
+func newClusterRoleBinding(name string) *rbacv1.ClusterRoleBinding {
+	return &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: name},
+	}
+}
+
+func newCRD(name string) *apiextensionsv1.CustomResourceDefinition {
+	return &apiextensionsv1.CustomResourceDefinition{ObjectMeta: metav1.ObjectMeta{Name: name}}
+}
+
+func newOperatorDeployment() *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: constants.OperatorNamespace, Name: "submariner-operator"},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"name": "submariner-operator"}},
+			},
+		},
+	}
+}
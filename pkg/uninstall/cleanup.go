@@ -0,0 +1,274 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package uninstall
+
+import (
+	"context"
+	"strings"
+
+	"github.com/submariner-io/admiral/pkg/reporter"
+	"github.com/submariner-io/admiral/pkg/resource"
+	"github.com/submariner-io/admiral/pkg/util"
+	"github.com/submariner-io/subctl/internal/constants"
+	"github.com/submariner-io/subctl/pkg/client"
+	operatorv1alpha1 "github.com/submariner-io/submariner-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/utils/set"
+	controller "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const submarinerGroupSuffix = "submariner.io"
+
+// The prefixes of the cluster roles and bindings created for the Submariner components, including the OpenShift ones
+var clusterRolePrefixes = []string{"submariner-", "ocp-submariner-"}
+
+// removeFinalizersPatch clears all the finalizers, so that resources can be deleted even if their controller is gone.
+var removeFinalizersPatch = []byte(`{"metadata":{"finalizers":null}}`)
+
+// ForceCleanup removes everything Submariner may have left on the cluster, without relying on the Submariner resources
+// to drive the process, typically after a partially-failed uninstall. It's idempotent: missing items are reported as
+// not found, and failures don't prevent the remaining items from being processed.
+func ForceCleanup(clients client.Producer, clusterName, submarinerNamespace string, status reporter.Interface) error {
+	cleanup := &forceCleanup{clients: clients, status: status}
+
+	// The broker namespaces must be determined before the Broker resources are deleted along with the CRDs
+	brokerNamespaces := cleanup.findBrokerNamespaces()
+
+	crds := cleanup.findCRDs(clusterName)
+	cleanup.deleteCustomResources(clusterName, crds)
+	cleanup.deleteCRDs(clusterName, crds)
+	cleanup.deleteClusterRolesAndBindings(clusterName)
+	cleanup.deleteNamespaces(clusterName, append([]string{submarinerNamespace}, brokerNamespaces...))
+	cleanup.unlabelGatewayNodes(clusterName)
+
+	return utilerrors.NewAggregate(cleanup.errs)
+}
+
+type forceCleanup struct {
+	clients client.Producer
+	status  reporter.Interface
+	errs    []error
+}
+
+// report reports the outcome of deleting (or updating) an item; not found errors aren't failures.
+func (c *forceCleanup) report(err error, kind, name, action string) {
+	switch {
+	case err == nil:
+		c.status.Success("%s %s %q", action, kind, name)
+	case apierrors.IsNotFound(err):
+		c.status.Success("%s %q not found", kind, name)
+	default:
+		c.errs = append(c.errs, c.status.Error(err, "Error processing %s %q", kind, name))
+	}
+}
+
+func (c *forceCleanup) findBrokerNamespaces() []string {
+	namespaces := set.New(constants.DefaultBrokerNamespace)
+
+	brokers := &operatorv1alpha1.BrokerList{}
+
+	err := c.clients.ForGeneral().List(context.TODO(), brokers, controller.InNamespace(metav1.NamespaceAll))
+	if err != nil && !resource.IsNotFoundErr(err) {
+		c.status.Warning("Unable to list the Broker resources, only the default broker namespace will be deleted: %v", err)
+	}
+
+	for i := range brokers.Items {
+		namespaces.Insert(brokers.Items[i].Namespace)
+	}
+
+	return namespaces.SortedList()
+}
+
+func (c *forceCleanup) findCRDs(clusterName string) []apiextensionsv1.CustomResourceDefinition {
+	c.status.Start("Looking for the Submariner custom resource definitions on cluster %q", clusterName)
+	defer c.status.End()
+
+	list := &apiextensionsv1.CustomResourceDefinitionList{}
+
+	err := c.clients.ForGeneral().List(context.TODO(), list)
+	if err != nil {
+		c.errs = append(c.errs, c.status.Error(err, "Error listing CustomResourceDefinitions"))
+		return nil
+	}
+
+	crds := []apiextensionsv1.CustomResourceDefinition{}
+
+	for i := range list.Items {
+		if isSubmarinerGroup(list.Items[i].Spec.Group) {
+			crds = append(crds, list.Items[i])
+		}
+	}
+
+	c.status.Success("Found %d Submariner custom resource definitions", len(crds))
+
+	return crds
+}
+
+// deleteCustomResources removes the finalizers from all the Submariner resources, then deletes them.
+func (c *forceCleanup) deleteCustomResources(clusterName string, crds []apiextensionsv1.CustomResourceDefinition) {
+	c.status.Start("Deleting the Submariner resources on cluster %q", clusterName)
+	defer c.status.End()
+
+	for i := range crds {
+		crd := &crds[i]
+
+		gvr := schema.GroupVersionResource{Group: crd.Spec.Group, Version: storageVersion(crd), Resource: crd.Spec.Names.Plural}
+		resourceClient := c.clients.ForDynamic().Resource(gvr)
+
+		list, err := resourceClient.List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			c.errs = append(c.errs, c.status.Error(err, "Error listing the %s resources", gvr.GroupResource()))
+			continue
+		}
+
+		for j := range list.Items {
+			obj := &list.Items[j]
+			name := obj.GetName()
+
+			if obj.GetNamespace() != "" {
+				name = obj.GetNamespace() + "/" + name
+			}
+
+			kind := crd.Spec.Names.Kind
+
+			if len(obj.GetFinalizers()) > 0 {
+				_, err = resourceClient.Namespace(obj.GetNamespace()).Patch(context.TODO(), obj.GetName(), types.MergePatchType,
+					removeFinalizersPatch, metav1.PatchOptions{})
+				if err != nil && !apierrors.IsNotFound(err) {
+					c.report(err, kind, name, "Removed the finalizers from")
+					continue
+				}
+			}
+
+			err = resourceClient.Namespace(obj.GetNamespace()).Delete(context.TODO(), obj.GetName(), metav1.DeleteOptions{})
+			c.report(err, kind, name, "Deleted")
+		}
+	}
+}
+
+func (c *forceCleanup) deleteCRDs(clusterName string, crds []apiextensionsv1.CustomResourceDefinition) {
+	c.status.Start("Deleting the Submariner custom resource definitions on cluster %q", clusterName)
+	defer c.status.End()
+
+	for i := range crds {
+		err := c.clients.ForGeneral().Delete(context.TODO(), &crds[i])
+		c.report(err, "CustomResourceDefinition", crds[i].Name, "Deleted")
+	}
+}
+
+func (c *forceCleanup) deleteClusterRolesAndBindings(clusterName string) {
+	c.status.Start("Deleting the Submariner cluster roles and bindings on cluster %q", clusterName)
+	defer c.status.End()
+
+	rbac := c.clients.ForKubernetes().RbacV1()
+
+	bindings, err := rbac.ClusterRoleBindings().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		c.errs = append(c.errs, c.status.Error(err, "Error listing ClusterRoleBindings"))
+	} else {
+		for i := range bindings.Items {
+			if hasClusterRolePrefix(bindings.Items[i].Name) {
+				err = rbac.ClusterRoleBindings().Delete(context.TODO(), bindings.Items[i].Name, metav1.DeleteOptions{})
+				c.report(err, "ClusterRoleBinding", bindings.Items[i].Name, "Deleted")
+			}
+		}
+	}
+
+	roles, err := rbac.ClusterRoles().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		c.errs = append(c.errs, c.status.Error(err, "Error listing ClusterRoles"))
+		return
+	}
+
+	for i := range roles.Items {
+		if hasClusterRolePrefix(roles.Items[i].Name) {
+			err = rbac.ClusterRoles().Delete(context.TODO(), roles.Items[i].Name, metav1.DeleteOptions{})
+			c.report(err, "ClusterRole", roles.Items[i].Name, "Deleted")
+		}
+	}
+}
+
+func (c *forceCleanup) deleteNamespaces(clusterName string, namespaces []string) {
+	c.status.Start("Deleting the Submariner namespaces on cluster %q", clusterName)
+	defer c.status.End()
+
+	for _, namespace := range set.New(namespaces...).SortedList() {
+		err := c.clients.ForKubernetes().CoreV1().Namespaces().Delete(context.TODO(), namespace, metav1.DeleteOptions{})
+		c.report(err, "namespace", namespace, "Deleted")
+	}
+}
+
+func (c *forceCleanup) unlabelGatewayNodes(clusterName string) {
+	c.status.Start("Unlabeling gateway nodes on cluster %q", clusterName)
+	defer c.status.End()
+
+	nodes := c.clients.ForKubernetes().CoreV1().Nodes()
+
+	list, err := nodes.List(context.TODO(), metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(map[string]string{constants.SubmarinerGatewayLabel: constants.TrueLabel}).String(),
+	})
+	if err != nil {
+		c.errs = append(c.errs, c.status.Error(err, "Error listing Nodes"))
+		return
+	}
+
+	nodeInterface := &resource.InterfaceFuncs[*corev1.Node]{
+		GetFunc:    nodes.Get,
+		UpdateFunc: nodes.Update,
+	}
+
+	for i := range list.Items {
+		err = util.Update[*corev1.Node](context.TODO(), nodeInterface, &list.Items[i], func(existing *corev1.Node) (*corev1.Node, error) {
+			delete(existing.Labels, constants.SubmarinerGatewayLabel)
+			return existing, nil
+		})
+		c.report(err, "Node", list.Items[i].Name, "Removed the gateway label from")
+	}
+}
+
+func storageVersion(crd *apiextensionsv1.CustomResourceDefinition) string {
+	for i := range crd.Spec.Versions {
+		if crd.Spec.Versions[i].Storage {
+			return crd.Spec.Versions[i].Name
+		}
+	}
+
+	return crd.Spec.Versions[0].Name
+}
+
+func isSubmarinerGroup(group string) bool {
+	return group == submarinerGroupSuffix || strings.HasSuffix(group, "."+submarinerGroupSuffix)
+}
+
+func hasClusterRolePrefix(name string) bool {
+	for _, prefix := range clusterRolePrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,155 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package uninstall_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/admiral/pkg/reporter"
+	"github.com/submariner-io/subctl/internal/constants"
+	"github.com/submariner-io/subctl/pkg/client"
+	"github.com/submariner-io/subctl/pkg/uninstall"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+	controller "sigs.k8s.io/controller-runtime/pkg/client"
+	fakecontroller "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("ForceCleanup", func() {
+	clustersGVR := schema.GroupVersionResource{Group: "submariner.io", Version: "v1", Resource: "clusters"}
+
+	var (
+		kubeClient    *fakeclientset.Clientset
+		dynamicClient *dynamicfake.FakeDynamicClient
+		generalClient controller.Client
+	)
+
+	BeforeEach(func() {
+		kubeClient = fakeclientset.NewClientset(
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: constants.OperatorNamespace}},
+			&corev1.Node{ObjectMeta: metav1.ObjectMeta{
+				Name:   "gateway",
+				Labels: map[string]string{constants.SubmarinerGatewayLabel: constants.TrueLabel},
+			}},
+			&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "submariner-gateway"}},
+			&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "ocp-submariner-gateway"}},
+			&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "other"}},
+			&rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: "submariner-gateway"}},
+		)
+
+		cluster := &unstructured.Unstructured{}
+		cluster.SetAPIVersion("submariner.io/v1")
+		cluster.SetKind("Cluster")
+		cluster.SetNamespace(constants.OperatorNamespace)
+		cluster.SetName("east")
+		cluster.SetFinalizers([]string{"submariner.io/cleanup"})
+
+		dynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+			map[schema.GroupVersionResource]string{clustersGVR: "ClusterList"}, cluster)
+
+		testScheme := runtime.NewScheme()
+		Expect(apiextensionsv1.AddToScheme(testScheme)).To(Succeed())
+
+		generalClient = fakecontroller.NewClientBuilder().WithScheme(testScheme).WithObjects(
+			&apiextensionsv1.CustomResourceDefinition{
+				ObjectMeta: metav1.ObjectMeta{Name: "clusters.submariner.io"},
+				Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+					Group: clustersGVR.Group,
+					Names: apiextensionsv1.CustomResourceDefinitionNames{Plural: clustersGVR.Resource, Kind: "Cluster"},
+					Scope: apiextensionsv1.NamespaceScoped,
+					Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+						{Name: clustersGVR.Version, Served: true, Storage: true},
+					},
+				},
+			},
+			&apiextensionsv1.CustomResourceDefinition{
+				ObjectMeta: metav1.ObjectMeta{Name: "serviceexports.multicluster.x-k8s.io"},
+				Spec:       apiextensionsv1.CustomResourceDefinitionSpec{Group: "multicluster.x-k8s.io"},
+			},
+		).Build()
+	})
+
+	runCleanup := func() {
+		Expect(uninstall.ForceCleanup(&client.DefaultProducer{
+			KubeClient:    kubeClient,
+			DynamicClient: dynamicClient,
+			GeneralClient: generalClient,
+		}, clusterName, constants.OperatorNamespace, reporter.Silent())).To(Succeed())
+	}
+
+	assertCleanedUp := func() {
+		_, err := dynamicClient.Resource(clustersGVR).Namespace(constants.OperatorNamespace).Get(context.TODO(), "east",
+			metav1.GetOptions{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+		crds := &apiextensionsv1.CustomResourceDefinitionList{}
+		Expect(generalClient.List(context.TODO(), crds)).To(Succeed())
+		Expect(crds.Items).To(HaveLen(1))
+		Expect(crds.Items[0].Name).To(Equal("serviceexports.multicluster.x-k8s.io"))
+
+		roles, err := kubeClient.RbacV1().ClusterRoles().List(context.TODO(), metav1.ListOptions{})
+		Expect(err).To(Succeed())
+		Expect(roles.Items).To(HaveLen(1))
+		Expect(roles.Items[0].Name).To(Equal("other"))
+
+		bindings, err := kubeClient.RbacV1().ClusterRoleBindings().List(context.TODO(), metav1.ListOptions{})
+		Expect(err).To(Succeed())
+		Expect(bindings.Items).To(BeEmpty())
+
+		_, err = kubeClient.CoreV1().Namespaces().Get(context.TODO(), constants.OperatorNamespace, metav1.GetOptions{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+		node, err := kubeClient.CoreV1().Nodes().Get(context.TODO(), "gateway", metav1.GetOptions{})
+		Expect(err).To(Succeed())
+		Expect(node.Labels).ToNot(HaveKey(constants.SubmarinerGatewayLabel))
+	}
+
+	It("should remove the finalizers and delete all the Submariner artifacts", func() {
+		runCleanup()
+		assertCleanedUp()
+
+		patched := false
+
+		for _, action := range dynamicClient.Actions() {
+			if action.GetVerb() == "patch" && action.GetResource() == clustersGVR {
+				patched = true
+			}
+		}
+
+		Expect(patched).To(BeTrue())
+	})
+
+	When("run again after a successful cleanup", func() {
+		It("should succeed", func() {
+			runCleanup()
+			runCleanup()
+			assertCleanedUp()
+		})
+	})
+})
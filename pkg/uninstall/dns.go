@@ -0,0 +1,342 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package uninstall
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/submariner-io/admiral/pkg/names"
+	"github.com/submariner-io/admiral/pkg/reporter"
+	"github.com/submariner-io/admiral/pkg/resource"
+	"github.com/submariner-io/subctl/pkg/client"
+	operatorv1alpha1 "github.com/submariner-io/submariner-operator/api/v1alpha1"
+	opnames "github.com/submariner-io/submariner-operator/pkg/names"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	controller "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	lighthouseSectionStart      = "#lighthouse-start"
+	lighthouseSectionEnd        = "#lighthouse-end"
+	lighthouseForwardServerName = "lighthouse"
+	openShiftDNSName            = "default"
+)
+
+// The CoreDNS ConfigMaps which the operator adds the Lighthouse forwarding section to, on Kubernetes and MicroShift.
+var coreDNSConfigMaps = []types.NamespacedName{
+	{Namespace: "kube-system", Name: "coredns"},
+	{Namespace: "openshift-dns", Name: "dns-default"},
+}
+
+var openShiftDNSGVR = schema.GroupVersionResource{
+	Group:    "operator.openshift.io",
+	Version:  "v1",
+	Resource: "dnses",
+}
+
+var (
+	lighthouseStanzaStart = regexp.MustCompile(`^(\S+):\d+ {$`)
+	lighthouseForward     = regexp.MustCompile(`^forward \. (\S+)$`)
+)
+
+// lighthouseDNS describes the Lighthouse DNS server which the cluster DNS forwards to; it must be determined before
+// service discovery is uninstalled, since its Service is deleted along with it.
+type lighthouseDNS struct {
+	installed bool
+	clusterIP string
+}
+
+func findLighthouseDNS(clients client.Producer, namespace string) (*lighthouseDNS, error) {
+	err := clients.ForGeneral().Get(context.TODO(), controller.ObjectKey{
+		Namespace: namespace,
+		Name:      opnames.ServiceDiscoveryCrName,
+	}, &operatorv1alpha1.ServiceDiscovery{})
+	if resource.IsNotFoundErr(err) {
+		return &lighthouseDNS{}, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "error retrieving the ServiceDiscovery resource")
+	}
+
+	service, err := clients.ForKubernetes().CoreV1().Services(namespace).Get(context.TODO(), names.LighthouseCoreDNSComponent,
+		metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return &lighthouseDNS{installed: true}, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "error retrieving the %q Service", names.LighthouseCoreDNSComponent)
+	}
+
+	return &lighthouseDNS{installed: true, clusterIP: service.Spec.ClusterIP}, nil
+}
+
+// removeLighthouseDNSConfig removes the forwarding to the Lighthouse DNS server from the cluster DNS configuration.
+// Only the configuration added by the operator is removed; configurations which don't look as expected are left as is.
+func removeLighthouseDNSConfig(clients client.Producer, clusterName string, dns *lighthouseDNS, status reporter.Interface) error {
+	status.Start("Removing the Lighthouse DNS configuration on cluster %q", clusterName)
+	defer status.End()
+
+	if dns.clusterIP == "" {
+		status.Warning("The Lighthouse DNS server address couldn't be determined - the cluster DNS configuration is left as is")
+		return nil
+	}
+
+	removed := false
+
+	for _, name := range coreDNSConfigMaps {
+		found, err := removeLighthouseFromConfigMap(clients, name, dns.clusterIP, status)
+		if err != nil {
+			return err
+		}
+
+		removed = removed || found
+	}
+
+	found, err := removeLighthouseFromOpenShiftDNS(clients, dns.clusterIP, status)
+	if err != nil {
+		return err
+	}
+
+	if !removed && !found {
+		status.Success("No Lighthouse DNS configuration was found")
+	}
+
+	return nil
+}
+
+func removeLighthouseFromConfigMap(clients client.Producer, name types.NamespacedName, serverIP string,
+	status reporter.Interface,
+) (bool, error) {
+	configMaps := clients.ForKubernetes().CoreV1().ConfigMaps(name.Namespace)
+
+	configMap, err := configMaps.Get(context.TODO(), name.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, status.Error(err, "Error retrieving ConfigMap %q", name)
+	}
+
+	corefile, zones, err := removeLighthouseSection(configMap.Data["Corefile"], serverIP)
+	if err != nil {
+		status.Warning("ConfigMap %q appears to have been customized, it is left as is: %v", name, err)
+		return false, nil
+	}
+
+	if corefile == configMap.Data["Corefile"] {
+		return false, nil
+	}
+
+	configMap.Data["Corefile"] = corefile
+
+	_, err = configMaps.Update(context.TODO(), configMap, metav1.UpdateOptions{})
+	if err != nil {
+		return false, status.Error(err, "Error updating ConfigMap %q", name)
+	}
+
+	status.Success("Removed the forwarding of %v to the Lighthouse DNS server %s from ConfigMap %q", zones, serverIP, name)
+
+	return true, nil
+}
+
+// removeLighthouseSection removes the section added by the operator to the given Corefile, provided it only contains
+// stanzas forwarding to the given server. It returns the updated Corefile and the zones which were forwarded.
+func removeLighthouseSection(corefile, serverIP string) (string, []string, error) {
+	lines := strings.Split(corefile, "\n")
+	start, end := -1, -1
+
+	for i := range lines {
+		line := strings.TrimSpace(lines[i])
+
+		switch {
+		case strings.HasPrefix(line, lighthouseSectionStart):
+			if start >= 0 {
+				return "", nil, errors.New("the Lighthouse section start marker appears more than once")
+			}
+
+			start = i
+		case strings.HasPrefix(line, lighthouseSectionEnd):
+			if end >= 0 {
+				return "", nil, errors.New("the Lighthouse section end marker appears more than once")
+			}
+
+			end = i
+		}
+	}
+
+	if start < 0 && end < 0 {
+		if strings.Contains(corefile, serverIP) {
+			return "", nil, fmt.Errorf("it refers to the Lighthouse DNS server %s outside of a Lighthouse section", serverIP)
+		}
+
+		return corefile, nil, nil
+	}
+
+	if start < 0 || end < start {
+		return "", nil, errors.New("the Lighthouse section markers are mismatched")
+	}
+
+	zones, err := parseLighthouseStanzas(lines[start+1:end], serverIP)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return strings.Join(append(lines[:start:start], lines[end+1:]...), "\n"), zones, nil
+}
+
+// parseLighthouseStanzas returns the zones forwarded by the given stanzas, which must be of the form generated by the
+// operator, "<zone>:<port> {", "forward . <server>", "}".
+func parseLighthouseStanzas(lines []string, serverIP string) ([]string, error) {
+	zones := []string{}
+	zone := ""
+	forwarded := false
+
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+
+		switch {
+		case line == "":
+			continue
+		case zone == "":
+			matches := lighthouseStanzaStart.FindStringSubmatch(line)
+			if matches == nil {
+				return nil, fmt.Errorf("unexpected line %q in the Lighthouse section", line)
+			}
+
+			zone = matches[1]
+		case !forwarded:
+			matches := lighthouseForward.FindStringSubmatch(line)
+			if matches == nil {
+				return nil, fmt.Errorf("unexpected line %q in the Lighthouse stanza for %q", line, zone)
+			}
+
+			if matches[1] != serverIP {
+				return nil, fmt.Errorf("the Lighthouse stanza for %q forwards to %s instead of the Lighthouse DNS server %s",
+					zone, matches[1], serverIP)
+			}
+
+			forwarded = true
+		case line == "}":
+			zones = append(zones, zone)
+			zone = ""
+			forwarded = false
+		default:
+			return nil, fmt.Errorf("unexpected line %q in the Lighthouse stanza for %q", line, zone)
+		}
+	}
+
+	if zone != "" {
+		return nil, fmt.Errorf("the Lighthouse stanza for %q is incomplete", zone)
+	}
+
+	return zones, nil
+}
+
+func removeLighthouseFromOpenShiftDNS(clients client.Producer, serverIP string, status reporter.Interface) (bool, error) {
+	dnses := clients.ForDynamic().Resource(openShiftDNSGVR)
+
+	dns, err := dnses.Get(context.TODO(), openShiftDNSName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, status.Error(err, "Error retrieving the OpenShift DNS operator configuration %q", openShiftDNSName)
+	}
+
+	servers, _, err := unstructured.NestedSlice(dns.Object, "spec", "servers")
+	if err != nil {
+		status.Warning("The OpenShift DNS operator configuration %q appears to have been customized, it is left as is: %v",
+			openShiftDNSName, err)
+		return false, nil
+	}
+
+	remaining, zones, err := removeLighthouseServers(servers, serverIP)
+	if err != nil {
+		status.Warning("The OpenShift DNS operator configuration %q appears to have been customized, it is left as is: %v",
+			openShiftDNSName, err)
+		return false, nil
+	}
+
+	if len(remaining) == len(servers) {
+		return false, nil
+	}
+
+	if err := unstructured.SetNestedSlice(dns.Object, remaining, "spec", "servers"); err != nil {
+		return false, status.Error(err, "Error updating the servers of the OpenShift DNS operator configuration %q", openShiftDNSName)
+	}
+
+	_, err = dnses.Update(context.TODO(), dns, metav1.UpdateOptions{})
+	if err != nil {
+		return false, status.Error(err, "Error updating the OpenShift DNS operator configuration %q", openShiftDNSName)
+	}
+
+	status.Success("Removed the forwarding of %v to the Lighthouse DNS server %s from the OpenShift DNS operator configuration %q",
+		zones, serverIP, openShiftDNSName)
+
+	return true, nil
+}
+
+// removeLighthouseServers removes the servers added by the operator, provided they only forward to the given server. It
+// returns the remaining servers and the zones which were forwarded.
+func removeLighthouseServers(servers []interface{}, serverIP string) ([]interface{}, []string, error) {
+	remaining := make([]interface{}, 0, len(servers))
+	zones := []string{}
+
+	for _, server := range servers {
+		fields, ok := server.(map[string]interface{})
+		if !ok || fields["name"] != lighthouseForwardServerName {
+			remaining = append(remaining, server)
+			continue
+		}
+
+		upstreams, _, err := unstructured.NestedStringSlice(fields, "forwardPlugin", "upstreams")
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "error reading the upstreams of server %q", lighthouseForwardServerName)
+		}
+
+		for _, upstream := range upstreams {
+			if upstream != serverIP {
+				return nil, nil, fmt.Errorf("server %q forwards to %s instead of the Lighthouse DNS server %s",
+					lighthouseForwardServerName, upstream, serverIP)
+			}
+		}
+
+		serverZones, _, err := unstructured.NestedStringSlice(fields, "zones")
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "error reading the zones of server %q", lighthouseForwardServerName)
+		}
+
+		zones = append(zones, serverZones...)
+	}
+
+	return remaining, zones, nil
+}
@@ -24,6 +24,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/coreos/go-semver/semver"
 	"github.com/pkg/errors"
 	"k8s.io/client-go/kubernetes"
 )
@@ -41,6 +42,68 @@ func PrintSubctlVersion(w io.Writer) {
 	fmt.Fprintf(w, "subctl version: %s\n", Version)
 }
 
+// Direction describes how a target version relates to a current version.
+type Direction int
+
+const (
+	Upgrade Direction = iota
+	Unchanged
+	Downgrade
+)
+
+// Compare determines whether moving from the current version to the target version is an upgrade, a downgrade,
+// or no change. A leading "v" is ignored on both versions. Current versions which can't be compared (see Parse) are
+// always upgraded.
+func Compare(current, target string) (Direction, error) {
+	if current == target {
+		return Unchanged, nil
+	}
+
+	toVersion, err := semver.NewVersion(strings.TrimPrefix(target, "v"))
+	if err != nil {
+		return Upgrade, errors.Wrapf(err, "invalid target version %q", target)
+	}
+
+	if isDevelopment(current) {
+		return Upgrade, nil
+	}
+
+	currentVersion, err := semver.NewVersion(strings.TrimPrefix(current, "v"))
+	if err != nil {
+		return Upgrade, errors.Wrapf(err, "error parsing current version %q", current)
+	}
+
+	if toVersion.LessThan(*currentVersion) {
+		return Downgrade, nil
+	}
+
+	if toVersion.Equal(*currentVersion) {
+		return Unchanged, nil
+	}
+
+	return Upgrade, nil
+}
+
+// Parse parses the given version, ignoring a leading "v". It returns nil for versions which can't be compared: unknown
+// versions, development builds such as "devel" or "release-0.18", and anything which isn't a semantic version.
+func Parse(v string) *semver.Version {
+	if isDevelopment(v) {
+		return nil
+	}
+
+	parsed, err := semver.NewVersion(strings.TrimPrefix(v, "v"))
+	if err != nil {
+		return nil
+	}
+
+	return parsed
+}
+
+func isDevelopment(v string) bool {
+	// semver needs a dotted triplet, which is at least five characters
+	return len(v) < 5 || strings.HasPrefix(v, "devel") || strings.HasPrefix(v, "release")
+}
+
 func CheckRequirements(k8sclient kubernetes.Interface, serviceDiscovery bool) (string, []string, error) {
 	failedRequirements := []string{}
 
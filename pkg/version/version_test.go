@@ -0,0 +1,97 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package version_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/subctl/pkg/version"
+)
+
+var _ = Describe("Compare", func() {
+	expectDirection := func(current, target string, expected version.Direction) {
+		direction, err := version.Compare(current, target)
+		Expect(err).To(Succeed())
+		Expect(direction).To(Equal(expected))
+	}
+
+	When("the target version is newer", func() {
+		It("should return Upgrade", func() {
+			expectDirection("0.17.2", "0.18.0", version.Upgrade)
+			expectDirection("v0.18.0", "0.18.1", version.Upgrade)
+			expectDirection("0.18.0-rc1", "0.18.0", version.Upgrade)
+		})
+	})
+
+	When("the target version is older", func() {
+		It("should return Downgrade", func() {
+			expectDirection("0.18.1", "0.18.0", version.Downgrade)
+			expectDirection("v0.18.0", "v0.17.5", version.Downgrade)
+			expectDirection("0.18.0", "0.18.0-rc1", version.Downgrade)
+		})
+	})
+
+	When("the versions are the same", func() {
+		It("should return Unchanged", func() {
+			expectDirection("0.18.0", "0.18.0", version.Unchanged)
+			expectDirection("v0.18.0", "0.18.0", version.Unchanged)
+			expectDirection("devel", "devel", version.Unchanged)
+		})
+	})
+
+	When("the current version is a development version", func() {
+		It("should return Upgrade", func() {
+			expectDirection("devel", "0.18.0", version.Upgrade)
+			expectDirection("release-0.18", "0.17.0", version.Upgrade)
+			expectDirection("v1", "0.1.0", version.Upgrade)
+		})
+	})
+
+	When("the target version is invalid", func() {
+		It("should return an error", func() {
+			_, err := version.Compare("0.18.0", "latest")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	When("the current version is invalid", func() {
+		It("should return an error", func() {
+			_, err := version.Compare("not-a-version", "0.18.0")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("Parse", func() {
+	When("the version is a semantic version", func() {
+		It("should parse it, ignoring a leading v", func() {
+			Expect(version.Parse("0.18.2")).To(Equal(version.Parse("v0.18.2")))
+			Expect(version.Parse("0.17.0-rc1").LessThan(*version.Parse("0.17.0"))).To(BeTrue())
+		})
+	})
+
+	When("the version can't be compared", func() {
+		It("should return nil", func() {
+			Expect(version.Parse("devel")).To(BeNil())
+			Expect(version.Parse("release-0.18")).To(BeNil())
+			Expect(version.Parse("")).To(BeNil())
+			Expect(version.Parse("not-a-version")).To(BeNil())
+		})
+	})
+})